@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"backend/internal/config"
+	"backend/internal/db"
+	"backend/internal/repository"
+)
+
+func main() {
+	databaseURL := flag.String("url", "", "database URL (defaults to DATABASE_URL from env or .env)")
+	days := flag.Int("days", 365, "delete actions older than this many days")
+	timeout := flag.Duration("timeout", 30*time.Minute, "overall timeout")
+	flag.Parse()
+
+	if *days <= 0 {
+		fmt.Fprintln(os.Stderr, "-days must be greater than zero")
+		os.Exit(2)
+	}
+
+	url := *databaseURL
+	if url == "" {
+		cfg, err := config.Load()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "config error: %v\n", err)
+			os.Exit(2)
+		}
+		url = cfg.DatabaseURL
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	pool, err := db.NewPool(ctx, url, db.PoolOptions{MaxConns: 1, MinConns: 1})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "database error: %v\n", err)
+		os.Exit(1)
+	}
+	defer pool.Close()
+
+	cutoff := time.Now().AddDate(0, 0, -*days)
+	deleted, err := repository.New(pool).DeleteActionsBefore(ctx, cutoff)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cleanup failed after %d rows: %v\n", deleted, err)
+		os.Exit(1)
+	}
+	fmt.Printf("deleted %d actions created before %s\n", deleted, cutoff.Format(time.RFC3339))
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"backend/internal/config"
+	"backend/internal/db"
+)
+
+func main() {
+	databaseURL := flag.String("url", "", "database URL to check (defaults to DATABASE_URL from env or .env)")
+	timeout := flag.Duration("timeout", 10*time.Second, "overall timeout for the check")
+	flag.Parse()
+
+	url := *databaseURL
+	if url == "" {
+		cfg, err := config.Load()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "config error: %v\n", err)
+			os.Exit(2)
+		}
+		url = cfg.DatabaseURL
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	version, err := db.Check(ctx, url)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "database check failed: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("database check ok (PostgreSQL %s)\n", version)
+}
@@ -1,17 +1,12 @@
 package main
 
 import (
-	"bytes"
 	"context"
-	"encoding/csv"
-	"errors"
+	"database/sql"
 	"flag"
 	"fmt"
 	"log"
-	"math"
 	"os"
-	"os/exec"
-	"strconv"
 	"strings"
 	"time"
 
@@ -19,9 +14,11 @@ import (
 	"backend/internal/db"
 	"backend/internal/domain"
 	"backend/internal/excel"
+	"backend/internal/matching"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	_ "modernc.org/sqlite"
 )
 
 type options struct {
@@ -31,6 +28,9 @@ type options struct {
 	sellPriceThreshold float64
 	replace            bool
 	syncProducts       bool
+
+	defaultAdminUsername string
+	defaultAdminPassword string
 }
 
 type legacyData struct {
@@ -99,9 +99,18 @@ func main() {
 	if err != nil {
 		log.Fatalf("config error: %v", err)
 	}
+	if err := cfg.CheckDefaultAdmin(); err != nil {
+		log.Fatalf("config error: %v", err)
+	}
+	opts.defaultAdminUsername = cfg.DefaultAdminUsername
+	opts.defaultAdminPassword = cfg.DefaultAdminPassword
 
 	ctx := context.Background()
-	pool, err := db.NewPool(ctx, cfg.DatabaseURL)
+	pool, err := db.NewPool(ctx, cfg.DatabaseURL, db.PoolOptions{
+		MaxConns:    cfg.DBMaxConns,
+		MinConns:    cfg.DBMinConns,
+		MaxConnIdle: cfg.DBMaxConnIdle,
+	})
 	if err != nil {
 		log.Fatalf("database error: %v", err)
 	}
@@ -136,7 +145,7 @@ func main() {
 		)
 	}
 
-	legacy, err := readLegacySQLite(opts.sqlitePath)
+	legacy, err := readLegacySQLite(ctx, opts.sqlitePath)
 	if err != nil {
 		log.Fatalf("read sqlite data: %v", err)
 	}
@@ -242,247 +251,72 @@ func applySellPriceOverrides(
 		return stats
 	}
 
-	type priceEntry struct {
-		ProductName string
-		SellPrice   float64
-		Normalized  string
-		Runes       []rune
-	}
-
-	normalizedToPrice := make(map[string]priceEntry, len(priceRows))
-	entries := make([]priceEntry, 0, len(priceRows))
-	firstRuneIndex := make(map[rune][]int)
-	allEntryIndexes := make([]int, 0, len(priceRows))
+	names := make([]string, 0, len(priceRows))
+	prices := make([]float64, 0, len(priceRows))
+	seen := make(map[string]struct{}, len(priceRows))
 	for _, row := range priceRows {
 		name := strings.TrimSpace(row.ProductName)
-		if name == "" {
-			continue
-		}
-		normalized := normalizeProductName(name)
+		normalized := matching.NormalizeName(name)
 		if normalized == "" {
 			continue
 		}
-		entry := priceEntry{
-			ProductName: name,
-			SellPrice:   row.Price,
-			Normalized:  normalized,
-			Runes:       []rune(normalized),
-		}
-		if _, exists := normalizedToPrice[normalized]; exists {
+		if _, exists := seen[normalized]; exists {
 			stats.DuplicateNameRows++
 			continue
 		}
-		normalizedToPrice[normalized] = entry
-		entryIndex := len(entries)
-		entries = append(entries, entry)
-		allEntryIndexes = append(allEntryIndexes, entryIndex)
-		first := firstRune(entry.Runes)
-		firstRuneIndex[first] = append(firstRuneIndex[first], entryIndex)
-	}
-	if len(entries) == 0 {
+		seen[normalized] = struct{}{}
+		names = append(names, name)
+		prices = append(prices, row.Price)
+	}
+	matcher := matching.NewMatcher(names)
+	if matcher.Len() == 0 {
 		return stats
 	}
 
 	for idx := range stockRows {
-		name := strings.TrimSpace(stockRows[idx].ProductName)
-		if name == "" {
+		match, ok := matcher.Best(stockRows[idx].ProductName, threshold)
+		if !ok {
 			continue
 		}
-		normalized := normalizeProductName(name)
-		if normalized == "" {
-			continue
-		}
-		if exact, ok := normalizedToPrice[normalized]; ok {
-			stockRows[idx].SellPrice = exact.SellPrice
+		stockRows[idx].SellPrice = prices[match.Index]
+		if match.Exact {
 			stats.ExactMatched++
-			stats.Unchanged--
-			continue
-		}
-
-		candidateIndexes := firstRuneIndex[firstRune([]rune(normalized))]
-		if len(candidateIndexes) == 0 {
-			candidateIndexes = allEntryIndexes
-		}
-		targetRunes := []rune(normalized)
-		bestScore := -1.0
-		bestDistance := math.MaxInt
-		bestSellPrice := 0.0
-
-		for _, candidateIndex := range candidateIndexes {
-			entry := entries[candidateIndex]
-			score, distance, ok := similarityPercent(
-				targetRunes,
-				entry.Runes,
-				threshold,
-			)
-			if !ok {
-				continue
-			}
-			if score > bestScore || (score == bestScore && distance < bestDistance) {
-				bestScore = score
-				bestDistance = distance
-				bestSellPrice = entry.SellPrice
-			}
-		}
-		if bestScore >= threshold {
-			stockRows[idx].SellPrice = bestSellPrice
+		} else {
 			stats.FuzzyMatched++
-			stats.Unchanged--
 		}
+		stats.Unchanged--
 	}
 
 	return stats
 }
 
-func normalizeProductName(raw string) string {
-	value := strings.TrimSpace(strings.ToLower(raw))
-	if value == "" {
-		return ""
-	}
-	replacer := strings.NewReplacer(
-		"\u200c", " ", // zwnj
-		"\u200f", " ", // rtl mark
-		"\u200e", " ", // ltr mark
-		"\u064a", "\u06cc", // ي -> ی
-		"\u0643", "\u06a9", // ك -> ک
-		"\u0629", "\u0647", // ة -> ه
-		"،", " ",
-		",", " ",
-		":", " ",
-		";", " ",
-		"/", " ",
-		"\\", " ",
-		"(", " ",
-		")", " ",
-		"[", " ",
-		"]", " ",
-		"{", " ",
-		"}", " ",
-		"-", " ",
-		"_", " ",
-		"+", " ",
-	)
-	value = replacer.Replace(value)
-	return strings.Join(strings.Fields(value), " ")
-}
-
-func firstRune(chars []rune) rune {
-	if len(chars) == 0 {
-		return rune(0)
-	}
-	return chars[0]
-}
-
-func similarityPercent(
-	left []rune,
-	right []rune,
-	threshold float64,
-) (float64, int, bool) {
-	maxLen := len(left)
-	if len(right) > maxLen {
-		maxLen = len(right)
-	}
-	if maxLen == 0 {
-		return 100.0, 0, true
-	}
-	if threshold >= 100 {
-		if string(left) == string(right) {
-			return 100.0, 0, true
-		}
-		return 0, 1, false
-	}
-	maxDistance := int(math.Floor((100.0 - threshold) * float64(maxLen) / 100.0))
-	if maxDistance < 1 {
-		maxDistance = 1
-	}
-	if abs(len(left)-len(right)) > maxDistance {
-		return 0, 0, false
-	}
-	distance, ok := levenshteinWithin(left, right, maxDistance)
-	if !ok {
-		return 0, distance, false
-	}
-	score := 100.0 * (1.0 - (float64(distance) / float64(maxLen)))
-	return score, distance, score >= threshold
-}
-
-func levenshteinWithin(left []rune, right []rune, maxDistance int) (int, bool) {
-	leftLen := len(left)
-	rightLen := len(right)
-	if leftLen == 0 {
-		return rightLen, rightLen <= maxDistance
-	}
-	if rightLen == 0 {
-		return leftLen, leftLen <= maxDistance
-	}
-	if abs(leftLen-rightLen) > maxDistance {
-		return maxDistance + 1, false
-	}
-
-	prev := make([]int, rightLen+1)
-	curr := make([]int, rightLen+1)
-	for j := 0; j <= rightLen; j++ {
-		prev[j] = j
-	}
-
-	for i := 1; i <= leftLen; i++ {
-		start := max(1, i-maxDistance)
-		end := min(rightLen, i+maxDistance)
-		curr[0] = i
-		rowMin := curr[0]
-		for j := 1; j < start; j++ {
-			curr[j] = maxDistance + 1
-		}
-		for j := start; j <= end; j++ {
-			cost := 1
-			if left[i-1] == right[j-1] {
-				cost = 0
-			}
-			deletion := prev[j] + 1
-			insertion := curr[j-1] + 1
-			substitution := prev[j-1] + cost
-			curr[j] = min(deletion, min(insertion, substitution))
-			if curr[j] < rowMin {
-				rowMin = curr[j]
-			}
-		}
-		for j := end + 1; j <= rightLen; j++ {
-			curr[j] = maxDistance + 1
-		}
-		if rowMin > maxDistance {
-			return rowMin, false
-		}
-		prev, curr = curr, prev
+func readLegacySQLite(ctx context.Context, path string) (legacyData, error) {
+	conn, err := sql.Open("sqlite", "file:"+path+"?mode=ro")
+	if err != nil {
+		return legacyData{}, fmt.Errorf("open sqlite %s: %w", path, err)
 	}
-	distance := prev[rightLen]
-	return distance, distance <= maxDistance
-}
-
-func abs(value int) int {
-	if value < 0 {
-		return -value
+	defer conn.Close()
+	if err := conn.PingContext(ctx); err != nil {
+		return legacyData{}, fmt.Errorf("open sqlite %s: %w", path, err)
 	}
-	return value
-}
 
-func readLegacySQLite(path string) (legacyData, error) {
-	admins, err := loadAdmins(path)
+	admins, err := loadAdmins(ctx, conn)
 	if err != nil {
 		return legacyData{}, err
 	}
-	invoices, err := loadInvoices(path)
+	invoices, err := loadInvoices(ctx, conn)
 	if err != nil {
 		return legacyData{}, err
 	}
-	invoiceLines, err := loadInvoiceLines(path)
+	invoiceLines, err := loadInvoiceLines(ctx, conn)
 	if err != nil {
 		return legacyData{}, err
 	}
-	actions, err := loadActions(path)
+	actions, err := loadActions(ctx, conn)
 	if err != nil {
 		return legacyData{}, err
 	}
-	basalamIDs, err := loadBasalamIDs(path)
+	basalamIDs, err := loadBasalamIDs(ctx, conn)
 	if err != nil {
 		return legacyData{}, err
 	}
@@ -496,37 +330,57 @@ func readLegacySQLite(path string) (legacyData, error) {
 	}, nil
 }
 
-func loadAdmins(path string) ([]legacyAdmin, error) {
-	records, err := runSQLiteQuery(path, `
-		SELECT username, password_hash, role, auto_lock_minutes, created_at
+// Legacy columns are loosely typed, so queries CAST to the expected storage
+// class and timestamps stay TEXT for normalizeTimestamp.
+
+func loadAdmins(ctx context.Context, conn *sql.DB) ([]legacyAdmin, error) {
+	rows, err := conn.QueryContext(ctx, `
+		SELECT
+			username,
+			password_hash,
+			role,
+			CAST(auto_lock_minutes AS INTEGER),
+			CAST(created_at AS TEXT)
 		FROM admins
 		ORDER BY id ASC
 	`)
 	if err != nil {
 		return nil, fmt.Errorf("query sqlite admins: %w", err)
 	}
-	rows := make([]legacyAdmin, 0, len(records))
-	for _, record := range records {
-		rows = append(rows, legacyAdmin{
-			Username:        strings.TrimSpace(record["username"]),
-			PasswordHash:    strings.TrimSpace(record["password_hash"]),
-			Role:            strings.TrimSpace(record["role"]),
-			AutoLockMinutes: parseInt(record["auto_lock_minutes"]),
-			CreatedAt:       strings.TrimSpace(record["created_at"]),
+	defer rows.Close()
+
+	items := make([]legacyAdmin, 0)
+	for rows.Next() {
+		var (
+			username, passwordHash, role, createdAt sql.NullString
+			autoLock                                sql.NullInt64
+		)
+		if err := rows.Scan(&username, &passwordHash, &role, &autoLock, &createdAt); err != nil {
+			return nil, fmt.Errorf("scan sqlite admin: %w", err)
+		}
+		items = append(items, legacyAdmin{
+			Username:        strings.TrimSpace(username.String),
+			PasswordHash:    strings.TrimSpace(passwordHash.String),
+			Role:            strings.TrimSpace(role.String),
+			AutoLockMinutes: int(autoLock.Int64),
+			CreatedAt:       strings.TrimSpace(createdAt.String),
 		})
 	}
-	return rows, nil
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate sqlite admins: %w", err)
+	}
+	return items, nil
 }
 
-func loadInvoices(path string) ([]legacyInvoice, error) {
-	records, err := runSQLiteQuery(path, `
+func loadInvoices(ctx context.Context, conn *sql.DB) ([]legacyInvoice, error) {
+	rows, err := conn.QueryContext(ctx, `
 		SELECT
 			id,
 			invoice_type,
-			created_at,
-			total_lines,
-			total_qty,
-			total_amount,
+			CAST(created_at AS TEXT),
+			CAST(total_lines AS INTEGER),
+			CAST(total_qty AS INTEGER),
+			CAST(total_amount AS REAL),
 			invoice_name,
 			admin_username
 		FROM invoices
@@ -535,58 +389,103 @@ func loadInvoices(path string) ([]legacyInvoice, error) {
 	if err != nil {
 		return nil, fmt.Errorf("query sqlite invoices: %w", err)
 	}
-	rows := make([]legacyInvoice, 0, len(records))
-	for _, record := range records {
-		rows = append(rows, legacyInvoice{
-			ID:            parseInt64(record["id"]),
-			InvoiceType:   strings.TrimSpace(record["invoice_type"]),
-			CreatedAt:     strings.TrimSpace(record["created_at"]),
-			TotalLines:    parseInt(record["total_lines"]),
-			TotalQty:      parseInt(record["total_qty"]),
-			TotalAmount:   parseFloat(record["total_amount"]),
-			InvoiceName:   strings.TrimSpace(record["invoice_name"]),
-			AdminUsername: strings.TrimSpace(record["admin_username"]),
+	defer rows.Close()
+
+	items := make([]legacyInvoice, 0)
+	for rows.Next() {
+		var (
+			id                                                 int64
+			invoiceType, createdAt, invoiceName, adminUsername sql.NullString
+			totalLines, totalQty                               sql.NullInt64
+			totalAmount                                        sql.NullFloat64
+		)
+		if err := rows.Scan(
+			&id,
+			&invoiceType,
+			&createdAt,
+			&totalLines,
+			&totalQty,
+			&totalAmount,
+			&invoiceName,
+			&adminUsername,
+		); err != nil {
+			return nil, fmt.Errorf("scan sqlite invoice: %w", err)
+		}
+		items = append(items, legacyInvoice{
+			ID:            id,
+			InvoiceType:   strings.TrimSpace(invoiceType.String),
+			CreatedAt:     strings.TrimSpace(createdAt.String),
+			TotalLines:    int(totalLines.Int64),
+			TotalQty:      int(totalQty.Int64),
+			TotalAmount:   totalAmount.Float64,
+			InvoiceName:   strings.TrimSpace(invoiceName.String),
+			AdminUsername: strings.TrimSpace(adminUsername.String),
 		})
 	}
-	return rows, nil
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate sqlite invoices: %w", err)
+	}
+	return items, nil
 }
 
-func loadInvoiceLines(path string) ([]legacyInvoiceLine, error) {
-	records, err := runSQLiteQuery(path, `
+func loadInvoiceLines(ctx context.Context, conn *sql.DB) ([]legacyInvoiceLine, error) {
+	rows, err := conn.QueryContext(ctx, `
 		SELECT
 			id,
 			invoice_id,
 			product_name,
-			price,
-			quantity,
-			line_total,
-			cost_price
+			CAST(price AS REAL),
+			CAST(quantity AS INTEGER),
+			CAST(line_total AS REAL),
+			CAST(cost_price AS REAL)
 		FROM invoice_lines
 		ORDER BY id ASC
 	`)
 	if err != nil {
 		return nil, fmt.Errorf("query sqlite invoice_lines: %w", err)
 	}
-	rows := make([]legacyInvoiceLine, 0, len(records))
-	for _, record := range records {
-		rows = append(rows, legacyInvoiceLine{
-			ID:          parseInt64(record["id"]),
-			InvoiceID:   parseInt64(record["invoice_id"]),
-			ProductName: strings.TrimSpace(record["product_name"]),
-			Price:       parseFloat(record["price"]),
-			Quantity:    parseInt(record["quantity"]),
-			LineTotal:   parseFloat(record["line_total"]),
-			CostPrice:   parseFloat(record["cost_price"]),
+	defer rows.Close()
+
+	items := make([]legacyInvoiceLine, 0)
+	for rows.Next() {
+		var (
+			id, invoiceID               int64
+			productName                 sql.NullString
+			quantity                    sql.NullInt64
+			price, lineTotal, costPrice sql.NullFloat64
+		)
+		if err := rows.Scan(
+			&id,
+			&invoiceID,
+			&productName,
+			&price,
+			&quantity,
+			&lineTotal,
+			&costPrice,
+		); err != nil {
+			return nil, fmt.Errorf("scan sqlite invoice line: %w", err)
+		}
+		items = append(items, legacyInvoiceLine{
+			ID:          id,
+			InvoiceID:   invoiceID,
+			ProductName: strings.TrimSpace(productName.String),
+			Price:       price.Float64,
+			Quantity:    int(quantity.Int64),
+			LineTotal:   lineTotal.Float64,
+			CostPrice:   costPrice.Float64,
 		})
 	}
-	return rows, nil
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate sqlite invoice_lines: %w", err)
+	}
+	return items, nil
 }
 
-func loadActions(path string) ([]legacyAction, error) {
-	records, err := runSQLiteQuery(path, `
+func loadActions(ctx context.Context, conn *sql.DB) ([]legacyAction, error) {
+	rows, err := conn.QueryContext(ctx, `
 		SELECT
 			id,
-			created_at,
+			CAST(created_at AS TEXT),
 			admin_username,
 			action_type,
 			title,
@@ -597,23 +496,35 @@ func loadActions(path string) ([]legacyAction, error) {
 	if err != nil {
 		return nil, fmt.Errorf("query sqlite actions: %w", err)
 	}
-	rows := make([]legacyAction, 0, len(records))
-	for _, record := range records {
-		rows = append(rows, legacyAction{
-			ID:            parseInt64(record["id"]),
-			CreatedAt:     strings.TrimSpace(record["created_at"]),
-			AdminUsername: strings.TrimSpace(record["admin_username"]),
-			ActionType:    strings.TrimSpace(record["action_type"]),
-			Title:         strings.TrimSpace(record["title"]),
-			Details:       record["details"],
+	defer rows.Close()
+
+	items := make([]legacyAction, 0)
+	for rows.Next() {
+		var (
+			id                                                   int64
+			createdAt, adminUsername, actionType, title, details sql.NullString
+		)
+		if err := rows.Scan(&id, &createdAt, &adminUsername, &actionType, &title, &details); err != nil {
+			return nil, fmt.Errorf("scan sqlite action: %w", err)
+		}
+		items = append(items, legacyAction{
+			ID:            id,
+			CreatedAt:     strings.TrimSpace(createdAt.String),
+			AdminUsername: strings.TrimSpace(adminUsername.String),
+			ActionType:    strings.TrimSpace(actionType.String),
+			Title:         strings.TrimSpace(title.String),
+			Details:       details.String,
 		})
 	}
-	return rows, nil
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate sqlite actions: %w", err)
+	}
+	return items, nil
 }
 
-func loadBasalamIDs(path string) ([]legacyBasalamID, error) {
-	records, err := runSQLiteQuery(path, `
-		SELECT id, saved_at
+func loadBasalamIDs(ctx context.Context, conn *sql.DB) ([]legacyBasalamID, error) {
+	rows, err := conn.QueryContext(ctx, `
+		SELECT CAST(id AS TEXT), CAST(saved_at AS TEXT)
 		FROM basalam_order_ids
 		ORDER BY id ASC
 	`)
@@ -623,51 +534,23 @@ func loadBasalamIDs(path string) ([]legacyBasalamID, error) {
 		}
 		return nil, fmt.Errorf("query sqlite basalam_order_ids: %w", err)
 	}
-	rows := make([]legacyBasalamID, 0, len(records))
-	for _, record := range records {
-		rows = append(rows, legacyBasalamID{
-			ID:      strings.TrimSpace(record["id"]),
-			SavedAt: strings.TrimSpace(record["saved_at"]),
-		})
-	}
-	return rows, nil
-}
-
-func runSQLiteQuery(path, query string) ([]map[string]string, error) {
-	cmd := exec.Command("sqlite3", "-header", "-csv", path, strings.TrimSpace(query))
-	out, err := cmd.CombinedOutput()
-	if err != nil {
-		return nil, fmt.Errorf("sqlite3 query failed: %s", strings.TrimSpace(string(out)))
-	}
-	trimmed := strings.TrimSpace(string(out))
-	if trimmed == "" {
-		return []map[string]string{}, nil
-	}
-
-	r := csv.NewReader(bytes.NewBuffer(out))
-	r.FieldsPerRecord = -1
-	records, err := r.ReadAll()
-	if err != nil {
-		return nil, fmt.Errorf("parse sqlite csv output: %w", err)
-	}
-	if len(records) <= 1 {
-		return []map[string]string{}, nil
-	}
+	defer rows.Close()
 
-	headers := records[0]
-	rows := make([]map[string]string, 0, len(records)-1)
-	for _, raw := range records[1:] {
-		row := make(map[string]string, len(headers))
-		for idx, header := range headers {
-			value := ""
-			if idx < len(raw) {
-				value = raw[idx]
-			}
-			row[strings.TrimSpace(header)] = value
+	items := make([]legacyBasalamID, 0)
+	for rows.Next() {
+		var id, savedAt sql.NullString
+		if err := rows.Scan(&id, &savedAt); err != nil {
+			return nil, fmt.Errorf("scan sqlite basalam order id: %w", err)
 		}
-		rows = append(rows, row)
+		items = append(items, legacyBasalamID{
+			ID:      strings.TrimSpace(id.String),
+			SavedAt: strings.TrimSpace(savedAt.String),
+		})
 	}
-	return rows, nil
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate sqlite basalam_order_ids: %w", err)
+	}
+	return items, nil
 }
 
 func importAll(
@@ -705,7 +588,7 @@ func importAll(
 	if err := upsertAdmins(ctx, tx, legacy.admins); err != nil {
 		return err
 	}
-	if err := ensureDefaultAdmin(ctx, tx); err != nil {
+	if err := ensureDefaultAdmin(ctx, tx, opts.defaultAdminUsername, opts.defaultAdminPassword); err != nil {
 		return err
 	}
 	if err := upsertInvoices(ctx, tx, legacy.invoices); err != nil {
@@ -851,9 +734,10 @@ func upsertAdmins(ctx context.Context, tx pgx.Tx, rows []legacyAdmin) error {
 	return nil
 }
 
-func ensureDefaultAdmin(ctx context.Context, tx pgx.Tx) error {
+func ensureDefaultAdmin(ctx context.Context, tx pgx.Tx, username, password string) error {
 	// Legacy sqlite stores hashed passwords that are not compatible with the
-	// current backend's plain-text check; force a known bootstrap credential.
+	// current backend's plain-text check; force the configured bootstrap
+	// credential so at least one manager can log in after the import.
 	if _, err := tx.Exec(ctx, `
 		INSERT INTO admins (
 			username,
@@ -861,12 +745,12 @@ func ensureDefaultAdmin(ctx context.Context, tx pgx.Tx) error {
 			role,
 			auto_lock_minutes,
 			created_at
-		) VALUES ('reza', 'reza1375', 'manager', 1, NOW())
+		) VALUES ($1, $2, 'manager', 1, NOW())
 		ON CONFLICT (username)
 		DO UPDATE SET
 			password = EXCLUDED.password,
 			role = EXCLUDED.role
-	`); err != nil {
+	`, username, password); err != nil {
 		return fmt.Errorf("ensure default admin: %w", err)
 	}
 	return nil
@@ -1054,50 +938,6 @@ func nullableText(value string) any {
 	return trimmed
 }
 
-func parseInt(raw string) int {
-	value := strings.TrimSpace(raw)
-	if value == "" {
-		return 0
-	}
-	n, err := strconv.Atoi(value)
-	if err == nil {
-		return n
-	}
-	f, err := strconv.ParseFloat(value, 64)
-	if err != nil {
-		return 0
-	}
-	return int(f)
-}
-
-func parseInt64(raw string) int64 {
-	value := strings.TrimSpace(raw)
-	if value == "" {
-		return 0
-	}
-	n, err := strconv.ParseInt(value, 10, 64)
-	if err == nil {
-		return n
-	}
-	f, err := strconv.ParseFloat(value, 64)
-	if err != nil {
-		return 0
-	}
-	return int64(f)
-}
-
-func parseFloat(raw string) float64 {
-	value := strings.TrimSpace(strings.ReplaceAll(raw, ",", ""))
-	if value == "" {
-		return 0
-	}
-	f, err := strconv.ParseFloat(value, 64)
-	if err != nil {
-		return 0
-	}
-	return f
-}
-
 func normalizeTimestamp(raw string) string {
 	value := strings.TrimSpace(raw)
 	if value == "" {
@@ -1119,9 +959,3 @@ func normalizeTimestamp(raw string) string {
 	}
 	return value
 }
-
-func init() {
-	if _, err := exec.LookPath("sqlite3"); err != nil {
-		log.Fatal(errors.New("sqlite3 command is required for legacy import"))
-	}
-}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"backend/internal/config"
+	"backend/internal/db"
+)
+
+func main() {
+	databaseURL := flag.String("url", "", "database URL (defaults to DATABASE_URL from env or .env)")
+	down := flag.Int("down", 0, "roll back the last N applied migrations instead of migrating up")
+	timeout := flag.Duration("timeout", 5*time.Minute, "overall timeout")
+	flag.Parse()
+
+	if *down < 0 {
+		fmt.Fprintln(os.Stderr, "-down must not be negative")
+		os.Exit(2)
+	}
+
+	url := *databaseURL
+	if url == "" {
+		cfg, err := config.Load()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "config error: %v\n", err)
+			os.Exit(2)
+		}
+		url = cfg.DatabaseURL
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	pool, err := db.NewPool(ctx, url, db.PoolOptions{MaxConns: 1, MinConns: 1})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "database error: %v\n", err)
+		os.Exit(1)
+	}
+	defer pool.Close()
+
+	if *down > 0 {
+		versions, err := db.RollbackMigrations(ctx, pool, *down)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "rollback failed: %v\n", err)
+			os.Exit(1)
+		}
+		for _, version := range versions {
+			fmt.Printf("rolled back %s\n", version)
+		}
+		return
+	}
+
+	if err := db.RunMigrations(ctx, pool); err != nil {
+		fmt.Fprintf(os.Stderr, "migration failed: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println("migrations up to date")
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
@@ -13,6 +14,7 @@ import (
 	"backend/internal/config"
 	"backend/internal/db"
 	httpapi "backend/internal/http"
+	"backend/internal/logging"
 	"backend/internal/repository"
 	"backend/internal/service"
 )
@@ -22,9 +24,17 @@ func main() {
 	if err != nil {
 		log.Fatalf("config error: %v", err)
 	}
+	if err := cfg.CheckDefaultAdmin(); err != nil {
+		log.Fatalf("config error: %v", err)
+	}
+	slog.SetDefault(logging.New(os.Stdout, cfg.LogLevel))
 
 	ctx := context.Background()
-	pool, err := db.NewPool(ctx, cfg.DatabaseURL)
+	pool, err := db.NewPool(ctx, cfg.DatabaseURL, db.PoolOptions{
+		MaxConns:    cfg.DBMaxConns,
+		MinConns:    cfg.DBMinConns,
+		MaxConnIdle: cfg.DBMaxConnIdle,
+	})
 	if err != nil {
 		log.Fatalf("database error: %v", err)
 	}
@@ -35,12 +45,23 @@ func main() {
 	}
 
 	repo := repository.New(pool)
-	svc := service.New(repo)
-	if err := svc.EnsureDefaultAdmin(ctx); err != nil {
+	svc := service.New(repo, service.Options{
+		PasswordMinLength:  cfg.PasswordMinLength,
+		AllowNegativeStock: !cfg.StrictStock,
+		RequireSellPrice:   cfg.RequireSellPrice,
+		MaxAutoLockMinutes: cfg.MaxAutoLockMinutes,
+		ReportFontPath:     cfg.ReportFontPath,
+		FractionalQuantity: cfg.FractionalQuantity,
+	})
+	if err := svc.EnsureDefaultAdmin(ctx, cfg.DefaultAdminUsername, cfg.DefaultAdminPassword); err != nil {
 		log.Fatalf("default admin init error: %v", err)
 	}
 	handler := httpapi.NewHandler(svc)
-	router := httpapi.NewRouter(handler)
+	router := httpapi.NewRouter(handler, httpapi.RouterOptions{
+		AllowedOrigins: cfg.AllowedOrigins,
+		MaxBodyBytes:   cfg.MaxBodyBytes,
+		MaxUploadBytes: cfg.MaxUploadBytes,
+	})
 
 	server := &http.Server{
 		Addr:              ":" + strconv.Itoa(cfg.Port),
@@ -52,7 +73,7 @@ func main() {
 	}
 
 	go func() {
-		log.Printf("backend listening on %s", server.Addr)
+		slog.Info("backend listening", "addr", server.Addr)
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			log.Fatalf("server error: %v", err)
 		}
@@ -65,9 +86,9 @@ func main() {
 	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 	if err := server.Shutdown(shutdownCtx); err != nil {
-		log.Printf("graceful shutdown failed: %v", err)
+		slog.Error("graceful shutdown failed", "error", err)
 		if closeErr := server.Close(); closeErr != nil {
-			log.Printf("force close failed: %v", closeErr)
+			slog.Error("force close failed", "error", closeErr)
 		}
 	}
 }
@@ -3,15 +3,40 @@ package config
 import (
 	"bufio"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
+)
+
+const (
+	defaultAdminUsername = "reza"
+	defaultAdminPassword = "reza1375"
 )
 
 type Config struct {
-	Port        int
-	DatabaseURL string
+	Port               int
+	DatabaseURL        string
+	PasswordMinLength  int
+	StrictStock        bool
+	RequireSellPrice   bool
+	MaxAutoLockMinutes int
+	ReportFontPath     string
+	FractionalQuantity bool
+	LogLevel           slog.Level
+	AllowedOrigins     []string
+	MaxBodyBytes       int64
+	MaxUploadBytes     int64
+	DBMaxConns         int
+	DBMinConns         int
+	DBMaxConnIdle      time.Duration
+	AppEnv             string
+	// DefaultAdminUsername and DefaultAdminPassword seed the first manager
+	// account when no admin with that username exists.
+	DefaultAdminUsername string
+	DefaultAdminPassword string
 }
 
 func Load() (Config, error) {
@@ -28,7 +53,17 @@ func Load() (Config, error) {
 		return Config{}, fmt.Errorf("stat %s: %w", envPath, err)
 	}
 
-	cfg := Config{Port: 8080}
+	cfg := Config{
+		Port:               8080,
+		PasswordMinLength:  8,
+		StrictStock:        true,
+		MaxAutoLockMinutes: 60,
+		DBMaxConns:         20,
+		DBMinConns:         2,
+		DBMaxConnIdle:      5 * time.Minute,
+		MaxBodyBytes:       4 << 20,
+		MaxUploadBytes:     32 << 20,
+	}
 	if portRaw := firstNonEmpty(os.Getenv("PORT"), values["PORT"]); portRaw != "" {
 		port, err := strconv.Atoi(portRaw)
 		if err != nil || port <= 0 {
@@ -37,6 +72,110 @@ func Load() (Config, error) {
 		cfg.Port = port
 	}
 
+	if minLengthRaw := firstNonEmpty(os.Getenv("PASSWORD_MIN_LENGTH"), values["PASSWORD_MIN_LENGTH"]); minLengthRaw != "" {
+		minLength, err := strconv.Atoi(minLengthRaw)
+		if err != nil || minLength <= 0 {
+			return Config{}, fmt.Errorf("invalid PASSWORD_MIN_LENGTH: %q", minLengthRaw)
+		}
+		cfg.PasswordMinLength = minLength
+	}
+
+	if strictStockRaw := firstNonEmpty(os.Getenv("STRICT_STOCK"), values["STRICT_STOCK"]); strictStockRaw != "" {
+		strictStock, err := strconv.ParseBool(strictStockRaw)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid STRICT_STOCK: %q", strictStockRaw)
+		}
+		cfg.StrictStock = strictStock
+	}
+
+	if requireSellPriceRaw := firstNonEmpty(os.Getenv("REQUIRE_SELL_PRICE"), values["REQUIRE_SELL_PRICE"]); requireSellPriceRaw != "" {
+		requireSellPrice, err := strconv.ParseBool(requireSellPriceRaw)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid REQUIRE_SELL_PRICE: %q", requireSellPriceRaw)
+		}
+		cfg.RequireSellPrice = requireSellPrice
+	}
+
+	if maxAutoLockRaw := firstNonEmpty(os.Getenv("MAX_AUTO_LOCK_MINUTES"), values["MAX_AUTO_LOCK_MINUTES"]); maxAutoLockRaw != "" {
+		maxAutoLock, err := strconv.Atoi(maxAutoLockRaw)
+		if err != nil || maxAutoLock <= 0 {
+			return Config{}, fmt.Errorf("invalid MAX_AUTO_LOCK_MINUTES: %q", maxAutoLockRaw)
+		}
+		cfg.MaxAutoLockMinutes = maxAutoLock
+	}
+
+	if fractionalQuantityRaw := firstNonEmpty(os.Getenv("FRACTIONAL_QUANTITY"), values["FRACTIONAL_QUANTITY"]); fractionalQuantityRaw != "" {
+		fractionalQuantity, err := strconv.ParseBool(fractionalQuantityRaw)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid FRACTIONAL_QUANTITY: %q", fractionalQuantityRaw)
+		}
+		cfg.FractionalQuantity = fractionalQuantity
+	}
+
+	if logLevelRaw := firstNonEmpty(os.Getenv("LOG_LEVEL"), values["LOG_LEVEL"]); logLevelRaw != "" {
+		if err := cfg.LogLevel.UnmarshalText([]byte(logLevelRaw)); err != nil {
+			return Config{}, fmt.Errorf("invalid LOG_LEVEL: %q", logLevelRaw)
+		}
+	}
+
+	if allowedOriginsRaw := firstNonEmpty(os.Getenv("ALLOWED_ORIGINS"), values["ALLOWED_ORIGINS"]); allowedOriginsRaw != "" {
+		for _, origin := range strings.Split(allowedOriginsRaw, ",") {
+			if origin = strings.TrimSpace(origin); origin != "" {
+				cfg.AllowedOrigins = append(cfg.AllowedOrigins, origin)
+			}
+		}
+	}
+
+	if maxBodyBytesRaw := firstNonEmpty(os.Getenv("MAX_BODY_BYTES"), values["MAX_BODY_BYTES"]); maxBodyBytesRaw != "" {
+		maxBodyBytes, err := strconv.ParseInt(maxBodyBytesRaw, 10, 64)
+		if err != nil || maxBodyBytes <= 0 {
+			return Config{}, fmt.Errorf("invalid MAX_BODY_BYTES: %q", maxBodyBytesRaw)
+		}
+		cfg.MaxBodyBytes = maxBodyBytes
+	}
+
+	if maxUploadBytesRaw := firstNonEmpty(os.Getenv("MAX_UPLOAD_BYTES"), values["MAX_UPLOAD_BYTES"]); maxUploadBytesRaw != "" {
+		maxUploadBytes, err := strconv.ParseInt(maxUploadBytesRaw, 10, 64)
+		if err != nil || maxUploadBytes <= 0 {
+			return Config{}, fmt.Errorf("invalid MAX_UPLOAD_BYTES: %q", maxUploadBytesRaw)
+		}
+		cfg.MaxUploadBytes = maxUploadBytes
+	}
+
+	if maxConnsRaw := firstNonEmpty(os.Getenv("DB_MAX_CONNS"), values["DB_MAX_CONNS"]); maxConnsRaw != "" {
+		maxConns, err := strconv.Atoi(maxConnsRaw)
+		if err != nil || maxConns <= 0 {
+			return Config{}, fmt.Errorf("invalid DB_MAX_CONNS: %q", maxConnsRaw)
+		}
+		cfg.DBMaxConns = maxConns
+	}
+
+	if minConnsRaw := firstNonEmpty(os.Getenv("DB_MIN_CONNS"), values["DB_MIN_CONNS"]); minConnsRaw != "" {
+		minConns, err := strconv.Atoi(minConnsRaw)
+		if err != nil || minConns <= 0 {
+			return Config{}, fmt.Errorf("invalid DB_MIN_CONNS: %q", minConnsRaw)
+		}
+		cfg.DBMinConns = minConns
+	}
+
+	if cfg.DBMinConns > cfg.DBMaxConns {
+		return Config{}, fmt.Errorf("DB_MIN_CONNS (%d) must not exceed DB_MAX_CONNS (%d)", cfg.DBMinConns, cfg.DBMaxConns)
+	}
+
+	if maxConnIdleRaw := firstNonEmpty(os.Getenv("DB_MAX_CONN_IDLE"), values["DB_MAX_CONN_IDLE"]); maxConnIdleRaw != "" {
+		maxConnIdle, err := time.ParseDuration(maxConnIdleRaw)
+		if err != nil || maxConnIdle <= 0 {
+			return Config{}, fmt.Errorf("invalid DB_MAX_CONN_IDLE: %q", maxConnIdleRaw)
+		}
+		cfg.DBMaxConnIdle = maxConnIdle
+	}
+
+	cfg.AppEnv = strings.ToLower(firstNonEmpty(os.Getenv("APP_ENV"), values["APP_ENV"], "development"))
+	cfg.DefaultAdminUsername = firstNonEmpty(os.Getenv("DEFAULT_ADMIN_USERNAME"), values["DEFAULT_ADMIN_USERNAME"], defaultAdminUsername)
+	cfg.DefaultAdminPassword = firstNonEmpty(os.Getenv("DEFAULT_ADMIN_PASSWORD"), values["DEFAULT_ADMIN_PASSWORD"], defaultAdminPassword)
+
+	cfg.ReportFontPath = firstNonEmpty(os.Getenv("REPORT_FONT_PATH"), values["REPORT_FONT_PATH"])
+
 	cfg.DatabaseURL = firstNonEmpty(os.Getenv("DATABASE_URL"), values["DATABASE_URL"])
 	if cfg.DatabaseURL == "" {
 		return Config{}, fmt.Errorf("DATABASE_URL is required (environment variable or .env)")
@@ -45,6 +184,16 @@ func Load() (Config, error) {
 	return cfg, nil
 }
 
+// CheckDefaultAdmin rejects the built-in default admin password when
+// APP_ENV=production. Only commands that seed the default admin call it, so
+// migrate, dbcheck and cleanup still run where that admin is not configured.
+func (c Config) CheckDefaultAdmin() error {
+	if c.AppEnv == "production" && c.DefaultAdminPassword == defaultAdminPassword {
+		return fmt.Errorf("DEFAULT_ADMIN_PASSWORD must be changed from the built-in default when APP_ENV=production")
+	}
+	return nil
+}
+
 func firstNonEmpty(candidates ...string) string {
 	for _, candidate := range candidates {
 		if value := strings.TrimSpace(candidate); value != "" {
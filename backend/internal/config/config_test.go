@@ -0,0 +1,176 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// loadWithEnv runs Load in an empty directory, so no .env file is read, with
+// DATABASE_URL set and every other key from env applied on top. Keys that
+// tests vary are cleared first so the caller's environment cannot leak in.
+func loadWithEnv(t *testing.T, env map[string]string) (Config, error) {
+	t.Helper()
+	t.Chdir(t.TempDir())
+	for _, key := range []string{
+		"DB_MAX_CONNS", "DB_MIN_CONNS", "DB_MAX_CONN_IDLE",
+		"APP_ENV", "DEFAULT_ADMIN_USERNAME", "DEFAULT_ADMIN_PASSWORD",
+		"REQUIRE_SELL_PRICE", "MAX_AUTO_LOCK_MINUTES",
+	} {
+		t.Setenv(key, "")
+	}
+	t.Setenv("DATABASE_URL", "postgres://localhost/test")
+	for key, value := range env {
+		t.Setenv(key, value)
+	}
+	return Load()
+}
+
+func TestLoadDBPool(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		wantMax  int
+		wantMin  int
+		wantIdle time.Duration
+		wantErr  string
+	}{
+		{name: "defaults", wantMax: 20, wantMin: 2, wantIdle: 5 * time.Minute},
+		{
+			name:     "all set",
+			env:      map[string]string{"DB_MAX_CONNS": "50", "DB_MIN_CONNS": "5", "DB_MAX_CONN_IDLE": "90s"},
+			wantMax:  50,
+			wantMin:  5,
+			wantIdle: 90 * time.Second,
+		},
+		{
+			name:     "min equal to max",
+			env:      map[string]string{"DB_MAX_CONNS": "4", "DB_MIN_CONNS": "4"},
+			wantMax:  4,
+			wantMin:  4,
+			wantIdle: 5 * time.Minute,
+		},
+		{name: "default min above max", env: map[string]string{"DB_MAX_CONNS": "1"}, wantErr: "DB_MIN_CONNS (2) must not exceed DB_MAX_CONNS (1)"},
+		{name: "max not a number", env: map[string]string{"DB_MAX_CONNS": "many"}, wantErr: `invalid DB_MAX_CONNS: "many"`},
+		{name: "max zero", env: map[string]string{"DB_MAX_CONNS": "0"}, wantErr: `invalid DB_MAX_CONNS: "0"`},
+		{name: "min negative", env: map[string]string{"DB_MIN_CONNS": "-1"}, wantErr: `invalid DB_MIN_CONNS: "-1"`},
+		{name: "idle without unit", env: map[string]string{"DB_MAX_CONN_IDLE": "300"}, wantErr: `invalid DB_MAX_CONN_IDLE: "300"`},
+		{name: "idle negative", env: map[string]string{"DB_MAX_CONN_IDLE": "-1m"}, wantErr: `invalid DB_MAX_CONN_IDLE: "-1m"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := loadWithEnv(t, tt.env)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Load() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load(): %v", err)
+			}
+			if cfg.DBMaxConns != tt.wantMax || cfg.DBMinConns != tt.wantMin || cfg.DBMaxConnIdle != tt.wantIdle {
+				t.Fatalf("pool = max %d, min %d, idle %s; want max %d, min %d, idle %s",
+					cfg.DBMaxConns, cfg.DBMinConns, cfg.DBMaxConnIdle, tt.wantMax, tt.wantMin, tt.wantIdle)
+			}
+		})
+	}
+}
+
+func TestLoadRequireSellPrice(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    bool
+		wantErr bool
+	}{
+		{raw: "", want: false},
+		{raw: "true", want: true},
+		{raw: "1", want: true},
+		{raw: "false", want: false},
+		{raw: "sometimes", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			cfg, err := loadWithEnv(t, map[string]string{"REQUIRE_SELL_PRICE": tt.raw})
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "invalid REQUIRE_SELL_PRICE") {
+					t.Fatalf("Load() error = %v, want invalid REQUIRE_SELL_PRICE", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load(): %v", err)
+			}
+			if cfg.RequireSellPrice != tt.want {
+				t.Fatalf("RequireSellPrice = %v, want %v", cfg.RequireSellPrice, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadMaxAutoLockMinutes(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    int
+		wantErr bool
+	}{
+		{raw: "", want: 60},
+		{raw: "240", want: 240},
+		{raw: "5", want: 5},
+		{raw: "0", wantErr: true},
+		{raw: "-10", wantErr: true},
+		{raw: "an hour", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			cfg, err := loadWithEnv(t, map[string]string{"MAX_AUTO_LOCK_MINUTES": tt.raw})
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "invalid MAX_AUTO_LOCK_MINUTES") {
+					t.Fatalf("Load() error = %v, want invalid MAX_AUTO_LOCK_MINUTES", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load(): %v", err)
+			}
+			if cfg.MaxAutoLockMinutes != tt.want {
+				t.Fatalf("MaxAutoLockMinutes = %d, want %d", cfg.MaxAutoLockMinutes, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckDefaultAdmin(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		wantEnv  string
+		wantUser string
+		wantErr  bool
+	}{
+		{name: "development default is fine", wantEnv: "development", wantUser: defaultAdminUsername},
+		{name: "production default is rejected", env: map[string]string{"APP_ENV": "production"}, wantEnv: "production", wantUser: defaultAdminUsername, wantErr: true},
+		{name: "APP_ENV is case-insensitive", env: map[string]string{"APP_ENV": "Production"}, wantEnv: "production", wantUser: defaultAdminUsername, wantErr: true},
+		{
+			name:     "production with its own password",
+			env:      map[string]string{"APP_ENV": "production", "DEFAULT_ADMIN_USERNAME": "owner", "DEFAULT_ADMIN_PASSWORD": "a-long-secret"},
+			wantEnv:  "production",
+			wantUser: "owner",
+		},
+		{name: "staging default is fine", env: map[string]string{"APP_ENV": "staging"}, wantEnv: "staging", wantUser: defaultAdminUsername},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := loadWithEnv(t, tt.env)
+			if err != nil {
+				t.Fatalf("Load(): %v", err)
+			}
+			if cfg.AppEnv != tt.wantEnv || cfg.DefaultAdminUsername != tt.wantUser {
+				t.Fatalf("AppEnv = %q, admin = %q; want %q, %q", cfg.AppEnv, cfg.DefaultAdminUsername, tt.wantEnv, tt.wantUser)
+			}
+			if err := cfg.CheckDefaultAdmin(); (err != nil) != tt.wantErr {
+				t.Fatalf("CheckDefaultAdmin() = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package db
 import (
 	"context"
 	"embed"
+	"errors"
 	"fmt"
 	"io/fs"
 	"sort"
@@ -11,10 +12,20 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// Migrations are either a single forward-only NNN_name.sql file or a
+// NNN_name.up.sql / NNN_name.down.sql pair. Both forms are recorded in
+// schema_migrations as NNN_name.sql, so a forward-only migration can later
+// gain a down file without being re-applied. Versions apply in filename order
+// and roll back in reverse.
+const (
+	upSuffix   = ".up.sql"
+	downSuffix = ".down.sql"
+)
+
 //go:embed migrations/*.sql
 var migrationFiles embed.FS
 
-func RunMigrations(ctx context.Context, pool *pgxpool.Pool) error {
+func ensureSchemaMigrationsTable(ctx context.Context, pool *pgxpool.Pool) error {
 	if _, err := pool.Exec(ctx, `
 		CREATE TABLE IF NOT EXISTS schema_migrations (
 			version TEXT PRIMARY KEY,
@@ -23,24 +34,22 @@ func RunMigrations(ctx context.Context, pool *pgxpool.Pool) error {
 	`); err != nil {
 		return fmt.Errorf("create schema_migrations table: %w", err)
 	}
+	return nil
+}
 
-	if err := ensureCoreSchema(ctx, pool); err != nil {
+func RunMigrations(ctx context.Context, pool *pgxpool.Pool) error {
+	if err := ensureSchemaMigrationsTable(ctx, pool); err != nil {
 		return err
 	}
 
-	entries, err := fs.ReadDir(migrationFiles, "migrations")
-	if err != nil {
-		return fmt.Errorf("read embedded migrations: %w", err)
+	if err := ensureCoreSchema(ctx, pool); err != nil {
+		return err
 	}
 
-	versions := make([]string, 0, len(entries))
-	for _, entry := range entries {
-		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
-			continue
-		}
-		versions = append(versions, entry.Name())
+	versions, err := MigrationVersions()
+	if err != nil {
+		return err
 	}
-	sort.Strings(versions)
 
 	for _, version := range versions {
 		var exists bool
@@ -54,9 +63,9 @@ func RunMigrations(ctx context.Context, pool *pgxpool.Pool) error {
 			continue
 		}
 
-		body, err := migrationFiles.ReadFile("migrations/" + version)
+		body, err := readMigration(version, upSuffix)
 		if err != nil {
-			return fmt.Errorf("read migration %s: %w", version, err)
+			return err
 		}
 
 		tx, err := pool.Begin(ctx)
@@ -87,6 +96,117 @@ func RunMigrations(ctx context.Context, pool *pgxpool.Pool) error {
 	return nil
 }
 
+// RollbackMigrations reverts the last steps applied versions using their down
+// files, newest first, in a single transaction. It fails without changing
+// anything if one of them has no down file.
+func RollbackMigrations(ctx context.Context, pool *pgxpool.Pool, steps int) ([]string, error) {
+	if steps <= 0 {
+		return nil, fmt.Errorf("rollback steps must be positive")
+	}
+	if err := ensureSchemaMigrationsTable(ctx, pool); err != nil {
+		return nil, err
+	}
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin rollback tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, `
+		SELECT version
+		FROM schema_migrations
+		ORDER BY version DESC
+		LIMIT $1
+		FOR UPDATE
+	`, steps)
+	if err != nil {
+		return nil, fmt.Errorf("list applied migrations: %w", err)
+	}
+	versions := make([]string, 0, steps)
+	for rows.Next() {
+		var version string
+		if err := rows.Scan(&version); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan applied migration: %w", err)
+		}
+		versions = append(versions, version)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return nil, fmt.Errorf("iterate applied migrations: %w", err)
+	}
+	rows.Close()
+	if len(versions) < steps {
+		return nil, fmt.Errorf("cannot roll back %d migrations: only %d applied", steps, len(versions))
+	}
+
+	bodies := make([][]byte, 0, len(versions))
+	for _, version := range versions {
+		body, err := readMigration(version, downSuffix)
+		if err != nil {
+			return nil, err
+		}
+		bodies = append(bodies, body)
+	}
+
+	for i, version := range versions {
+		if _, err := tx.Exec(ctx, string(bodies[i])); err != nil {
+			return nil, fmt.Errorf("roll back migration %s: %w", version, err)
+		}
+		if _, err := tx.Exec(ctx, "DELETE FROM schema_migrations WHERE version = $1", version); err != nil {
+			return nil, fmt.Errorf("unrecord migration %s: %w", version, err)
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("commit rollback tx: %w", err)
+	}
+	return versions, nil
+}
+
+// MigrationVersions returns the embedded migration versions in apply order.
+func MigrationVersions() ([]string, error) {
+	entries, err := fs.ReadDir(migrationFiles, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("read embedded migrations: %w", err)
+	}
+
+	versions := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".sql") || strings.HasSuffix(name, downSuffix) {
+			continue
+		}
+		if strings.HasSuffix(name, upSuffix) {
+			name = strings.TrimSuffix(name, upSuffix) + ".sql"
+		}
+		versions = append(versions, name)
+	}
+	sort.Strings(versions)
+	return versions, nil
+}
+
+// readMigration loads the body of version in the given direction. Forward
+// migrations fall back to the plain NNN_name.sql file.
+func readMigration(version string, suffix string) ([]byte, error) {
+	base := strings.TrimSuffix(version, ".sql")
+	body, err := migrationFiles.ReadFile("migrations/" + base + suffix)
+	if err == nil {
+		return body, nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("read migration %s: %w", version, err)
+	}
+	if suffix == downSuffix {
+		return nil, fmt.Errorf("migration %s has no down file", version)
+	}
+	body, err = migrationFiles.ReadFile("migrations/" + version)
+	if err != nil {
+		return nil, fmt.Errorf("read migration %s: %w", version, err)
+	}
+	return body, nil
+}
+
 func ensureCoreSchema(ctx context.Context, pool *pgxpool.Pool) error {
 	steps := []struct {
 		name string
@@ -238,6 +358,18 @@ func ensureCoreSchema(ctx context.Context, pool *pgxpool.Pool) error {
 				)
 			`,
 		},
+		{
+			name: "categories table",
+			sql: `
+				CREATE TABLE IF NOT EXISTS categories (
+					id BIGSERIAL PRIMARY KEY,
+					name TEXT NOT NULL,
+					name_normalized TEXT GENERATED ALWAYS AS (LOWER(name)) STORED,
+					created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+					CONSTRAINT uq_categories_name_normalized UNIQUE (name_normalized)
+				)
+			`,
+		},
 		{
 			name: "invoice_stock_effects table",
 			sql: `
@@ -311,6 +443,17 @@ func ensureCoreSchema(ctx context.Context, pool *pgxpool.Pool) error {
 				ADD COLUMN IF NOT EXISTS sell_price NUMERIC(14,4) NOT NULL DEFAULT 0
 			`,
 		},
+		{
+			name: "products.category_id column",
+			sql: `
+				ALTER TABLE products
+				ADD COLUMN IF NOT EXISTS category_id BIGINT REFERENCES categories(id) ON DELETE SET NULL
+			`,
+		},
+		{
+			name: "products category index",
+			sql:  `CREATE INDEX IF NOT EXISTS idx_products_category_id ON products (category_id)`,
+		},
 	}
 
 	for _, step := range steps {
@@ -0,0 +1,161 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func TestMigrationVersions(t *testing.T) {
+	versions, err := MigrationVersions()
+	if err != nil {
+		t.Fatalf("MigrationVersions: %v", err)
+	}
+	if !slices.IsSorted(versions) {
+		t.Fatalf("versions are not in apply order: %v", versions)
+	}
+	for i, version := range versions {
+		if !strings.HasSuffix(version, ".sql") || strings.HasSuffix(version, upSuffix) || strings.HasSuffix(version, downSuffix) {
+			t.Errorf("version %q should be recorded as NNN_name.sql", version)
+		}
+		if i > 0 && versions[i-1] == version {
+			t.Errorf("version %q is listed twice", version)
+		}
+		if _, err := readMigration(version, upSuffix); err != nil {
+			t.Errorf("read %s: %v", version, err)
+		}
+		// 001-007 predate down files; every later migration must be
+		// reversible.
+		_, err := readMigration(version, downSuffix)
+		if version >= "008" && err != nil {
+			t.Errorf("%s has no down file: %v", version, err)
+		}
+	}
+}
+
+func TestRollbackMigrationsRejectsSteps(t *testing.T) {
+	for _, steps := range []int{0, -1} {
+		if _, err := RollbackMigrations(context.Background(), nil, steps); err == nil || err.Error() != "rollback steps must be positive" {
+			t.Errorf("RollbackMigrations(%d) error = %v", steps, err)
+		}
+	}
+}
+
+// TestRollbackMigrations applies every migration in a fresh schema of
+// TEST_DATABASE_URL, rolls back all that have down files, and applies them
+// again. The schema keeps it apart from other tests sharing the database.
+func TestRollbackMigrations(t *testing.T) {
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	admin, err := NewPool(ctx, url, PoolOptions{MaxConns: 1, MinConns: 1})
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	schema := fmt.Sprintf("migrate_test_%d", time.Now().UnixNano())
+	if _, err := admin.Exec(ctx, "CREATE SCHEMA "+schema); err != nil {
+		admin.Close()
+		t.Fatalf("create schema: %v", err)
+	}
+	t.Cleanup(func() {
+		_, _ = admin.Exec(context.Background(), "DROP SCHEMA "+schema+" CASCADE")
+		admin.Close()
+	})
+
+	cfg, err := pgxpool.ParseConfig(url)
+	if err != nil {
+		t.Fatalf("parse url: %v", err)
+	}
+	cfg.ConnConfig.RuntimeParams["search_path"] = schema
+	cfg.MaxConns = 1
+	pool, err := pgxpool.NewWithConfig(ctx, cfg)
+	if err != nil {
+		t.Fatalf("connect to schema: %v", err)
+	}
+	t.Cleanup(pool.Close)
+
+	applied := func() []string {
+		t.Helper()
+		rows, err := pool.Query(ctx, "SELECT version FROM schema_migrations ORDER BY version")
+		if err != nil {
+			t.Fatalf("list applied: %v", err)
+		}
+		defer rows.Close()
+		versions := make([]string, 0)
+		for rows.Next() {
+			var version string
+			if err := rows.Scan(&version); err != nil {
+				t.Fatalf("scan applied: %v", err)
+			}
+			versions = append(versions, version)
+		}
+		if err := rows.Err(); err != nil {
+			t.Fatalf("iterate applied: %v", err)
+		}
+		return versions
+	}
+
+	if err := RunMigrations(ctx, pool); err != nil {
+		t.Fatalf("migrate up: %v", err)
+	}
+	versions, err := MigrationVersions()
+	if err != nil {
+		t.Fatalf("MigrationVersions: %v", err)
+	}
+	if got := applied(); !reflect.DeepEqual(got, versions) {
+		t.Fatalf("applied = %v, want %v", got, versions)
+	}
+
+	reversible := 0
+	for _, version := range versions {
+		if _, err := readMigration(version, downSuffix); err == nil {
+			reversible++
+		}
+	}
+	kept := versions[:len(versions)-reversible]
+	want := slices.Clone(versions[len(kept):])
+	slices.Reverse(want)
+
+	rolledBack, err := RollbackMigrations(ctx, pool, reversible)
+	if err != nil {
+		t.Fatalf("roll back %d: %v", reversible, err)
+	}
+	if !reflect.DeepEqual(rolledBack, want) {
+		t.Fatalf("rolled back %v, want %v", rolledBack, want)
+	}
+	if got := applied(); !reflect.DeepEqual(got, kept) {
+		t.Fatalf("applied after rollback = %v, want %v", got, kept)
+	}
+	if _, err := pool.Exec(ctx, "SELECT last_login_at FROM admins LIMIT 0"); err == nil {
+		t.Fatalf("admins.last_login_at still exists after rollback")
+	}
+
+	// The next version has no down file, so nothing may change.
+	if _, err := RollbackMigrations(ctx, pool, 1); err == nil || !strings.Contains(err.Error(), "has no down file") {
+		t.Fatalf("rollback past the reversible migrations error = %v", err)
+	}
+	if got := applied(); !reflect.DeepEqual(got, kept) {
+		t.Fatalf("applied after failed rollback = %v, want %v", got, kept)
+	}
+
+	if err := RunMigrations(ctx, pool); err != nil {
+		t.Fatalf("migrate up again: %v", err)
+	}
+	if got := applied(); !reflect.DeepEqual(got, versions) {
+		t.Fatalf("applied after re-apply = %v, want %v", got, versions)
+	}
+	if _, err := pool.Exec(ctx, "SELECT last_login_at FROM admins LIMIT 0"); err != nil {
+		t.Fatalf("admins.last_login_at missing after re-apply: %v", err)
+	}
+}
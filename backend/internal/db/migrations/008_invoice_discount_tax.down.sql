@@ -0,0 +1,3 @@
+ALTER TABLE invoices
+    DROP COLUMN IF EXISTS discount_amount,
+    DROP COLUMN IF EXISTS tax_amount;
@@ -0,0 +1,3 @@
+ALTER TABLE invoices
+    ADD COLUMN IF NOT EXISTS discount_amount NUMERIC(14,4),
+    ADD COLUMN IF NOT EXISTS tax_amount NUMERIC(14,4);
@@ -0,0 +1,2 @@
+ALTER TABLE invoice_lines
+    DROP COLUMN IF EXISTS discount;
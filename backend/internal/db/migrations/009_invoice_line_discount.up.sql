@@ -0,0 +1,2 @@
+ALTER TABLE invoice_lines
+    ADD COLUMN IF NOT EXISTS discount NUMERIC(14,4) NOT NULL DEFAULT 0;
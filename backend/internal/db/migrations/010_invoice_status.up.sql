@@ -0,0 +1,3 @@
+ALTER TABLE invoices
+    ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'finalized'
+        CHECK (status IN ('draft', 'finalized', 'void'));
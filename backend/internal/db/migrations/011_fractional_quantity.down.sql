@@ -0,0 +1,24 @@
+-- Whole-unit columns cannot hold decimal stock, so refuse instead of
+-- rounding it away.
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM products WHERE quantity <> TRUNC(quantity))
+        OR EXISTS (SELECT 1 FROM invoices WHERE total_qty <> TRUNC(total_qty))
+        OR EXISTS (SELECT 1 FROM invoice_lines WHERE quantity <> TRUNC(quantity))
+        OR EXISTS (SELECT 1 FROM invoice_stock_effects WHERE quantity <> TRUNC(quantity)) THEN
+        RAISE EXCEPTION 'decimal quantities are stored; they must be whole numbers before rolling back';
+    END IF;
+END
+$$;
+
+ALTER TABLE products
+    ALTER COLUMN quantity TYPE INTEGER;
+
+ALTER TABLE invoices
+    ALTER COLUMN total_qty TYPE INTEGER;
+
+ALTER TABLE invoice_lines
+    ALTER COLUMN quantity TYPE INTEGER;
+
+ALTER TABLE invoice_stock_effects
+    ALTER COLUMN quantity TYPE INTEGER;
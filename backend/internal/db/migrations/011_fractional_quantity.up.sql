@@ -0,0 +1,11 @@
+ALTER TABLE products
+    ALTER COLUMN quantity TYPE NUMERIC(14,3);
+
+ALTER TABLE invoices
+    ALTER COLUMN total_qty TYPE NUMERIC(14,3);
+
+ALTER TABLE invoice_lines
+    ALTER COLUMN quantity TYPE NUMERIC(14,3);
+
+ALTER TABLE invoice_stock_effects
+    ALTER COLUMN quantity TYPE NUMERIC(14,3);
@@ -0,0 +1,3 @@
+ALTER TABLE invoices
+    DROP COLUMN IF EXISTS supplier_name,
+    DROP COLUMN IF EXISTS customer_name;
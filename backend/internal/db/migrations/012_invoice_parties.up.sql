@@ -0,0 +1,3 @@
+ALTER TABLE invoices
+    ADD COLUMN IF NOT EXISTS supplier_name TEXT,
+    ADD COLUMN IF NOT EXISTS customer_name TEXT;
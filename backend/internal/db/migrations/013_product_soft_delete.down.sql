@@ -0,0 +1,5 @@
+DELETE FROM products
+WHERE deleted_at IS NOT NULL;
+
+ALTER TABLE products
+    DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS inventory_snapshots (
+    snapshot_date DATE PRIMARY KEY,
+    total_products INTEGER NOT NULL,
+    total_quantity NUMERIC(14,3) NOT NULL,
+    inventory_value NUMERIC(18,4) NOT NULL,
+    recorded_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
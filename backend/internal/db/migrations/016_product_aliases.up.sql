@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS product_aliases (
+    alias_normalized TEXT PRIMARY KEY,
+    product_id BIGINT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    alias TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_aliases_product_id
+    ON product_aliases (product_id);
@@ -0,0 +1,8 @@
+ALTER TABLE products
+    ADD COLUMN IF NOT EXISTS unit TEXT NOT NULL DEFAULT 'piece';
+
+ALTER TABLE products
+    DROP CONSTRAINT IF EXISTS chk_products_unit;
+
+ALTER TABLE products
+    ADD CONSTRAINT chk_products_unit CHECK (unit IN ('piece', 'weight'));
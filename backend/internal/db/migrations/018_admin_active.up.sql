@@ -0,0 +1,2 @@
+ALTER TABLE admins
+    ADD COLUMN IF NOT EXISTS active BOOLEAN NOT NULL DEFAULT TRUE;
@@ -0,0 +1,2 @@
+ALTER TABLE admins
+    DROP COLUMN IF EXISTS last_login_at;
@@ -0,0 +1,2 @@
+ALTER TABLE admins
+    ADD COLUMN IF NOT EXISTS last_login_at TIMESTAMPTZ;
@@ -8,15 +8,41 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-func NewPool(ctx context.Context, databaseURL string) (*pgxpool.Pool, error) {
+const (
+	DefaultMaxConns    = 20
+	DefaultMinConns    = 2
+	DefaultMaxConnIdle = 5 * time.Minute
+)
+
+// PoolOptions sizes the connection pool; zero values use the defaults above.
+type PoolOptions struct {
+	MaxConns    int
+	MinConns    int
+	MaxConnIdle time.Duration
+}
+
+func NewPool(ctx context.Context, databaseURL string, opts PoolOptions) (*pgxpool.Pool, error) {
 	cfg, err := pgxpool.ParseConfig(databaseURL)
 	if err != nil {
 		return nil, fmt.Errorf("parse database url: %w", err)
 	}
 
-	cfg.MaxConns = 20
-	cfg.MinConns = 2
-	cfg.MaxConnIdleTime = 5 * time.Minute
+	if opts.MaxConns <= 0 {
+		opts.MaxConns = DefaultMaxConns
+	}
+	if opts.MinConns <= 0 {
+		opts.MinConns = DefaultMinConns
+	}
+	if opts.MaxConnIdle <= 0 {
+		opts.MaxConnIdle = DefaultMaxConnIdle
+	}
+	if opts.MinConns > opts.MaxConns {
+		return nil, fmt.Errorf("min conns %d exceeds max conns %d", opts.MinConns, opts.MaxConns)
+	}
+
+	cfg.MaxConns = int32(opts.MaxConns)
+	cfg.MinConns = int32(opts.MinConns)
+	cfg.MaxConnIdleTime = opts.MaxConnIdle
 	cfg.HealthCheckPeriod = 30 * time.Second
 
 	pool, err := pgxpool.NewWithConfig(ctx, cfg)
@@ -33,3 +59,19 @@ func NewPool(ctx context.Context, databaseURL string) (*pgxpool.Pool, error) {
 
 	return pool, nil
 }
+
+// Check opens a short-lived pool, pings the database and returns the server
+// version. It does not run migrations.
+func Check(ctx context.Context, databaseURL string) (string, error) {
+	pool, err := NewPool(ctx, databaseURL, PoolOptions{MaxConns: 1, MinConns: 1})
+	if err != nil {
+		return "", err
+	}
+	defer pool.Close()
+
+	var version string
+	if err := pool.QueryRow(ctx, "SHOW server_version").Scan(&version); err != nil {
+		return "", fmt.Errorf("query server version: %w", err)
+	}
+	return version, nil
+}
@@ -5,14 +5,19 @@ import "time"
 type Product struct {
 	ID           int64     `json:"id"`
 	ProductName  string    `json:"product_name"`
-	Quantity     int       `json:"quantity"`
+	Quantity     float64   `json:"quantity"`
 	AvgBuyPrice  float64   `json:"avg_buy_price"`
 	LastBuyPrice float64   `json:"last_buy_price"`
 	SellPrice    float64   `json:"sell_price"`
 	Alarm        *int      `json:"alarm,omitempty"`
 	Source       *string   `json:"source,omitempty"`
+	CategoryID   *int64    `json:"category_id,omitempty"`
+	CategoryName *string   `json:"category_name,omitempty"`
+	SKU          *string   `json:"sku,omitempty"`
+	Unit         string    `json:"unit"`
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
+	MatchScore   *float64  `json:"match_score,omitempty"`
 }
 
 type Invoice struct {
@@ -20,10 +25,15 @@ type Invoice struct {
 	InvoiceType    string                `json:"invoice_type"`
 	CreatedAt      time.Time             `json:"created_at"`
 	TotalLines     int                   `json:"total_lines"`
-	TotalQty       int                   `json:"total_qty"`
+	TotalQty       float64               `json:"total_qty"`
 	TotalAmount    float64               `json:"total_amount"`
 	InvoiceName    *string               `json:"invoice_name,omitempty"`
 	AdminUsername  *string               `json:"admin_username,omitempty"`
+	SupplierName   *string               `json:"supplier_name,omitempty"`
+	CustomerName   *string               `json:"customer_name,omitempty"`
+	DiscountAmount *float64              `json:"discount_amount,omitempty"`
+	TaxAmount      *float64              `json:"tax_amount,omitempty"`
+	Status         string                `json:"status"`
 	ProductMatches []InvoiceProductMatch `json:"product_matches,omitempty"`
 }
 
@@ -31,7 +41,7 @@ type InvoiceProductMatch struct {
 	RowNumber   int     `json:"row_number"`
 	ProductName string  `json:"product_name"`
 	Price       float64 `json:"price"`
-	Quantity    int     `json:"quantity"`
+	Quantity    float64 `json:"quantity"`
 	LineTotal   float64 `json:"line_total"`
 	CostPrice   float64 `json:"cost_price"`
 }
@@ -41,7 +51,8 @@ type InvoiceLine struct {
 	InvoiceID   int64   `json:"invoice_id"`
 	ProductName string  `json:"product_name"`
 	Price       float64 `json:"price"`
-	Quantity    int     `json:"quantity"`
+	Quantity    float64 `json:"quantity"`
+	Discount    float64 `json:"discount"`
 	LineTotal   float64 `json:"line_total"`
 	CostPrice   float64 `json:"cost_price"`
 }
@@ -54,25 +65,92 @@ type MonthlySummary struct {
 	InvoiceCount  int     `json:"invoice_count"`
 }
 
+type DailySummary struct {
+	Day           string  `json:"day"`
+	PurchaseTotal float64 `json:"purchase_total"`
+	SalesTotal    float64 `json:"sales_total"`
+	Profit        float64 `json:"profit"`
+	InvoiceCount  int     `json:"invoice_count"`
+}
+
 type MonthlyQuantitySummary struct {
-	Month            string `json:"month"`
-	SalesQty         int    `json:"sales_qty"`
-	PurchaseQty      int    `json:"purchase_qty"`
-	NetQty           int    `json:"net_qty"`
-	SalesInvoices    int    `json:"sales_invoices"`
-	PurchaseInvoices int    `json:"purchase_invoices"`
+	Month            string  `json:"month"`
+	SalesQty         float64 `json:"sales_qty"`
+	PurchaseQty      float64 `json:"purchase_qty"`
+	NetQty           float64 `json:"net_qty"`
+	SalesInvoices    int     `json:"sales_invoices"`
+	PurchaseInvoices int     `json:"purchase_invoices"`
 }
 
 type TopSoldProduct struct {
 	ProductName  string     `json:"product_name"`
-	SoldQty      int        `json:"sold_qty"`
+	SoldQty      float64    `json:"sold_qty"`
 	InvoiceCount int        `json:"invoice_count"`
 	LastSoldAt   *time.Time `json:"last_sold_at,omitempty"`
 }
 
+type ProductProfit struct {
+	ProductName   string  `json:"product_name"`
+	UnitsSold     float64 `json:"units_sold"`
+	Revenue       float64 `json:"revenue"`
+	Profit        float64 `json:"profit"`
+	MarginPercent float64 `json:"margin_percent"`
+}
+
+type AdminSalesStat struct {
+	AdminUsername string  `json:"admin_username"`
+	InvoiceCount  int     `json:"invoice_count"`
+	TotalSales    float64 `json:"total_sales"`
+	TotalProfit   float64 `json:"total_profit"`
+	UnitsSold     float64 `json:"units_sold"`
+}
+
+type RevenueParetoRow struct {
+	ProductName       string  `json:"product_name"`
+	Revenue           float64 `json:"revenue"`
+	SharePercent      float64 `json:"share_percent"`
+	CumulativePercent float64 `json:"cumulative_percent"`
+}
+
+type StockReconciliationRow struct {
+	ProductName    string  `json:"product_name"`
+	TotalPurchased float64 `json:"total_purchased"`
+	TotalSold      float64 `json:"total_sold"`
+	ExpectedQty    float64 `json:"expected_qty"`
+	ActualQty      float64 `json:"actual_qty"`
+	Discrepancy    float64 `json:"discrepancy"`
+}
+
+type DeadStockRow struct {
+	ProductID   int64   `json:"product_id"`
+	ProductName string  `json:"product_name"`
+	Quantity    float64 `json:"quantity"`
+	AvgBuyPrice float64 `json:"avg_buy_price"`
+	TiedUpValue float64 `json:"tied_up_value"`
+	Source      *string `json:"source,omitempty"`
+}
+
+type ReorderSuggestion struct {
+	ProductID      int64   `json:"product_id"`
+	ProductName    string  `json:"product_name"`
+	Quantity       float64 `json:"quantity"`
+	DailyVelocity  float64 `json:"daily_velocity"`
+	DaysToStockout float64 `json:"days_to_stockout"`
+	SuggestedQty   float64 `json:"suggested_qty"`
+	Source         *string `json:"source,omitempty"`
+}
+
+type InventorySnapshot struct {
+	Date           string    `json:"date"`
+	TotalProducts  int       `json:"total_products"`
+	TotalQuantity  float64   `json:"total_quantity"`
+	InventoryValue float64   `json:"inventory_value"`
+	RecordedAt     time.Time `json:"recorded_at"`
+}
+
 type UnsoldProduct struct {
 	ProductName string    `json:"product_name"`
-	Quantity    int       `json:"quantity"`
+	Quantity    float64   `json:"quantity"`
 	AvgBuyPrice float64   `json:"avg_buy_price"`
 	SellPrice   float64   `json:"sell_price"`
 	Source      *string   `json:"source,omitempty"`
@@ -82,18 +160,20 @@ type UnsoldProduct struct {
 type PurchaseLineInput struct {
 	ProductName string  `json:"product_name"`
 	Price       float64 `json:"price"`
-	Quantity    int     `json:"quantity"`
+	Quantity    float64 `json:"quantity"`
+	Discount    float64 `json:"discount"`
 }
 
 type SalesLineInput struct {
 	ProductName string  `json:"product_name"`
 	Price       float64 `json:"price"`
-	Quantity    int     `json:"quantity"`
+	Quantity    float64 `json:"quantity"`
+	Discount    float64 `json:"discount"`
 }
 
 type InventoryImportRow struct {
 	ProductName  string  `json:"product_name"`
-	Quantity     int     `json:"quantity"`
+	Quantity     float64 `json:"quantity"`
 	AvgBuyPrice  float64 `json:"avg_buy_price"`
 	LastBuyPrice float64 `json:"last_buy_price"`
 	SellPrice    float64 `json:"sell_price"`
@@ -106,29 +186,90 @@ type InventorySyncResult struct {
 	Deleted  int `json:"deleted"`
 }
 
+type InventoryImportPreview struct {
+	TotalRows      int      `json:"total_rows"`
+	WouldCreate    int      `json:"would_create"`
+	WouldUpdate    int      `json:"would_update"`
+	DuplicateNames []string `json:"duplicate_names"`
+	MergedNames    []string `json:"merged_names"`
+}
+
 type ProductPriceRow struct {
 	ProductName string  `json:"product_name"`
 	Price       float64 `json:"price"`
 }
 
 type SellPriceImportResult struct {
-	TotalRows       int      `json:"total_rows"`
-	MatchedRows     int      `json:"matched_rows"`
-	UpdatedProducts int      `json:"updated_products"`
-	UnmatchedCount  int      `json:"unmatched_count"`
-	UnmatchedNames  []string `json:"unmatched_names,omitempty"`
+	TotalRows        int                   `json:"total_rows"`
+	MatchedRows      int                   `json:"matched_rows"`
+	TokenMatchedRows int                   `json:"token_matched"`
+	FuzzyMatchedRows int                   `json:"fuzzy_matched_rows"`
+	UpdatedProducts  int                   `json:"updated_products"`
+	UnmatchedCount   int                   `json:"unmatched_count"`
+	UnmatchedNames   []string              `json:"unmatched_names,omitempty"`
+	FuzzyMatches     []SellPriceFuzzyMatch `json:"fuzzy_matches,omitempty"`
+}
+
+type SellPriceFuzzyMatch struct {
+	ProductName string  `json:"product_name"`
+	MatchedName string  `json:"matched_name"`
+	Score       float64 `json:"score"`
+}
+
+type SellPriceMatchPreview struct {
+	Row                int     `json:"row"`
+	ProductName        string  `json:"product_name"`
+	Price              float64 `json:"price"`
+	MatchedProductID   *int64  `json:"matched_product_id,omitempty"`
+	MatchedProductName *string `json:"matched_product_name,omitempty"`
+	Score              float64 `json:"score"`
+	MatchType          string  `json:"match_type"`
 }
 
 type LowStockRow struct {
 	ProductName string  `json:"product_name"`
-	Quantity    int     `json:"quantity"`
+	Quantity    float64 `json:"quantity"`
 	Alarm       int     `json:"alarm"`
-	Needed      int     `json:"needed"`
+	Needed      float64 `json:"needed"`
 	AvgBuyPrice float64 `json:"avg_buy_price"`
 	SellPrice   float64 `json:"sell_price"`
 	Source      *string `json:"source,omitempty"`
 }
 
+type PriceAlarmRow struct {
+	ProductName   string  `json:"product_name"`
+	AvgBuyPrice   float64 `json:"avg_buy_price"`
+	LastBuyPrice  float64 `json:"last_buy_price"`
+	SellPrice     float64 `json:"sell_price"`
+	MarginPercent float64 `json:"margin_percent"`
+	Source        *string `json:"source,omitempty"`
+}
+
+type MarginAlertRow struct {
+	ProductID     int64   `json:"product_id"`
+	ProductName   string  `json:"product_name"`
+	AvgBuyPrice   float64 `json:"avg_buy_price"`
+	SellPrice     float64 `json:"sell_price"`
+	MarginPercent float64 `json:"margin_percent"`
+	Shortfall     float64 `json:"shortfall"`
+	Source        *string `json:"source,omitempty"`
+}
+
+type Setting struct {
+	Key       string     `json:"key"`
+	Value     float64    `json:"value"`
+	Default   float64    `json:"default"`
+	Min       float64    `json:"min"`
+	Max       float64    `json:"max"`
+	Integer   bool       `json:"integer"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+type ProductAlias struct {
+	Alias     string    `json:"alias"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
 type ProductRenameResult struct {
 	UpdatedLines      int     `json:"updated_lines"`
 	UpdatedInvoiceIDs []int64 `json:"updated_invoice_ids"`
@@ -143,18 +284,26 @@ type ActionEntry struct {
 	Details       string    `json:"details"`
 }
 
+type BasalamOrderID struct {
+	ID      string    `json:"id"`
+	SavedAt time.Time `json:"saved_at"`
+}
+
 type AdminUser struct {
-	AdminID         int64  `json:"admin_id"`
-	Username        string `json:"username"`
-	Role            string `json:"role"`
-	AutoLockMinutes int    `json:"auto_lock_minutes"`
+	AdminID         int64      `json:"admin_id"`
+	Username        string     `json:"username"`
+	Role            string     `json:"role"`
+	AutoLockMinutes int        `json:"auto_lock_minutes"`
+	Active          bool       `json:"active"`
+	LastLoginAt     *time.Time `json:"last_login_at,omitempty"`
 }
 
 type SalesPreviewRow struct {
 	ProductName  string  `json:"product_name"`
-	QuantitySold int     `json:"quantity_sold"`
+	QuantitySold float64 `json:"quantity_sold"`
 	SellPrice    float64 `json:"sell_price"`
 	CostPrice    float64 `json:"cost_price"`
+	Reserved     float64 `json:"reserved"`
 	Status       string  `json:"status"`
 	Message      string  `json:"message"`
 	ResolvedName string  `json:"resolved_name"`
@@ -170,3 +319,9 @@ type ProductGroup struct {
 	Name    string               `json:"name"`
 	Members []ProductGroupMember `json:"members,omitempty"`
 }
+
+type Category struct {
+	CategoryID   int64  `json:"category_id"`
+	Name         string `json:"name"`
+	ProductCount int    `json:"product_count"`
+}
@@ -0,0 +1,159 @@
+package excel
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+
+	"backend/internal/domain"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// InventoryExportHeader uses the canonical names accepted by the inventory
+// parser so an exported file can be imported again unchanged.
+var InventoryExportHeader = []string{
+	"product_name",
+	"quantity",
+	"avg_buy_price",
+	"last_buy_price",
+	"sell_price",
+	"alarm",
+	"source",
+}
+
+// WriteInventoryCSV writes one row per product that each passes to its
+// callback, so a caller can stream products from the database without
+// holding them all.
+func WriteInventoryCSV(w io.Writer, each func(fn func(domain.Product) error) error) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(InventoryExportHeader); err != nil {
+		return fmt.Errorf("write csv header: %w", err)
+	}
+	written := 0
+	err := each(func(product domain.Product) error {
+		if err := writer.Write(inventoryExportRow(product)); err != nil {
+			return fmt.Errorf("write csv row: %w", err)
+		}
+		written++
+		if written%500 == 0 {
+			writer.Flush()
+			return writer.Error()
+		}
+		return nil
+	})
+	writer.Flush()
+	if err != nil {
+		return err
+	}
+	return writer.Error()
+}
+
+// WriteInventoryXLSX is WriteInventoryCSV for xlsx. Rows go through a
+// StreamWriter, which spills large sheets to a temporary file; excelize
+// still assembles the compressed workbook in memory before writing it to w.
+func WriteInventoryXLSX(w io.Writer, each func(fn func(domain.Product) error) error) error {
+	file := excelize.NewFile()
+	defer file.Close()
+
+	const sheet = "Inventory"
+	if err := file.SetSheetName(file.GetSheetName(0), sheet); err != nil {
+		return fmt.Errorf("rename sheet: %w", err)
+	}
+	stream, err := file.NewStreamWriter(sheet)
+	if err != nil {
+		return fmt.Errorf("open sheet writer: %w", err)
+	}
+
+	header := make([]any, len(InventoryExportHeader))
+	for i, name := range InventoryExportHeader {
+		header[i] = name
+	}
+	if err := stream.SetRow("A1", header); err != nil {
+		return fmt.Errorf("write xlsx header: %w", err)
+	}
+	row := 2
+	err = each(func(product domain.Product) error {
+		var alarm, source any
+		if product.Alarm != nil {
+			alarm = *product.Alarm
+		}
+		if product.Source != nil {
+			source = *product.Source
+		}
+		cell, err := excelize.CoordinatesToCellName(1, row)
+		if err != nil {
+			return err
+		}
+		if err := stream.SetRow(cell, []any{
+			product.ProductName,
+			product.Quantity,
+			product.AvgBuyPrice,
+			product.LastBuyPrice,
+			product.SellPrice,
+			alarm,
+			source,
+		}); err != nil {
+			return fmt.Errorf("write xlsx row %d: %w", row, err)
+		}
+		row++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := stream.Flush(); err != nil {
+		return fmt.Errorf("flush xlsx sheet: %w", err)
+	}
+	if _, err := file.WriteTo(w); err != nil {
+		return fmt.Errorf("write xlsx file: %w", err)
+	}
+	return nil
+}
+
+// inventoryTemplateProducts passes the single example row of the import
+// template, filled in for every column so users see the expected formats.
+func inventoryTemplateProducts(fn func(domain.Product) error) error {
+	alarm := 5
+	source := "supplier"
+	return fn(domain.Product{
+		ProductName:  "Sample product",
+		Quantity:     10,
+		AvgBuyPrice:  100000,
+		LastBuyPrice: 110000,
+		SellPrice:    150000,
+		Alarm:        &alarm,
+		Source:       &source,
+	})
+}
+
+// WriteInventoryTemplateCSV writes the inventory import template: the
+// canonical header plus one example row.
+func WriteInventoryTemplateCSV(w io.Writer) error {
+	return WriteInventoryCSV(w, inventoryTemplateProducts)
+}
+
+func WriteInventoryTemplateXLSX(w io.Writer) error {
+	return WriteInventoryXLSX(w, inventoryTemplateProducts)
+}
+
+func inventoryExportRow(product domain.Product) []string {
+	alarm := ""
+	if product.Alarm != nil {
+		alarm = strconv.Itoa(*product.Alarm)
+	}
+	source := ""
+	if product.Source != nil {
+		source = *product.Source
+	}
+	return []string{
+		product.ProductName,
+		strconv.FormatFloat(product.Quantity, 'f', -1, 64),
+		strconv.FormatFloat(product.AvgBuyPrice, 'f', -1, 64),
+		strconv.FormatFloat(product.LastBuyPrice, 'f', -1, 64),
+		strconv.FormatFloat(product.SellPrice, 'f', -1, 64),
+		alarm,
+		source,
+	}
+}
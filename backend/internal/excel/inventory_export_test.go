@@ -0,0 +1,91 @@
+package excel
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"backend/internal/domain"
+)
+
+// TestInventoryTemplateRoundTrip checks that each template format imports
+// back unchanged, which is the point of shipping it.
+func TestInventoryTemplateRoundTrip(t *testing.T) {
+	alarm := 5
+	source := "supplier"
+	want := []domain.InventoryImportRow{{
+		ProductName:  "Sample product",
+		Quantity:     10,
+		AvgBuyPrice:  100000,
+		LastBuyPrice: 110000,
+		SellPrice:    150000,
+		Alarm:        &alarm,
+		Source:       &source,
+	}}
+
+	tests := []struct {
+		name     string
+		fileName string
+		write    func(io.Writer) error
+	}{
+		{name: "csv", fileName: "inventory_template.csv", write: WriteInventoryTemplateCSV},
+		{name: "xlsx", fileName: "inventory_template.xlsx", write: WriteInventoryTemplateXLSX},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := tt.write(&buf); err != nil {
+				t.Fatalf("write template: %v", err)
+			}
+			result, err := ParseInventoryFile(&buf, InventoryParseOptions{FileName: tt.fileName, Strict: true})
+			if err != nil {
+				t.Fatalf("parse template: %v", err)
+			}
+			if !reflect.DeepEqual(result.Rows, want) {
+				t.Fatalf("rows = %+v, want %+v", result.Rows, want)
+			}
+		})
+	}
+}
+
+func TestWriteInventoryTemplateCSVHeader(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteInventoryTemplateCSV(&buf); err != nil {
+		t.Fatalf("write template: %v", err)
+	}
+	header, _, _ := strings.Cut(buf.String(), "\n")
+	if want := strings.Join(InventoryExportHeader, ","); header != want {
+		t.Fatalf("header = %q, want %q", header, want)
+	}
+}
+
+func TestWriteInventoryStopsOnSourceError(t *testing.T) {
+	errSource := errors.New("connection reset")
+	each := func(fn func(domain.Product) error) error {
+		for _, name := range []string{"Pen", "Ink"} {
+			if err := fn(domain.Product{ProductName: name, Quantity: 1}); err != nil {
+				return err
+			}
+		}
+		return errSource
+	}
+
+	var csvBuf bytes.Buffer
+	if err := WriteInventoryCSV(&csvBuf, each); !errors.Is(err, errSource) {
+		t.Fatalf("WriteInventoryCSV error = %v, want %v", err, errSource)
+	}
+	if lines := strings.Count(csvBuf.String(), "\n"); lines != 3 {
+		t.Fatalf("csv has %d lines, want the header and 2 rows:\n%s", lines, csvBuf.String())
+	}
+
+	var xlsxBuf bytes.Buffer
+	if err := WriteInventoryXLSX(&xlsxBuf, each); !errors.Is(err, errSource) {
+		t.Fatalf("WriteInventoryXLSX error = %v, want %v", err, errSource)
+	}
+	if xlsxBuf.Len() != 0 {
+		t.Fatalf("xlsx wrote %d bytes after a source error, want none", xlsxBuf.Len())
+	}
+}
@@ -1,9 +1,11 @@
 package excel
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"math"
+	"path/filepath"
 	"strconv"
 	"strings"
 
@@ -42,119 +44,258 @@ var headerAliases = map[string]string{
 	"منبع":              "source",
 }
 
+var requiredInventoryColumns = []string{"product_name", "quantity", "avg_buy_price"}
+
+type InventoryParseOptions struct {
+	Strict bool
+	// FileName is the uploaded name; a .csv extension selects CSV parsing.
+	// Without a known extension the format is detected from the content.
+	FileName string
+	// SheetName selects a worksheet. When empty, the first sheet whose header
+	// maps every required column is used. It is ignored for CSV files.
+	SheetName string
+	// SkipInvalidRows collects rows with unparseable cells into Skipped
+	// instead of failing the whole file.
+	SkipInvalidRows bool
+	// FractionalQuantity accepts decimal quantities (weight, length) instead
+	// of requiring whole units.
+	FractionalQuantity bool
+	// DecimalProducts lists, by lower-cased name, products whose quantity
+	// may be a decimal even without FractionalQuantity (those sold by
+	// weight).
+	DecimalProducts map[string]bool
+}
+
+type RowError struct {
+	Row    int    `json:"row"`
+	Reason string `json:"reason"`
+}
+
+type InventoryParseResult struct {
+	Rows     []domain.InventoryImportRow
+	Warnings []string
+	Skipped  []RowError
+}
+
 func ParseInventoryRows(reader io.Reader) ([]domain.InventoryImportRow, error) {
-	file, err := excelize.OpenReader(reader)
+	result, err := ParseInventoryFile(reader, InventoryParseOptions{})
 	if err != nil {
-		return nil, fmt.Errorf("open excel file: %w", err)
+		return nil, err
 	}
-	defer file.Close()
+	return result.Rows, nil
+}
 
-	sheets := file.GetSheetList()
-	if len(sheets) == 0 {
-		return nil, fmt.Errorf("excel file has no sheets")
+func ParseInventoryFile(reader io.Reader, opts InventoryParseOptions) (InventoryParseResult, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return InventoryParseResult{}, fmt.Errorf("read file: %w", err)
+	}
+	if len(data) == 0 {
+		return InventoryParseResult{}, fmt.Errorf("input file is empty")
 	}
 
-	rows, err := file.GetRows(sheets[0])
+	var rows [][]string
+	if isCSVInput(opts.FileName, data) {
+		rows, err = parseCSVRows(data)
+	} else {
+		rows, err = readInventorySheet(data, opts.SheetName)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("read sheet rows: %w", err)
+		return InventoryParseResult{}, err
 	}
 	if len(rows) == 0 {
-		return nil, fmt.Errorf("excel file is empty")
+		return InventoryParseResult{}, fmt.Errorf("input file is empty")
 	}
 
-	colMap := mapColumns(rows[0])
-	if _, ok := colMap["product_name"]; !ok {
-		return nil, fmt.Errorf("missing required column: product_name")
-	}
-	if _, ok := colMap["quantity"]; !ok {
-		return nil, fmt.Errorf("missing required column: quantity")
+	colMap, warnings := mapColumns(rows[0], rows[1:])
+	if opts.Strict && len(warnings) > 0 {
+		return InventoryParseResult{}, fmt.Errorf("%s", strings.Join(warnings, "; "))
 	}
-	if _, ok := colMap["avg_buy_price"]; !ok {
-		return nil, fmt.Errorf("missing required column: avg_buy_price")
+	for _, column := range requiredInventoryColumns {
+		if _, ok := colMap[column]; !ok {
+			return InventoryParseResult{}, fmt.Errorf("missing required column: %s", column)
+		}
 	}
 
-	result := make([]domain.InventoryImportRow, 0, len(rows)-1)
+	result := InventoryParseResult{
+		Rows:     make([]domain.InventoryImportRow, 0, len(rows)-1),
+		Warnings: warnings,
+	}
 	for index := 1; index < len(rows); index++ {
 		cells := rows[index]
-		name := readCell(cells, colMap["product_name"])
-		name = strings.TrimSpace(name)
+		name := strings.TrimSpace(readCell(cells, colMap["product_name"]))
 		if name == "" {
 			continue
 		}
 
-		qty, err := parseInt(readCell(cells, colMap["quantity"]))
+		fractional := opts.FractionalQuantity || opts.DecimalProducts[strings.ToLower(name)]
+		row, err := parseInventoryRow(name, cells, colMap, fractional)
 		if err != nil {
-			return nil, fmt.Errorf("row %d invalid quantity: %w", index+1, err)
+			if !opts.SkipInvalidRows {
+				return InventoryParseResult{}, fmt.Errorf("row %d %w", index+1, err)
+			}
+			result.Skipped = append(result.Skipped, RowError{Row: index + 1, Reason: err.Error()})
+			continue
 		}
+		result.Rows = append(result.Rows, row)
+	}
 
-		avgPrice, err := parseFloat(readCell(cells, colMap["avg_buy_price"]))
-		if err != nil {
-			return nil, fmt.Errorf("row %d invalid avg_buy_price: %w", index+1, err)
-		}
+	if len(result.Rows) == 0 {
+		return InventoryParseResult{}, fmt.Errorf("input file has no valid data rows")
+	}
+	return result, nil
+}
+
+// isCSVInput picks CSV by extension and falls back to sniffing: xlsx files
+// are zip archives and legacy xls files are OLE containers, anything else is
+// treated as text.
+func isCSVInput(fileName string, data []byte) bool {
+	switch strings.ToLower(strings.TrimSpace(filepath.Ext(fileName))) {
+	case ".csv":
+		return true
+	case ".xlsx", ".xlsm", ".xls":
+		return false
+	}
+	return !bytes.HasPrefix(data, []byte("PK\x03\x04")) &&
+		!bytes.HasPrefix(data, []byte("\xd0\xcf\x11\xe0"))
+}
+
+func readInventorySheet(data []byte, sheetName string) ([][]string, error) {
+	file, err := excelize.OpenReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("open excel file: %w", err)
+	}
+	defer file.Close()
+	return selectInventorySheet(file, sheetName)
+}
 
-		lastPrice := avgPrice
-		if idx, ok := colMap["last_buy_price"]; ok {
-			raw := strings.TrimSpace(readCell(cells, idx))
-			if raw != "" {
-				parsed, err := parseFloat(raw)
+// selectInventorySheet returns the rows of the named sheet, or of the first
+// sheet carrying every required column. A single-sheet workbook is returned
+// as is so the caller can report exactly which column is missing.
+func selectInventorySheet(file *excelize.File, sheetName string) ([][]string, error) {
+	sheets := file.GetSheetList()
+	if len(sheets) == 0 {
+		return nil, fmt.Errorf("excel file has no sheets")
+	}
+
+	if sheetName = strings.TrimSpace(sheetName); sheetName != "" {
+		for _, sheet := range sheets {
+			if sheet == sheetName {
+				rows, err := file.GetRows(sheet)
 				if err != nil {
-					return nil, fmt.Errorf("row %d invalid last_buy_price: %w", index+1, err)
+					return nil, fmt.Errorf("read sheet rows: %w", err)
 				}
-				lastPrice = parsed
+				return rows, nil
 			}
 		}
+		return nil, fmt.Errorf("sheet %q not found; available sheets: %s", sheetName, strings.Join(sheets, ", "))
+	}
 
-		sellPrice := 0.0
-		if idx, ok := colMap["sell_price"]; ok {
-			raw := strings.TrimSpace(readCell(cells, idx))
-			if raw != "" {
-				parsed, err := parseFloat(raw)
-				if err != nil {
-					return nil, fmt.Errorf("row %d invalid sell_price: %w", index+1, err)
-				}
-				sellPrice = parsed
-			}
+	if len(sheets) == 1 {
+		rows, err := file.GetRows(sheets[0])
+		if err != nil {
+			return nil, fmt.Errorf("read sheet rows: %w", err)
 		}
+		return rows, nil
+	}
 
-		var alarm *int
-		if idx, ok := colMap["alarm"]; ok {
-			raw := strings.TrimSpace(readCell(cells, idx))
-			if raw != "" {
-				value, err := parseInt(raw)
-				if err != nil {
-					return nil, fmt.Errorf("row %d invalid alarm: %w", index+1, err)
-				}
-				alarm = &value
+	for _, sheet := range sheets {
+		rows, err := file.GetRows(sheet)
+		if err != nil {
+			return nil, fmt.Errorf("read sheet %q rows: %w", sheet, err)
+		}
+		if len(rows) == 0 {
+			continue
+		}
+		colMap, _ := mapColumns(rows[0], nil)
+		if hasRequiredColumns(colMap, requiredInventoryColumns...) {
+			return rows, nil
+		}
+	}
+	return nil, fmt.Errorf(
+		"no sheet has the required columns (%s); available sheets: %s",
+		strings.Join(requiredInventoryColumns, ", "),
+		strings.Join(sheets, ", "),
+	)
+}
+
+func parseInventoryRow(
+	name string,
+	cells []string,
+	colMap map[string]int,
+	fractionalQuantity bool,
+) (domain.InventoryImportRow, error) {
+	qty, err := parseQuantity(readCell(cells, colMap["quantity"]), fractionalQuantity)
+	if err != nil {
+		return domain.InventoryImportRow{}, fmt.Errorf("invalid quantity: %w", err)
+	}
+
+	avgPrice, err := parseFloat(readCell(cells, colMap["avg_buy_price"]))
+	if err != nil {
+		return domain.InventoryImportRow{}, fmt.Errorf("invalid avg_buy_price: %w", err)
+	}
+
+	lastPrice := avgPrice
+	if idx, ok := colMap["last_buy_price"]; ok {
+		raw := strings.TrimSpace(readCell(cells, idx))
+		if raw != "" {
+			parsed, err := parseFloat(raw)
+			if err != nil {
+				return domain.InventoryImportRow{}, fmt.Errorf("invalid last_buy_price: %w", err)
 			}
+			lastPrice = parsed
 		}
+	}
 
-		var source *string
-		if idx, ok := colMap["source"]; ok {
-			value := strings.TrimSpace(readCell(cells, idx))
-			if value != "" {
-				source = &value
+	sellPrice := 0.0
+	if idx, ok := colMap["sell_price"]; ok {
+		raw := strings.TrimSpace(readCell(cells, idx))
+		if raw != "" {
+			parsed, err := parseFloat(raw)
+			if err != nil {
+				return domain.InventoryImportRow{}, fmt.Errorf("invalid sell_price: %w", err)
 			}
+			sellPrice = parsed
 		}
+	}
 
-		result = append(result, domain.InventoryImportRow{
-			ProductName:  name,
-			Quantity:     qty,
-			AvgBuyPrice:  avgPrice,
-			LastBuyPrice: lastPrice,
-			SellPrice:    sellPrice,
-			Alarm:        alarm,
-			Source:       source,
-		})
+	var alarm *int
+	if idx, ok := colMap["alarm"]; ok {
+		raw := strings.TrimSpace(readCell(cells, idx))
+		if raw != "" {
+			value, err := parseInt(raw)
+			if err != nil {
+				return domain.InventoryImportRow{}, fmt.Errorf("invalid alarm: %w", err)
+			}
+			alarm = &value
+		}
 	}
 
-	if len(result) == 0 {
-		return nil, fmt.Errorf("excel file has no valid data rows")
+	var source *string
+	if idx, ok := colMap["source"]; ok {
+		value := strings.TrimSpace(readCell(cells, idx))
+		if value != "" {
+			source = &value
+		}
 	}
-	return result, nil
+
+	return domain.InventoryImportRow{
+		ProductName:  name,
+		Quantity:     qty,
+		AvgBuyPrice:  avgPrice,
+		LastBuyPrice: lastPrice,
+		SellPrice:    sellPrice,
+		Alarm:        alarm,
+		Source:       source,
+	}, nil
 }
 
-func mapColumns(header []string) map[string]int {
-	mapped := make(map[string]int)
+// mapColumns resolves header aliases to canonical fields. When several
+// columns alias the same field, the one with the most non-empty data cells
+// wins and a warning describing the conflict is returned.
+func mapColumns(header []string, dataRows [][]string) (map[string]int, []string) {
+	candidates := make(map[string][]int)
+	order := make([]string, 0)
 	for idx, col := range header {
 		normalized := normalizeHeader(col)
 		if normalized == "" {
@@ -164,11 +305,57 @@ func mapColumns(header []string) map[string]int {
 		if !ok {
 			continue
 		}
-		if _, exists := mapped[canonical]; !exists {
-			mapped[canonical] = idx
+		if _, exists := candidates[canonical]; !exists {
+			order = append(order, canonical)
+		}
+		candidates[canonical] = append(candidates[canonical], idx)
+	}
+
+	mapped := make(map[string]int, len(candidates))
+	warnings := make([]string, 0)
+	for _, canonical := range order {
+		indexes := candidates[canonical]
+		best := indexes[0]
+		if len(indexes) > 1 {
+			bestCount := -1
+			labels := make([]string, 0, len(indexes))
+			for _, idx := range indexes {
+				count := countNonEmptyCells(dataRows, idx)
+				if count > bestCount {
+					best = idx
+					bestCount = count
+				}
+				labels = append(labels, fmt.Sprintf("%q (column %s)", strings.TrimSpace(header[idx]), columnName(idx)))
+			}
+			warnings = append(warnings, fmt.Sprintf(
+				"duplicate columns for %s: %s; using column %s with %d non-empty rows",
+				canonical,
+				strings.Join(labels, ", "),
+				columnName(best),
+				bestCount,
+			))
+		}
+		mapped[canonical] = best
+	}
+	return mapped, warnings
+}
+
+func countNonEmptyCells(rows [][]string, idx int) int {
+	count := 0
+	for _, row := range rows {
+		if strings.TrimSpace(readCell(row, idx)) != "" {
+			count++
 		}
 	}
-	return mapped
+	return count
+}
+
+func columnName(idx int) string {
+	name, err := excelize.ColumnNumberToName(idx + 1)
+	if err != nil {
+		return strconv.Itoa(idx + 1)
+	}
+	return name
 }
 
 func normalizeHeader(raw string) string {
@@ -203,6 +390,17 @@ func parseInt(raw string) (int, error) {
 	return int(asFloat), nil
 }
 
+func parseQuantity(raw string, fractional bool) (float64, error) {
+	if fractional {
+		return parseFloat(raw)
+	}
+	value, err := parseInt(raw)
+	if err != nil {
+		return 0, err
+	}
+	return float64(value), nil
+}
+
 func parseFloat(raw string) (float64, error) {
 	value := strings.TrimSpace(raw)
 	if value == "" {
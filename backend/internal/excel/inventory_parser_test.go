@@ -0,0 +1,281 @@
+package excel
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"backend/internal/domain"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestMapColumns(t *testing.T) {
+	tests := []struct {
+		name         string
+		header       []string
+		rows         [][]string
+		want         map[string]int
+		wantWarnings []string
+	}{
+		{
+			name:   "english aliases",
+			header: []string{"Product Name", "Qty", "Avg Buy Price", "notes"},
+			want:   map[string]int{"product_name": 0, "quantity": 1, "avg_buy_price": 2},
+		},
+		{
+			name:   "persian aliases and underscores",
+			header: []string{"نام کالا", "تعداد", "قیمت خرید", "sell_price"},
+			want:   map[string]int{"product_name": 0, "quantity": 1, "avg_buy_price": 2, "sell_price": 3},
+		},
+		{
+			name:   "BOM, case and extra spaces",
+			header: []string{"\ufeffPRODUCT   name", " quantity ", "average buy price"},
+			want:   map[string]int{"product_name": 0, "quantity": 1, "avg_buy_price": 2},
+		},
+		{
+			name:   "duplicate alias picks the fuller column",
+			header: []string{"product", "قیمت خرید", "avg buy price", "qty"},
+			rows: [][]string{
+				{"a", "", "10", "1"},
+				{"b", "20", "20", "1"},
+			},
+			want:         map[string]int{"product_name": 0, "avg_buy_price": 2, "quantity": 3},
+			wantWarnings: []string{`duplicate columns for avg_buy_price: "قیمت خرید" (column B), "avg buy price" (column C); using column C with 2 non-empty rows`},
+		},
+		{
+			name:         "duplicate alias tie keeps the first column",
+			header:       []string{"product", "qty", "تعداد"},
+			rows:         [][]string{{"a", "1", "2"}},
+			want:         map[string]int{"product_name": 0, "quantity": 1},
+			wantWarnings: []string{`duplicate columns for quantity: "qty" (column B), "تعداد" (column C); using column B with 1 non-empty rows`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, warnings := mapColumns(tt.header, tt.rows)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("columns = %v, want %v", got, tt.want)
+			}
+			if len(warnings) != len(tt.wantWarnings) {
+				t.Fatalf("warnings = %q, want %q", warnings, tt.wantWarnings)
+			}
+			for i := range warnings {
+				if warnings[i] != tt.wantWarnings[i] {
+					t.Errorf("warning %d = %q, want %q", i, warnings[i], tt.wantWarnings[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseInventoryFileStrictRejectsDuplicateColumns(t *testing.T) {
+	input := "product,qty,تعداد,avg_buy_price\nA,1,2,10\n"
+	opts := InventoryParseOptions{FileName: "stock.csv"}
+	if _, err := ParseInventoryFile(strings.NewReader(input), opts); err != nil {
+		t.Fatalf("non-strict parse: %v", err)
+	}
+	opts.Strict = true
+	_, err := ParseInventoryFile(strings.NewReader(input), opts)
+	if err == nil || !strings.Contains(err.Error(), "duplicate columns for quantity") {
+		t.Fatalf("strict parse error = %v, want duplicate columns", err)
+	}
+}
+
+type testSheet struct {
+	name string
+	rows [][]string
+}
+
+// workbook builds an xlsx file with one sheet per entry, in order.
+func workbook(t *testing.T, sheets []testSheet) []byte {
+	t.Helper()
+	file := excelize.NewFile()
+	defer file.Close()
+	for i, sheet := range sheets {
+		if i == 0 {
+			if err := file.SetSheetName(file.GetSheetName(0), sheet.name); err != nil {
+				t.Fatalf("rename sheet: %v", err)
+			}
+		} else if _, err := file.NewSheet(sheet.name); err != nil {
+			t.Fatalf("add sheet %q: %v", sheet.name, err)
+		}
+		for r, row := range sheet.rows {
+			cell, _ := excelize.CoordinatesToCellName(1, r+1)
+			values := make([]any, len(row))
+			for c, value := range row {
+				values[c] = value
+			}
+			if err := file.SetSheetRow(sheet.name, cell, &values); err != nil {
+				t.Fatalf("write sheet %q: %v", sheet.name, err)
+			}
+		}
+	}
+	buf, err := file.WriteToBuffer()
+	if err != nil {
+		t.Fatalf("write workbook: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestParseInventoryFileSheetSelection(t *testing.T) {
+	notes := testSheet{name: "Notes", rows: [][]string{{"updated by", "date"}, {"reza", "1403/01/01"}}}
+	stock := testSheet{name: "Stock", rows: [][]string{{"product", "qty", "avg_buy_price"}, {"from stock", "3", "10"}}}
+	other := testSheet{name: "Other", rows: [][]string{{"product", "qty", "avg_buy_price"}, {"from other", "5", "20"}}}
+
+	tests := []struct {
+		name      string
+		sheets    []testSheet
+		sheetName string
+		wantName  string
+		wantErr   string
+	}{
+		{name: "first sheet with the required columns", sheets: []testSheet{notes, stock, other}, wantName: "from stock"},
+		{name: "named sheet", sheets: []testSheet{notes, stock, other}, sheetName: "Other", wantName: "from other"},
+		{name: "named sheet is trimmed", sheets: []testSheet{notes, stock, other}, sheetName: " Other ", wantName: "from other"},
+		{name: "unknown sheet", sheets: []testSheet{notes, stock}, sheetName: "Missing", wantErr: `sheet "Missing" not found; available sheets: Notes, Stock`},
+		{name: "no sheet qualifies", sheets: []testSheet{notes, {name: "Empty"}}, wantErr: "no sheet has the required columns"},
+		{name: "single sheet reports the missing column", sheets: []testSheet{notes}, wantErr: "missing required column: product_name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := workbook(t, tt.sheets)
+			result, err := ParseInventoryFile(bytes.NewReader(data), InventoryParseOptions{FileName: "stock.xlsx", SheetName: tt.sheetName})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseInventoryFile: %v", err)
+			}
+			if len(result.Rows) != 1 || result.Rows[0].ProductName != tt.wantName {
+				t.Fatalf("rows = %+v, want one row named %q", result.Rows, tt.wantName)
+			}
+		})
+	}
+}
+
+func TestIsCSVInput(t *testing.T) {
+	tests := []struct {
+		name     string
+		fileName string
+		data     string
+		want     bool
+	}{
+		{name: "csv extension", fileName: "stock.csv", data: "PK\x03\x04", want: true},
+		{name: "upper-case extension", fileName: "STOCK.CSV", data: "product,qty", want: true},
+		{name: "xlsx extension", fileName: "stock.xlsx", data: "product,qty", want: false},
+		{name: "xls extension", fileName: "stock.xls", data: "product,qty", want: false},
+		{name: "sniffed zip is xlsx", fileName: "", data: "PK\x03\x04rest", want: false},
+		{name: "sniffed OLE is xls", fileName: "upload", data: "\xd0\xcf\x11\xe0rest", want: false},
+		{name: "sniffed text is csv", fileName: "upload.txt", data: "product,qty", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isCSVInput(tt.fileName, []byte(tt.data)); got != tt.want {
+				t.Fatalf("isCSVInput(%q, %q) = %v, want %v", tt.fileName, tt.data, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseInventoryFileCSV(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		opts    InventoryParseOptions
+		want    []domain.InventoryImportRow
+		wantErr string
+	}{
+		{
+			name:  "excel export with BOM and quoted thousands",
+			input: "\ufeffproduct_name,quantity,avg_buy_price,sell_price\r\n\"Tea, green\",3,\"1,200\",1500\r\n",
+			opts:  InventoryParseOptions{FileName: "stock.csv"},
+			want: []domain.InventoryImportRow{
+				{ProductName: "Tea, green", Quantity: 3, AvgBuyPrice: 1200, LastBuyPrice: 1200, SellPrice: 1500},
+			},
+		},
+		{
+			name:  "detected without extension, blank names skipped",
+			input: "نام کالا,تعداد,قیمت خرید\nچای,2,100\n,5,5\n",
+			want: []domain.InventoryImportRow{
+				{ProductName: "چای", Quantity: 2, AvgBuyPrice: 100, LastBuyPrice: 100},
+			},
+		},
+		{
+			name:  "ragged rows are allowed",
+			input: "product,qty,avg_buy_price,sell_price\nA,1,10\n",
+			want: []domain.InventoryImportRow{
+				{ProductName: "A", Quantity: 1, AvgBuyPrice: 10, LastBuyPrice: 10},
+			},
+		},
+		{name: "missing column", input: "product,qty\nA,1\n", wantErr: "missing required column: avg_buy_price"},
+		{name: "bad cell", input: "product,qty,avg_buy_price\nA,one,10\n", wantErr: "row 2 invalid quantity: not a number"},
+		{name: "only a header", input: "product,qty,avg_buy_price\n", wantErr: "input file has no valid data rows"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ParseInventoryFile(strings.NewReader(tt.input), tt.opts)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseInventoryFile: %v", err)
+			}
+			if !reflect.DeepEqual(result.Rows, tt.want) {
+				t.Fatalf("rows = %+v, want %+v", result.Rows, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseInventoryFileDecimalQuantities(t *testing.T) {
+	const input = "product_name,quantity,avg_buy_price\nRice,2.5,100\nPen,3,10\n"
+	rice := domain.InventoryImportRow{ProductName: "Rice", Quantity: 2.5, AvgBuyPrice: 100, LastBuyPrice: 100}
+	pen := domain.InventoryImportRow{ProductName: "Pen", Quantity: 3, AvgBuyPrice: 10, LastBuyPrice: 10}
+	tests := []struct {
+		name        string
+		opts        InventoryParseOptions
+		want        []domain.InventoryImportRow
+		wantSkipped []RowError
+		wantErr     string
+	}{
+		{name: "whole units only", opts: InventoryParseOptions{}, wantErr: "row 2 invalid quantity: must be an integer"},
+		{
+			name:        "whole units only, skipping invalid rows",
+			opts:        InventoryParseOptions{SkipInvalidRows: true},
+			want:        []domain.InventoryImportRow{pen},
+			wantSkipped: []RowError{{Row: 2, Reason: "invalid quantity: must be an integer"}},
+		},
+		{name: "fractional mode", opts: InventoryParseOptions{FractionalQuantity: true}, want: []domain.InventoryImportRow{rice, pen}},
+		{name: "product sold by weight", opts: InventoryParseOptions{DecimalProducts: map[string]bool{"rice": true}}, want: []domain.InventoryImportRow{rice, pen}},
+		{name: "other product sold by weight", opts: InventoryParseOptions{DecimalProducts: map[string]bool{"pen": true}}, wantErr: "row 2 invalid quantity: must be an integer"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.FileName = "stock.csv"
+			result, err := ParseInventoryFile(strings.NewReader(input), tt.opts)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseInventoryFile: %v", err)
+			}
+			if !reflect.DeepEqual(result.Rows, tt.want) {
+				t.Fatalf("rows = %+v, want %+v", result.Rows, tt.want)
+			}
+			if !reflect.DeepEqual(result.Skipped, tt.wantSkipped) {
+				t.Fatalf("skipped = %+v, want %+v", result.Skipped, tt.wantSkipped)
+			}
+		})
+	}
+}
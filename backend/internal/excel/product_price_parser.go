@@ -142,16 +142,88 @@ type rawProductRow struct {
 	optionValues2 string
 }
 
+// Inventory prices are kept in Toman; a Rial price column is scaled down.
+const (
+	PriceUnitToman = "toman"
+	PriceUnitRial  = "rial"
+)
+
+var priceUnitHints = map[string]string{
+	"تومان": PriceUnitToman,
+	"toman": PriceUnitToman,
+	"irt":   PriceUnitToman,
+	"ریال":  PriceUnitRial,
+	"rial":  PriceUnitRial,
+	"irr":   PriceUnitRial,
+}
+
+var priceUnitMultipliers = map[string]float64{
+	PriceUnitToman: 1,
+	PriceUnitRial:  0.1,
+}
+
+type ProductPriceParseOptions struct {
+	// Multiplier scales every parsed price and overrides any unit detected
+	// from the price column header.
+	Multiplier *float64
+}
+
+type ProductPriceParseResult struct {
+	Rows       []domain.ProductPriceRow
+	Format     string
+	Unit       string
+	Multiplier float64
+}
+
 func ParseProductPriceRows(
 	fileName string,
 	reader io.Reader,
 ) ([]domain.ProductPriceRow, string, error) {
+	result, err := ParseProductPriceFile(fileName, reader, ProductPriceParseOptions{})
+	if err != nil {
+		return nil, "", err
+	}
+	return result.Rows, result.Format, nil
+}
+
+func ParseProductPriceFile(
+	fileName string,
+	reader io.Reader,
+	opts ProductPriceParseOptions,
+) (ProductPriceParseResult, error) {
+	result, err := parseProductPriceData(fileName, reader)
+	if err != nil {
+		return ProductPriceParseResult{}, err
+	}
+
+	result.Multiplier = 1
+	if multiplier, ok := priceUnitMultipliers[result.Unit]; ok {
+		result.Multiplier = multiplier
+	}
+	if opts.Multiplier != nil {
+		if *opts.Multiplier <= 0 {
+			return ProductPriceParseResult{}, fmt.Errorf("multiplier must be greater than zero")
+		}
+		result.Multiplier = *opts.Multiplier
+	}
+	if result.Multiplier != 1 {
+		for i := range result.Rows {
+			result.Rows[i].Price *= result.Multiplier
+		}
+	}
+	return result, nil
+}
+
+func parseProductPriceData(
+	fileName string,
+	reader io.Reader,
+) (ProductPriceParseResult, error) {
 	data, err := io.ReadAll(reader)
 	if err != nil {
-		return nil, "", fmt.Errorf("read file: %w", err)
+		return ProductPriceParseResult{}, fmt.Errorf("read file: %w", err)
 	}
 	if len(data) == 0 {
-		return nil, "", fmt.Errorf("input file is empty")
+		return ProductPriceParseResult{}, fmt.Errorf("input file is empty")
 	}
 
 	ext := strings.ToLower(strings.TrimSpace(filepath.Ext(fileName)))
@@ -159,41 +231,36 @@ func ParseProductPriceRows(
 	case ".csv":
 		rows, parseErr := parseCSVRows(data)
 		if parseErr != nil {
-			return nil, "", parseErr
-		}
-		items, mode, parseErr := parseProductPriceTable(rows)
-		if parseErr != nil {
-			return nil, "", parseErr
+			return ProductPriceParseResult{}, parseErr
 		}
-		return items, mode, nil
+		return parseProductPriceTable(rows)
 	case ".xlsx", ".xlsm", ".xls":
 		rows, parseErr := parseExcelRows(data)
 		if parseErr != nil {
-			return nil, "", parseErr
-		}
-		items, mode, parseErr := parseProductPriceTable(rows)
-		if parseErr != nil {
-			return nil, "", parseErr
+			return ProductPriceParseResult{}, parseErr
 		}
-		return items, mode, nil
+		return parseProductPriceTable(rows)
 	default:
 		excelRows, excelErr := parseExcelRows(data)
 		if excelErr == nil {
-			if items, mode, parseErr := parseProductPriceTable(excelRows); parseErr == nil {
-				return items, mode, nil
+			if result, parseErr := parseProductPriceTable(excelRows); parseErr == nil {
+				return result, nil
 			}
 		}
 		csvRows, csvErr := parseCSVRows(data)
 		if csvErr == nil {
-			if items, mode, parseErr := parseProductPriceTable(csvRows); parseErr == nil {
-				return items, mode, nil
+			if result, parseErr := parseProductPriceTable(csvRows); parseErr == nil {
+				return result, nil
 			}
 		}
-		return nil, "", fmt.Errorf("unsupported or invalid price file format")
+		return ProductPriceParseResult{}, fmt.Errorf("unsupported or invalid price file format")
 	}
 }
 
 func parseCSVRows(data []byte) ([][]string, error) {
+	// Excel writes a UTF-8 BOM in front of CSV exports; drop it so the first
+	// header cell still matches its alias.
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
 	reader := csv.NewReader(bytes.NewReader(data))
 	reader.FieldsPerRecord = -1
 	reader.LazyQuotes = true
@@ -231,34 +298,34 @@ func parseExcelRows(data []byte) ([][]string, error) {
 
 func parseProductPriceTable(
 	rows [][]string,
-) ([]domain.ProductPriceRow, string, error) {
+) (ProductPriceParseResult, error) {
 	if len(rows) == 0 {
-		return nil, "", fmt.Errorf("input file is empty")
+		return ProductPriceParseResult{}, fmt.Errorf("input file is empty")
 	}
 
 	header := rows[0]
-	directMap := mapDirectPriceColumns(header)
+	directMap, unit := mapDirectPriceColumns(header)
 	if hasRequiredColumns(directMap, "product_name", "price") {
 		parsed, err := parseDirectPriceRows(rows, directMap)
 		if err != nil {
-			return nil, "", err
+			return ProductPriceParseResult{}, err
 		}
-		return uniquePriceRows(parsed), "direct", nil
+		return ProductPriceParseResult{Rows: uniquePriceRows(parsed), Format: "direct", Unit: unit}, nil
 	}
 
 	rawMap := mapRawOptionColumns(header)
 	if hasRequiredColumns(rawMap, "title", "price") {
 		parsed, err := parseRawOptionPriceRows(rows, rawMap)
 		if err != nil {
-			return nil, "", err
+			return ProductPriceParseResult{}, err
 		}
 		if len(parsed) > 0 {
-			return uniquePriceRows(parsed), "options", nil
+			return ProductPriceParseResult{Rows: uniquePriceRows(parsed), Format: "options"}, nil
 		}
-		return nil, "", fmt.Errorf("file has no valid option-based price rows")
+		return ProductPriceParseResult{}, fmt.Errorf("file has no valid option-based price rows")
 	}
 
-	return nil, "", fmt.Errorf("missing required columns: product_name+price or title+price")
+	return ProductPriceParseResult{}, fmt.Errorf("missing required columns: product_name+price or title+price")
 }
 
 func parseDirectPriceRows(
@@ -438,7 +505,9 @@ func generateCleanNames(
 	}}, nil
 }
 
-func mapDirectPriceColumns(header []string) map[string]int {
+// mapDirectPriceColumns also accepts price headers carrying a currency hint
+// such as "قیمت (تومان)" or "price (rial)" and returns the detected unit.
+func mapDirectPriceColumns(header []string) (map[string]int, string) {
 	aliases := map[string]string{
 		"product_name": "product_name",
 		"product name": "product_name",
@@ -457,20 +526,43 @@ func mapDirectPriceColumns(header []string) map[string]int {
 		"قيمت فروش":    "price",
 	}
 	mapped := make(map[string]int)
+	unit := ""
 	for idx, col := range header {
 		normalized := normalizeHeader(col)
 		if normalized == "" {
 			continue
 		}
-		canonical, ok := aliases[normalized]
+		base, hint := splitPriceUnitHint(normalized)
+		canonical, ok := aliases[base]
 		if !ok {
 			continue
 		}
+		if canonical != "price" && hint != "" {
+			continue
+		}
 		if _, exists := mapped[canonical]; !exists {
 			mapped[canonical] = idx
+			if canonical == "price" {
+				unit = hint
+			}
 		}
 	}
-	return mapped
+	return mapped, unit
+}
+
+// splitPriceUnitHint strips a trailing currency word, optionally wrapped in
+// brackets, from a normalized header.
+func splitPriceUnitHint(header string) (string, string) {
+	cleaned := strings.NewReplacer("(", " ", ")", " ", "[", " ", "]", " ", "-", " ").Replace(header)
+	fields := strings.Fields(cleaned)
+	if len(fields) < 2 {
+		return header, ""
+	}
+	unit, ok := priceUnitHints[fields[len(fields)-1]]
+	if !ok {
+		return header, ""
+	}
+	return strings.Join(fields[:len(fields)-1], " "), unit
 }
 
 func mapRawOptionColumns(header []string) map[string]int {
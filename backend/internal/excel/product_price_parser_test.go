@@ -0,0 +1,117 @@
+package excel
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"backend/internal/domain"
+)
+
+func TestMapDirectPriceColumns(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   []string
+		want     map[string]int
+		wantUnit string
+	}{
+		{
+			name:   "no unit hint",
+			header: []string{"product_name", "قیمت فروش"},
+			want:   map[string]int{"product_name": 0, "price": 1},
+		},
+		{
+			name:     "persian toman in brackets",
+			header:   []string{"نام کالا", "قیمت (تومان)"},
+			want:     map[string]int{"product_name": 0, "price": 1},
+			wantUnit: PriceUnitToman,
+		},
+		{
+			name:     "persian rial after a longer alias",
+			header:   []string{"نام محصول", "قیمت فروش ریال"},
+			want:     map[string]int{"product_name": 0, "price": 1},
+			wantUnit: PriceUnitRial,
+		},
+		{
+			name:     "english rial in brackets",
+			header:   []string{"Product", "Price (Rial)"},
+			want:     map[string]int{"product_name": 0, "price": 1},
+			wantUnit: PriceUnitRial,
+		},
+		{
+			name:     "currency code",
+			header:   []string{"name", "sell price - IRT"},
+			want:     map[string]int{"product_name": 0, "price": 1},
+			wantUnit: PriceUnitToman,
+		},
+		{
+			name:   "a hint on a non-price column is ignored",
+			header: []string{"product (rial)", "name", "price"},
+			want:   map[string]int{"product_name": 1, "price": 2},
+		},
+		{
+			name:     "the first price column decides the unit",
+			header:   []string{"name", "price (toman)", "price (rial)"},
+			want:     map[string]int{"product_name": 0, "price": 1},
+			wantUnit: PriceUnitToman,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, unit := mapDirectPriceColumns(tt.header)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("columns = %v, want %v", got, tt.want)
+			}
+			if unit != tt.wantUnit {
+				t.Errorf("unit = %q, want %q", unit, tt.wantUnit)
+			}
+		})
+	}
+}
+
+func TestParseProductPriceFileUnits(t *testing.T) {
+	multiplier := func(value float64) *float64 { return &value }
+	tests := []struct {
+		name           string
+		priceHeader    string
+		opts           ProductPriceParseOptions
+		wantUnit       string
+		wantMultiplier float64
+		wantPrice      float64
+	}{
+		{name: "unhinted prices are kept", priceHeader: "price", wantMultiplier: 1, wantPrice: 125000},
+		{name: "toman header", priceHeader: "قیمت (تومان)", wantUnit: PriceUnitToman, wantMultiplier: 1, wantPrice: 125000},
+		{name: "rial header scales to toman", priceHeader: "قیمت (ریال)", wantUnit: PriceUnitRial, wantMultiplier: 0.1, wantPrice: 12500},
+		{
+			name:           "explicit multiplier overrides the header",
+			priceHeader:    "price (rial)",
+			opts:           ProductPriceParseOptions{Multiplier: multiplier(2)},
+			wantUnit:       PriceUnitRial,
+			wantMultiplier: 2,
+			wantPrice:      250000,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := "product_name," + tt.priceHeader + "\nwidget,125000\n"
+			result, err := ParseProductPriceFile("prices.csv", strings.NewReader(input), tt.opts)
+			if err != nil {
+				t.Fatalf("ParseProductPriceFile: %v", err)
+			}
+			if result.Format != "direct" || result.Unit != tt.wantUnit || result.Multiplier != tt.wantMultiplier {
+				t.Fatalf("format, unit, multiplier = %q, %q, %v; want direct, %q, %v",
+					result.Format, result.Unit, result.Multiplier, tt.wantUnit, tt.wantMultiplier)
+			}
+			want := []domain.ProductPriceRow{{ProductName: "widget", Price: tt.wantPrice}}
+			if !reflect.DeepEqual(result.Rows, want) {
+				t.Fatalf("rows = %+v, want %+v", result.Rows, want)
+			}
+		})
+	}
+
+	_, err := ParseProductPriceFile("prices.csv", strings.NewReader("name,price (rial)\nwidget,10\n"),
+		ProductPriceParseOptions{Multiplier: multiplier(0)})
+	if err == nil || err.Error() != "multiplier must be greater than zero" {
+		t.Fatalf("zero multiplier error = %v", err)
+	}
+}
@@ -0,0 +1,99 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"backend/internal/repository"
+	"backend/internal/service"
+)
+
+// apiError is the JSON error body. Code is stable so clients can branch on it
+// (and localize); Message stays human readable under the "error" key.
+type apiError struct {
+	Status  int
+	Code    string
+	Message string
+}
+
+var errBodyTooLarge = errors.New("request body too large")
+
+// sentinelErrors maps repository and service errors that mean the same thing
+// on every endpoint. ErrNotFound is handled separately because its code names
+// the resource. bare errors answer with the sentinel's own text instead of the
+// wrapped chain, which for unique violations only adds the failed operation.
+var sentinelErrors = []struct {
+	err    error
+	status int
+	code   string
+	bare   bool
+}{
+	{repository.ErrInvoiceLineNotFound, http.StatusNotFound, "invoice_line_not_found", true},
+	{repository.ErrInvoiceStatusConflict, http.StatusConflict, "invoice_status_conflict", false},
+	{repository.ErrDuplicateSKU, http.StatusConflict, "duplicate_sku", true},
+	{repository.ErrDuplicateProductName, http.StatusConflict, "duplicate_product_name", true},
+	{repository.ErrDuplicateUsername, http.StatusConflict, "duplicate_username", true},
+	{repository.ErrProductStale, http.StatusConflict, "product_stale", false},
+	{repository.ErrProductMerged, http.StatusConflict, "product_merged", false},
+	{repository.ErrAliasInUse, http.StatusConflict, "alias_in_use", false},
+	{repository.ErrInsufficientStock, http.StatusBadRequest, "insufficient_stock", false},
+	{service.ErrWrongPassword, http.StatusUnauthorized, "wrong_password", true},
+	{errBodyTooLarge, http.StatusRequestEntityTooLarge, "body_too_large", false},
+}
+
+// serviceError maps err to an apiError. resource names what ErrNotFound
+// refers to (e.g. "product" gives product_not_found); anything unrecognized
+// keeps its text and gets fallback as the status.
+func serviceError(err error, resource string, fallback int) apiError {
+	for _, sentinel := range sentinelErrors {
+		if !errors.Is(err, sentinel.err) {
+			continue
+		}
+		message := err.Error()
+		if sentinel.bare {
+			message = sentinel.err.Error()
+		}
+		return apiError{Status: sentinel.status, Code: sentinel.code, Message: message}
+	}
+	if errors.Is(err, repository.ErrNotFound) {
+		if resource == "" {
+			return apiError{Status: http.StatusNotFound, Code: "not_found", Message: err.Error()}
+		}
+		return apiError{
+			Status:  http.StatusNotFound,
+			Code:    resource + "_not_found",
+			Message: strings.ReplaceAll(resource, "_", " ") + " not found",
+		}
+	}
+	return apiError{Status: fallback, Code: statusCode(fallback), Message: err.Error()}
+}
+
+// statusCode is the generic code for errors without a more specific one.
+func statusCode(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return "invalid_request"
+	case http.StatusUnauthorized:
+		return "unauthorized"
+	case http.StatusNotFound:
+		return "not_found"
+	case http.StatusConflict:
+		return "conflict"
+	case http.StatusInternalServerError:
+		return "internal_error"
+	}
+	return strings.ReplaceAll(strings.ToLower(http.StatusText(status)), " ", "_")
+}
+
+func writeServiceError(w http.ResponseWriter, err error, resource string, fallback int) {
+	writeAPIError(w, serviceError(err, resource, fallback))
+}
+
+func writeAPIError(w http.ResponseWriter, apiErr apiError) {
+	writeJSON(w, apiErr.Status, map[string]any{"error": apiErr.Message, "code": apiErr.Code})
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeAPIError(w, apiError{Status: status, Code: statusCode(status), Message: message})
+}
@@ -0,0 +1,126 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"backend/internal/repository"
+	"backend/internal/service"
+)
+
+func TestServiceError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		resource string
+		fallback int
+		want     apiError
+	}{
+		{
+			name:     "bare sentinel drops the wrapped chain",
+			err:      fmt.Errorf("update product 7: %w", repository.ErrDuplicateSKU),
+			fallback: http.StatusBadRequest,
+			want:     apiError{Status: http.StatusConflict, Code: "duplicate_sku", Message: "sku already in use"},
+		},
+		{
+			name:     "duplicate product name hides the constraint",
+			err:      fmt.Errorf("update product 3: %w", repository.ErrDuplicateProductName),
+			fallback: http.StatusBadRequest,
+			want:     apiError{Status: http.StatusConflict, Code: "duplicate_product_name", Message: "product name already exists"},
+		},
+		{
+			name:     "duplicate username",
+			err:      repository.ErrDuplicateUsername,
+			resource: "admin",
+			fallback: http.StatusBadRequest,
+			want:     apiError{Status: http.StatusConflict, Code: "duplicate_username", Message: "username already exists"},
+		},
+		{
+			name:     "other sentinels keep the full message",
+			err:      fmt.Errorf("%w: need 3, have 1", repository.ErrInsufficientStock),
+			fallback: http.StatusInternalServerError,
+			want:     apiError{Status: http.StatusBadRequest, Code: "insufficient_stock", Message: "insufficient stock: need 3, have 1"},
+		},
+		{
+			name:     "merged product",
+			err:      fmt.Errorf("%w: %q", repository.ErrProductMerged, "Old name"),
+			fallback: http.StatusBadRequest,
+			want:     apiError{Status: http.StatusConflict, Code: "product_merged", Message: `product was merged into another product: "Old name"`},
+		},
+		{
+			name:     "stale product keeps the stored timestamp",
+			err:      fmt.Errorf("%w: product 4 was updated at 2025-01-02T03:04:05Z", repository.ErrProductStale),
+			resource: "product",
+			fallback: http.StatusBadRequest,
+			want: apiError{
+				Status:  http.StatusConflict,
+				Code:    "product_stale",
+				Message: "product was modified by someone else: product 4 was updated at 2025-01-02T03:04:05Z",
+			},
+		},
+		{
+			name:     "wrong password",
+			err:      service.ErrWrongPassword,
+			fallback: http.StatusBadRequest,
+			want:     apiError{Status: http.StatusUnauthorized, Code: "wrong_password", Message: "current password is incorrect"},
+		},
+		{
+			name:     "body too large",
+			err:      fmt.Errorf("%w (limit 1024 bytes)", errBodyTooLarge),
+			fallback: http.StatusBadRequest,
+			want:     apiError{Status: http.StatusRequestEntityTooLarge, Code: "body_too_large", Message: "request body too large (limit 1024 bytes)"},
+		},
+		{
+			name:     "sentinel wins over the resource",
+			err:      repository.ErrInvoiceLineNotFound,
+			resource: "invoice",
+			fallback: http.StatusBadRequest,
+			want:     apiError{Status: http.StatusNotFound, Code: "invoice_line_not_found", Message: "invoice line not found"},
+		},
+		{
+			name:     "not found names the resource",
+			err:      fmt.Errorf("get invoice 9: %w", repository.ErrNotFound),
+			resource: "sell_price",
+			fallback: http.StatusBadRequest,
+			want:     apiError{Status: http.StatusNotFound, Code: "sell_price_not_found", Message: "sell price not found"},
+		},
+		{
+			name:     "not found without a resource",
+			err:      fmt.Errorf("get invoice 9: %w", repository.ErrNotFound),
+			fallback: http.StatusBadRequest,
+			want:     apiError{Status: http.StatusNotFound, Code: "not_found", Message: "get invoice 9: not found"},
+		},
+		{
+			name:     "unknown error uses the fallback",
+			err:      errors.New("quantity must be positive"),
+			fallback: http.StatusBadRequest,
+			want:     apiError{Status: http.StatusBadRequest, Code: "invalid_request", Message: "quantity must be positive"},
+		},
+		{
+			name:     "fallback without a named code",
+			err:      errors.New("boom"),
+			fallback: http.StatusServiceUnavailable,
+			want:     apiError{Status: http.StatusServiceUnavailable, Code: "service_unavailable", Message: "boom"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := serviceError(tt.err, tt.resource, tt.fallback); got != tt.want {
+				t.Fatalf("serviceError() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestSentinelErrorsAreDistinct guards against a new row being shadowed by an
+// earlier one that the same error also matches.
+func TestSentinelErrorsAreDistinct(t *testing.T) {
+	for _, sentinel := range sentinelErrors {
+		got := serviceError(sentinel.err, "", http.StatusInternalServerError)
+		if got.Status != sentinel.status || got.Code != sentinel.code {
+			t.Errorf("%v maps to %d %s, want %d %s", sentinel.err, got.Status, got.Code, sentinel.status, sentinel.code)
+		}
+	}
+}
@@ -1,16 +1,21 @@
 package http
 
 import (
+	"encoding/csv"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
+	"reflect"
 	"strconv"
 	"strings"
 	"time"
 
 	"backend/internal/domain"
 	"backend/internal/excel"
+	"backend/internal/logging"
 	"backend/internal/repository"
 	"backend/internal/service"
 
@@ -23,7 +28,7 @@ type Handler struct {
 
 type inventoryProductView struct {
 	ProductName  string  `json:"product_name"`
-	Quantity     int     `json:"quantity"`
+	Quantity     float64 `json:"quantity"`
 	AvgBuyPrice  float64 `json:"avg_buy_price"`
 	LastBuyPrice float64 `json:"last_buy_price"`
 	SellPrice    float64 `json:"sell_price"`
@@ -39,6 +44,15 @@ func (h *Handler) Health(w http.ResponseWriter, _ *http.Request) {
 	writeJSON(w, http.StatusOK, map[string]any{"status": "ok"})
 }
 
+func (h *Handler) PendingMigrations(w http.ResponseWriter, r *http.Request) {
+	pending, err := h.svc.PendingMigrations(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"items": pending, "count": len(pending)})
+}
+
 func (h *Handler) ListProducts(w http.ResponseWriter, r *http.Request) {
 	query := r.URL.Query()
 	limit, err := parseOptionalInt(query.Get("limit"), 200)
@@ -53,14 +67,16 @@ func (h *Handler) ListProducts(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var threshold *int
+	lowStock := false
 	if lowStockRaw := strings.TrimSpace(query.Get("low_stock")); lowStockRaw != "" {
-		lowStock, err := strconv.ParseBool(lowStockRaw)
+		value, err := strconv.ParseBool(lowStockRaw)
 		if err != nil {
 			writeError(w, http.StatusBadRequest, "low_stock must be true or false")
 			return
 		}
+		lowStock = value
 		if lowStock {
-			value, err := parseOptionalInt(query.Get("threshold"), 5)
+			value, err := parseOptionalInt(query.Get("threshold"), 0)
 			if err != nil {
 				writeError(w, http.StatusBadRequest, err.Error())
 				return
@@ -69,7 +85,62 @@ func (h *Handler) ListProducts(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	items, err := h.svc.ListProducts(r.Context(), query.Get("search"), limit, offset, threshold)
+	categoryID, err := parseOptionalInt64(query.Get("category_id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	sortOrder, err := repository.ParseProductSort(query.Get("sort"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	minSell, err := parseOptionalFloat(query.Get("min_sell"), "min_sell")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	maxSell, err := parseOptionalFloat(query.Get("max_sell"), "max_sell")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if minSell != nil && maxSell != nil && *minSell > *maxSell {
+		writeError(w, http.StatusBadRequest, "min_sell cannot be greater than max_sell")
+		return
+	}
+
+	fuzzy := false
+	fuzzyThreshold := 0.0
+	if fuzzyRaw := strings.TrimSpace(query.Get("fuzzy")); fuzzyRaw != "" {
+		fuzzy, err = strconv.ParseBool(fuzzyRaw)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "fuzzy must be true or false")
+			return
+		}
+	}
+	if fuzzy {
+		fuzzyThreshold, err = parseFuzzyThreshold(query.Get("fuzzy_threshold"))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	items, hasMore, err := h.svc.ListProducts(r.Context(), repository.ProductListFilter{
+		Search:     query.Get("search"),
+		Limit:      limit,
+		Offset:     offset,
+		Threshold:  threshold,
+		CategoryID: categoryID,
+		MinSell:    minSell,
+		MaxSell:    maxSell,
+		Source:     query.Get("source"),
+		Sort:       sortOrder,
+
+		Fuzzy:          fuzzy,
+		FuzzyThreshold: fuzzyThreshold,
+	})
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, err.Error())
 		return
@@ -87,29 +158,29 @@ func (h *Handler) ListProducts(w http.ResponseWriter, r *http.Request) {
 				Source:       item.Source,
 			})
 		}
-		writeJSON(
-			w,
-			http.StatusOK,
-			map[string]any{"items": leanItems, "count": len(leanItems)},
-		)
+		writeJSON(w, http.StatusOK, pageResponse(leanItems, len(leanItems), hasMore, limit, offset))
 		return
 	}
-	writeJSON(w, http.StatusOK, map[string]any{"items": items, "count": len(items)})
+	writeJSON(w, http.StatusOK, pageResponse(items, len(items), hasMore, limit, offset))
 }
 
 func (h *Handler) GetProduct(w http.ResponseWriter, r *http.Request) {
-	id, err := parseID(chi.URLParam(r, "id"))
-	if err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
+	id, ok := urlID(w, r)
+	if !ok {
 		return
 	}
 	product, err := h.svc.GetProduct(r.Context(), id)
 	if err != nil {
-		if errors.Is(err, repository.ErrNotFound) {
-			writeError(w, http.StatusNotFound, "product not found")
-			return
-		}
-		writeError(w, http.StatusInternalServerError, err.Error())
+		writeServiceError(w, err, "product", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, product)
+}
+
+func (h *Handler) GetProductBySKU(w http.ResponseWriter, r *http.Request) {
+	product, err := h.svc.GetProductBySKU(r.Context(), chi.URLParam(r, "sku"))
+	if err != nil {
+		writeServiceError(w, err, "product", http.StatusInternalServerError)
 		return
 	}
 	writeJSON(w, http.StatusOK, product)
@@ -117,18 +188,21 @@ func (h *Handler) GetProduct(w http.ResponseWriter, r *http.Request) {
 
 type createProductRequest struct {
 	ProductName  string  `json:"product_name"`
-	Quantity     int     `json:"quantity"`
+	Quantity     float64 `json:"quantity"`
 	AvgBuyPrice  float64 `json:"avg_buy_price"`
 	LastBuyPrice float64 `json:"last_buy_price"`
 	SellPrice    float64 `json:"sell_price"`
 	Alarm        *int    `json:"alarm"`
 	Source       *string `json:"source"`
+	CategoryID   *int64  `json:"category_id"`
+	SKU          *string `json:"sku"`
+	Unit         string  `json:"unit"`
 }
 
 func (h *Handler) CreateProduct(w http.ResponseWriter, r *http.Request) {
 	var req createProductRequest
 	if err := decodeJSON(r, &req); err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
+		writeServiceError(w, err, "", http.StatusBadRequest)
 		return
 	}
 	if req.LastBuyPrice <= 0 {
@@ -142,9 +216,12 @@ func (h *Handler) CreateProduct(w http.ResponseWriter, r *http.Request) {
 		SellPrice:    req.SellPrice,
 		Alarm:        req.Alarm,
 		Source:       req.Source,
+		CategoryID:   req.CategoryID,
+		SKU:          req.SKU,
+		Unit:         req.Unit,
 	})
 	if err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
+		writeServiceError(w, err, "product", http.StatusBadRequest)
 		return
 	}
 	writeJSON(w, http.StatusCreated, created)
@@ -152,24 +229,28 @@ func (h *Handler) CreateProduct(w http.ResponseWriter, r *http.Request) {
 
 type patchProductRequest struct {
 	ProductName  *string  `json:"product_name"`
-	Quantity     *int     `json:"quantity"`
+	Quantity     *float64 `json:"quantity"`
 	AvgBuyPrice  *float64 `json:"avg_buy_price"`
 	LastBuyPrice *float64 `json:"last_buy_price"`
 	SellPrice    *float64 `json:"sell_price"`
 	Alarm        *int     `json:"alarm"`
 	Source       *string  `json:"source"`
+	CategoryID   *int64   `json:"category_id"`
+	SKU          *string  `json:"sku"`
+	Unit         *string  `json:"unit"`
+	// UpdatedAt is the updated_at the client last saw; stale edits get 409.
+	UpdatedAt *time.Time `json:"updated_at"`
 }
 
 func (h *Handler) PatchProduct(w http.ResponseWriter, r *http.Request) {
-	id, err := parseID(chi.URLParam(r, "id"))
-	if err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
+	id, ok := urlID(w, r)
+	if !ok {
 		return
 	}
 
 	var req patchProductRequest
 	if err := decodeJSON(r, &req); err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
+		writeServiceError(w, err, "", http.StatusBadRequest)
 		return
 	}
 
@@ -181,253 +262,816 @@ func (h *Handler) PatchProduct(w http.ResponseWriter, r *http.Request) {
 		SellPrice:    req.SellPrice,
 		Alarm:        req.Alarm,
 		Source:       req.Source,
+		CategoryID:   req.CategoryID,
+		SKU:          req.SKU,
+		Unit:         req.Unit,
+
+		ExpectedUpdatedAt: req.UpdatedAt,
 	})
 	if err != nil {
-		if errors.Is(err, repository.ErrNotFound) {
-			writeError(w, http.StatusNotFound, "product not found")
-			return
-		}
-		writeError(w, http.StatusBadRequest, err.Error())
+		writeServiceError(w, err, "product", http.StatusBadRequest)
 		return
 	}
 	writeJSON(w, http.StatusOK, updated)
 }
 
-func (h *Handler) DeleteProduct(w http.ResponseWriter, r *http.Request) {
-	id, err := parseID(chi.URLParam(r, "id"))
-	if err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
+type adjustProductRequest struct {
+	Delta         float64 `json:"delta"`
+	Reason        string  `json:"reason"`
+	AdminUsername *string `json:"admin_username"`
+	Force         bool    `json:"force"`
+}
+
+func (h *Handler) AdjustProduct(w http.ResponseWriter, r *http.Request) {
+	id, ok := urlID(w, r)
+	if !ok {
 		return
 	}
-	if err := h.svc.DeleteProduct(r.Context(), id); err != nil {
-		if errors.Is(err, repository.ErrNotFound) {
-			writeError(w, http.StatusNotFound, "product not found")
-			return
-		}
-		writeError(w, http.StatusInternalServerError, err.Error())
+
+	var req adjustProductRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeServiceError(w, err, "", http.StatusBadRequest)
 		return
 	}
-	w.WriteHeader(http.StatusNoContent)
-}
 
-func (h *Handler) InventorySummary(w http.ResponseWriter, r *http.Request) {
-	summary, err := h.svc.InventorySummary(r.Context())
+	updated, err := h.svc.AdjustProductQuantity(r.Context(), id, req.Delta, req.Reason, req.AdminUsername, req.Force)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error())
+		writeServiceError(w, err, "product", http.StatusBadRequest)
 		return
 	}
-	writeJSON(w, http.StatusOK, summary)
+	writeJSON(w, http.StatusOK, updated)
 }
 
-func (h *Handler) LowStock(w http.ResponseWriter, r *http.Request) {
-	threshold, err := parseOptionalInt(r.URL.Query().Get("threshold"), 5)
-	if err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
+type productAliasRequest struct {
+	Alias string `json:"alias"`
+}
+
+func (h *Handler) ListProductAliases(w http.ResponseWriter, r *http.Request) {
+	id, ok := urlID(w, r)
+	if !ok {
 		return
 	}
-	rows, err := h.svc.LowStock(r.Context(), threshold)
+	items, err := h.svc.ListProductAliases(r.Context(), id)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
-	writeJSON(w, http.StatusOK, map[string]any{"items": rows, "count": len(rows)})
+	writeJSON(w, http.StatusOK, map[string]any{"items": items, "count": len(items)})
 }
 
-func (h *Handler) ImportInventoryExcel(w http.ResponseWriter, r *http.Request) {
-	if err := r.ParseMultipartForm(32 << 20); err != nil {
-		writeError(w, http.StatusBadRequest, "failed to parse multipart form")
+func (h *Handler) AddProductAlias(w http.ResponseWriter, r *http.Request) {
+	id, ok := urlID(w, r)
+	if !ok {
 		return
 	}
-	file, header, err := r.FormFile("file")
-	if err != nil {
-		writeError(w, http.StatusBadRequest, "file field is required")
+	var req productAliasRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeServiceError(w, err, "", http.StatusBadRequest)
 		return
 	}
-	defer file.Close()
-
-	rows, err := excel.ParseInventoryRows(file)
+	items, err := h.svc.AddProductAlias(r.Context(), id, req.Alias)
 	if err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
+		writeServiceError(w, err, "product", http.StatusBadRequest)
 		return
 	}
+	writeJSON(w, http.StatusCreated, map[string]any{"items": items, "count": len(items)})
+}
 
-	created, updated, err := h.svc.ImportInventory(r.Context(), rows)
+func (h *Handler) DeleteProductAlias(w http.ResponseWriter, r *http.Request) {
+	id, ok := urlID(w, r)
+	if !ok {
+		return
+	}
+	var req productAliasRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeServiceError(w, err, "", http.StatusBadRequest)
+		return
+	}
+	items, err := h.svc.DeleteProductAlias(r.Context(), id, req.Alias)
 	if err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
+		writeServiceError(w, err, "alias", http.StatusInternalServerError)
 		return
 	}
+	writeJSON(w, http.StatusOK, map[string]any{"items": items, "count": len(items)})
+}
 
-	writeJSON(w, http.StatusOK, map[string]any{
-		"file_name":  header.Filename,
-		"total_rows": len(rows),
-		"created":    created,
-		"updated":    updated,
-	})
+type mergeProductsRequest struct {
+	TargetID  int64   `json:"target_id"`
+	SourceIDs []int64 `json:"source_ids"`
 }
 
-func (h *Handler) ImportSellPrices(w http.ResponseWriter, r *http.Request) {
-	if err := r.ParseMultipartForm(32 << 20); err != nil {
-		writeError(w, http.StatusBadRequest, "failed to parse multipart form")
+func (h *Handler) MergeProducts(w http.ResponseWriter, r *http.Request) {
+	var req mergeProductsRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeServiceError(w, err, "", http.StatusBadRequest)
 		return
 	}
-	file, header, err := r.FormFile("file")
+	merged, updatedLines, err := h.svc.MergeProducts(r.Context(), req.TargetID, req.SourceIDs)
 	if err != nil {
-		writeError(w, http.StatusBadRequest, "file field is required")
+		if errors.Is(err, repository.ErrNotFound) {
+			// Keep the text: it names which of the products is missing.
+			writeAPIError(w, apiError{Status: http.StatusNotFound, Code: "product_not_found", Message: err.Error()})
+			return
+		}
+		writeServiceError(w, err, "product", http.StatusBadRequest)
 		return
 	}
-	defer file.Close()
+	writeJSON(w, http.StatusOK, map[string]any{"product": merged, "updated_lines": updatedLines})
+}
 
-	rows, detectedFormat, err := excel.ParseProductPriceRows(header.Filename, file)
-	if err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
+type bulkSetSourceRequest struct {
+	IDs    []int64 `json:"ids"`
+	Search string  `json:"search"`
+	Source *string `json:"source"`
+}
+
+func (h *Handler) BulkSetProductSource(w http.ResponseWriter, r *http.Request) {
+	var req bulkSetSourceRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeServiceError(w, err, "", http.StatusBadRequest)
 		return
 	}
-
-	result, err := h.svc.ImportSellPrices(r.Context(), rows)
+	updated, err := h.svc.BulkSetProductSource(r.Context(), req.IDs, req.Search, req.Source)
 	if err != nil {
 		writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
+	writeJSON(w, http.StatusOK, map[string]any{"updated": updated})
+}
 
-	writeJSON(w, http.StatusOK, map[string]any{
-		"file_name":        header.Filename,
-		"detected_format":  detectedFormat,
-		"total_rows":       result.TotalRows,
-		"matched_rows":     result.MatchedRows,
-		"updated_products": result.UpdatedProducts,
-		"unmatched_count":  result.UnmatchedCount,
-		"unmatched_names":  result.UnmatchedNames,
-	})
+func (h *Handler) DeleteProduct(w http.ResponseWriter, r *http.Request) {
+	id, ok := urlID(w, r)
+	if !ok {
+		return
+	}
+	if err := h.svc.DeleteProduct(r.Context(), id); err != nil {
+		writeServiceError(w, err, "product", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
 }
 
-func (h *Handler) GetSellPriceAlarmPercent(w http.ResponseWriter, r *http.Request) {
-	percent, err := h.svc.GetSellPriceAlarmPercent(r.Context())
+func (h *Handler) InventorySummary(w http.ResponseWriter, r *http.Request) {
+	summary, err := h.svc.InventorySummary(r.Context())
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
-	writeJSON(w, http.StatusOK, map[string]any{
-		"percent": percent,
-	})
+	writeJSON(w, http.StatusOK, summary)
 }
 
-type updateSellPriceAlarmPercentRequest struct {
-	Percent float64 `json:"percent"`
+func (h *Handler) RecordInventorySnapshot(w http.ResponseWriter, r *http.Request) {
+	snapshot, err := h.svc.RecordInventorySnapshot(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, snapshot)
 }
 
-func (h *Handler) UpdateSellPriceAlarmPercent(w http.ResponseWriter, r *http.Request) {
-	var req updateSellPriceAlarmPercentRequest
-	if err := decodeJSON(r, &req); err != nil {
+func (h *Handler) InventoryHistory(w http.ResponseWriter, r *http.Request) {
+	days, err := parseOptionalInt(r.URL.Query().Get("days"), 90)
+	if err != nil {
 		writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
-	percent, err := h.svc.SetSellPriceAlarmPercent(r.Context(), req.Percent)
+	items, err := h.svc.InventoryHistory(r.Context(), days)
 	if err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
+		writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
-	writeJSON(w, http.StatusOK, map[string]any{
-		"percent": percent,
-	})
+	writeJSON(w, http.StatusOK, map[string]any{"items": items, "count": len(items)})
 }
 
-func (h *Handler) GetSalesImportFuzzyMatchPercent(w http.ResponseWriter, r *http.Request) {
-	percent, err := h.svc.GetSalesImportFuzzyMatchPercent(r.Context())
+func (h *Handler) LowStock(w http.ResponseWriter, r *http.Request) {
+	threshold, err := parseOptionalInt(r.URL.Query().Get("threshold"), 0)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	rows, err := h.svc.LowStock(r.Context(), threshold)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
-	writeJSON(w, http.StatusOK, map[string]any{
-		"percent": percent,
-	})
-}
-
-type updateSalesImportFuzzyMatchPercentRequest struct {
-	Percent float64 `json:"percent"`
+	writeJSON(w, http.StatusOK, map[string]any{"items": rows, "count": len(rows)})
 }
 
-func (h *Handler) UpdateSalesImportFuzzyMatchPercent(w http.ResponseWriter, r *http.Request) {
-	var req updateSalesImportFuzzyMatchPercentRequest
-	if err := decodeJSON(r, &req); err != nil {
+func (h *Handler) MissingSellPrice(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	limit, err := parseOptionalInt(query.Get("limit"), 200)
+	if err != nil {
 		writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
-	percent, err := h.svc.SetSalesImportFuzzyMatchPercent(r.Context(), req.Percent)
+	offset, err := parseOptionalInt(query.Get("offset"), 0)
 	if err != nil {
 		writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
-	writeJSON(w, http.StatusOK, map[string]any{
-		"percent": percent,
-	})
+	items, hasMore, total, err := h.svc.ListMissingSellPrice(r.Context(), limit, offset)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	response := pageResponse(items, len(items), hasMore, limit, offset)
+	response["total_count"] = total
+	writeJSON(w, http.StatusOK, response)
 }
 
-type replaceInventoryRequest struct {
-	Rows []domain.InventoryImportRow `json:"rows"`
+func (h *Handler) MarginAlerts(w http.ResponseWriter, r *http.Request) {
+	var percent *float64
+	if percentRaw := strings.TrimSpace(r.URL.Query().Get("percent")); percentRaw != "" {
+		value, err := strconv.ParseFloat(percentRaw, 64)
+		if err != nil || value < 0 || value > 100 {
+			writeError(w, http.StatusBadRequest, "percent must be between 0 and 100")
+			return
+		}
+		percent = &value
+	}
+	rows, err := h.svc.MarginAlerts(r.Context(), percent)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"items": rows, "count": len(rows)})
 }
 
-func (h *Handler) ReplaceInventory(w http.ResponseWriter, r *http.Request) {
-	var req replaceInventoryRequest
-	if err := decodeJSON(r, &req); err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
-		return
+// ExportInventory streams every product as xlsx or csv.
+func (h *Handler) ExportInventory(w http.ResponseWriter, r *http.Request) {
+	format := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("format")))
+	if format == "" {
+		format = "xlsx"
 	}
-	if len(req.Rows) == 0 {
-		writeError(w, http.StatusBadRequest, "rows are required")
+	if format != "xlsx" && format != "csv" {
+		writeError(w, http.StatusBadRequest, "format must be xlsx or csv")
 		return
 	}
-	if err := h.svc.ReplaceInventory(r.Context(), req.Rows); err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
+
+	each := func(fn func(domain.Product) error) error {
+		return h.svc.ExportProducts(r.Context(), fn)
+	}
+	fileName := fmt.Sprintf("inventory-%s.%s", time.Now().Format("20060102-150405"), format)
+	if format == "csv" {
+		writeExport(w, r, exportFile{Name: fileName, ContentType: csvContentType, Prefix: csvBOM}, func(out io.Writer) error {
+			return excel.WriteInventoryCSV(out, each)
+		})
 		return
 	}
-	writeJSON(w, http.StatusOK, map[string]any{"replaced": len(req.Rows)})
+	writeExport(w, r, exportFile{Name: fileName, ContentType: xlsxContentType}, func(out io.Writer) error {
+		return excel.WriteInventoryXLSX(out, each)
+	})
 }
 
-type syncInventoryRequest struct {
-	Upserts []domain.InventoryImportRow `json:"upserts"`
-	Deletes []string                    `json:"deletes"`
+const (
+	csvContentType  = "text/csv; charset=utf-8"
+	xlsxContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	// csvBOM makes Excel read the file as UTF-8.
+	csvBOM = "\uFEFF"
+)
+
+// exportFile describes a download written by writeExport. Prefix is sent
+// before the content, e.g. csvBOM.
+type exportFile struct {
+	Name        string
+	ContentType string
+	Prefix      string
+}
+
+// exportWriter holds back the status line and headers of a download until
+// the first byte of the file, so an error before that can still be answered
+// with an error status.
+type exportWriter struct {
+	w       http.ResponseWriter
+	file    exportFile
+	started bool
+}
+
+func (e *exportWriter) Write(p []byte) (int, error) {
+	if !e.started {
+		e.started = true
+		e.w.Header().Set("Content-Type", e.file.ContentType)
+		e.w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, e.file.Name))
+		e.w.WriteHeader(http.StatusOK)
+		if _, err := io.WriteString(e.w, e.file.Prefix); err != nil {
+			return 0, err
+		}
+	}
+	return e.w.Write(p)
 }
 
-func (h *Handler) SyncInventory(w http.ResponseWriter, r *http.Request) {
-	var req syncInventoryRequest
-	if err := decodeJSON(r, &req); err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
+// writeExport sends the file that write produces. An error before the first
+// byte goes out becomes a 500. After that the connection is aborted, so the
+// client sees a failed download instead of a short file that looks complete.
+func writeExport(w http.ResponseWriter, r *http.Request, file exportFile, write func(io.Writer) error) {
+	out := &exportWriter{w: w, file: file}
+	err := write(out)
+	if err == nil {
+		if !out.started {
+			_, _ = out.Write(nil)
+		}
 		return
 	}
-	if len(req.Upserts) == 0 && len(req.Deletes) == 0 {
-		writeError(w, http.StatusBadRequest, "upserts or deletes are required")
+	if !out.started {
+		writeServiceError(w, err, "", http.StatusInternalServerError)
 		return
 	}
-	result, err := h.svc.SyncInventory(r.Context(), req.Upserts, req.Deletes)
-	if err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
+	logging.FromContext(r.Context()).Error("export aborted", "file", file.Name, "error", err)
+	panic(http.ErrAbortHandler)
+}
+
+func (h *Handler) InventoryImportTemplate(w http.ResponseWriter, r *http.Request) {
+	format := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("format")))
+	if format == "" {
+		format = "xlsx"
+	}
+	if format != "xlsx" && format != "csv" {
+		writeError(w, http.StatusBadRequest, "format must be xlsx or csv")
 		return
 	}
-	writeJSON(w, http.StatusOK, map[string]any{
-		"upserted": result.Upserted,
-		"deleted":  result.Deleted,
-	})
-}
 
-func (h *Handler) ListProductGroups(w http.ResponseWriter, r *http.Request) {
-	items, err := h.svc.ListProductGroups(r.Context())
-	if err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error())
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="inventory-template.%s"`, format))
+	if format == "csv" {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("\uFEFF"))
+		_ = excel.WriteInventoryTemplateCSV(w)
 		return
 	}
-	writeJSON(w, http.StatusOK, map[string]any{
-		"items": items,
+	w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	w.WriteHeader(http.StatusOK)
+	_ = excel.WriteInventoryTemplateXLSX(w)
+}
+
+func (h *Handler) ExportPriceAlarmsCSV(w http.ResponseWriter, r *http.Request) {
+	each := func(fn func(domain.PriceAlarmRow) error) error {
+		return h.svc.ExportPriceAlarms(r.Context(), fn)
+	}
+	writeExport(w, r, exportFile{Name: "price-alarms.csv", ContentType: csvContentType, Prefix: csvBOM}, func(out io.Writer) error {
+		return writePriceAlarmsCSV(out, each)
 	})
 }
 
-type createProductGroupRequest struct {
+var priceAlarmsCSVHeader = []string{"product_name", "avg_buy_price", "sell_price", "margin_percent", "source"}
+
+// writePriceAlarmsCSV writes one row per alarm that each passes to its
+// callback, flushing every 500 rows.
+func writePriceAlarmsCSV(w io.Writer, each func(fn func(domain.PriceAlarmRow) error) error) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(priceAlarmsCSVHeader); err != nil {
+		return err
+	}
+	written := 0
+	err := each(func(row domain.PriceAlarmRow) error {
+		source := ""
+		if row.Source != nil {
+			source = *row.Source
+		}
+		if err := writer.Write([]string{
+			row.ProductName,
+			strconv.FormatFloat(row.AvgBuyPrice, 'f', -1, 64),
+			strconv.FormatFloat(row.SellPrice, 'f', -1, 64),
+			strconv.FormatFloat(row.MarginPercent, 'f', 2, 64),
+			source,
+		}); err != nil {
+			return err
+		}
+		written++
+		if written%500 == 0 {
+			writer.Flush()
+			return writer.Error()
+		}
+		return nil
+	})
+	writer.Flush()
+	if err != nil {
+		return err
+	}
+	return writer.Error()
+}
+
+// parseUploadForm parses a multipart upload and writes the error response
+// itself on failure. The body size is capped by the router's upload limit;
+// the 32 MiB here only bounds how much of it is kept in memory before the
+// rest spills to temporary files.
+func parseUploadForm(w http.ResponseWriter, r *http.Request) bool {
+	err := r.ParseMultipartForm(32 << 20)
+	if err == nil {
+		return true
+	}
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		writeServiceError(w, fmt.Errorf("%w: limit is %d bytes", errBodyTooLarge, maxBytesErr.Limit), "", http.StatusBadRequest)
+		return false
+	}
+	writeError(w, http.StatusBadRequest, "failed to parse multipart form")
+	return false
+}
+
+// parseInventoryUpload reads the multipart inventory file shared by the
+// import and validate endpoints. It writes the error response itself.
+func (h *Handler) parseInventoryUpload(w http.ResponseWriter, r *http.Request) (string, excel.InventoryParseResult, bool) {
+	if !parseUploadForm(w, r) {
+		return "", excel.InventoryParseResult{}, false
+	}
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "file field is required")
+		return "", excel.InventoryParseResult{}, false
+	}
+	defer file.Close()
+
+	strict := false
+	if strictRaw := strings.TrimSpace(r.FormValue("strict")); strictRaw != "" {
+		value, parseErr := strconv.ParseBool(strictRaw)
+		if parseErr != nil {
+			writeError(w, http.StatusBadRequest, "strict must be true or false")
+			return "", excel.InventoryParseResult{}, false
+		}
+		strict = value
+	}
+
+	opts := excel.InventoryParseOptions{
+		FileName:           header.Filename,
+		Strict:             strict,
+		SheetName:          r.FormValue("sheet_name"),
+		SkipInvalidRows:    !strict,
+		FractionalQuantity: h.svc.FractionalQuantity(),
+	}
+	if !opts.FractionalQuantity {
+		opts.DecimalProducts, err = h.svc.DecimalQuantityProducts(r.Context())
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return "", excel.InventoryParseResult{}, false
+		}
+	}
+	parsed, err := excel.ParseInventoryFile(file, opts)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return "", excel.InventoryParseResult{}, false
+	}
+	if parsed.Skipped == nil {
+		parsed.Skipped = []excel.RowError{}
+	}
+	return header.Filename, parsed, true
+}
+
+func (h *Handler) ImportInventoryExcel(w http.ResponseWriter, r *http.Request) {
+	fileName, parsed, ok := h.parseInventoryUpload(w, r)
+	if !ok {
+		return
+	}
+	rows := parsed.Rows
+
+	created, updated, err := h.svc.ImportInventory(r.Context(), rows)
+	if err != nil {
+		writeServiceError(w, err, "", http.StatusBadRequest)
+		return
+	}
+
+	response := map[string]any{
+		"file_name":  fileName,
+		"total_rows": len(rows),
+		"imported":   len(rows),
+		"created":    created,
+		"updated":    updated,
+		"skipped":    parsed.Skipped,
+	}
+	if len(parsed.Warnings) > 0 {
+		response["warnings"] = parsed.Warnings
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (h *Handler) ValidateInventoryExcel(w http.ResponseWriter, r *http.Request) {
+	fileName, parsed, ok := h.parseInventoryUpload(w, r)
+	if !ok {
+		return
+	}
+
+	preview, err := h.svc.PreviewInventoryImport(r.Context(), parsed.Rows)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	response := map[string]any{
+		"file_name":       fileName,
+		"total_rows":      preview.TotalRows,
+		"would_create":    preview.WouldCreate,
+		"would_update":    preview.WouldUpdate,
+		"duplicate_names": preview.DuplicateNames,
+		"skipped":         parsed.Skipped,
+	}
+	if len(parsed.Warnings) > 0 {
+		response["warnings"] = parsed.Warnings
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (h *Handler) ImportSellPrices(w http.ResponseWriter, r *http.Request) {
+	if !parseUploadForm(w, r) {
+		return
+	}
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "file field is required")
+		return
+	}
+	defer file.Close()
+
+	var opts repository.SellPriceImportOptions
+	if opts.FuzzyThreshold, err = parseOptionalFloat(r.FormValue("threshold"), "threshold"); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if opts.TokenThreshold, err = parseOptionalFloat(r.FormValue("token_threshold"), "token_threshold"); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	multiplier, err := parseOptionalFloat(r.FormValue("multiplier"), "multiplier")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	parsed, err := excel.ParseProductPriceFile(header.Filename, file, excel.ProductPriceParseOptions{
+		Multiplier: multiplier,
+	})
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	rows := parsed.Rows
+
+	result, err := h.svc.ImportSellPrices(r.Context(), rows, opts)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"file_name":          header.Filename,
+		"detected_format":    parsed.Format,
+		"price_unit":         parsed.Unit,
+		"price_multiplier":   parsed.Multiplier,
+		"total_rows":         result.TotalRows,
+		"matched_rows":       result.MatchedRows,
+		"token_matched":      result.TokenMatchedRows,
+		"fuzzy_matched_rows": result.FuzzyMatchedRows,
+		"fuzzy_matches":      result.FuzzyMatches,
+		"updated_products":   result.UpdatedProducts,
+		"unmatched_count":    result.UnmatchedCount,
+		"unmatched_names":    result.UnmatchedNames,
+	})
+}
+
+func (h *Handler) ListSettings(w http.ResponseWriter, r *http.Request) {
+	items, err := h.svc.ListSettings(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"items": items, "count": len(items)})
+}
+
+func (h *Handler) GetSetting(w http.ResponseWriter, r *http.Request) {
+	setting, err := h.svc.GetSetting(r.Context(), chi.URLParam(r, "key"))
+	if err != nil {
+		writeServiceError(w, err, "setting", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, setting)
+}
+
+type updateSettingRequest struct {
+	Value *float64 `json:"value"`
+}
+
+func (h *Handler) UpdateSetting(w http.ResponseWriter, r *http.Request) {
+	var req updateSettingRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeServiceError(w, err, "", http.StatusBadRequest)
+		return
+	}
+	if req.Value == nil {
+		writeError(w, http.StatusBadRequest, "value is required")
+		return
+	}
+	setting, err := h.svc.SetSetting(r.Context(), chi.URLParam(r, "key"), *req.Value)
+	if err != nil {
+		writeServiceError(w, err, "setting", http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, http.StatusOK, setting)
+}
+
+func (h *Handler) GetSellPriceAlarmPercent(w http.ResponseWriter, r *http.Request) {
+	percent, err := h.svc.GetSellPriceAlarmPercent(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"percent": percent,
+	})
+}
+
+type matchSellPricesPreviewRequest struct {
+	Rows      []domain.ProductPriceRow `json:"rows"`
+	Threshold *float64                 `json:"threshold"`
+}
+
+func (h *Handler) PreviewSellPriceMatches(w http.ResponseWriter, r *http.Request) {
+	var req matchSellPricesPreviewRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeServiceError(w, err, "", http.StatusBadRequest)
+		return
+	}
+	items, err := h.svc.PreviewSellPriceMatches(r.Context(), req.Rows, req.Threshold)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"items": items, "count": len(items)})
+}
+
+type updateSellPriceAlarmPercentRequest struct {
+	Percent float64 `json:"percent"`
+}
+
+func (h *Handler) UpdateSellPriceAlarmPercent(w http.ResponseWriter, r *http.Request) {
+	var req updateSellPriceAlarmPercentRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeServiceError(w, err, "", http.StatusBadRequest)
+		return
+	}
+	percent, err := h.svc.SetSellPriceAlarmPercent(r.Context(), req.Percent)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"percent": percent,
+	})
+}
+
+func (h *Handler) GetSalesImportFuzzyMatchPercent(w http.ResponseWriter, r *http.Request) {
+	percent, err := h.svc.GetSalesImportFuzzyMatchPercent(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"percent": percent,
+	})
+}
+
+type updateSalesImportFuzzyMatchPercentRequest struct {
+	Percent float64 `json:"percent"`
+}
+
+func (h *Handler) UpdateSalesImportFuzzyMatchPercent(w http.ResponseWriter, r *http.Request) {
+	var req updateSalesImportFuzzyMatchPercentRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeServiceError(w, err, "", http.StatusBadRequest)
+		return
+	}
+	percent, err := h.svc.SetSalesImportFuzzyMatchPercent(r.Context(), req.Percent)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"percent": percent,
+	})
+}
+
+func (h *Handler) GetLowStockDefault(w http.ResponseWriter, r *http.Request) {
+	threshold, err := h.svc.GetLowStockDefault(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"threshold": threshold,
+	})
+}
+
+type updateLowStockDefaultRequest struct {
+	Threshold int `json:"threshold"`
+}
+
+func (h *Handler) UpdateLowStockDefault(w http.ResponseWriter, r *http.Request) {
+	var req updateLowStockDefaultRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeServiceError(w, err, "", http.StatusBadRequest)
+		return
+	}
+	threshold, err := h.svc.SetLowStockDefault(r.Context(), req.Threshold)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"threshold": threshold,
+	})
+}
+
+type replaceInventoryRequest struct {
+	Rows []domain.InventoryImportRow `json:"rows"`
+}
+
+func (h *Handler) ReplaceInventory(w http.ResponseWriter, r *http.Request) {
+	var req replaceInventoryRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeServiceError(w, err, "", http.StatusBadRequest)
+		return
+	}
+	if len(req.Rows) == 0 {
+		writeError(w, http.StatusBadRequest, "rows are required")
+		return
+	}
+	if err := h.svc.ReplaceInventory(r.Context(), req.Rows); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"replaced": len(req.Rows)})
+}
+
+type syncInventoryRequest struct {
+	Upserts []domain.InventoryImportRow `json:"upserts"`
+	Deletes []string                    `json:"deletes"`
+}
+
+func (h *Handler) SyncInventory(w http.ResponseWriter, r *http.Request) {
+	var req syncInventoryRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeServiceError(w, err, "", http.StatusBadRequest)
+		return
+	}
+	if len(req.Upserts) == 0 && len(req.Deletes) == 0 {
+		writeError(w, http.StatusBadRequest, "upserts or deletes are required")
+		return
+	}
+	result, err := h.svc.SyncInventory(r.Context(), req.Upserts, req.Deletes)
+	if err != nil {
+		writeServiceError(w, err, "", http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"upserted": result.Upserted,
+		"deleted":  result.Deleted,
+	})
+}
+
+func (h *Handler) ListProductGroups(w http.ResponseWriter, r *http.Request) {
+	items, err := h.svc.ListProductGroups(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"items": items,
+	})
+}
+
+type createCategoryRequest struct {
+	Name string `json:"name"`
+}
+
+func (h *Handler) ListCategories(w http.ResponseWriter, r *http.Request) {
+	items, err := h.svc.ListCategories(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"items": items, "count": len(items)})
+}
+
+func (h *Handler) CreateCategory(w http.ResponseWriter, r *http.Request) {
+	var req createCategoryRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeServiceError(w, err, "", http.StatusBadRequest)
+		return
+	}
+	category, err := h.svc.CreateCategory(r.Context(), req.Name)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusCreated, category)
+}
+
+type createProductGroupRequest struct {
 	Name string `json:"name"`
 }
 
 func (h *Handler) CreateProductGroup(w http.ResponseWriter, r *http.Request) {
 	var req createProductGroupRequest
 	if err := decodeJSON(r, &req); err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
+		writeServiceError(w, err, "", http.StatusBadRequest)
 		return
 	}
 	group, err := h.svc.CreateProductGroup(r.Context(), req.Name)
@@ -446,14 +1090,13 @@ type updateProductGroupRequest struct {
 }
 
 func (h *Handler) UpdateProductGroup(w http.ResponseWriter, r *http.Request) {
-	groupID, err := parseID(chi.URLParam(r, "id"))
-	if err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
+	groupID, ok := urlID(w, r)
+	if !ok {
 		return
 	}
 	var req updateProductGroupRequest
 	if err := decodeJSON(r, &req); err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
+		writeServiceError(w, err, "", http.StatusBadRequest)
 		return
 	}
 	group, err := h.svc.UpdateProductGroup(
@@ -463,11 +1106,7 @@ func (h *Handler) UpdateProductGroup(w http.ResponseWriter, r *http.Request) {
 		req.Members,
 	)
 	if err != nil {
-		status := http.StatusBadRequest
-		if errors.Is(err, repository.ErrNotFound) {
-			status = http.StatusNotFound
-		}
-		writeError(w, status, err.Error())
+		writeServiceError(w, err, "product_group", http.StatusBadRequest)
 		return
 	}
 	writeJSON(w, http.StatusOK, map[string]any{
@@ -476,17 +1115,12 @@ func (h *Handler) UpdateProductGroup(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) DeleteProductGroup(w http.ResponseWriter, r *http.Request) {
-	groupID, err := parseID(chi.URLParam(r, "id"))
-	if err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
+	groupID, ok := urlID(w, r)
+	if !ok {
 		return
 	}
 	if err := h.svc.DeleteProductGroup(r.Context(), groupID); err != nil {
-		status := http.StatusBadRequest
-		if errors.Is(err, repository.ErrNotFound) {
-			status = http.StatusNotFound
-		}
-		writeError(w, status, err.Error())
+		writeServiceError(w, err, "product_group", http.StatusBadRequest)
 		return
 	}
 	writeJSON(w, http.StatusOK, map[string]any{
@@ -495,44 +1129,183 @@ func (h *Handler) DeleteProductGroup(w http.ResponseWriter, r *http.Request) {
 }
 
 type createPurchaseInvoiceRequest struct {
-	InvoiceName   *string                    `json:"invoice_name"`
-	AdminUsername *string                    `json:"admin_username"`
-	Lines         []domain.PurchaseLineInput `json:"lines"`
+	InvoiceName    *string                    `json:"invoice_name"`
+	AdminUsername  *string                    `json:"admin_username"`
+	SupplierName   *string                    `json:"supplier_name"`
+	DiscountAmount *float64                   `json:"discount_amount"`
+	TaxAmount      *float64                   `json:"tax_amount"`
+	Status         string                     `json:"status"`
+	WarnDuplicate  bool                       `json:"warn_duplicate_name"`
+	Lines          []domain.PurchaseLineInput `json:"lines"`
 }
 
 func (h *Handler) CreatePurchaseInvoice(w http.ResponseWriter, r *http.Request) {
 	var req createPurchaseInvoiceRequest
 	if err := decodeJSON(r, &req); err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
+		writeServiceError(w, err, "", http.StatusBadRequest)
 		return
 	}
-	invoiceID, err := h.svc.CreatePurchaseInvoice(r.Context(), req.InvoiceName, req.AdminUsername, req.Lines)
+	var duplicateIDs []int64
+	if req.WarnDuplicate {
+		ids, err := h.svc.DuplicateInvoiceNameIDs(r.Context(), req.InvoiceName)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		duplicateIDs = ids
+	}
+	invoiceID, err := h.svc.CreatePurchaseInvoice(
+		r.Context(),
+		req.InvoiceName,
+		req.AdminUsername,
+		req.SupplierName,
+		req.Lines,
+		repository.InvoiceAdjustments{
+			DiscountAmount: req.DiscountAmount,
+			TaxAmount:      req.TaxAmount,
+		},
+		req.Status,
+	)
 	if err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
+		writeServiceError(w, err, "invoice", http.StatusBadRequest)
 		return
 	}
-	writeJSON(w, http.StatusCreated, map[string]any{"invoice_id": invoiceID})
+	writeJSON(w, http.StatusCreated, invoiceCreatedResponse(invoiceID, duplicateIDs))
 }
 
 type createSalesInvoiceRequest struct {
-	InvoiceName   *string                 `json:"invoice_name"`
-	AdminUsername *string                 `json:"admin_username"`
-	InvoiceType   string                  `json:"invoice_type"`
-	Lines         []domain.SalesLineInput `json:"lines"`
+	InvoiceName    *string                 `json:"invoice_name"`
+	AdminUsername  *string                 `json:"admin_username"`
+	CustomerName   *string                 `json:"customer_name"`
+	InvoiceType    string                  `json:"invoice_type"`
+	DiscountAmount *float64                `json:"discount_amount"`
+	TaxAmount      *float64                `json:"tax_amount"`
+	Status         string                  `json:"status"`
+	Force          bool                    `json:"force"`
+	WarnDuplicate  bool                    `json:"warn_duplicate_name"`
+	Lines          []domain.SalesLineInput `json:"lines"`
 }
 
 func (h *Handler) CreateSalesInvoice(w http.ResponseWriter, r *http.Request) {
 	var req createSalesInvoiceRequest
 	if err := decodeJSON(r, &req); err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
+		writeServiceError(w, err, "", http.StatusBadRequest)
+		return
+	}
+	var duplicateIDs []int64
+	if req.WarnDuplicate {
+		ids, err := h.svc.DuplicateInvoiceNameIDs(r.Context(), req.InvoiceName)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		duplicateIDs = ids
+	}
+	invoiceID, err := h.svc.CreateSalesInvoice(
+		r.Context(),
+		req.InvoiceName,
+		req.AdminUsername,
+		req.CustomerName,
+		req.InvoiceType,
+		req.Lines,
+		repository.InvoiceAdjustments{
+			DiscountAmount: req.DiscountAmount,
+			TaxAmount:      req.TaxAmount,
+		},
+		req.Status,
+		req.Force,
+	)
+	if err != nil {
+		writeServiceError(w, err, "invoice", http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, http.StatusCreated, invoiceCreatedResponse(invoiceID, duplicateIDs))
+}
+
+type bulkInvoiceRequest struct {
+	InvoiceType    string                     `json:"invoice_type"`
+	InvoiceName    *string                    `json:"invoice_name"`
+	AdminUsername  *string                    `json:"admin_username"`
+	SupplierName   *string                    `json:"supplier_name"`
+	CustomerName   *string                    `json:"customer_name"`
+	DiscountAmount *float64                   `json:"discount_amount"`
+	TaxAmount      *float64                   `json:"tax_amount"`
+	Status         string                     `json:"status"`
+	Lines          []domain.PurchaseLineInput `json:"lines"`
+}
+
+type createInvoicesBulkRequest struct {
+	Force    bool                 `json:"force"`
+	Invoices []bulkInvoiceRequest `json:"invoices"`
+}
+
+func (h *Handler) CreateInvoicesBulk(w http.ResponseWriter, r *http.Request) {
+	var req createInvoicesBulkRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeServiceError(w, err, "", http.StatusBadRequest)
 		return
 	}
-	invoiceID, err := h.svc.CreateSalesInvoice(r.Context(), req.InvoiceName, req.AdminUsername, req.InvoiceType, req.Lines)
+
+	ids, err := h.svc.CreateInvoicesBulk(r.Context(), bulkInvoiceInputs(req.Invoices), req.Force)
 	if err != nil {
+		var bulkErr *repository.BulkInvoiceError
+		if errors.As(err, &bulkErr) {
+			apiErr := serviceError(bulkErr.Err, "", http.StatusBadRequest)
+			writeJSON(w, http.StatusBadRequest, map[string]any{
+				"error": apiErr.Message,
+				"code":  apiErr.Code,
+				"index": bulkErr.Index,
+			})
+			return
+		}
 		writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
-	writeJSON(w, http.StatusCreated, map[string]any{"invoice_id": invoiceID})
+	writeJSON(w, http.StatusCreated, map[string]any{"invoice_ids": ids, "count": len(ids)})
+}
+
+// bulkInvoiceInputs puts each invoice's lines on the purchase or sales side.
+// "purchase" is matched in any case and spacing, like the service does when
+// it validates the type, so the two never disagree about which side is set.
+func bulkInvoiceInputs(items []bulkInvoiceRequest) []repository.BulkInvoiceInput {
+	inputs := make([]repository.BulkInvoiceInput, 0, len(items))
+	for _, item := range items {
+		input := repository.BulkInvoiceInput{
+			InvoiceType:   item.InvoiceType,
+			InvoiceName:   item.InvoiceName,
+			AdminUsername: item.AdminUsername,
+			SupplierName:  item.SupplierName,
+			CustomerName:  item.CustomerName,
+			Adjustments: repository.InvoiceAdjustments{
+				DiscountAmount: item.DiscountAmount,
+				TaxAmount:      item.TaxAmount,
+			},
+			Status: item.Status,
+		}
+		if strings.EqualFold(strings.TrimSpace(item.InvoiceType), "purchase") {
+			input.InvoiceType = "purchase"
+			input.PurchaseLines = item.Lines
+		} else {
+			input.SalesLines = make([]domain.SalesLineInput, 0, len(item.Lines))
+			for _, line := range item.Lines {
+				input.SalesLines = append(input.SalesLines, domain.SalesLineInput(line))
+			}
+		}
+		inputs = append(inputs, input)
+	}
+	return inputs
+}
+
+func invoiceCreatedResponse(invoiceID int64, duplicateIDs []int64) map[string]any {
+	response := map[string]any{"invoice_id": invoiceID}
+	if len(duplicateIDs) > 0 {
+		response["warnings"] = []map[string]any{{
+			"code":        "duplicate_invoice_name",
+			"message":     "invoice_name is already used by other invoices",
+			"invoice_ids": duplicateIDs,
+		}}
+	}
+	return response
 }
 
 func (h *Handler) ListInvoices(w http.ResponseWriter, r *http.Request) {
@@ -552,28 +1325,77 @@ func (h *Handler) ListInvoices(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusBadRequest, "invalid from date")
 		return
 	}
-	to, err := parseOptionalTime(query.Get("to"))
+	to, err := parseOptionalEndTime(query.Get("to"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid to date")
+		return
+	}
+	minAmount, err := parseOptionalFloat(query.Get("min_amount"), "min_amount")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	maxAmount, err := parseOptionalFloat(query.Get("max_amount"), "max_amount")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if (minAmount != nil && *minAmount < 0) || (maxAmount != nil && *maxAmount < 0) {
+		writeError(w, http.StatusBadRequest, "min_amount and max_amount must not be negative")
+		return
+	}
+	if minAmount != nil && maxAmount != nil && *minAmount > *maxAmount {
+		writeError(w, http.StatusBadRequest, "min_amount must not exceed max_amount")
+		return
+	}
+
+	filter := repository.InvoiceListFilter{
+		InvoiceType: query.Get("type"),
+		From:        from,
+		To:          to,
+		Supplier:    query.Get("supplier"),
+		Customer:    query.Get("customer"),
+		Product:     query.Get("product"),
+		MinAmount:   minAmount,
+		MaxAmount:   maxAmount,
+		Limit:       limit,
+		Offset:      offset,
+	}
+
+	invoices, hasMore, err := h.svc.ListInvoices(r.Context(), filter)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	totalCount, totalAmount, err := h.svc.InvoiceStatsFiltered(r.Context(), filter)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	response := pageResponse(invoices, len(invoices), hasMore, limit, offset)
+	response["total_count"] = totalCount
+	response["total_amount"] = totalAmount
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (h *Handler) ListRecentInvoices(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	minutes, err := parseOptionalInt(query.Get("minutes"), 15)
 	if err != nil {
-		writeError(w, http.StatusBadRequest, "invalid to date")
+		writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
-
-	invoices, err := h.svc.ListInvoices(r.Context(), query.Get("type"), from, to, limit, offset)
+	limit, err := parseOptionalInt(query.Get("limit"), 200)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error())
+		writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
-	totalCount, totalAmount, err := h.svc.InvoiceStats(r.Context(), query.Get("type"))
+	invoices, err := h.svc.ListRecentInvoices(r.Context(), minutes, limit)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error())
+		writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
-	writeJSON(w, http.StatusOK, map[string]any{
-		"items":        invoices,
-		"count":        len(invoices),
-		"total_count":  totalCount,
-		"total_amount": totalAmount,
-	})
+	writeJSON(w, http.StatusOK, map[string]any{"items": invoices, "count": len(invoices)})
 }
 
 func (h *Handler) ListInvoicesBetween(w http.ResponseWriter, r *http.Request) {
@@ -583,7 +1405,7 @@ func (h *Handler) ListInvoicesBetween(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusBadRequest, "start is required and must be a valid date")
 		return
 	}
-	end, err := parseRequiredTime(query.Get("end"))
+	end, err := parseRequiredEndTime(query.Get("end"))
 	if err != nil {
 		writeError(w, http.StatusBadRequest, "end is required and must be a valid date")
 		return
@@ -597,6 +1419,15 @@ func (h *Handler) ListInvoicesBetween(w http.ResponseWriter, r *http.Request) {
 		}
 		fuzzy = value
 	}
+	includeLines := false
+	if includeRaw := strings.TrimSpace(query.Get("include_lines")); includeRaw != "" {
+		value, parseErr := strconv.ParseBool(includeRaw)
+		if parseErr != nil {
+			writeError(w, http.StatusBadRequest, "include_lines must be true or false")
+			return
+		}
+		includeLines = value
+	}
 	idFrom, err := parseOptionalInt64(query.Get("id_from"))
 	if err != nil {
 		writeError(w, http.StatusBadRequest, err.Error())
@@ -615,6 +1446,7 @@ func (h *Handler) ListInvoicesBetween(w http.ResponseWriter, r *http.Request) {
 		fuzzy,
 		idFrom,
 		idTo,
+		includeLines,
 	)
 	if err != nil {
 		writeError(w, http.StatusBadRequest, err.Error())
@@ -623,20 +1455,33 @@ func (h *Handler) ListInvoicesBetween(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, map[string]any{"items": items, "count": len(items)})
 }
 
-func (h *Handler) GetInvoice(w http.ResponseWriter, r *http.Request) {
-	id, err := parseID(chi.URLParam(r, "id"))
+func (h *Handler) InvoicePDF(w http.ResponseWriter, r *http.Request) {
+	id, ok := urlID(w, r)
+	if !ok {
+		return
+	}
+
+	body, err := h.svc.InvoicePDF(r.Context(), id)
 	if err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
+		writeServiceError(w, err, "invoice", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`inline; filename="invoice-%d.pdf"`, id))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(body)
+}
+
+func (h *Handler) GetInvoice(w http.ResponseWriter, r *http.Request) {
+	id, ok := urlID(w, r)
+	if !ok {
 		return
 	}
 
 	invoice, err := h.svc.GetInvoice(r.Context(), id)
 	if err != nil {
-		if errors.Is(err, repository.ErrNotFound) {
-			writeError(w, http.StatusNotFound, "invoice not found")
-			return
-		}
-		writeError(w, http.StatusInternalServerError, err.Error())
+		writeServiceError(w, err, "invoice", http.StatusInternalServerError)
 		return
 	}
 
@@ -646,34 +1491,35 @@ func (h *Handler) GetInvoice(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	writeJSON(w, http.StatusOK, map[string]any{
+	response := map[string]any{
 		"invoice": invoice,
 		"lines":   lines,
-	})
+	}
+	if profit, ok := service.InvoiceProfit(*invoice, lines); ok {
+		response["profit"] = profit
+	}
+	writeJSON(w, http.StatusOK, response)
 }
 
 type updateInvoiceNameRequest struct {
-	InvoiceName *string `json:"invoice_name"`
+	InvoiceName  *string `json:"invoice_name"`
+	SupplierName *string `json:"supplier_name"`
+	CustomerName *string `json:"customer_name"`
 }
 
 func (h *Handler) UpdateInvoiceName(w http.ResponseWriter, r *http.Request) {
-	id, err := parseID(chi.URLParam(r, "id"))
-	if err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
+	id, ok := urlID(w, r)
+	if !ok {
 		return
 	}
 
 	var req updateInvoiceNameRequest
 	if err := decodeJSON(r, &req); err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
+		writeServiceError(w, err, "", http.StatusBadRequest)
 		return
 	}
-	if err := h.svc.UpdateInvoiceName(r.Context(), id, req.InvoiceName); err != nil {
-		if errors.Is(err, repository.ErrNotFound) {
-			writeError(w, http.StatusNotFound, "invoice not found")
-			return
-		}
-		writeError(w, http.StatusInternalServerError, err.Error())
+	if err := h.svc.UpdateInvoiceName(r.Context(), id, req.InvoiceName, req.SupplierName, req.CustomerName); err != nil {
+		writeServiceError(w, err, "invoice", http.StatusInternalServerError)
 		return
 	}
 	writeJSON(w, http.StatusOK, map[string]any{"invoice_id": id, "updated": true})
@@ -685,14 +1531,13 @@ type updateInvoiceLinesRequest struct {
 }
 
 func (h *Handler) UpdateInvoiceLines(w http.ResponseWriter, r *http.Request) {
-	id, err := parseID(chi.URLParam(r, "id"))
-	if err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
+	id, ok := urlID(w, r)
+	if !ok {
 		return
 	}
 	var req updateInvoiceLinesRequest
 	if err := decodeJSON(r, &req); err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
+		writeServiceError(w, err, "", http.StatusBadRequest)
 		return
 	}
 	if len(req.Lines) == 0 {
@@ -700,31 +1545,96 @@ func (h *Handler) UpdateInvoiceLines(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if err := h.svc.UpdateInvoiceLines(r.Context(), id, req.InvoiceName, req.Lines); err != nil {
-		if errors.Is(err, repository.ErrNotFound) {
-			writeError(w, http.StatusNotFound, "invoice not found")
-			return
-		}
-		writeError(w, http.StatusBadRequest, err.Error())
+		writeServiceError(w, err, "invoice", http.StatusBadRequest)
 		return
 	}
 	writeJSON(w, http.StatusOK, map[string]any{"invoice_id": id, "updated": true})
 }
 
-func (h *Handler) DeleteInvoice(w http.ResponseWriter, r *http.Request) {
-	id, err := parseID(chi.URLParam(r, "id"))
+type patchInvoiceLineRequest struct {
+	Price    *float64 `json:"price"`
+	Quantity *float64 `json:"quantity"`
+	Discount *float64 `json:"discount"`
+}
+
+func (h *Handler) PatchInvoiceLine(w http.ResponseWriter, r *http.Request) {
+	id, ok := urlID(w, r)
+	if !ok {
+		return
+	}
+	lineID, err := parseID(chi.URLParam(r, "lineId"))
 	if err != nil {
 		writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
+	var req patchInvoiceLineRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeServiceError(w, err, "", http.StatusBadRequest)
+		return
+	}
+	line, err := h.svc.UpdateInvoiceLine(r.Context(), id, lineID, repository.InvoiceLinePatch{
+		Price:    req.Price,
+		Quantity: req.Quantity,
+		Discount: req.Discount,
+	})
+	if err != nil {
+		writeServiceError(w, err, "invoice", http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, http.StatusOK, line)
+}
+
+func (h *Handler) DeleteInvoice(w http.ResponseWriter, r *http.Request) {
+	id, ok := urlID(w, r)
+	if !ok {
+		return
+	}
 	if err := h.svc.DeleteInvoice(r.Context(), id); err != nil {
-		if errors.Is(err, repository.ErrNotFound) {
-			writeError(w, http.StatusNotFound, "invoice not found")
+		writeServiceError(w, err, "invoice", http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type finalizeInvoiceRequest struct {
+	Force bool `json:"force"`
+}
+
+func (h *Handler) FinalizeInvoice(w http.ResponseWriter, r *http.Request) {
+	id, ok := urlID(w, r)
+	if !ok {
+		return
+	}
+	var req finalizeInvoiceRequest
+	if r.ContentLength != 0 {
+		if err := decodeJSON(r, &req); err != nil {
+			writeServiceError(w, err, "", http.StatusBadRequest)
 			return
 		}
-		writeError(w, http.StatusBadRequest, err.Error())
+	}
+	if err := h.svc.FinalizeInvoice(r.Context(), id, req.Force); err != nil {
+		writeServiceError(w, err, "invoice", http.StatusBadRequest)
 		return
 	}
-	w.WriteHeader(http.StatusNoContent)
+	writeJSON(w, http.StatusOK, map[string]any{
+		"invoice_id": id,
+		"status":     repository.InvoiceStatusFinalized,
+	})
+}
+
+func (h *Handler) VoidInvoice(w http.ResponseWriter, r *http.Request) {
+	id, ok := urlID(w, r)
+	if !ok {
+		return
+	}
+	if err := h.svc.VoidInvoice(r.Context(), id); err != nil {
+		writeServiceError(w, err, "invoice", http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"invoice_id": id,
+		"status":     repository.InvoiceStatusVoid,
+	})
 }
 
 func (h *Handler) InvoiceStats(w http.ResponseWriter, r *http.Request) {
@@ -749,7 +1659,7 @@ type renameProductsRequest struct {
 func (h *Handler) RenameProducts(w http.ResponseWriter, r *http.Request) {
 	var req renameProductsRequest
 	if err := decodeJSON(r, &req); err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
+		writeServiceError(w, err, "", http.StatusBadRequest)
 		return
 	}
 	changes := make([][2]string, 0, len(req.Changes))
@@ -764,49 +1674,276 @@ func (h *Handler) RenameProducts(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
-	writeJSON(w, http.StatusOK, result)
-}
-
-func (h *Handler) MonthlySummary(w http.ResponseWriter, r *http.Request) {
-	limit, err := parseOptionalInt(r.URL.Query().Get("limit"), 12)
+	writeJSON(w, http.StatusOK, result)
+}
+
+type backfillCostsRequest struct {
+	Confirm bool `json:"confirm"`
+}
+
+func (h *Handler) BackfillSalesCostPrices(w http.ResponseWriter, r *http.Request) {
+	var req backfillCostsRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeServiceError(w, err, "", http.StatusBadRequest)
+		return
+	}
+	if !req.Confirm {
+		writeError(w, http.StatusBadRequest, "confirm must be true to rewrite sales cost prices")
+		return
+	}
+	updated, err := h.svc.BackfillSalesCostPrices(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"updated_lines": updated})
+}
+
+func (h *Handler) RecalcInvoiceTotals(w http.ResponseWriter, r *http.Request) {
+	id, ok := urlID(w, r)
+	if !ok {
+		return
+	}
+	invoice, err := h.svc.RecalcInvoiceTotals(r.Context(), id)
+	if err != nil {
+		writeServiceError(w, err, "invoice", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, invoice)
+}
+
+type recalcInvoicesRequest struct {
+	Confirm bool `json:"confirm"`
+}
+
+func (h *Handler) RecalcAllInvoiceTotals(w http.ResponseWriter, r *http.Request) {
+	var req recalcInvoicesRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeServiceError(w, err, "", http.StatusBadRequest)
+		return
+	}
+	if !req.Confirm {
+		writeError(w, http.StatusBadRequest, "confirm must be true to rewrite invoice totals")
+		return
+	}
+	updated, err := h.svc.RecalcAllInvoiceTotals(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"updated_invoices": updated})
+}
+
+func (h *Handler) MonthlySummary(w http.ResponseWriter, r *http.Request) {
+	limit, err := parseOptionalInt(r.URL.Query().Get("limit"), 12)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	data, err := h.svc.MonthlySummary(r.Context(), limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"items": data, "count": len(data)})
+}
+
+func (h *Handler) DailySummary(w http.ResponseWriter, r *http.Request) {
+	days, err := parseOptionalInt(r.URL.Query().Get("days"), 30)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	data, err := h.svc.DailySummary(r.Context(), days)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"items": data, "count": len(data)})
+}
+
+func (h *Handler) MonthlyQuantitySummary(w http.ResponseWriter, r *http.Request) {
+	limit, err := parseOptionalInt(r.URL.Query().Get("limit"), 12)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	data, err := h.svc.MonthlyQuantitySummary(r.Context(), limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"items": data, "count": len(data)})
+}
+
+func (h *Handler) TopSoldProducts(w http.ResponseWriter, r *http.Request) {
+	days, err := parseOptionalInt(r.URL.Query().Get("days"), 90)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	limit, err := parseOptionalInt(r.URL.Query().Get("limit"), 10)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	from, err := parseOptionalTime(r.URL.Query().Get("from"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid from date")
+		return
+	}
+	to, err := parseOptionalEndTime(r.URL.Query().Get("to"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid to date")
+		return
+	}
+	if from != nil && to != nil && from.After(*to) {
+		writeError(w, http.StatusBadRequest, "from must be before to")
+		return
+	}
+	items, err := h.svc.TopSoldProducts(r.Context(), days, limit, from, to)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"items": items, "count": len(items)})
+}
+
+func (h *Handler) ProductProfit(w http.ResponseWriter, r *http.Request) {
+	days, err := parseOptionalInt(r.URL.Query().Get("days"), 90)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	limit, err := parseOptionalInt(r.URL.Query().Get("limit"), 20)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	items, err := h.svc.ProductProfit(r.Context(), days, limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"items": items, "count": len(items)})
+}
+
+func (h *Handler) SalesByAdmin(w http.ResponseWriter, r *http.Request) {
+	from, err := parseOptionalTime(r.URL.Query().Get("from"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid from date")
+		return
+	}
+	to, err := parseOptionalEndTime(r.URL.Query().Get("to"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid to date")
+		return
+	}
+	if from != nil && to != nil && from.After(*to) {
+		writeError(w, http.StatusBadRequest, "from must be before to")
+		return
+	}
+	items, err := h.svc.SalesByAdmin(r.Context(), from, to)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"items": items, "count": len(items)})
+}
+
+func (h *Handler) RevenuePareto(w http.ResponseWriter, r *http.Request) {
+	days, err := parseOptionalInt(r.URL.Query().Get("days"), 90)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	items, err := h.svc.RevenuePareto(r.Context(), days)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"items": items, "count": len(items)})
+}
+
+func (h *Handler) Dashboard(w http.ResponseWriter, r *http.Request) {
+	threshold, err := parseOptionalInt(r.URL.Query().Get("threshold"), 0)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, h.svc.Dashboard(r.Context(), threshold))
+}
+
+func (h *Handler) UnsoldProducts(w http.ResponseWriter, r *http.Request) {
+	days, err := parseOptionalInt(r.URL.Query().Get("days"), 30)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	limit, err := parseOptionalInt(r.URL.Query().Get("limit"), 200)
 	if err != nil {
 		writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
-	data, err := h.svc.MonthlySummary(r.Context(), limit)
+	items, err := h.svc.UnsoldProducts(r.Context(), days, limit)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
-	writeJSON(w, http.StatusOK, map[string]any{"items": data, "count": len(data)})
+	writeJSON(w, http.StatusOK, map[string]any{"items": items, "count": len(items)})
 }
 
-func (h *Handler) MonthlyQuantitySummary(w http.ResponseWriter, r *http.Request) {
-	limit, err := parseOptionalInt(r.URL.Query().Get("limit"), 12)
+func (h *Handler) DeadStock(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	days, err := parseOptionalInt(query.Get("days"), 180)
 	if err != nil {
 		writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
-	data, err := h.svc.MonthlyQuantitySummary(r.Context(), limit)
+	limit, err := parseOptionalInt(query.Get("limit"), 200)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	minValue, err := parseOptionalFloat(query.Get("min_value"), "min_value")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	threshold := 0.0
+	if minValue != nil {
+		threshold = *minValue
+	}
+	items, err := h.svc.DeadStock(r.Context(), days, limit, threshold)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
-	writeJSON(w, http.StatusOK, map[string]any{"items": data, "count": len(data)})
+	writeJSON(w, http.StatusOK, map[string]any{"items": items, "count": len(items)})
 }
 
-func (h *Handler) TopSoldProducts(w http.ResponseWriter, r *http.Request) {
-	days, err := parseOptionalInt(r.URL.Query().Get("days"), 90)
+func (h *Handler) ReorderSuggestions(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	days, err := parseOptionalInt(query.Get("days"), 60)
 	if err != nil {
 		writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
-	limit, err := parseOptionalInt(r.URL.Query().Get("limit"), 10)
+	leadDays, err := parseOptionalInt(query.Get("lead_days"), 14)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	coverDays, err := parseOptionalInt(query.Get("cover_days"), 30)
 	if err != nil {
 		writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
-	items, err := h.svc.TopSoldProducts(r.Context(), days, limit)
+	items, err := h.svc.ReorderSuggestions(r.Context(), repository.ReorderOptions{
+		Days:      days,
+		LeadDays:  leadDays,
+		CoverDays: coverDays,
+	})
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, err.Error())
 		return
@@ -814,18 +1951,13 @@ func (h *Handler) TopSoldProducts(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, map[string]any{"items": items, "count": len(items)})
 }
 
-func (h *Handler) UnsoldProducts(w http.ResponseWriter, r *http.Request) {
-	days, err := parseOptionalInt(r.URL.Query().Get("days"), 30)
-	if err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
-		return
-	}
+func (h *Handler) StockReconciliation(w http.ResponseWriter, r *http.Request) {
 	limit, err := parseOptionalInt(r.URL.Query().Get("limit"), 200)
 	if err != nil {
 		writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
-	items, err := h.svc.UnsoldProducts(r.Context(), days, limit)
+	items, err := h.svc.StockReconciliation(r.Context(), limit)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, err.Error())
 		return
@@ -840,7 +1972,7 @@ type salesPreviewRequest struct {
 func (h *Handler) SalesPreview(w http.ResponseWriter, r *http.Request) {
 	var req salesPreviewRequest
 	if err := decodeJSON(r, &req); err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
+		writeServiceError(w, err, "", http.StatusBadRequest)
 		return
 	}
 	rows, successCount, errorCount, err := h.svc.PreviewSales(r.Context(), req.Rows)
@@ -865,7 +1997,7 @@ type basalamCheckRequest struct {
 func (h *Handler) BasalamCheckExistingIDs(w http.ResponseWriter, r *http.Request) {
 	var req basalamCheckRequest
 	if err := decodeJSON(r, &req); err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
+		writeServiceError(w, err, "", http.StatusBadRequest)
 		return
 	}
 	items, err := h.svc.FetchExistingBasalamIDs(r.Context(), req.IDs)
@@ -886,7 +2018,7 @@ type basalamStoreRequest struct {
 func (h *Handler) BasalamStoreIDs(w http.ResponseWriter, r *http.Request) {
 	var req basalamStoreRequest
 	if err := decodeJSON(r, &req); err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
+		writeServiceError(w, err, "", http.StatusBadRequest)
 		return
 	}
 	inserted, err := h.svc.StoreBasalamIDs(r.Context(), req.IDs)
@@ -899,6 +2031,64 @@ func (h *Handler) BasalamStoreIDs(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+func (h *Handler) BasalamListIDs(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	limit, err := parseOptionalInt(query.Get("limit"), 200)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	offset, err := parseOptionalInt(query.Get("offset"), 0)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	from, err := parseOptionalTime(query.Get("from"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid from date")
+		return
+	}
+	to, err := parseOptionalEndTime(query.Get("to"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid to date")
+		return
+	}
+	if from != nil && to != nil && from.After(*to) {
+		writeError(w, http.StatusBadRequest, "from must be before to")
+		return
+	}
+
+	items, hasMore, err := h.svc.ListBasalamIDs(r.Context(), limit, offset, from, to)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	totalCount, err := h.svc.CountBasalamIDs(r.Context(), from, to)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	response := pageResponse(items, len(items), hasMore, limit, offset)
+	response["total_count"] = totalCount
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (h *Handler) BasalamDeleteIDs(w http.ResponseWriter, r *http.Request) {
+	var req basalamStoreRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeServiceError(w, err, "", http.StatusBadRequest)
+		return
+	}
+	deleted, err := h.svc.DeleteBasalamIDs(r.Context(), req.IDs)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"deleted": deleted,
+	})
+}
+
 type authAdminRequest struct {
 	Username string `json:"username"`
 	Password string `json:"password"`
@@ -907,7 +2097,7 @@ type authAdminRequest struct {
 func (h *Handler) AuthenticateAdmin(w http.ResponseWriter, r *http.Request) {
 	var req authAdminRequest
 	if err := decodeJSON(r, &req); err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
+		writeServiceError(w, err, "", http.StatusBadRequest)
 		return
 	}
 	admin, err := h.svc.AuthenticateAdmin(r.Context(), req.Username, req.Password)
@@ -923,7 +2113,16 @@ func (h *Handler) AuthenticateAdmin(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) ListAdmins(w http.ResponseWriter, r *http.Request) {
-	items, err := h.svc.ListAdmins(r.Context())
+	includeInactive := false
+	if raw := strings.TrimSpace(r.URL.Query().Get("include_inactive")); raw != "" {
+		value, err := strconv.ParseBool(raw)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "include_inactive must be true or false")
+			return
+		}
+		includeInactive = value
+	}
+	items, err := h.svc.ListAdmins(r.Context(), includeInactive)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, err.Error())
 		return
@@ -941,56 +2140,95 @@ type createAdminRequest struct {
 func (h *Handler) CreateAdmin(w http.ResponseWriter, r *http.Request) {
 	var req createAdminRequest
 	if err := decodeJSON(r, &req); err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
+		writeServiceError(w, err, "", http.StatusBadRequest)
 		return
 	}
 	admin, err := h.svc.CreateAdmin(r.Context(), req.Username, req.Password, req.Role, req.AutoLockMinutes)
 	if err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
+		writeServiceError(w, err, "admin", http.StatusBadRequest)
 		return
 	}
 	writeJSON(w, http.StatusCreated, admin)
 }
 
 func (h *Handler) GetAdmin(w http.ResponseWriter, r *http.Request) {
-	id, err := parseID(chi.URLParam(r, "id"))
-	if err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
+	id, ok := urlID(w, r)
+	if !ok {
 		return
 	}
 	admin, err := h.svc.GetAdminByID(r.Context(), id)
 	if err != nil {
-		if errors.Is(err, repository.ErrNotFound) {
-			writeError(w, http.StatusNotFound, "admin not found")
-			return
-		}
-		writeError(w, http.StatusInternalServerError, err.Error())
+		writeServiceError(w, err, "admin", http.StatusInternalServerError)
 		return
 	}
 	writeJSON(w, http.StatusOK, admin)
 }
 
+func (h *Handler) ListAdminActions(w http.ResponseWriter, r *http.Request) {
+	id, ok := urlID(w, r)
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+	limit, err := parseOptionalInt(query.Get("limit"), 200)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	offset, err := parseOptionalInt(query.Get("offset"), 0)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	items, err := h.svc.ListAdminActions(r.Context(), id, limit, offset)
+	if err != nil {
+		writeServiceError(w, err, "admin", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"items": items, "count": len(items)})
+}
+
 type updatePasswordRequest struct {
 	Password string `json:"password"`
 }
 
 func (h *Handler) UpdateAdminPassword(w http.ResponseWriter, r *http.Request) {
-	id, err := parseID(chi.URLParam(r, "id"))
-	if err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
+	id, ok := urlID(w, r)
+	if !ok {
 		return
 	}
 	var req updatePasswordRequest
 	if err := decodeJSON(r, &req); err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
+		writeServiceError(w, err, "", http.StatusBadRequest)
 		return
 	}
 	if err := h.svc.UpdateAdminPassword(r.Context(), id, req.Password); err != nil {
-		if errors.Is(err, repository.ErrNotFound) {
-			writeError(w, http.StatusNotFound, "admin not found")
-			return
-		}
-		writeError(w, http.StatusBadRequest, err.Error())
+		writeServiceError(w, err, "admin", http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"updated": true})
+}
+
+type changeOwnPasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+// ChangeOwnPassword changes the password of the admin named in
+// X-Admin-Username after checking their current password.
+func (h *Handler) ChangeOwnPassword(w http.ResponseWriter, r *http.Request) {
+	username := repository.AdminUsernameFromContext(r.Context())
+	if username == nil {
+		writeError(w, http.StatusUnauthorized, "X-Admin-Username header is required")
+		return
+	}
+	var req changeOwnPasswordRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeServiceError(w, err, "", http.StatusBadRequest)
+		return
+	}
+	if err := h.svc.ChangeOwnPassword(r.Context(), *username, req.CurrentPassword, req.NewPassword); err != nil {
+		writeServiceError(w, err, "admin", http.StatusBadRequest)
 		return
 	}
 	writeJSON(w, http.StatusOK, map[string]any{"updated": true})
@@ -1001,39 +2239,54 @@ type updateAutoLockRequest struct {
 }
 
 func (h *Handler) UpdateAdminAutoLock(w http.ResponseWriter, r *http.Request) {
-	id, err := parseID(chi.URLParam(r, "id"))
-	if err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
+	id, ok := urlID(w, r)
+	if !ok {
 		return
 	}
 	var req updateAutoLockRequest
 	if err := decodeJSON(r, &req); err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
+		writeServiceError(w, err, "", http.StatusBadRequest)
 		return
 	}
 	if err := h.svc.UpdateAdminAutoLock(r.Context(), id, req.AutoLockMinutes); err != nil {
-		if errors.Is(err, repository.ErrNotFound) {
-			writeError(w, http.StatusNotFound, "admin not found")
-			return
-		}
-		writeError(w, http.StatusBadRequest, err.Error())
+		writeServiceError(w, err, "admin", http.StatusBadRequest)
 		return
 	}
 	writeJSON(w, http.StatusOK, map[string]any{"updated": true})
 }
 
+type updateAdminActiveRequest struct {
+	Active *bool `json:"active"`
+}
+
+func (h *Handler) UpdateAdminActive(w http.ResponseWriter, r *http.Request) {
+	id, ok := urlID(w, r)
+	if !ok {
+		return
+	}
+	var req updateAdminActiveRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeServiceError(w, err, "", http.StatusBadRequest)
+		return
+	}
+	if req.Active == nil {
+		writeError(w, http.StatusBadRequest, "active is required")
+		return
+	}
+	if err := h.svc.SetAdminActive(r.Context(), id, *req.Active); err != nil {
+		writeServiceError(w, err, "admin", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"updated": true, "active": *req.Active})
+}
+
 func (h *Handler) DeleteAdmin(w http.ResponseWriter, r *http.Request) {
-	id, err := parseID(chi.URLParam(r, "id"))
-	if err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
+	id, ok := urlID(w, r)
+	if !ok {
 		return
 	}
 	if err := h.svc.DeleteAdmin(r.Context(), id); err != nil {
-		if errors.Is(err, repository.ErrNotFound) {
-			writeError(w, http.StatusNotFound, "admin not found")
-			return
-		}
-		writeError(w, http.StatusInternalServerError, err.Error())
+		writeServiceError(w, err, "admin", http.StatusInternalServerError)
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
@@ -1049,7 +2302,7 @@ type logActionRequest struct {
 func (h *Handler) LogAction(w http.ResponseWriter, r *http.Request) {
 	var req logActionRequest
 	if err := decodeJSON(r, &req); err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
+		writeServiceError(w, err, "", http.StatusBadRequest)
 		return
 	}
 	if err := h.svc.LogAction(r.Context(), req.ActionType, req.Title, req.Details, req.AdminUsername); err != nil {
@@ -1059,6 +2312,29 @@ func (h *Handler) LogAction(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusCreated, map[string]any{"created": true})
 }
 
+func (h *Handler) LogActionsBulk(w http.ResponseWriter, r *http.Request) {
+	var req []logActionRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeServiceError(w, err, "", http.StatusBadRequest)
+		return
+	}
+	entries := make([]repository.ActionInput, 0, len(req))
+	for _, action := range req {
+		entries = append(entries, repository.ActionInput{
+			ActionType:    action.ActionType,
+			Title:         action.Title,
+			Details:       action.Details,
+			AdminUsername: action.AdminUsername,
+		})
+	}
+	inserted, err := h.svc.LogActions(r.Context(), entries)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]any{"inserted": inserted})
+}
+
 func (h *Handler) ListActions(w http.ResponseWriter, r *http.Request) {
 	query := r.URL.Query()
 	limit, err := parseOptionalInt(query.Get("limit"), 200)
@@ -1071,16 +2347,26 @@ func (h *Handler) ListActions(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
-	items, err := h.svc.ListActions(r.Context(), limit, offset, query.Get("search"))
+	filter, err := parseActionFilter(query)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	items, hasMore, err := h.svc.ListActions(r.Context(), limit, offset, filter)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
-	writeJSON(w, http.StatusOK, map[string]any{"items": items, "count": len(items)})
+	writeJSON(w, http.StatusOK, pageResponse(items, len(items), hasMore, limit, offset))
 }
 
 func (h *Handler) CountActions(w http.ResponseWriter, r *http.Request) {
-	count, err := h.svc.CountActions(r.Context(), r.URL.Query().Get("search"))
+	filter, err := parseActionFilter(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	count, err := h.svc.CountActions(r.Context(), filter)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, err.Error())
 		return
@@ -1088,15 +2374,139 @@ func (h *Handler) CountActions(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, map[string]any{"count": count})
 }
 
+// parseActionFilter reads the search, action_type and from/to parameters
+// shared by the action list, count and export endpoints. The export also
+// accepts the shorter `type` spelling.
+func parseActionFilter(query url.Values) (repository.ActionFilter, error) {
+	from, err := parseOptionalTime(query.Get("from"))
+	if err != nil {
+		return repository.ActionFilter{}, errors.New("invalid from date")
+	}
+	to, err := parseOptionalEndTime(query.Get("to"))
+	if err != nil {
+		return repository.ActionFilter{}, errors.New("invalid to date")
+	}
+	if from != nil && to != nil && from.After(*to) {
+		return repository.ActionFilter{}, errors.New("from must be before to")
+	}
+	actionType := strings.TrimSpace(query.Get("action_type"))
+	if actionType == "" {
+		actionType = strings.TrimSpace(query.Get("type"))
+	}
+	return repository.ActionFilter{
+		Search:     query.Get("search"),
+		ActionType: actionType,
+		From:       from,
+		To:         to,
+	}, nil
+}
+
+// ExportActions streams the matching audit trail as CSV. Once the header is
+// sent a database error can only truncate the file, so it is logged.
+func (h *Handler) ExportActions(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseActionFilter(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="actions.csv"`)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("\uFEFF"))
+
+	writer := csv.NewWriter(w)
+	_ = writer.Write([]string{"created_at", "admin_username", "action_type", "title", "details"})
+	written := 0
+	err = h.svc.ExportActions(r.Context(), filter, func(row domain.ActionEntry) error {
+		admin := ""
+		if row.AdminUsername != nil {
+			admin = *row.AdminUsername
+		}
+		if err := writer.Write([]string{
+			row.CreatedAt.Format(time.RFC3339),
+			admin,
+			row.ActionType,
+			row.Title,
+			row.Details,
+		}); err != nil {
+			return err
+		}
+		written++
+		if written%500 == 0 {
+			writer.Flush()
+			return writer.Error()
+		}
+		return nil
+	})
+	writer.Flush()
+	if err != nil {
+		logging.FromContext(r.Context()).Error("actions export aborted", "error", err, "rows", written)
+	}
+}
+
 func decodeJSON(r *http.Request, out any) error {
 	dec := json.NewDecoder(r.Body)
 	dec.DisallowUnknownFields()
 	if err := dec.Decode(out); err != nil {
-		return fmt.Errorf("invalid JSON body")
+		return jsonBodyError(err)
 	}
 	return nil
 }
 
+// jsonBodyError turns a decoder failure into a message that says what is
+// wrong with the body instead of a flat "invalid JSON body".
+func jsonBodyError(err error) error {
+	var (
+		maxBytesErr *http.MaxBytesError
+		syntaxErr   *json.SyntaxError
+		typeErr     *json.UnmarshalTypeError
+		timeErr     *time.ParseError
+	)
+	switch {
+	case errors.As(err, &maxBytesErr):
+		return fmt.Errorf("%w: limit is %d bytes", errBodyTooLarge, maxBytesErr.Limit)
+	case errors.Is(err, io.EOF):
+		return fmt.Errorf("request body is empty")
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return fmt.Errorf("invalid JSON body: unexpected end of input")
+	case errors.As(err, &syntaxErr):
+		return fmt.Errorf("invalid JSON body: syntax error at byte %d", syntaxErr.Offset)
+	case errors.As(err, &typeErr):
+		if typeErr.Field == "" {
+			return fmt.Errorf("invalid JSON body: expected %s, got %s", jsonKind(typeErr.Type), typeErr.Value)
+		}
+		return fmt.Errorf("invalid JSON body: %q must be %s, got %s", typeErr.Field, jsonKind(typeErr.Type), typeErr.Value)
+	case errors.As(err, &timeErr):
+		return fmt.Errorf("invalid JSON body: timestamps must be RFC 3339, got %q", timeErr.Value)
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		return fmt.Errorf("invalid JSON body: unknown field %s", strings.TrimPrefix(err.Error(), "json: unknown field "))
+	}
+	return fmt.Errorf("invalid JSON body")
+}
+
+func jsonKind(t reflect.Type) string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "true or false"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.Struct, reflect.Map:
+		return "an object"
+	}
+	return t.String()
+}
+
 func parseOptionalInt(raw string, defaultValue int) (int, error) {
 	value := strings.TrimSpace(raw)
 	if value == "" {
@@ -1113,6 +2523,16 @@ func parseOptionalInt(raw string, defaultValue int) (int, error) {
 }
 
 func parseOptionalTime(raw string) (*time.Time, error) {
+	return parseTimeValue(raw, false)
+}
+
+// parseOptionalEndTime parses an upper bound; a bare date covers the whole
+// day so that from=to=2006-01-02 still matches that day's rows.
+func parseOptionalEndTime(raw string) (*time.Time, error) {
+	return parseTimeValue(raw, true)
+}
+
+func parseTimeValue(raw string, endOfDay bool) (*time.Time, error) {
 	value := strings.TrimSpace(raw)
 	if value == "" {
 		return nil, nil
@@ -1121,6 +2541,9 @@ func parseOptionalTime(raw string) (*time.Time, error) {
 		if parsed, err := time.Parse(layout, value); err == nil {
 			if layout == "2006-01-02" {
 				utc := parsed.UTC()
+				if endOfDay {
+					utc = utc.Add(24*time.Hour - time.Nanosecond)
+				}
 				return &utc, nil
 			}
 			return &parsed, nil
@@ -1136,6 +2559,13 @@ func parseRequiredTime(raw string) (*time.Time, error) {
 	return parseOptionalTime(raw)
 }
 
+func parseRequiredEndTime(raw string) (*time.Time, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, fmt.Errorf("time is required")
+	}
+	return parseOptionalEndTime(raw)
+}
+
 func parseOptionalInt64(raw string) (*int64, error) {
 	value := strings.TrimSpace(raw)
 	if value == "" {
@@ -1148,20 +2578,65 @@ func parseOptionalInt64(raw string) (*int64, error) {
 	return &parsed, nil
 }
 
+func parseOptionalFloat(raw string, field string) (*float64, error) {
+	value := strings.TrimSpace(raw)
+	if value == "" {
+		return nil, nil
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be a number", field)
+	}
+	return &parsed, nil
+}
+
+// parseFuzzyThreshold reads fuzzy_threshold as a similarity in (0, 1] and
+// returns it in percent, the scale of match_score. Empty means the default.
+func parseFuzzyThreshold(raw string) (float64, error) {
+	value, err := parseOptionalFloat(raw, "fuzzy_threshold")
+	if err != nil || value == nil {
+		return 0, err
+	}
+	if !(*value > 0 && *value <= 1) {
+		return 0, fmt.Errorf("fuzzy_threshold must be greater than 0 and at most 1")
+	}
+	return *value * 100, nil
+}
+
 func parseID(raw string) (int64, error) {
 	id, err := strconv.ParseInt(strings.TrimSpace(raw), 10, 64)
 	if err != nil || id <= 0 {
-		return 0, fmt.Errorf("invalid id")
+		return 0, fmt.Errorf("id must be a positive integer")
 	}
 	return id, nil
 }
 
+// urlID parses the {id} route parameter and answers 400 when it is malformed,
+// leaving 404 to the handler once the lookup misses.
+func urlID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := parseID(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return 0, false
+	}
+	return id, true
+}
+
+// pageResponse is the list envelope for paged endpoints; limit and offset are
+// echoed after the repository defaults and caps are applied.
+func pageResponse(items any, count int, hasMore bool, limit, offset int) map[string]any {
+	limit, offset = repository.NormalizePage(limit, offset)
+	return map[string]any{
+		"items":    items,
+		"count":    count,
+		"has_more": hasMore,
+		"limit":    limit,
+		"offset":   offset,
+	}
+}
+
 func writeJSON(w http.ResponseWriter, status int, payload any) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
 	_ = json.NewEncoder(w).Encode(payload)
 }
-
-func writeError(w http.ResponseWriter, status int, message string) {
-	writeJSON(w, status, map[string]any{"error": message})
-}
@@ -0,0 +1,1027 @@
+package http
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/internal/db"
+	"backend/internal/domain"
+	"backend/internal/repository"
+	"backend/internal/service"
+)
+
+// testRouter serves the API against TEST_DATABASE_URL, migrated, and returns
+// the repository behind it for seeding. Tests create rows with unique names
+// and leave them.
+func testRouter(t *testing.T) (http.Handler, *repository.Repository) {
+	t.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	pool, err := db.NewPool(ctx, url, db.PoolOptions{MaxConns: 4, MinConns: 1})
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(pool.Close)
+	if err := db.RunMigrations(ctx, pool); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	repo := repository.New(pool)
+	handler := NewHandler(service.New(repo, service.Options{}))
+	return NewRouter(handler, RouterOptions{}), repo
+}
+
+func TestParseOptionalEndTime(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    *time.Time
+		wantErr bool
+	}{
+		{name: "empty", raw: "", want: nil},
+		{name: "blank", raw: "   ", want: nil},
+		{
+			name: "bare date covers the whole day",
+			raw:  "2024-03-05",
+			want: ptrTime(time.Date(2024, 3, 5, 23, 59, 59, 999999999, time.UTC)),
+		},
+		{
+			name: "bare date with spaces",
+			raw:  " 2024-03-05 ",
+			want: ptrTime(time.Date(2024, 3, 5, 23, 59, 59, 999999999, time.UTC)),
+		},
+		{
+			name: "RFC 3339 is taken as is",
+			raw:  "2024-03-05T10:30:00Z",
+			want: ptrTime(time.Date(2024, 3, 5, 10, 30, 0, 0, time.UTC)),
+		},
+		{
+			name: "RFC 3339 keeps its offset",
+			raw:  "2024-03-05T10:30:00+03:30",
+			want: ptrTime(time.Date(2024, 3, 5, 7, 0, 0, 0, time.UTC)),
+		},
+		{name: "invalid", raw: "05/03/2024", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseOptionalEndTime(tt.raw)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseOptionalEndTime(%q) = %v, want error", tt.raw, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseOptionalEndTime(%q): %v", tt.raw, err)
+			}
+			if (got == nil) != (tt.want == nil) || (got != nil && !got.Equal(*tt.want)) {
+				t.Fatalf("parseOptionalEndTime(%q) = %v, want %v", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseOptionalTimeBareDateStartsTheDay(t *testing.T) {
+	got, err := parseOptionalTime("2024-03-05")
+	if err != nil {
+		t.Fatalf("parseOptionalTime: %v", err)
+	}
+	if want := time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Fatalf("parseOptionalTime = %v, want %v", got, want)
+	}
+}
+
+func TestParseFuzzyThreshold(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    float64
+		wantErr bool
+	}{
+		{raw: "", want: 0},
+		{raw: "0.9", want: 90},
+		{raw: " 1 ", want: 100},
+		{raw: "0.05", want: 5},
+		{raw: "0", wantErr: true},
+		{raw: "-0.5", wantErr: true},
+		{raw: "1.01", wantErr: true},
+		// The old percent scale is rejected rather than read as 9000%.
+		{raw: "90", wantErr: true},
+		{raw: "NaN", wantErr: true},
+		{raw: "high", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseFuzzyThreshold(tt.raw)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseFuzzyThreshold(%q) = %v, want error", tt.raw, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseFuzzyThreshold(%q): %v", tt.raw, err)
+			continue
+		}
+		if math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("parseFuzzyThreshold(%q) = %v, want %v", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func ptrTime(value time.Time) *time.Time {
+	return &value
+}
+
+func TestDecodeJSON(t *testing.T) {
+	type body struct {
+		Name     string     `json:"name"`
+		Quantity int        `json:"quantity"`
+		Tags     []string   `json:"tags"`
+		At       *time.Time `json:"at"`
+	}
+	tests := []struct {
+		name     string
+		body     string
+		limit    int64
+		wantErr  string
+		wantSize bool
+	}{
+		{name: "valid", body: `{"name":"Pen","quantity":2,"tags":["a"],"at":"2024-05-01T10:00:00Z"}`},
+		{name: "empty body", body: "", wantErr: "request body is empty"},
+		{name: "truncated", body: `{"name":"Pen"`, wantErr: "invalid JSON body: unexpected end of input"},
+		{name: "syntax error", body: `{"name":}`, wantErr: "invalid JSON body: syntax error at byte 9"},
+		{name: "wrong field type", body: `{"quantity":"two"}`, wantErr: `invalid JSON body: "quantity" must be an integer, got string`},
+		{name: "wrong element type", body: `{"tags":[1]}`, wantErr: `invalid JSON body: "tags.0" must be a string, got number`},
+		{name: "wrong top-level type", body: `[]`, wantErr: "invalid JSON body: expected an object, got array"},
+		{name: "unknown field", body: `{"nme":"Pen"}`, wantErr: `invalid JSON body: unknown field "nme"`},
+		{name: "bad timestamp", body: `{"at":"2024-05-01"}`, wantErr: `invalid JSON body: timestamps must be RFC 3339, got "2024-05-01"`},
+		{
+			name:     "over the limit",
+			body:     `{"name":"` + strings.Repeat("x", 64) + `"}`,
+			limit:    16,
+			wantErr:  "request body too large: limit is 16 bytes",
+			wantSize: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			if tt.limit > 0 {
+				req.Body = http.MaxBytesReader(httptest.NewRecorder(), req.Body, tt.limit)
+			}
+			var out body
+			err := decodeJSON(req, &out)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("decodeJSON: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("decodeJSON error = %v, want %q", err, tt.wantErr)
+			}
+			if errors.Is(err, errBodyTooLarge) != tt.wantSize {
+				t.Fatalf("errors.Is(err, errBodyTooLarge) = %v, want %v", !tt.wantSize, tt.wantSize)
+			}
+		})
+	}
+}
+
+func TestWriteExport(t *testing.T) {
+	errDB := errors.New("connection reset")
+	file := exportFile{Name: "inventory.csv", ContentType: csvContentType, Prefix: csvBOM}
+	tests := []struct {
+		name            string
+		write           func(io.Writer) error
+		wantStatus      int
+		wantBody        string
+		wantAttachment  bool
+		wantAbort       bool
+		wantContentType string
+	}{
+		{
+			name: "complete file",
+			write: func(out io.Writer) error {
+				_, err := io.WriteString(out, "product_name\nPen\n")
+				return err
+			},
+			wantStatus:      http.StatusOK,
+			wantBody:        csvBOM + "product_name\nPen\n",
+			wantAttachment:  true,
+			wantContentType: csvContentType,
+		},
+		{
+			name:            "empty file still gets its headers",
+			write:           func(io.Writer) error { return nil },
+			wantStatus:      http.StatusOK,
+			wantBody:        csvBOM,
+			wantAttachment:  true,
+			wantContentType: csvContentType,
+		},
+		{
+			name:            "error before the first byte is a 500",
+			write:           func(io.Writer) error { return errDB },
+			wantStatus:      http.StatusInternalServerError,
+			wantBody:        `{"code":"internal_error","error":"connection reset"}` + "\n",
+			wantContentType: "application/json",
+		},
+		{
+			name: "error after the first byte aborts",
+			write: func(out io.Writer) error {
+				if _, err := io.WriteString(out, "product_name\n"); err != nil {
+					return err
+				}
+				return errDB
+			},
+			wantStatus:      http.StatusOK,
+			wantBody:        csvBOM + "product_name\n",
+			wantAttachment:  true,
+			wantAbort:       true,
+			wantContentType: csvContentType,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/inventory/export", nil)
+			aborted := func() (aborted bool) {
+				defer func() {
+					if v := recover(); v != nil {
+						if v != http.ErrAbortHandler {
+							panic(v)
+						}
+						aborted = true
+					}
+				}()
+				writeExport(rec, req, file, tt.write)
+				return false
+			}()
+
+			if aborted != tt.wantAbort {
+				t.Errorf("aborted = %v, want %v", aborted, tt.wantAbort)
+			}
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := rec.Body.String(); got != tt.wantBody {
+				t.Errorf("body = %q, want %q", got, tt.wantBody)
+			}
+			if got := rec.Header().Get("Content-Type"); got != tt.wantContentType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.wantContentType)
+			}
+			if got := rec.Header().Get("Content-Disposition") != ""; got != tt.wantAttachment {
+				t.Errorf("Content-Disposition set = %v, want %v", got, tt.wantAttachment)
+			}
+		})
+	}
+}
+
+func TestWritePriceAlarmsCSV(t *testing.T) {
+	source := "supplier"
+	rows := []domain.PriceAlarmRow{
+		{ProductName: "Pen, blue", AvgBuyPrice: 1500.5, SellPrice: 1000, MarginPercent: -90.9091, Source: &source},
+		{ProductName: "Ink", AvgBuyPrice: 200, SellPrice: 210, MarginPercent: 5},
+	}
+	tests := []struct {
+		name    string
+		err     error
+		want    [][]string
+		wantErr bool
+	}{
+		{
+			name: "rows",
+			want: [][]string{
+				priceAlarmsCSVHeader,
+				{"Pen, blue", "1500.5", "1000", "-90.91", "supplier"},
+				{"Ink", "200", "210", "5.00", ""},
+			},
+		},
+		{
+			name:    "source error is returned",
+			err:     errors.New("connection reset"),
+			want:    [][]string{priceAlarmsCSVHeader, {"Pen, blue", "1500.5", "1000", "-90.91", "supplier"}, {"Ink", "200", "210", "5.00", ""}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf strings.Builder
+			err := writePriceAlarmsCSV(&buf, func(fn func(domain.PriceAlarmRow) error) error {
+				for _, row := range rows {
+					if err := fn(row); err != nil {
+						return err
+					}
+				}
+				return tt.err
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("writePriceAlarmsCSV error = %v, want error %v", err, tt.wantErr)
+			}
+			got, err := csv.NewReader(strings.NewReader(buf.String())).ReadAll()
+			if err != nil {
+				t.Fatalf("parse csv: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("csv = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func BenchmarkWritePriceAlarmsCSV(b *testing.B) {
+	source := "supplier"
+	rows := make([]domain.PriceAlarmRow, 10000)
+	for i := range rows {
+		rows[i] = domain.PriceAlarmRow{
+			ProductName:   fmt.Sprintf("product %d", i),
+			AvgBuyPrice:   120000,
+			SellPrice:     125000,
+			MarginPercent: 4.1666,
+			Source:        &source,
+		}
+	}
+	each := func(fn func(domain.PriceAlarmRow) error) error {
+		for _, row := range rows {
+			if err := fn(row); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	b.ReportAllocs()
+	for b.Loop() {
+		if err := writePriceAlarmsCSV(io.Discard, each); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// TestExportPriceAlarmsCSV prices one product far below and one far above
+// any allowed alarm percent, so the result does not depend on the setting.
+func TestExportPriceAlarmsCSV(t *testing.T) {
+	router, repo := testRouter(t)
+	ctx := context.Background()
+	suffix := time.Now().UnixNano()
+	below := fmt.Sprintf("price alarm below %d", suffix)
+	above := fmt.Sprintf("price alarm above %d", suffix)
+	for _, input := range []repository.ProductCreateInput{
+		{ProductName: below, Quantity: 1, AvgBuyPrice: 100000, LastBuyPrice: 100000, SellPrice: 1},
+		{ProductName: above, Quantity: 1, AvgBuyPrice: 100000, LastBuyPrice: 100000, SellPrice: 100000000},
+	} {
+		if _, err := repo.CreateProduct(ctx, input); err != nil {
+			t.Fatalf("create product %q: %v", input.ProductName, err)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/inventory/price-alarms/export.csv", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body.String())
+	}
+	if got := rec.Header().Get("Content-Type"); got != csvContentType {
+		t.Fatalf("Content-Type = %q, want %q", got, csvContentType)
+	}
+	body, ok := strings.CutPrefix(rec.Body.String(), csvBOM)
+	if !ok {
+		t.Fatalf("body does not start with a BOM: %q", rec.Body.String())
+	}
+	records, err := csv.NewReader(strings.NewReader(body)).ReadAll()
+	if err != nil {
+		t.Fatalf("parse csv: %v", err)
+	}
+	if len(records) == 0 || !reflect.DeepEqual(records[0], priceAlarmsCSVHeader) {
+		t.Fatalf("header = %q, want %q", records, priceAlarmsCSVHeader)
+	}
+	found := map[string][]string{}
+	for _, record := range records[1:] {
+		found[record[0]] = record
+	}
+	row, ok := found[below]
+	if !ok {
+		t.Fatalf("below-margin product %q missing from export", below)
+	}
+	if want := []string{below, "100000", "1", "-100.00", ""}; !reflect.DeepEqual(row, want) {
+		t.Fatalf("row = %q, want %q", row, want)
+	}
+	if _, ok := found[above]; ok {
+		t.Fatalf("well-priced product %q is in the export", above)
+	}
+}
+
+func TestBulkInvoiceInputs(t *testing.T) {
+	lines := []domain.PurchaseLineInput{{ProductName: "Pen", Price: 100, Quantity: 2}}
+	tests := []struct {
+		invoiceType  string
+		wantType     string
+		wantPurchase bool
+	}{
+		{invoiceType: "purchase", wantType: "purchase", wantPurchase: true},
+		{invoiceType: "Purchase", wantType: "purchase", wantPurchase: true},
+		{invoiceType: " PURCHASE ", wantType: "purchase", wantPurchase: true},
+		{invoiceType: "sales", wantType: "sales"},
+		{invoiceType: "Sales_Return", wantType: "Sales_Return"},
+		{invoiceType: "", wantType: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.invoiceType, func(t *testing.T) {
+			got := bulkInvoiceInputs([]bulkInvoiceRequest{{InvoiceType: tt.invoiceType, Lines: lines}})
+			if len(got) != 1 {
+				t.Fatalf("got %d inputs, want 1", len(got))
+			}
+			input := got[0]
+			if input.InvoiceType != tt.wantType {
+				t.Errorf("InvoiceType = %q, want %q", input.InvoiceType, tt.wantType)
+			}
+			if tt.wantPurchase {
+				if len(input.PurchaseLines) != 1 || input.SalesLines != nil {
+					t.Errorf("purchase lines = %v, sales lines = %v; want the line on the purchase side", input.PurchaseLines, input.SalesLines)
+				}
+				return
+			}
+			want := []domain.SalesLineInput{{ProductName: "Pen", Price: 100, Quantity: 2}}
+			if input.PurchaseLines != nil || !reflect.DeepEqual(input.SalesLines, want) {
+				t.Errorf("purchase lines = %v, sales lines = %v; want the line on the sales side", input.PurchaseLines, input.SalesLines)
+			}
+		})
+	}
+}
+
+func TestCreateInvoicesBulkPurchaseInAnyCase(t *testing.T) {
+	router, repo := testRouter(t)
+	ctx := context.Background()
+	name := fmt.Sprintf("bulk purchase case %d", time.Now().UnixNano())
+	product, err := repo.CreateProduct(ctx, repository.ProductCreateInput{ProductName: name, AvgBuyPrice: 100, SellPrice: 150})
+	if err != nil {
+		t.Fatalf("create product: %v", err)
+	}
+
+	body := fmt.Sprintf(`{"invoices":[{"invoice_type":"Purchase","lines":[{"product_name":%q,"price":100,"quantity":2}]}]}`, name)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/invoices/bulk", strings.NewReader(body)))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body.String())
+	}
+
+	got, err := repo.GetProductByID(ctx, product.ID)
+	if err != nil {
+		t.Fatalf("get product: %v", err)
+	}
+	if got.Quantity != 2 {
+		t.Fatalf("quantity = %v, want 2", got.Quantity)
+	}
+}
+
+func TestInvoiceCreatedResponse(t *testing.T) {
+	if got, want := invoiceCreatedResponse(7, nil), map[string]any{"invoice_id": int64(7)}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("without duplicates = %v, want %v", got, want)
+	}
+	got := invoiceCreatedResponse(7, []int64{3, 5})
+	warnings, ok := got["warnings"].([]map[string]any)
+	if !ok || len(warnings) != 1 {
+		t.Fatalf("warnings = %#v, want one warning", got["warnings"])
+	}
+	if warnings[0]["code"] != "duplicate_invoice_name" || !reflect.DeepEqual(warnings[0]["invoice_ids"], []int64{3, 5}) {
+		t.Fatalf("warning = %v", warnings[0])
+	}
+}
+
+// TestCreateInvoiceWarnsOnReusedName creates purchases sharing a name; only
+// the one asking with warn_duplicate_name gets the earlier invoice back.
+func TestCreateInvoiceWarnsOnReusedName(t *testing.T) {
+	router, _ := testRouter(t)
+	suffix := time.Now().UnixNano()
+	invoiceName := fmt.Sprintf("Order %d", suffix)
+
+	type warning struct {
+		Code       string  `json:"code"`
+		InvoiceIDs []int64 `json:"invoice_ids"`
+	}
+	create := func(name string, warn bool) (int64, []warning) {
+		t.Helper()
+		body := fmt.Sprintf(`{"invoice_name":%q,"warn_duplicate_name":%t,"lines":[{"product_name":"duplicate name %d","price":10,"quantity":1}]}`, name, warn, suffix)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/invoices/purchase", strings.NewReader(body)))
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("status = %d, body %s", rec.Code, rec.Body.String())
+		}
+		var response struct {
+			InvoiceID int64     `json:"invoice_id"`
+			Warnings  []warning `json:"warnings"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+		return response.InvoiceID, response.Warnings
+	}
+
+	firstID, warnings := create(invoiceName, true)
+	if len(warnings) != 0 {
+		t.Fatalf("first invoice warnings = %+v, want none", warnings)
+	}
+	secondID, warnings := create(" "+strings.ToUpper(invoiceName)+" ", true)
+	if want := []warning{{Code: "duplicate_invoice_name", InvoiceIDs: []int64{firstID}}}; !reflect.DeepEqual(warnings, want) {
+		t.Fatalf("reused name warnings = %+v, want %+v", warnings, want)
+	}
+	if _, warnings := create(invoiceName, false); len(warnings) != 0 {
+		t.Fatalf("warnings without warn_duplicate_name = %+v, want none", warnings)
+	}
+	if secondID == firstID {
+		t.Fatalf("both invoices got id %d", firstID)
+	}
+}
+
+// TestListAdminActions seeds actions for two admins and lists one admin's by
+// id; an unknown id is a 404.
+func TestListAdminActions(t *testing.T) {
+	router, repo := testRouter(t)
+	ctx := context.Background()
+	suffix := time.Now().UnixNano()
+
+	admins := make([]*domain.AdminUser, 2)
+	for i := range admins {
+		admin, err := repo.CreateAdmin(ctx, fmt.Sprintf("actions test %d %d", suffix, i), "secret-password", "employee", 5)
+		if err != nil {
+			t.Fatalf("create admin %d: %v", i, err)
+		}
+		admins[i] = admin
+	}
+	for i, title := range []string{"first", "second", "other admin", "third"} {
+		admin := admins[0]
+		if title == "other admin" {
+			admin = admins[1]
+		}
+		if err := repo.LogAction(ctx, "test", title, fmt.Sprintf("step %d", i), &admin.Username); err != nil {
+			t.Fatalf("log action %q: %v", title, err)
+		}
+	}
+
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+	rec := get(fmt.Sprintf("/api/v1/admins/%d/actions", admins[0].AdminID))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body.String())
+	}
+	var response struct {
+		Items []domain.ActionEntry `json:"items"`
+		Count int                  `json:"count"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	titles := make([]string, 0, len(response.Items))
+	for _, item := range response.Items {
+		if item.AdminUsername == nil || *item.AdminUsername != admins[0].Username {
+			t.Errorf("action %q belongs to %v", item.Title, item.AdminUsername)
+		}
+		titles = append(titles, item.Title)
+	}
+	if want := []string{"third", "second", "first"}; !reflect.DeepEqual(titles, want) || response.Count != len(want) {
+		t.Fatalf("titles = %q (count %d), want %q newest first", titles, response.Count, want)
+	}
+
+	rec = get(fmt.Sprintf("/api/v1/admins/%d/actions?limit=1&offset=1", admins[0].AdminID))
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("decode paged response: %v", err)
+	}
+	if len(response.Items) != 1 || response.Items[0].Title != "second" {
+		t.Fatalf("second page = %+v, want the second newest action", response.Items)
+	}
+
+	rec = get("/api/v1/admins/9223372036854775807/actions")
+	if rec.Code != http.StatusNotFound || !strings.Contains(rec.Body.String(), `"admin_not_found"`) {
+		t.Fatalf("unknown admin: status = %d, body %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestLogActionsBulk(t *testing.T) {
+	router, repo := testRouter(t)
+	ctx := context.Background()
+	marker := fmt.Sprintf("bulk action %d", time.Now().UnixNano())
+
+	post := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/actions/bulk", strings.NewReader(body)))
+		return rec
+	}
+	stored := func(search string) []domain.ActionEntry {
+		t.Helper()
+		items, _, err := repo.ListActions(ctx, 50, 0, repository.ActionFilter{Search: search})
+		if err != nil {
+			t.Fatalf("ListActions: %v", err)
+		}
+		return items
+	}
+
+	rec := post(fmt.Sprintf(`[
+		{"action_type":"ui","title":"%[1]s opened","admin_username":" clerk "},
+		{"action_type":"ui","title":"%[1]s saved","details":"row 4"},
+		{"action_type":"ui","title":"%[1]s closed"}
+	]`, marker))
+	if rec.Code != http.StatusCreated || strings.TrimSpace(rec.Body.String()) != `{"inserted":3}` {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body.String())
+	}
+	items := stored(marker)
+	if len(items) != 3 {
+		t.Fatalf("stored %d actions, want 3", len(items))
+	}
+	for _, item := range items {
+		switch {
+		case strings.HasSuffix(item.Title, "opened"):
+			if item.AdminUsername == nil || *item.AdminUsername != "clerk" {
+				t.Errorf("opened admin = %v, want clerk", item.AdminUsername)
+			}
+		case strings.HasSuffix(item.Title, "saved"):
+			if item.Details != "row 4" {
+				t.Errorf("saved details = %q, want row 4", item.Details)
+			}
+		case item.Details != "-":
+			t.Errorf("%q details = %q, want the - placeholder", item.Title, item.Details)
+		}
+	}
+
+	// One invalid entry rejects the whole batch.
+	rejected := marker + " rejected"
+	rec = post(fmt.Sprintf(`[{"action_type":"ui","title":"%s"},{"action_type":"ui","title":" "}]`, rejected))
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "action 2: action_type and title are required") {
+		t.Fatalf("invalid batch: status = %d, body %s", rec.Code, rec.Body.String())
+	}
+	if items := stored(rejected); len(items) != 0 {
+		t.Fatalf("stored %d actions from a rejected batch", len(items))
+	}
+
+	if rec := post(`[]`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("empty batch: status = %d, body %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestParseID(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    int64
+		wantErr bool
+	}{
+		{raw: "42", want: 42},
+		{raw: " 7 ", want: 7},
+		{raw: "0", wantErr: true},
+		{raw: "-3", wantErr: true},
+		{raw: "abc", wantErr: true},
+		{raw: "1.5", wantErr: true},
+		{raw: "", wantErr: true},
+		{raw: "99999999999999999999", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseID(tt.raw)
+		if tt.wantErr {
+			if err == nil || err.Error() != "id must be a positive integer" {
+				t.Errorf("parseID(%q) error = %v", tt.raw, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("parseID(%q) = %d, %v; want %d", tt.raw, got, err, tt.want)
+		}
+	}
+}
+
+// TestResourceIDContract locks the split between a malformed id (400) and a
+// well-formed id that matches nothing (404 with a resource-specific code).
+func TestResourceIDContract(t *testing.T) {
+	router, _ := testRouter(t)
+	const missing = "9223372036854775000"
+
+	tests := []struct {
+		method   string
+		path     string
+		status   int
+		wantCode string
+	}{
+		{method: http.MethodGet, path: "/api/v1/products/abc", status: http.StatusBadRequest, wantCode: "invalid_request"},
+		{method: http.MethodGet, path: "/api/v1/products/0", status: http.StatusBadRequest, wantCode: "invalid_request"},
+		{method: http.MethodGet, path: "/api/v1/products/" + missing, status: http.StatusNotFound, wantCode: "product_not_found"},
+		{method: http.MethodGet, path: "/api/v1/invoices/-1", status: http.StatusBadRequest, wantCode: "invalid_request"},
+		{method: http.MethodGet, path: "/api/v1/invoices/" + missing, status: http.StatusNotFound, wantCode: "invoice_not_found"},
+		{method: http.MethodDelete, path: "/api/v1/invoices/" + missing, status: http.StatusNotFound, wantCode: "invoice_not_found"},
+		{method: http.MethodGet, path: "/api/v1/admins/x1", status: http.StatusBadRequest, wantCode: "invalid_request"},
+		{method: http.MethodGet, path: "/api/v1/admins/" + missing, status: http.StatusNotFound, wantCode: "admin_not_found"},
+		{method: http.MethodGet, path: "/api/v1/admins/" + missing + "/actions", status: http.StatusNotFound, wantCode: "admin_not_found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d; body %s", rec.Code, tt.status, rec.Body.String())
+			}
+			var body struct {
+				Error string `json:"error"`
+				Code  string `json:"code"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decode body: %v", err)
+			}
+			if body.Code != tt.wantCode {
+				t.Fatalf("code = %q, want %q", body.Code, tt.wantCode)
+			}
+			if tt.status == http.StatusBadRequest && body.Error != "id must be a positive integer" {
+				t.Fatalf("error = %q", body.Error)
+			}
+		})
+	}
+}
+
+func TestListProductsRejectsUnknownSort(t *testing.T) {
+	router, _ := testRouter(t)
+	for _, sort := range []string{"price", "id", "name:up"} {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/products?sort="+sort, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("sort=%s: status = %d, body %s", sort, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+func TestPageResponse(t *testing.T) {
+	got := pageResponse([]int{1, 2}, 2, true, 0, -3)
+	want := map[string]any{"items": []int{1, 2}, "count": 2, "has_more": true, "limit": 200, "offset": 0}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("pageResponse = %v, want %v", got, want)
+	}
+}
+
+// TestPatchProductConflict sends updated_at through JSON, so it also checks
+// that the timestamp survives the round trip at full precision.
+func TestPatchProductConflict(t *testing.T) {
+	router, repo := testRouter(t)
+	ctx := context.Background()
+
+	product, err := repo.CreateProduct(ctx, repository.ProductCreateInput{
+		ProductName: fmt.Sprintf("patch conflict %d", time.Now().UnixNano()),
+		Quantity:    1,
+	})
+	if err != nil {
+		t.Fatalf("create product: %v", err)
+	}
+	patch := func(sellPrice float64, updatedAt time.Time) *httptest.ResponseRecorder {
+		body := fmt.Sprintf(`{"sell_price":%v,"updated_at":%q}`, sellPrice, updatedAt.Format(time.RFC3339Nano))
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodPatch, fmt.Sprintf("/api/v1/products/%d", product.ID), strings.NewReader(body)))
+		return rec
+	}
+
+	rec := patch(15, product.UpdatedAt)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("matching version: status = %d, body %s", rec.Code, rec.Body.String())
+	}
+	var updated domain.Product
+	if err := json.Unmarshal(rec.Body.Bytes(), &updated); err != nil {
+		t.Fatalf("decode product: %v", err)
+	}
+
+	rec = patch(25, product.UpdatedAt)
+	if rec.Code != http.StatusConflict || !strings.Contains(rec.Body.String(), `"code":"product_stale"`) {
+		t.Fatalf("stale version: status = %d, body %s", rec.Code, rec.Body.String())
+	}
+
+	if rec := patch(25, updated.UpdatedAt); rec.Code != http.StatusOK {
+		t.Fatalf("refreshed version: status = %d, body %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestUnknownSettingNotFound(t *testing.T) {
+	router, _ := testRouter(t)
+	tests := []struct {
+		method string
+		body   string
+	}{
+		{method: http.MethodGet},
+		{method: http.MethodPatch, body: `{"value": 10}`},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(tt.method, "/api/v1/settings/no_such_setting", strings.NewReader(tt.body)))
+		if rec.Code != http.StatusNotFound || !strings.Contains(rec.Body.String(), `"code":"setting_not_found"`) {
+			t.Errorf("%s: status = %d, body %s", tt.method, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+func TestListInvoicesAmountValidation(t *testing.T) {
+	router, _ := testRouter(t)
+	tests := []struct {
+		query   string
+		wantErr string
+	}{
+		{query: "min_amount=-1", wantErr: "min_amount and max_amount must not be negative"},
+		{query: "max_amount=-0.5", wantErr: "min_amount and max_amount must not be negative"},
+		{query: "min_amount=10&max_amount=5", wantErr: "min_amount must not exceed max_amount"},
+		{query: "min_amount=ten", wantErr: "min_amount must be a number"},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/invoices?"+tt.query, nil))
+		if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), tt.wantErr) {
+			t.Errorf("%s: status = %d, body %s", tt.query, rec.Code, rec.Body.String())
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/invoices?min_amount=5&max_amount=5", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("equal bounds: status = %d, body %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestRecalcAllInvoiceTotalsRequiresConfirm(t *testing.T) {
+	router, _ := testRouter(t)
+	for _, body := range []string{`{}`, `{"confirm":false}`} {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/invoices/recalc", strings.NewReader(body)))
+		if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "confirm must be true") {
+			t.Errorf("%s: status = %d, body %s", body, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+// TestChangeOwnPassword runs its cases in order against one admin: a wrong
+// current password changes nothing, and the right one swaps the password
+// without counting as a login.
+func TestChangeOwnPassword(t *testing.T) {
+	router, repo := testRouter(t)
+	ctx := context.Background()
+
+	username := fmt.Sprintf("self%d", time.Now().UnixNano())
+	admin, err := repo.CreateAdmin(ctx, username, "first1234", "employee", 5)
+	if err != nil {
+		t.Fatalf("create admin: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		username string
+		body     string
+		status   int
+		// wantPassword is the password that must authenticate afterwards.
+		wantPassword string
+	}{
+		{
+			name:         "no admin header",
+			body:         `{"current_password":"first1234","new_password":"second1234"}`,
+			status:       http.StatusUnauthorized,
+			wantPassword: "first1234",
+		},
+		{
+			name:         "wrong current password",
+			username:     username,
+			body:         `{"current_password":"guess1234","new_password":"second1234"}`,
+			status:       http.StatusUnauthorized,
+			wantPassword: "first1234",
+		},
+		{
+			name:         "new password fails the policy",
+			username:     username,
+			body:         `{"current_password":"first1234","new_password":"short"}`,
+			status:       http.StatusBadRequest,
+			wantPassword: "first1234",
+		},
+		{
+			name:         "success",
+			username:     username,
+			body:         `{"current_password":"first1234","new_password":"second1234"}`,
+			status:       http.StatusOK,
+			wantPassword: "second1234",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/admins/me/password", strings.NewReader(tt.body))
+			if tt.username != "" {
+				req.Header.Set("X-Admin-Username", tt.username)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d; body %s", rec.Code, tt.status, rec.Body.String())
+			}
+			verified, err := repo.VerifyAdminPassword(ctx, username, tt.wantPassword)
+			if err != nil || verified == nil {
+				t.Fatalf("password %q no longer verifies: %v", tt.wantPassword, err)
+			}
+		})
+	}
+
+	old, err := repo.VerifyAdminPassword(ctx, username, "first1234")
+	if err != nil || old != nil {
+		t.Fatalf("old password still verifies: %+v, %v", old, err)
+	}
+	stored, err := repo.GetAdminByID(ctx, admin.AdminID)
+	if err != nil {
+		t.Fatalf("get admin: %v", err)
+	}
+	if stored.LastLoginAt != nil {
+		t.Fatalf("password change recorded a login at %v", stored.LastLoginAt)
+	}
+}
+
+func TestAdminActiveValidation(t *testing.T) {
+	router, _ := testRouter(t)
+	tests := []struct {
+		method string
+		path   string
+		body   string
+		want   string
+	}{
+		{method: http.MethodGet, path: "/api/v1/admins?include_inactive=maybe", want: "include_inactive must be true or false"},
+		{method: http.MethodPatch, path: "/api/v1/admins/1/active", body: `{}`, want: "active is required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+			if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), tt.want) {
+				t.Fatalf("status = %d, body %s; want 400 %q", rec.Code, rec.Body.String(), tt.want)
+			}
+		})
+	}
+}
+
+// TestDuplicateConflicts checks unique violations come back as a 409 with a
+// friendly message instead of the raw Postgres error.
+func TestDuplicateConflicts(t *testing.T) {
+	router, repo := testRouter(t)
+	ctx := context.Background()
+	suffix := time.Now().UnixNano()
+
+	taken, err := repo.CreateProduct(ctx, repository.ProductCreateInput{ProductName: fmt.Sprintf("taken name %d", suffix), Quantity: 1})
+	if err != nil {
+		t.Fatalf("create product: %v", err)
+	}
+	sku := fmt.Sprintf("SKU-%d", suffix)
+	if _, err := repo.PatchProduct(ctx, taken.ID, repository.ProductPatchInput{SKU: &sku}); err != nil {
+		t.Fatalf("set sku: %v", err)
+	}
+	renamed, err := repo.CreateProduct(ctx, repository.ProductCreateInput{ProductName: fmt.Sprintf("renamed %d", suffix), Quantity: 1})
+	if err != nil {
+		t.Fatalf("create product: %v", err)
+	}
+	username := fmt.Sprintf("dup%d", suffix)
+	if _, err := repo.CreateAdmin(ctx, username, "pass1234", "employee", 5); err != nil {
+		t.Fatalf("create admin: %v", err)
+	}
+
+	productPath := fmt.Sprintf("/api/v1/products/%d", renamed.ID)
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		want   string
+	}{
+		{
+			name:   "username",
+			method: http.MethodPost,
+			path:   "/api/v1/admins",
+			body:   fmt.Sprintf(`{"username":%q,"password":"other1234","role":"employee"}`, username),
+			want:   `{"error":"username already exists","code":"duplicate_username"}`,
+		},
+		{
+			name:   "product name in another case",
+			method: http.MethodPatch,
+			path:   productPath,
+			body:   fmt.Sprintf(`{"product_name":%q}`, strings.ToUpper(taken.ProductName)),
+			want:   `{"error":"product name already exists","code":"duplicate_product_name"}`,
+		},
+		{
+			name:   "sku",
+			method: http.MethodPatch,
+			path:   productPath,
+			body:   fmt.Sprintf(`{"sku":%q}`, sku),
+			want:   `{"error":"sku already in use","code":"duplicate_sku"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+			if rec.Code != http.StatusConflict || strings.TrimSpace(rec.Body.String()) != tt.want {
+				t.Fatalf("status = %d, body %s; want 409 %s", rec.Code, rec.Body.String(), tt.want)
+			}
+		})
+	}
+}
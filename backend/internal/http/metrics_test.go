@@ -0,0 +1,59 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMetricsRouteLabels(t *testing.T) {
+	m := newMetrics(func() *pgxpool.Stat { return nil })
+	r := chi.NewRouter()
+	r.Use(m.middleware)
+	r.Route("/api/v1", func(r chi.Router) {
+		r.Get("/products/{id}", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		})
+		r.Post("/products", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusCreated)
+		})
+		r.Get("/silent", func(w http.ResponseWriter, r *http.Request) {})
+	})
+
+	requests := []struct{ method, path string }{
+		{http.MethodGet, "/api/v1/products/1"},
+		{http.MethodGet, "/api/v1/products/2"},
+		{http.MethodPost, "/api/v1/products"},
+		{http.MethodGet, "/api/v1/silent"},
+		{http.MethodGet, "/nowhere"},
+	}
+	for _, req := range requests {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(req.method, req.path, nil))
+	}
+
+	tests := []struct {
+		name   string
+		labels prometheus.Labels
+		want   float64
+	}{
+		{name: "ids share the route pattern", labels: prometheus.Labels{"method": "GET", "route": "/api/v1/products/{id}", "status": "404"}, want: 2},
+		{name: "status comes from the handler", labels: prometheus.Labels{"method": "POST", "route": "/api/v1/products", "status": "201"}, want: 1},
+		{name: "no explicit status is 200", labels: prometheus.Labels{"method": "GET", "route": "/api/v1/silent", "status": "200"}, want: 1},
+		{name: "unknown paths are unmatched", labels: prometheus.Labels{"method": "GET", "route": "unmatched", "status": "404"}, want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := testutil.ToFloat64(m.requests.With(tt.labels)); got != tt.want {
+				t.Fatalf("http_requests_total%v = %v, want %v", tt.labels, got, tt.want)
+			}
+		})
+	}
+	if got := testutil.CollectAndCount(m.requests); got != len(tests) {
+		t.Fatalf("http_requests_total has %d series, want %d", got, len(tests))
+	}
+}
@@ -1,19 +1,72 @@
 package http
 
 import (
-	"log"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
 	"net/http"
 	"runtime/debug"
+	"strings"
 	"time"
 
+	"backend/internal/logging"
+	"backend/internal/repository"
+
 	"github.com/go-chi/chi/v5/middleware"
 )
 
+const (
+	requestIDHeader     = "X-Request-Id"
+	maxRequestIDLength  = 128
+	adminUsernameHeader = "X-Admin-Username"
+)
+
+// RequestID keeps a client supplied X-Request-Id (or generates one), stores
+// it in the request context and echoes it on the response.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimSpace(r.Header.Get(requestIDHeader))
+		if id == "" || len(id) > maxRequestIDLength {
+			id = newRequestID()
+		}
+		w.Header().Set(requestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(logging.WithRequestID(r.Context(), id)))
+	})
+}
+
+// AdminUsername attributes the request to the admin named in
+// X-Admin-Username so invoice writes can record who made them.
+func AdminUsername(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if username := r.Header.Get(adminUsernameHeader); username != "" {
+			r = r.WithContext(repository.WithAdminUsername(r.Context(), username))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func newRequestID() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(buf)
+}
+
 func Recoverer(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
 			if rec := recover(); rec != nil {
-				log.Printf("panic recovered: %v\n%s", rec, string(debug.Stack()))
+				if rec == http.ErrAbortHandler {
+					panic(rec)
+				}
+				logging.FromContext(r.Context()).Error(
+					"panic recovered",
+					"panic", fmt.Sprint(rec),
+					"method", r.Method,
+					"path", r.URL.Path,
+					"stack", string(debug.Stack()),
+				)
 				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 			}
 		}()
@@ -22,22 +75,81 @@ func Recoverer(next http.Handler) http.Handler {
 }
 
 func Logger(next http.Handler) http.Handler {
-	return middleware.RequestLogger(&middleware.DefaultLogFormatter{Logger: log.New(log.Writer(), "http: ", log.LstdFlags), NoColor: true})(next)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		start := time.Now()
+		defer func() {
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			logging.FromContext(r.Context()).Info(
+				"http request",
+				"method", r.Method,
+				"path", r.URL.Path,
+				"status", status,
+				"bytes", ww.BytesWritten(),
+				"duration_ms", float64(time.Since(start).Microseconds())/1000,
+				"remote_addr", r.RemoteAddr,
+			)
+		}()
+		next.ServeHTTP(ww, r)
+	})
 }
 
 func Timeout(next http.Handler) http.Handler {
 	return middleware.Timeout(60 * time.Second)(next)
 }
 
-func CORS(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PATCH, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-		if r.Method == http.MethodOptions {
-			w.WriteHeader(http.StatusNoContent)
-			return
+// CORS allows any origin when allowedOrigins is empty. Otherwise only listed
+// origins are echoed back, with credentials allowed; other origins get no
+// CORS headers and are blocked by the browser.
+func CORS(allowedOrigins []string) func(http.Handler) http.Handler {
+	allowed := make(map[string]struct{}, len(allowedOrigins))
+	for _, origin := range allowedOrigins {
+		if origin = strings.TrimRight(strings.TrimSpace(origin), "/"); origin != "" {
+			allowed[origin] = struct{}{}
 		}
-		next.ServeHTTP(w, r)
-	})
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header := w.Header()
+			allowOrigin := ""
+			if len(allowed) == 0 {
+				allowOrigin = "*"
+			} else {
+				header.Add("Vary", "Origin")
+				if origin := r.Header.Get("Origin"); origin != "" {
+					if _, ok := allowed[origin]; ok {
+						allowOrigin = origin
+						header.Set("Access-Control-Allow-Credentials", "true")
+					}
+				}
+			}
+			if allowOrigin != "" {
+				header.Set("Access-Control-Allow-Origin", allowOrigin)
+				header.Set("Access-Control-Allow-Methods", "GET, POST, PATCH, DELETE, OPTIONS")
+				header.Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Request-Id, X-Admin-Username")
+				header.Set("Access-Control-Expose-Headers", requestIDHeader)
+			}
+			if r.Method == http.MethodOptions {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// MaxBodyBytes caps request bodies at limit bytes whatever their content
+// type. The router gives upload routes a larger limit than the rest.
+func MaxBodyBytes(limit int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if limit > 0 && r.Body != nil {
+				r.Body = http.MaxBytesReader(w, r.Body, limit)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
 }
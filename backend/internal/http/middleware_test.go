@@ -0,0 +1,106 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCORS(t *testing.T) {
+	tests := []struct {
+		name            string
+		allowed         []string
+		method          string
+		origin          string
+		wantOrigin      string
+		wantCredentials bool
+		wantVary        bool
+		wantStatus      int
+	}{
+		{name: "no list allows any origin", method: http.MethodGet, origin: "https://evil.example", wantOrigin: "*", wantStatus: http.StatusOK},
+		{name: "no list without origin", method: http.MethodGet, wantOrigin: "*", wantStatus: http.StatusOK},
+		{
+			name:            "listed origin is echoed with credentials",
+			allowed:         []string{"https://app.example.com", "http://localhost:5173"},
+			method:          http.MethodGet,
+			origin:          "http://localhost:5173",
+			wantOrigin:      "http://localhost:5173",
+			wantCredentials: true,
+			wantVary:        true,
+			wantStatus:      http.StatusOK,
+		},
+		{
+			name:            "configured entries are trimmed",
+			allowed:         []string{"  https://app.example.com/  ", ""},
+			method:          http.MethodGet,
+			origin:          "https://app.example.com",
+			wantOrigin:      "https://app.example.com",
+			wantCredentials: true,
+			wantVary:        true,
+			wantStatus:      http.StatusOK,
+		},
+		{
+			name:       "unlisted origin gets no CORS headers",
+			allowed:    []string{"https://app.example.com"},
+			method:     http.MethodGet,
+			origin:     "https://evil.example",
+			wantVary:   true,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "matching is exact",
+			allowed:    []string{"https://app.example.com"},
+			method:     http.MethodGet,
+			origin:     "https://app.example.com.evil.example",
+			wantVary:   true,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:            "preflight from a listed origin",
+			allowed:         []string{"https://app.example.com"},
+			method:          http.MethodOptions,
+			origin:          "https://app.example.com",
+			wantOrigin:      "https://app.example.com",
+			wantCredentials: true,
+			wantVary:        true,
+			wantStatus:      http.StatusNoContent,
+		},
+		{
+			name:       "preflight from an unlisted origin",
+			allowed:    []string{"https://app.example.com"},
+			method:     http.MethodOptions,
+			origin:     "https://evil.example",
+			wantVary:   true,
+			wantStatus: http.StatusNoContent,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := CORS(tt.allowed)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+			req := httptest.NewRequest(tt.method, "/api/v1/products", nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := rec.Header().Get("Access-Control-Allow-Origin"); got != tt.wantOrigin {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.wantOrigin)
+			}
+			if got := rec.Header().Get("Access-Control-Allow-Credentials") == "true"; got != tt.wantCredentials {
+				t.Errorf("credentials allowed = %v, want %v", got, tt.wantCredentials)
+			}
+			if got := rec.Header().Get("Vary") == "Origin"; got != tt.wantVary {
+				t.Errorf("Vary: Origin = %v, want %v", got, tt.wantVary)
+			}
+			if got := rec.Header().Get("Access-Control-Allow-Methods") != ""; got != (tt.wantOrigin != "") {
+				t.Errorf("Access-Control-Allow-Methods set = %v, want %v", got, tt.wantOrigin != "")
+			}
+		})
+	}
+}
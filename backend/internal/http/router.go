@@ -7,68 +7,143 @@ import (
 	"github.com/go-chi/chi/v5/middleware"
 )
 
-func NewRouter(handler *Handler) http.Handler {
+type RouterOptions struct {
+	// AllowedOrigins restricts CORS to these origins; empty allows any.
+	AllowedOrigins []string
+	// MaxBodyBytes caps request bodies outside the upload routes; zero
+	// disables the limit.
+	MaxBodyBytes int64
+	// MaxUploadBytes caps multipart file uploads; zero disables the limit.
+	MaxUploadBytes int64
+}
+
+func NewRouter(handler *Handler, opts RouterOptions) http.Handler {
+	m := newMetrics(handler.svc.DBPoolStat)
+
 	r := chi.NewRouter()
 	r.Use(middleware.RealIP)
+	r.Use(RequestID)
+	r.Use(AdminUsername)
 	r.Use(Logger)
+	r.Use(m.middleware)
 	r.Use(Recoverer)
 	r.Use(Timeout)
-	r.Use(CORS)
+	r.Use(CORS(opts.AllowedOrigins))
 
 	r.Get("/healthz", handler.Health)
+	r.Handle("/metrics", m.handler())
 
 	r.Route("/api/v1", func(r chi.Router) {
-		r.Get("/products", handler.ListProducts)
-		r.Get("/products/{id}", handler.GetProduct)
-		r.Post("/products", handler.CreateProduct)
-		r.Patch("/products/{id}", handler.PatchProduct)
-		r.Delete("/products/{id}", handler.DeleteProduct)
-
-		r.Get("/inventory/summary", handler.InventorySummary)
-		r.Get("/inventory/low-stock", handler.LowStock)
-		r.Post("/inventory/import-excel", handler.ImportInventoryExcel)
-		r.Post("/inventory/import-sell-prices", handler.ImportSellPrices)
-		r.Post("/inventory/replace", handler.ReplaceInventory)
-		r.Post("/inventory/sync", handler.SyncInventory)
-		r.Get("/product-groups", handler.ListProductGroups)
-		r.Post("/product-groups", handler.CreateProductGroup)
-		r.Patch("/product-groups/{id}", handler.UpdateProductGroup)
-		r.Delete("/product-groups/{id}", handler.DeleteProductGroup)
-		r.Get("/settings/sell-price-alarm", handler.GetSellPriceAlarmPercent)
-		r.Patch("/settings/sell-price-alarm", handler.UpdateSellPriceAlarmPercent)
-		r.Get("/settings/sales-import-fuzzy-match", handler.GetSalesImportFuzzyMatchPercent)
-		r.Patch("/settings/sales-import-fuzzy-match", handler.UpdateSalesImportFuzzyMatchPercent)
-
-		r.Get("/invoices", handler.ListInvoices)
-		r.Get("/invoices/range", handler.ListInvoicesBetween)
-		r.Get("/invoices/stats", handler.InvoiceStats)
-		r.Get("/invoices/{id}", handler.GetInvoice)
-		r.Delete("/invoices/{id}", handler.DeleteInvoice)
-		r.Patch("/invoices/{id}/name", handler.UpdateInvoiceName)
-		r.Patch("/invoices/{id}/lines", handler.UpdateInvoiceLines)
-		r.Post("/invoices/purchase", handler.CreatePurchaseInvoice)
-		r.Post("/invoices/sales", handler.CreateSalesInvoice)
-		r.Post("/invoices/rename-products", handler.RenameProducts)
-
-		r.Get("/analytics/monthly", handler.MonthlySummary)
-		r.Get("/analytics/monthly-qty", handler.MonthlyQuantitySummary)
-		r.Get("/analytics/top-products", handler.TopSoldProducts)
-		r.Get("/analytics/unsold-products", handler.UnsoldProducts)
-		r.Post("/sales/preview", handler.SalesPreview)
-		r.Post("/basalam/order-ids/check", handler.BasalamCheckExistingIDs)
-		r.Post("/basalam/order-ids/store", handler.BasalamStoreIDs)
-
-		r.Post("/admins/authenticate", handler.AuthenticateAdmin)
-		r.Get("/admins", handler.ListAdmins)
-		r.Post("/admins", handler.CreateAdmin)
-		r.Get("/admins/{id}", handler.GetAdmin)
-		r.Patch("/admins/{id}/password", handler.UpdateAdminPassword)
-		r.Patch("/admins/{id}/auto-lock", handler.UpdateAdminAutoLock)
-		r.Delete("/admins/{id}", handler.DeleteAdmin)
-
-		r.Post("/actions", handler.LogAction)
-		r.Get("/actions", handler.ListActions)
-		r.Get("/actions/count", handler.CountActions)
+		// Uploads get their own, larger cap; every other body is held to
+		// MaxBodyBytes.
+		r.Group(func(r chi.Router) {
+			r.Use(MaxBodyBytes(opts.MaxUploadBytes))
+			r.Post("/inventory/import-excel", handler.ImportInventoryExcel)
+			r.Post("/inventory/import-excel/validate", handler.ValidateInventoryExcel)
+			r.Post("/inventory/import-sell-prices", handler.ImportSellPrices)
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(MaxBodyBytes(opts.MaxBodyBytes))
+
+			r.Get("/system/migrations/pending", handler.PendingMigrations)
+
+			r.Get("/products", handler.ListProducts)
+			r.Get("/products/{id}", handler.GetProduct)
+			r.Get("/products/by-sku/{sku}", handler.GetProductBySKU)
+			r.Post("/products", handler.CreateProduct)
+			r.Post("/products/bulk-set-source", handler.BulkSetProductSource)
+			r.Post("/products/merge", handler.MergeProducts)
+			r.Patch("/products/{id}", handler.PatchProduct)
+			r.Post("/products/{id}/adjust", handler.AdjustProduct)
+			r.Get("/products/{id}/aliases", handler.ListProductAliases)
+			r.Post("/products/{id}/aliases", handler.AddProductAlias)
+			r.Delete("/products/{id}/aliases", handler.DeleteProductAlias)
+			r.Delete("/products/{id}", handler.DeleteProduct)
+
+			r.Get("/inventory/summary", handler.InventorySummary)
+			r.Post("/inventory/snapshot", handler.RecordInventorySnapshot)
+			r.Get("/inventory/low-stock", handler.LowStock)
+			r.Get("/inventory/price-alarms/export.csv", handler.ExportPriceAlarmsCSV)
+			r.Get("/inventory/export", handler.ExportInventory)
+			r.Get("/inventory/import-template", handler.InventoryImportTemplate)
+			r.Get("/inventory/margin-alerts", handler.MarginAlerts)
+			r.Get("/inventory/missing-sell-price", handler.MissingSellPrice)
+			r.Get("/inventory/reconciliation", handler.StockReconciliation)
+			r.Post("/inventory/match-sell-prices/preview", handler.PreviewSellPriceMatches)
+			r.Post("/inventory/replace", handler.ReplaceInventory)
+			r.Post("/inventory/sync", handler.SyncInventory)
+			r.Get("/categories", handler.ListCategories)
+			r.Post("/categories", handler.CreateCategory)
+			r.Get("/product-groups", handler.ListProductGroups)
+			r.Post("/product-groups", handler.CreateProductGroup)
+			r.Patch("/product-groups/{id}", handler.UpdateProductGroup)
+			r.Delete("/product-groups/{id}", handler.DeleteProductGroup)
+			r.Get("/settings", handler.ListSettings)
+			r.Get("/settings/{key}", handler.GetSetting)
+			r.Patch("/settings/{key}", handler.UpdateSetting)
+			r.Get("/settings/sell-price-alarm", handler.GetSellPriceAlarmPercent)
+			r.Patch("/settings/sell-price-alarm", handler.UpdateSellPriceAlarmPercent)
+			r.Get("/settings/sales-import-fuzzy-match", handler.GetSalesImportFuzzyMatchPercent)
+			r.Patch("/settings/sales-import-fuzzy-match", handler.UpdateSalesImportFuzzyMatchPercent)
+			r.Get("/settings/low-stock-default", handler.GetLowStockDefault)
+			r.Patch("/settings/low-stock-default", handler.UpdateLowStockDefault)
+
+			r.Get("/invoices", handler.ListInvoices)
+			r.Get("/invoices/range", handler.ListInvoicesBetween)
+			r.Get("/invoices/recent", handler.ListRecentInvoices)
+			r.Get("/invoices/stats", handler.InvoiceStats)
+			r.Get("/invoices/{id}", handler.GetInvoice)
+			r.Get("/invoices/{id}/pdf", handler.InvoicePDF)
+			r.Delete("/invoices/{id}", handler.DeleteInvoice)
+			r.Patch("/invoices/{id}/name", handler.UpdateInvoiceName)
+			r.Patch("/invoices/{id}/lines", handler.UpdateInvoiceLines)
+			r.Patch("/invoices/{id}/lines/{lineId}", handler.PatchInvoiceLine)
+			r.Post("/invoices/{id}/finalize", handler.FinalizeInvoice)
+			r.Post("/invoices/{id}/void", handler.VoidInvoice)
+			r.Post("/invoices/{id}/recalc", handler.RecalcInvoiceTotals)
+			r.Post("/invoices/purchase", handler.CreatePurchaseInvoice)
+			r.Post("/invoices/sales", handler.CreateSalesInvoice)
+			r.Post("/invoices/bulk", handler.CreateInvoicesBulk)
+			r.Post("/invoices/rename-products", handler.RenameProducts)
+			r.Post("/invoices/backfill-costs", handler.BackfillSalesCostPrices)
+			r.Post("/invoices/recalc", handler.RecalcAllInvoiceTotals)
+
+			r.Get("/analytics/monthly", handler.MonthlySummary)
+			r.Get("/analytics/daily", handler.DailySummary)
+			r.Get("/analytics/inventory-history", handler.InventoryHistory)
+			r.Get("/analytics/monthly-qty", handler.MonthlyQuantitySummary)
+			r.Get("/analytics/top-products", handler.TopSoldProducts)
+			r.Get("/analytics/product-profit", handler.ProductProfit)
+			r.Get("/analytics/sales-by-admin", handler.SalesByAdmin)
+			r.Get("/analytics/unsold-products", handler.UnsoldProducts)
+			r.Get("/analytics/dead-stock", handler.DeadStock)
+			r.Get("/analytics/reorder-suggestions", handler.ReorderSuggestions)
+			r.Get("/analytics/revenue-pareto", handler.RevenuePareto)
+			r.Get("/analytics/dashboard", handler.Dashboard)
+			r.Post("/sales/preview", handler.SalesPreview)
+			r.Post("/basalam/order-ids/check", handler.BasalamCheckExistingIDs)
+			r.Post("/basalam/order-ids/store", handler.BasalamStoreIDs)
+			r.Get("/basalam/order-ids", handler.BasalamListIDs)
+			r.Delete("/basalam/order-ids", handler.BasalamDeleteIDs)
+
+			r.Post("/admins/authenticate", handler.AuthenticateAdmin)
+			r.Get("/admins", handler.ListAdmins)
+			r.Post("/admins", handler.CreateAdmin)
+			r.Get("/admins/{id}", handler.GetAdmin)
+			r.Get("/admins/{id}/actions", handler.ListAdminActions)
+			r.Patch("/admins/{id}/password", handler.UpdateAdminPassword)
+			r.Post("/admins/me/password", handler.ChangeOwnPassword)
+			r.Patch("/admins/{id}/auto-lock", handler.UpdateAdminAutoLock)
+			r.Patch("/admins/{id}/active", handler.UpdateAdminActive)
+			r.Delete("/admins/{id}", handler.DeleteAdmin)
+
+			r.Post("/actions", handler.LogAction)
+			r.Post("/actions/bulk", handler.LogActionsBulk)
+			r.Get("/actions", handler.ListActions)
+			r.Get("/actions/count", handler.CountActions)
+			r.Get("/actions/export", handler.ExportActions)
+		})
 	})
 
 	return r
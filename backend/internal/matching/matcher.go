@@ -0,0 +1,349 @@
+package matching
+
+import (
+	"math"
+	"sort"
+	"strings"
+)
+
+type Match struct {
+	Index     int
+	Name      string
+	Score     float64
+	Distance  int
+	Exact     bool
+	Ambiguous bool
+}
+
+type candidate struct {
+	index  int
+	name   string
+	runes  []rune
+	tokens map[string]struct{}
+}
+
+// Matcher finds the closest candidate name for a product name, first by
+// normalized equality and then by Levenshtein similarity.
+type Matcher struct {
+	exact      map[string]candidate
+	candidates []candidate
+	byFirst    map[rune][]int
+	byToken    map[string][]int
+}
+
+// NewMatcher indexes names by their normalized form. When several names
+// normalize to the same value the first one wins.
+func NewMatcher(names []string) *Matcher {
+	m := &Matcher{
+		exact:   make(map[string]candidate, len(names)),
+		byFirst: make(map[rune][]int),
+		byToken: make(map[string][]int),
+	}
+	for index, name := range names {
+		normalized := NormalizeName(name)
+		if normalized == "" {
+			continue
+		}
+		if _, exists := m.exact[normalized]; exists {
+			continue
+		}
+		entry := candidate{
+			index:  index,
+			name:   name,
+			runes:  []rune(normalized),
+			tokens: tokenSet(normalized),
+		}
+		m.exact[normalized] = entry
+		position := len(m.candidates)
+		m.candidates = append(m.candidates, entry)
+		first := firstRune(entry.runes)
+		m.byFirst[first] = append(m.byFirst[first], position)
+		for token := range entry.tokens {
+			m.byToken[token] = append(m.byToken[token], position)
+		}
+	}
+	return m
+}
+
+func (m *Matcher) Len() int {
+	return len(m.candidates)
+}
+
+// Best returns the closest candidate scoring at least threshold percent.
+func (m *Matcher) Best(name string, threshold float64) (Match, bool) {
+	normalized := NormalizeName(name)
+	if normalized == "" || len(m.candidates) == 0 {
+		return Match{}, false
+	}
+	if entry, ok := m.exact[normalized]; ok {
+		return Match{Index: entry.index, Name: entry.name, Score: 100, Exact: true}, true
+	}
+	if threshold >= 100 {
+		return Match{}, false
+	}
+
+	target := []rune(normalized)
+	positions := m.byFirst[firstRune(target)]
+	if len(positions) == 0 {
+		positions = make([]int, len(m.candidates))
+		for i := range m.candidates {
+			positions[i] = i
+		}
+	}
+
+	best := Match{Score: -1, Distance: math.MaxInt}
+	found := false
+	for _, position := range positions {
+		entry := m.candidates[position]
+		score, distance, ok := SimilarityPercent(target, entry.runes, threshold)
+		if !ok {
+			continue
+		}
+		if score > best.Score || (score == best.Score && distance < best.Distance) {
+			best = Match{Index: entry.index, Name: entry.name, Score: score, Distance: distance}
+			found = true
+			continue
+		}
+		if score == best.Score && distance == best.Distance {
+			best.Ambiguous = true
+		}
+	}
+	if !found || best.Score < threshold {
+		return Match{}, false
+	}
+	return best, true
+}
+
+// Rank scores every candidate against a search query and returns those at or
+// above threshold, best first. Besides the whole name, the query is compared
+// with each run of consecutive words as long as the query, so a short query
+// with a typo still finds a long product name.
+func (m *Matcher) Rank(query string, threshold float64) []Match {
+	normalized := NormalizeName(query)
+	if normalized == "" {
+		return nil
+	}
+	target := []rune(normalized)
+	width := len(strings.Fields(normalized))
+
+	matches := make([]Match, 0)
+	for _, entry := range m.candidates {
+		best := Match{Index: entry.index, Name: entry.name, Score: -1}
+		if score, distance, ok := SimilarityPercent(target, entry.runes, threshold); ok {
+			best.Score, best.Distance = score, distance
+		}
+		words := strings.Fields(string(entry.runes))
+		for start := 0; start+width <= len(words) && width < len(words); start++ {
+			window := []rune(strings.Join(words[start:start+width], " "))
+			score, distance, ok := SimilarityPercent(target, window, threshold)
+			if ok && score > best.Score {
+				best.Score, best.Distance = score, distance
+			}
+		}
+		if best.Score < threshold {
+			continue
+		}
+		best.Exact = best.Score == 100
+		matches = append(matches, best)
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].Distance < matches[j].Distance
+	})
+	return matches
+}
+
+// BestTokenSet compares word sets instead of characters, so reordered names
+// ("a b c" vs "c a b") still match. Score is the Jaccard index in percent.
+func (m *Matcher) BestTokenSet(name string, threshold float64) (Match, bool) {
+	target := tokenSet(NormalizeName(name))
+	if len(target) == 0 || len(m.candidates) == 0 {
+		return Match{}, false
+	}
+
+	seen := make(map[int]struct{})
+	best := Match{Score: -1}
+	found := false
+	for token := range target {
+		for _, position := range m.byToken[token] {
+			if _, ok := seen[position]; ok {
+				continue
+			}
+			seen[position] = struct{}{}
+			entry := m.candidates[position]
+			score := TokenSetSimilarity(target, entry.tokens)
+			if score < threshold {
+				continue
+			}
+			if score > best.Score {
+				best = Match{Index: entry.index, Name: entry.name, Score: score}
+				found = true
+				continue
+			}
+			if score == best.Score {
+				best.Ambiguous = true
+			}
+		}
+	}
+	if !found {
+		return Match{}, false
+	}
+	best.Exact = best.Score == 100 && !best.Ambiguous
+	return best, true
+}
+
+func TokenSetSimilarity(left map[string]struct{}, right map[string]struct{}) float64 {
+	if len(left) == 0 && len(right) == 0 {
+		return 100.0
+	}
+	shared := 0
+	for token := range left {
+		if _, ok := right[token]; ok {
+			shared++
+		}
+	}
+	union := len(left) + len(right) - shared
+	return 100.0 * float64(shared) / float64(union)
+}
+
+func tokenSet(normalized string) map[string]struct{} {
+	fields := strings.Fields(normalized)
+	tokens := make(map[string]struct{}, len(fields))
+	for _, field := range fields {
+		tokens[field] = struct{}{}
+	}
+	return tokens
+}
+
+func NormalizeName(raw string) string {
+	value := strings.TrimSpace(strings.ToLower(raw))
+	if value == "" {
+		return ""
+	}
+	replacer := strings.NewReplacer(
+		"\u200c", " ", // zwnj
+		"\u200f", " ", // rtl mark
+		"\u200e", " ", // ltr mark
+		"\u064a", "\u06cc", // ي -> ی
+		"\u0643", "\u06a9", // ك -> ک
+		"\u0629", "\u0647", // ة -> ه
+		"،", " ",
+		",", " ",
+		":", " ",
+		";", " ",
+		"/", " ",
+		"\\", " ",
+		"(", " ",
+		")", " ",
+		"[", " ",
+		"]", " ",
+		"{", " ",
+		"}", " ",
+		"-", " ",
+		"_", " ",
+		"+", " ",
+	)
+	value = replacer.Replace(value)
+	return strings.Join(strings.Fields(value), " ")
+}
+
+func SimilarityPercent(
+	left []rune,
+	right []rune,
+	threshold float64,
+) (float64, int, bool) {
+	maxLen := len(left)
+	if len(right) > maxLen {
+		maxLen = len(right)
+	}
+	if maxLen == 0 {
+		return 100.0, 0, true
+	}
+	if threshold >= 100 {
+		if string(left) == string(right) {
+			return 100.0, 0, true
+		}
+		return 0, 1, false
+	}
+	maxDistance := int(math.Floor((100.0 - threshold) * float64(maxLen) / 100.0))
+	if maxDistance < 1 {
+		maxDistance = 1
+	}
+	if abs(len(left)-len(right)) > maxDistance {
+		return 0, 0, false
+	}
+	distance, ok := levenshteinWithin(left, right, maxDistance)
+	if !ok {
+		return 0, distance, false
+	}
+	score := 100.0 * (1.0 - (float64(distance) / float64(maxLen)))
+	return score, distance, score >= threshold
+}
+
+func firstRune(chars []rune) rune {
+	if len(chars) == 0 {
+		return rune(0)
+	}
+	return chars[0]
+}
+
+func levenshteinWithin(left []rune, right []rune, maxDistance int) (int, bool) {
+	leftLen := len(left)
+	rightLen := len(right)
+	if leftLen == 0 {
+		return rightLen, rightLen <= maxDistance
+	}
+	if rightLen == 0 {
+		return leftLen, leftLen <= maxDistance
+	}
+	if abs(leftLen-rightLen) > maxDistance {
+		return maxDistance + 1, false
+	}
+
+	prev := make([]int, rightLen+1)
+	curr := make([]int, rightLen+1)
+	for j := 0; j <= rightLen; j++ {
+		prev[j] = j
+	}
+
+	for i := 1; i <= leftLen; i++ {
+		start := max(1, i-maxDistance)
+		end := min(rightLen, i+maxDistance)
+		curr[0] = i
+		rowMin := curr[0]
+		for j := 1; j < start; j++ {
+			curr[j] = maxDistance + 1
+		}
+		for j := start; j <= end; j++ {
+			cost := 1
+			if left[i-1] == right[j-1] {
+				cost = 0
+			}
+			deletion := prev[j] + 1
+			insertion := curr[j-1] + 1
+			substitution := prev[j-1] + cost
+			curr[j] = min(deletion, min(insertion, substitution))
+			if curr[j] < rowMin {
+				rowMin = curr[j]
+			}
+		}
+		for j := end + 1; j <= rightLen; j++ {
+			curr[j] = maxDistance + 1
+		}
+		if rowMin > maxDistance {
+			return rowMin, false
+		}
+		prev, curr = curr, prev
+	}
+	distance := prev[rightLen]
+	return distance, distance <= maxDistance
+}
+
+func abs(value int) int {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
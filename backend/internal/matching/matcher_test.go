@@ -0,0 +1,109 @@
+package matching
+
+import (
+	"math"
+	"testing"
+)
+
+func TestTokenSetSimilarity(t *testing.T) {
+	tests := []struct {
+		name        string
+		left, right string
+		want        float64
+	}{
+		{name: "both empty", left: "", right: "", want: 100},
+		{name: "one empty", left: "a", right: "", want: 0},
+		{name: "same words reordered", left: "a b c", right: "c a b", want: 100},
+		{name: "one shared of three", left: "a b", right: "b c", want: 100.0 / 3},
+		{name: "subset", left: "a b", right: "a b c d", want: 50},
+		{name: "disjoint", left: "a b", right: "c d", want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := TokenSetSimilarity(tokenSet(tt.left), tokenSet(tt.right))
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Fatalf("TokenSetSimilarity(%q, %q) = %v, want %v", tt.left, tt.right, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBestTokenSet(t *testing.T) {
+	m := NewMatcher([]string{"red cotton shirt", "blue cotton shirt", "black hat"})
+	tests := []struct {
+		name          string
+		query         string
+		threshold     float64
+		wantFound     bool
+		wantName      string
+		wantExact     bool
+		wantAmbiguous bool
+	}{
+		{name: "reordered words", query: "shirt cotton red", threshold: 80, wantFound: true, wantName: "red cotton shirt", wantExact: true},
+		{name: "punctuation and case", query: "Shirt-Red (Cotton)", threshold: 80, wantFound: true, wantName: "red cotton shirt", wantExact: true},
+		{name: "tie is ambiguous", query: "cotton shirt", threshold: 60, wantFound: true, wantExact: false, wantAmbiguous: true},
+		{name: "below threshold", query: "cotton shirt", threshold: 70},
+		{name: "one shared word is not enough", query: "green hat", threshold: 50},
+		{name: "no shared word", query: "wool socks", threshold: 10},
+		{name: "empty query", query: "  ", threshold: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, found := m.BestTokenSet(tt.query, tt.threshold)
+			if found != tt.wantFound {
+				t.Fatalf("BestTokenSet(%q) found = %v, want %v (%+v)", tt.query, found, tt.wantFound, got)
+			}
+			if !found {
+				return
+			}
+			if tt.wantName != "" && got.Name != tt.wantName {
+				t.Errorf("name = %q, want %q", got.Name, tt.wantName)
+			}
+			if got.Exact != tt.wantExact {
+				t.Errorf("exact = %v, want %v", got.Exact, tt.wantExact)
+			}
+			if got.Ambiguous != tt.wantAmbiguous {
+				t.Errorf("ambiguous = %v, want %v", got.Ambiguous, tt.wantAmbiguous)
+			}
+		})
+	}
+}
+
+func TestRank(t *testing.T) {
+	// The repeated name is kept once, so it must not show up twice below.
+	m := NewMatcher([]string{"Samsung Galaxy S21 Case", "Apple iPhone 13 Case", "Samsung Charger", "Samsung Charger"})
+	tests := []struct {
+		name      string
+		query     string
+		threshold float64
+		want      []string
+		wantExact []bool
+	}{
+		{name: "typo in one word", query: "samsng", threshold: 70, want: []string{"Samsung Galaxy S21 Case", "Samsung Charger"}, wantExact: []bool{false, false}},
+		{name: "run of words", query: "Galaxy S21 case", threshold: 80, want: []string{"Samsung Galaxy S21 Case"}, wantExact: []bool{true}},
+		{name: "exact before close", query: "samsung charger", threshold: 60, want: []string{"Samsung Charger", "Samsung Galaxy S21 Case"}, wantExact: []bool{true, false}},
+		{name: "exact only", query: "iphone", threshold: 100, want: []string{"Apple iPhone 13 Case"}, wantExact: []bool{true}},
+		{name: "shared word ranks by score", query: "case", threshold: 100, want: []string{"Samsung Galaxy S21 Case", "Apple iPhone 13 Case"}, wantExact: []bool{true, true}},
+		{name: "nothing close", query: "keyboard", threshold: 70},
+		{name: "empty query", query: "  ", threshold: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := m.Rank(tt.query, tt.threshold)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Rank(%q) = %+v, want %q", tt.query, got, tt.want)
+			}
+			for i, match := range got {
+				if match.Name != tt.want[i] || match.Exact != tt.wantExact[i] {
+					t.Errorf("match %d = %q (exact %v), want %q (exact %v)", i, match.Name, match.Exact, tt.want[i], tt.wantExact[i])
+				}
+				if match.Score < tt.threshold {
+					t.Errorf("match %d score %v below threshold %v", i, match.Score, tt.threshold)
+				}
+				if i > 0 && match.Score > got[i-1].Score {
+					t.Errorf("match %d score %v above previous %v", i, match.Score, got[i-1].Score)
+				}
+			}
+		})
+	}
+}
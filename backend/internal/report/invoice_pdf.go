@@ -0,0 +1,134 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"backend/internal/domain"
+
+	"github.com/go-pdf/fpdf"
+)
+
+type Options struct {
+	// FontPath points to a TTF font with Persian glyphs (for example
+	// Vazirmatn or DejaVu Sans). Without it the core Helvetica font is used
+	// and non-Latin text cannot be printed.
+	FontPath string
+}
+
+const unicodeFontFamily = "invoice"
+
+// RenderInvoicePDF writes an A4 invoice with its header, lines and totals.
+func RenderInvoicePDF(w io.Writer, invoice domain.Invoice, lines []domain.InvoiceLine, opts Options) error {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(12, 12, 12)
+	pdf.SetAutoPageBreak(true, 15)
+
+	family := "Helvetica"
+	text := pdf.UnicodeTranslatorFromDescriptor("")
+	if opts.FontPath != "" {
+		pdf.AddUTF8Font(unicodeFontFamily, "", opts.FontPath)
+		if err := pdf.Error(); err != nil {
+			return fmt.Errorf("load report font: %w", err)
+		}
+		family = unicodeFontFamily
+		text = visualText
+	}
+
+	pdf.AddPage()
+	pdf.SetFont(family, "", 16)
+	pdf.CellFormat(0, 10, fmt.Sprintf("Invoice #%d", invoice.ID), "", 1, "L", false, 0, "")
+
+	pdf.SetFont(family, "", 10)
+	header := [][2]string{
+		{"Type", invoice.InvoiceType},
+		{"Date", invoice.CreatedAt.Format("2006-01-02 15:04")},
+	}
+	if invoice.InvoiceName != nil {
+		header = append(header, [2]string{"Name", *invoice.InvoiceName})
+	}
+	if invoice.AdminUsername != nil {
+		header = append(header, [2]string{"Admin", *invoice.AdminUsername})
+	}
+	for _, field := range header {
+		pdf.CellFormat(25, 6, field[0]+":", "", 0, "L", false, 0, "")
+		pdf.CellFormat(0, 6, text(field[1]), "", 1, alignFor(field[1]), false, 0, "")
+	}
+	pdf.Ln(4)
+
+	widths := []float64{10, 86, 20, 35, 35}
+	pdf.SetFillColor(230, 230, 230)
+	for i, title := range []string{"#", "Product", "Qty", "Price", "Line total"} {
+		pdf.CellFormat(widths[i], 7, title, "1", 0, "C", true, 0, "")
+	}
+	pdf.Ln(-1)
+
+	subtotal := 0.0
+	for index, line := range lines {
+		subtotal += line.LineTotal
+		pdf.CellFormat(widths[0], 7, strconv.Itoa(index+1), "1", 0, "C", false, 0, "")
+		pdf.CellFormat(widths[1], 7, text(line.ProductName), "1", 0, alignFor(line.ProductName), false, 0, "")
+		pdf.CellFormat(widths[2], 7, strconv.FormatFloat(line.Quantity, 'f', -1, 64), "1", 0, "R", false, 0, "")
+		pdf.CellFormat(widths[3], 7, formatAmount(line.Price), "1", 0, "R", false, 0, "")
+		pdf.CellFormat(widths[4], 7, formatAmount(line.LineTotal), "1", 1, "R", false, 0, "")
+	}
+
+	pdf.Ln(3)
+	labelWidth := widths[0] + widths[1] + widths[2] + widths[3]
+	totals := [][2]string{{"Subtotal", formatAmount(subtotal)}}
+	if invoice.DiscountAmount != nil && *invoice.DiscountAmount != 0 {
+		totals = append(totals, [2]string{"Discount", "-" + formatAmount(*invoice.DiscountAmount)})
+	}
+	if invoice.TaxAmount != nil && *invoice.TaxAmount != 0 {
+		totals = append(totals, [2]string{"Tax", formatAmount(*invoice.TaxAmount)})
+	}
+	totals = append(totals, [2]string{"Total", formatAmount(invoice.TotalAmount)})
+	for _, total := range totals {
+		pdf.CellFormat(labelWidth, 7, total[0], "", 0, "R", false, 0, "")
+		pdf.CellFormat(widths[4], 7, total[1], "1", 1, "R", false, 0, "")
+	}
+
+	if err := pdf.Output(w); err != nil {
+		return fmt.Errorf("write invoice pdf: %w", err)
+	}
+	return nil
+}
+
+func alignFor(value string) string {
+	if containsRTL(value) {
+		return "R"
+	}
+	return "L"
+}
+
+// formatAmount renders a number with thousands separators and at most two
+// decimals, dropping them for whole amounts.
+func formatAmount(value float64) string {
+	sign := ""
+	if value < 0 {
+		sign = "-"
+		value = -value
+	}
+	whole := math.Floor(value)
+	fraction := math.Round((value - whole) * 100)
+	if fraction >= 100 {
+		whole++
+		fraction = 0
+	}
+
+	digits := strconv.FormatFloat(whole, 'f', 0, 64)
+	var grouped strings.Builder
+	for i, digit := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			grouped.WriteByte(',')
+		}
+		grouped.WriteRune(digit)
+	}
+	if fraction > 0 {
+		return fmt.Sprintf("%s%s.%02d", sign, grouped.String(), int(fraction))
+	}
+	return sign + grouped.String()
+}
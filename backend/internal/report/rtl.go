@@ -0,0 +1,210 @@
+package report
+
+import (
+	"strings"
+	"unicode"
+)
+
+// arabicForms holds the presentation forms of a letter in the order
+// isolated, final, initial, medial. Letters that never join the following
+// letter only have the first two.
+var arabicForms = map[rune][]rune{
+	'آ': {0xFE81, 0xFE82},
+	'أ': {0xFE83, 0xFE84},
+	'ؤ': {0xFE85, 0xFE86},
+	'إ': {0xFE87, 0xFE88},
+	'ئ': {0xFE89, 0xFE8A, 0xFE8B, 0xFE8C},
+	'ا': {0xFE8D, 0xFE8E},
+	'ب': {0xFE8F, 0xFE90, 0xFE91, 0xFE92},
+	'ة': {0xFE93, 0xFE94},
+	'ت': {0xFE95, 0xFE96, 0xFE97, 0xFE98},
+	'ث': {0xFE99, 0xFE9A, 0xFE9B, 0xFE9C},
+	'ج': {0xFE9D, 0xFE9E, 0xFE9F, 0xFEA0},
+	'ح': {0xFEA1, 0xFEA2, 0xFEA3, 0xFEA4},
+	'خ': {0xFEA5, 0xFEA6, 0xFEA7, 0xFEA8},
+	'د': {0xFEA9, 0xFEAA},
+	'ذ': {0xFEAB, 0xFEAC},
+	'ر': {0xFEAD, 0xFEAE},
+	'ز': {0xFEAF, 0xFEB0},
+	'س': {0xFEB1, 0xFEB2, 0xFEB3, 0xFEB4},
+	'ش': {0xFEB5, 0xFEB6, 0xFEB7, 0xFEB8},
+	'ص': {0xFEB9, 0xFEBA, 0xFEBB, 0xFEBC},
+	'ض': {0xFEBD, 0xFEBE, 0xFEBF, 0xFEC0},
+	'ط': {0xFEC1, 0xFEC2, 0xFEC3, 0xFEC4},
+	'ظ': {0xFEC5, 0xFEC6, 0xFEC7, 0xFEC8},
+	'ع': {0xFEC9, 0xFECA, 0xFECB, 0xFECC},
+	'غ': {0xFECD, 0xFECE, 0xFECF, 0xFED0},
+	'ف': {0xFED1, 0xFED2, 0xFED3, 0xFED4},
+	'ق': {0xFED5, 0xFED6, 0xFED7, 0xFED8},
+	'ك': {0xFED9, 0xFEDA, 0xFEDB, 0xFEDC},
+	'ل': {0xFEDD, 0xFEDE, 0xFEDF, 0xFEE0},
+	'م': {0xFEE1, 0xFEE2, 0xFEE3, 0xFEE4},
+	'ن': {0xFEE5, 0xFEE6, 0xFEE7, 0xFEE8},
+	'ه': {0xFEE9, 0xFEEA, 0xFEEB, 0xFEEC},
+	'و': {0xFEED, 0xFEEE},
+	'ى': {0xFEEF, 0xFEF0},
+	'ي': {0xFEF1, 0xFEF2, 0xFEF3, 0xFEF4},
+	'پ': {0xFB56, 0xFB57, 0xFB58, 0xFB59},
+	'چ': {0xFB7A, 0xFB7B, 0xFB7C, 0xFB7D},
+	'ژ': {0xFB8A, 0xFB8B},
+	'ک': {0xFB8E, 0xFB8F, 0xFB90, 0xFB91},
+	'گ': {0xFB92, 0xFB93, 0xFB94, 0xFB95},
+	'ی': {0xFBFC, 0xFBFD, 0xFBFE, 0xFBFF},
+}
+
+// lamAlefForms maps the alef variant following a lam to the isolated and
+// final forms of the combined ligature.
+var lamAlefForms = map[rune][]rune{
+	'آ': {0xFEF5, 0xFEF6},
+	'أ': {0xFEF7, 0xFEF8},
+	'إ': {0xFEF9, 0xFEFA},
+	'ا': {0xFEFB, 0xFEFC},
+}
+
+var mirroredRunes = map[rune]rune{
+	'(': ')', ')': '(',
+	'[': ']', ']': '[',
+	'{': '}', '}': '{',
+	'<': '>', '>': '<',
+}
+
+// visualText prepares text for a PDF writer that only lays glyphs out left
+// to right: Persian/Arabic letters are replaced by their joined presentation
+// forms and right-to-left runs are reversed. Latin words and digits keep
+// their order. Text without right-to-left letters is returned unchanged.
+func visualText(text string) string {
+	if !containsRTL(text) {
+		return text
+	}
+	// ZWNJ only matters while shaping; fonts rarely carry a glyph for it.
+	runes := []rune(strings.ReplaceAll(string(shapeArabic([]rune(text))), "\u200c", ""))
+
+	const (
+		neutral = iota
+		ltr
+		rtl
+	)
+	strong := make([]int, len(runes))
+	for i, ch := range runes {
+		switch {
+		case isRTLRune(ch):
+			strong[i] = rtl
+		case unicode.IsLetter(ch) || unicode.IsDigit(ch):
+			strong[i] = ltr
+		}
+	}
+	// Neutrals take the paragraph direction (right to left) unless they sit
+	// between two left-to-right characters.
+	direction := make([]int, len(runes))
+	for i := range runes {
+		if strong[i] != neutral {
+			direction[i] = strong[i]
+			continue
+		}
+		before, after := rtl, rtl
+		for j := i - 1; j >= 0; j-- {
+			if strong[j] != neutral {
+				before = strong[j]
+				break
+			}
+		}
+		for j := i + 1; j < len(runes); j++ {
+			if strong[j] != neutral {
+				after = strong[j]
+				break
+			}
+		}
+		direction[i] = rtl
+		if before == ltr && after == ltr {
+			direction[i] = ltr
+		}
+	}
+
+	var out strings.Builder
+	end := len(runes)
+	for end > 0 {
+		start := end - 1
+		for start > 0 && direction[start-1] == direction[end-1] {
+			start--
+		}
+		if direction[start] == ltr {
+			out.WriteString(string(runes[start:end]))
+		} else {
+			for i := end - 1; i >= start; i-- {
+				ch := runes[i]
+				if mirrored, ok := mirroredRunes[ch]; ok {
+					ch = mirrored
+				}
+				out.WriteRune(ch)
+			}
+		}
+		end = start
+	}
+	return out.String()
+}
+
+func shapeArabic(runes []rune) []rune {
+	shaped := make([]rune, 0, len(runes))
+	for i := 0; i < len(runes); i++ {
+		forms, ok := arabicForms[runes[i]]
+		if !ok {
+			shaped = append(shaped, runes[i])
+			continue
+		}
+		joinsPrev := i > 0 && joinsForward(runes[i-1])
+		if runes[i] == 'ل' && i+1 < len(runes) {
+			if ligature, ok := lamAlefForms[runes[i+1]]; ok {
+				if joinsPrev {
+					shaped = append(shaped, ligature[1])
+				} else {
+					shaped = append(shaped, ligature[0])
+				}
+				i++
+				continue
+			}
+		}
+		nextIsLetter := false
+		if i+1 < len(runes) {
+			_, nextIsLetter = arabicForms[runes[i+1]]
+		}
+		joinsNext := len(forms) == 4 && nextIsLetter
+		switch {
+		case joinsPrev && joinsNext:
+			shaped = append(shaped, forms[3])
+		case joinsNext:
+			shaped = append(shaped, forms[2])
+		case joinsPrev:
+			shaped = append(shaped, forms[1])
+		default:
+			shaped = append(shaped, forms[0])
+		}
+	}
+	return shaped
+}
+
+func joinsForward(ch rune) bool {
+	forms, ok := arabicForms[ch]
+	return ok && len(forms) == 4
+}
+
+func containsRTL(text string) bool {
+	for _, ch := range text {
+		if isRTLRune(ch) {
+			return true
+		}
+	}
+	return false
+}
+
+func isRTLRune(ch rune) bool {
+	switch {
+	case ch >= 0x0660 && ch <= 0x0669, ch >= 0x06F0 && ch <= 0x06F9:
+		return false
+	case ch >= 0x0600 && ch <= 0x06FF,
+		ch >= 0x0750 && ch <= 0x077F,
+		ch >= 0xFB50 && ch <= 0xFDFF,
+		ch >= 0xFE70 && ch <= 0xFEFF:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestDeleteActionsBefore runs on an empty schema. The old rows outnumber one
+// batch so the loop has to come back for the rest, and a row stamped exactly
+// at the cutoff must survive.
+func TestDeleteActionsBefore(t *testing.T) {
+	repo := testEmptyRepository(t)
+	ctx := context.Background()
+
+	cutoff := time.Now().Add(-30 * 24 * time.Hour).Truncate(time.Microsecond)
+	old := deleteActionsBatchSize + 1
+	if _, err := repo.pool.Exec(ctx, `
+		INSERT INTO actions (created_at, action_type, title, details)
+		SELECT $1::timestamptz - (n * INTERVAL '1 minute'), 'test', 'old action', '-'
+		FROM generate_series(1, $2::int) AS n
+	`, cutoff, old); err != nil {
+		t.Fatalf("seed old actions: %v", err)
+	}
+	if _, err := repo.LogActions(ctx, []ActionInput{
+		{ActionType: "test", Title: "at cutoff"},
+		{ActionType: "test", Title: "new action"},
+	}); err != nil {
+		t.Fatalf("log actions: %v", err)
+	}
+	if _, err := repo.pool.Exec(ctx, "UPDATE actions SET created_at = $1 WHERE title = 'at cutoff'", cutoff); err != nil {
+		t.Fatalf("stamp cutoff action: %v", err)
+	}
+
+	deleted, err := repo.DeleteActionsBefore(ctx, cutoff)
+	if err != nil {
+		t.Fatalf("DeleteActionsBefore: %v", err)
+	}
+	if deleted != old {
+		t.Fatalf("deleted %d actions, want %d", deleted, old)
+	}
+	items, _, err := repo.ListActions(ctx, 10, 0, ActionFilter{})
+	if err != nil {
+		t.Fatalf("ListActions: %v", err)
+	}
+	got := make([]string, len(items))
+	for i, item := range items {
+		got[i] = item.Title
+	}
+	if want := []string{"new action", "at cutoff"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("remaining = %v, want %v", got, want)
+	}
+
+	deleted, err = repo.DeleteActionsBefore(ctx, cutoff)
+	if err != nil || deleted != 0 {
+		t.Fatalf("second run deleted %d, %v; want 0", deleted, err)
+	}
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestActionWhere(t *testing.T) {
+	from := time.Date(2025, time.May, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, time.May, 8, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		filter    ActionFilter
+		wantWhere string
+		wantArgs  []any
+	}{
+		{name: "no filter", filter: ActionFilter{Search: "  "}, wantWhere: "TRUE", wantArgs: []any{}},
+		{
+			name:      "type and range",
+			filter:    ActionFilter{ActionType: " stock_adjustment ", From: &from, To: &to},
+			wantWhere: "TRUE AND action_type = $1 AND created_at >= $2 AND created_at <= $3",
+			wantArgs:  []any{"stock_adjustment", from, to},
+		},
+		{
+			name:      "search keeps its placeholder first",
+			filter:    ActionFilter{Search: "rice", ActionType: "ui"},
+			wantWhere: "TRUE AND (title ILIKE '%' || $1 || '%' OR details ILIKE '%' || $1 || '%' OR COALESCE(admin_username, '') ILIKE '%' || $1 || '%') AND action_type = $2",
+			wantArgs:  []any{"rice", "ui"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			where, args := actionWhere(tt.filter)
+			if where != tt.wantWhere {
+				t.Errorf("where = %q, want %q", where, tt.wantWhere)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("args = %v, want %v", args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+// TestActionFilter runs on an empty schema: two actions are moved back ten
+// days and the list and count must agree on every combination.
+func TestActionFilter(t *testing.T) {
+	repo := testEmptyRepository(t)
+	ctx := context.Background()
+
+	if _, err := repo.LogActions(ctx, []ActionInput{
+		{ActionType: "stock_adjustment", Title: "old rice adjustment"},
+		{ActionType: "stock_adjustment", Title: "new rice adjustment"},
+		{ActionType: "ui", Title: "old rice view"},
+		{ActionType: "ui", Title: "new tea view"},
+	}); err != nil {
+		t.Fatalf("log actions: %v", err)
+	}
+	if _, err := repo.pool.Exec(ctx, "UPDATE actions SET created_at = NOW() - INTERVAL '10 days' WHERE title LIKE 'old %'"); err != nil {
+		t.Fatalf("backdate actions: %v", err)
+	}
+
+	lastWeek := time.Now().Add(-7 * 24 * time.Hour)
+	tests := []struct {
+		name   string
+		filter ActionFilter
+		want   []string
+	}{
+		{name: "no filter", want: []string{"new tea view", "old rice view", "new rice adjustment", "old rice adjustment"}},
+		{name: "type", filter: ActionFilter{ActionType: "stock_adjustment"}, want: []string{"new rice adjustment", "old rice adjustment"}},
+		{name: "type is exact", filter: ActionFilter{ActionType: "stock"}, want: []string{}},
+		{name: "from", filter: ActionFilter{From: &lastWeek}, want: []string{"new tea view", "new rice adjustment"}},
+		{name: "to", filter: ActionFilter{To: &lastWeek}, want: []string{"old rice view", "old rice adjustment"}},
+		{name: "type and from", filter: ActionFilter{ActionType: "stock_adjustment", From: &lastWeek}, want: []string{"new rice adjustment"}},
+		{name: "search and to", filter: ActionFilter{Search: "RICE", To: &lastWeek}, want: []string{"old rice view", "old rice adjustment"}},
+		{name: "search, type and from", filter: ActionFilter{Search: "rice", ActionType: "ui", From: &lastWeek}, want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items, _, err := repo.ListActions(ctx, 10, 0, tt.filter)
+			if err != nil {
+				t.Fatalf("ListActions: %v", err)
+			}
+			got := make([]string, len(items))
+			for i, item := range items {
+				got[i] = item.Title
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("titles = %v, want %v", got, tt.want)
+			}
+			count, err := repo.CountActions(ctx, tt.filter)
+			if err != nil {
+				t.Fatalf("CountActions: %v", err)
+			}
+			if count != len(tt.want) {
+				t.Fatalf("count = %d, want %d", count, len(tt.want))
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"backend/internal/domain"
+)
+
+// TestSetAdminActive disables an admin who already has an invoice and an
+// action: they can no longer authenticate, but both records keep their name.
+func TestSetAdminActive(t *testing.T) {
+	repo := testRepository(t)
+	ctx := context.Background()
+
+	username := fmt.Sprintf("departed%d", time.Now().UnixNano())
+	admin, err := repo.CreateAdmin(ctx, username, "pass1234", "employee", 5)
+	if err != nil {
+		t.Fatalf("create admin: %v", err)
+	}
+	if !admin.Active {
+		t.Fatal("new admin is not active")
+	}
+	invoiceID, err := repo.CreatePurchaseInvoice(ctx, nil, &username, nil, []domain.PurchaseLineInput{
+		{ProductName: username + " stock", Price: 10, Quantity: 1},
+	}, InvoiceAdjustments{}, InvoiceStatusFinalized)
+	if err != nil {
+		t.Fatalf("create invoice: %v", err)
+	}
+	if err := repo.LogAction(ctx, "ui", username+" action", "-", &username); err != nil {
+		t.Fatalf("log action: %v", err)
+	}
+
+	listed := func(includeInactive bool) *domain.AdminUser {
+		t.Helper()
+		admins, err := repo.ListAdmins(ctx, includeInactive)
+		if err != nil {
+			t.Fatalf("ListAdmins(%v): %v", includeInactive, err)
+		}
+		for i := range admins {
+			if admins[i].Username == username {
+				return &admins[i]
+			}
+		}
+		return nil
+	}
+
+	if err := repo.SetAdminActive(ctx, admin.AdminID, false); err != nil {
+		t.Fatalf("disable: %v", err)
+	}
+	if got, err := repo.AuthenticateAdmin(ctx, username, "pass1234"); err != nil || got != nil {
+		t.Fatalf("disabled admin authenticated: %+v, %v", got, err)
+	}
+	if got := listed(false); got != nil {
+		t.Fatalf("disabled admin listed without include_inactive: %+v", got)
+	}
+	if got := listed(true); got == nil || got.Active {
+		t.Fatalf("disabled admin with include_inactive = %+v, want listed and inactive", got)
+	}
+
+	invoice, err := repo.GetInvoice(ctx, invoiceID)
+	if err != nil {
+		t.Fatalf("GetInvoice: %v", err)
+	}
+	if invoice.AdminUsername == nil || *invoice.AdminUsername != username {
+		t.Fatalf("invoice admin_username = %v, want %q", invoice.AdminUsername, username)
+	}
+	actions, err := repo.ListActionsByAdmin(ctx, username, 10, 0)
+	if err != nil {
+		t.Fatalf("ListActionsByAdmin: %v", err)
+	}
+	if len(actions) == 0 {
+		t.Fatal("disabled admin's actions are gone")
+	}
+
+	if err := repo.SetAdminActive(ctx, admin.AdminID, true); err != nil {
+		t.Fatalf("re-enable: %v", err)
+	}
+	if got, err := repo.AuthenticateAdmin(ctx, username, "pass1234"); err != nil || got == nil {
+		t.Fatalf("re-enabled admin did not authenticate: %+v, %v", got, err)
+	}
+
+	if err := repo.SetAdminActive(ctx, 1<<62, false); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("missing admin: error = %v, want ErrNotFound", err)
+	}
+}
+
+// TestAdminLastLogin checks last_login_at only moves on a successful login.
+func TestAdminLastLogin(t *testing.T) {
+	repo := testRepository(t)
+	ctx := context.Background()
+
+	username := fmt.Sprintf("login%d", time.Now().UnixNano())
+	admin, err := repo.CreateAdmin(ctx, username, "pass1234", "employee", 5)
+	if err != nil {
+		t.Fatalf("create admin: %v", err)
+	}
+	lastLogin := func(step string) *time.Time {
+		t.Helper()
+		got, err := repo.GetAdminByID(ctx, admin.AdminID)
+		if err != nil {
+			t.Fatalf("%s: GetAdminByID: %v", step, err)
+		}
+		return got.LastLoginAt
+	}
+	if got := lastLogin("before any login"); got != nil {
+		t.Fatalf("new admin last_login_at = %v, want nil", got)
+	}
+
+	login := func(step string) time.Time {
+		t.Helper()
+		got, err := repo.AuthenticateAdmin(ctx, username, "pass1234")
+		if err != nil || got == nil || got.LastLoginAt == nil {
+			t.Fatalf("%s: AuthenticateAdmin = %+v, %v", step, got, err)
+		}
+		stored := lastLogin(step)
+		if stored == nil || !stored.Equal(*got.LastLoginAt) {
+			t.Fatalf("%s: stored last_login_at = %v, returned %v", step, stored, got.LastLoginAt)
+		}
+		return *stored
+	}
+	first := login("first login")
+	time.Sleep(10 * time.Millisecond)
+	second := login("second login")
+	if !second.After(first) {
+		t.Fatalf("second login at %v is not after the first at %v", second, first)
+	}
+
+	if got, err := repo.AuthenticateAdmin(ctx, username, "wrong1234"); err != nil || got != nil {
+		t.Fatalf("wrong password authenticated: %+v, %v", got, err)
+	}
+	if got := lastLogin("after a failed login"); got == nil || !got.Equal(second) {
+		t.Fatalf("failed login moved last_login_at to %v, want %v", got, second)
+	}
+}
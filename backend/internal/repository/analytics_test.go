@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"backend/internal/domain"
+)
+
+func TestRevenuePareto(t *testing.T) {
+	repo := testRepository(t)
+	ctx := context.Background()
+
+	suffix := time.Now().UnixNano()
+	big := fmt.Sprintf("pareto big %d", suffix)
+	small := fmt.Sprintf("pareto small %d", suffix)
+	for _, name := range []string{big, small} {
+		if _, err := repo.CreateProduct(ctx, ProductCreateInput{ProductName: name, Quantity: 100, AvgBuyPrice: 1, SellPrice: 10}); err != nil {
+			t.Fatalf("create product %q: %v", name, err)
+		}
+	}
+	invoices := []struct {
+		status string
+		lines  []domain.SalesLineInput
+	}{
+		{InvoiceStatusFinalized, []domain.SalesLineInput{
+			{ProductName: big, Price: 100, Quantity: 3},
+			{ProductName: small, Price: 100, Quantity: 1},
+		}},
+		// Drafts must not count towards revenue.
+		{InvoiceStatusDraft, []domain.SalesLineInput{
+			{ProductName: small, Price: 100, Quantity: 50},
+		}},
+	}
+	for _, invoice := range invoices {
+		if _, err := repo.CreateSalesInvoice(ctx, nil, nil, nil, "sales", invoice.lines, InvoiceAdjustments{}, invoice.status, false); err != nil {
+			t.Fatalf("create %s invoice: %v", invoice.status, err)
+		}
+	}
+
+	rows, err := repo.GetRevenuePareto(ctx, 0)
+	if err != nil {
+		t.Fatalf("GetRevenuePareto: %v", err)
+	}
+	if len(rows) == 0 {
+		t.Fatal("no rows")
+	}
+	revenue := map[string]float64{}
+	share := 0.0
+	for i, row := range rows {
+		revenue[row.ProductName] = row.Revenue
+		share += row.SharePercent
+		if i > 0 {
+			if row.Revenue > rows[i-1].Revenue {
+				t.Errorf("row %d revenue %v above previous %v", i, row.Revenue, rows[i-1].Revenue)
+			}
+			if row.CumulativePercent < rows[i-1].CumulativePercent {
+				t.Errorf("row %d cumulative %v below previous %v", i, row.CumulativePercent, rows[i-1].CumulativePercent)
+			}
+		}
+	}
+	if last := rows[len(rows)-1].CumulativePercent; last != 100 {
+		t.Errorf("last cumulative = %v, want 100", last)
+	}
+	if math.Abs(share-100) > 1e-6 {
+		t.Errorf("shares sum to %v, want 100", share)
+	}
+	for name, want := range map[string]float64{big: 300, small: 100} {
+		if got := revenue[name]; got != want {
+			t.Errorf("%q revenue = %v, want %v", name, got, want)
+		}
+	}
+}
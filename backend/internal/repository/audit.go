@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	invoiceCreateActionType = "invoice_create"
+	invoiceUpdateActionType = "invoice_update"
+	invoiceDeleteActionType = "invoice_delete"
+)
+
+type adminUsernameKey struct{}
+
+// WithAdminUsername records the admin acting on behalf of ctx. Mutating
+// repository calls attribute the actions rows they write to this admin.
+func WithAdminUsername(ctx context.Context, username string) context.Context {
+	username = strings.TrimSpace(username)
+	if username == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, adminUsernameKey{}, username)
+}
+
+// AdminUsernameFromContext returns the admin stored by WithAdminUsername, or
+// nil when the call is not attributed to anyone.
+func AdminUsernameFromContext(ctx context.Context) *string {
+	username, ok := ctx.Value(adminUsernameKey{}).(string)
+	if !ok {
+		return nil
+	}
+	return &username
+}
+
+// actingAdmin prefers an admin passed explicitly with the request body over
+// the one carried by ctx.
+func actingAdmin(ctx context.Context, explicit *string) *string {
+	if explicit != nil {
+		return explicit
+	}
+	return AdminUsernameFromContext(ctx)
+}
+
+func insertActionTx(
+	ctx context.Context,
+	tx pgx.Tx,
+	adminUsername *string,
+	actionType, title, details string,
+) error {
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO actions (
+			admin_username,
+			action_type,
+			title,
+			details
+		) VALUES ($1, $2, $3, $4)
+	`, adminUsername, actionType, title, details); err != nil {
+		return fmt.Errorf("log %s action: %w", actionType, err)
+	}
+	return nil
+}
+
+// logInvoiceActionTx writes an actions row describing the current state of
+// an invoice. Delete paths must call it before the row is removed.
+func logInvoiceActionTx(
+	ctx context.Context,
+	tx pgx.Tx,
+	actionType string,
+	invoiceID int64,
+	adminUsername *string,
+) error {
+	var (
+		invoiceType string
+		status      string
+		totalLines  int
+		totalQty    float64
+		totalAmount float64
+	)
+	if err := tx.QueryRow(ctx, `
+		SELECT
+			invoice_type,
+			status,
+			total_lines,
+			total_qty::double precision,
+			total_amount::double precision
+		FROM invoices
+		WHERE id = $1
+	`, invoiceID).Scan(&invoiceType, &status, &totalLines, &totalQty, &totalAmount); err != nil {
+		return fmt.Errorf("load invoice %d for action log: %w", invoiceID, err)
+	}
+
+	var title string
+	switch actionType {
+	case invoiceCreateActionType:
+		title = fmt.Sprintf("Invoice %d created", invoiceID)
+	case invoiceDeleteActionType:
+		title = fmt.Sprintf("Invoice %d deleted", invoiceID)
+	default:
+		title = fmt.Sprintf("Invoice %d updated", invoiceID)
+	}
+	details := fmt.Sprintf(
+		"invoice_id: %d\ninvoice_type: %s\nstatus: %s\ntotal_lines: %d\ntotal_qty: %s\ntotal_amount: %s",
+		invoiceID,
+		invoiceType,
+		status,
+		totalLines,
+		formatQuantity(totalQty),
+		formatQuantity(totalAmount),
+	)
+	return insertActionTx(ctx, tx, adminUsername, actionType, title, details)
+}
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"backend/internal/domain"
+)
+
+// TestBackfillSalesCostPrices runs on an empty schema because the backfill
+// touches every sales line. Only finalized zero-cost lines of products that
+// now have an average cost are filled.
+func TestBackfillSalesCostPrices(t *testing.T) {
+	repo := testEmptyRepository(t)
+	ctx := context.Background()
+
+	for _, input := range []ProductCreateInput{
+		{ProductName: "uncosted", Quantity: 10},
+		{ProductName: "still free", Quantity: 10},
+		{ProductName: "costed", Quantity: 10, AvgBuyPrice: 5},
+	} {
+		if _, err := repo.CreateProduct(ctx, input); err != nil {
+			t.Fatalf("create product %q: %v", input.ProductName, err)
+		}
+	}
+	sell := func(name, status string) int64 {
+		t.Helper()
+		id, err := repo.CreateSalesInvoice(ctx, nil, nil, nil, "sales",
+			[]domain.SalesLineInput{{ProductName: name, Price: 20, Quantity: 1}},
+			InvoiceAdjustments{}, status, false)
+		if err != nil {
+			t.Fatalf("sell %q: %v", name, err)
+		}
+		return id
+	}
+	filled := sell("uncosted", InvoiceStatusFinalized)
+	draft := sell("uncosted", InvoiceStatusDraft)
+	free := sell("still free", InvoiceStatusFinalized)
+	costed := sell("costed", InvoiceStatusFinalized)
+
+	if _, err := repo.pool.Exec(ctx, `
+		UPDATE products SET avg_buy_price = 12 WHERE product_name IN ('uncosted', 'costed')
+	`); err != nil {
+		t.Fatalf("set average costs: %v", err)
+	}
+
+	changed, err := repo.BackfillSalesCostPrices(ctx)
+	if err != nil {
+		t.Fatalf("BackfillSalesCostPrices: %v", err)
+	}
+	if changed != 1 {
+		t.Fatalf("changed %d lines, want 1", changed)
+	}
+	for _, want := range []struct {
+		invoiceID int64
+		cost      float64
+	}{
+		{invoiceID: filled, cost: 12},
+		{invoiceID: draft, cost: 0},
+		{invoiceID: free, cost: 0},
+		{invoiceID: costed, cost: 5},
+	} {
+		lines, err := repo.GetInvoiceLines(ctx, want.invoiceID)
+		if err != nil {
+			t.Fatalf("get lines of invoice %d: %v", want.invoiceID, err)
+		}
+		if len(lines) != 1 || lines[0].CostPrice != want.cost {
+			t.Errorf("invoice %d lines = %+v, want one line costing %v", want.invoiceID, lines, want.cost)
+		}
+	}
+
+	if changed, err := repo.BackfillSalesCostPrices(ctx); err != nil || changed != 0 {
+		t.Fatalf("second backfill = %d, %v; want 0, nil", changed, err)
+	}
+}
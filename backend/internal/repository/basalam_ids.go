@@ -4,6 +4,9 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
+
+	"backend/internal/domain"
 )
 
 func (r *Repository) FetchExistingBasalamIDs(
@@ -49,12 +52,100 @@ func (r *Repository) FetchExistingBasalamIDs(
 	return existing, nil
 }
 
+// StoreBasalamIDs inserts the ids in one statement and returns how many
+// were new; ids repeated in the input or already stored are not counted.
 func (r *Repository) StoreBasalamIDs(ctx context.Context, ids []string) (int, error) {
-	if len(ids) == 0 {
+	clean := cleanBasalamIDs(ids)
+	if len(clean) == 0 {
 		return 0, nil
 	}
+
+	tag, err := r.pool.Exec(ctx, `
+		INSERT INTO basalam_order_ids (id)
+		SELECT value
+		FROM unnest($1::text[]) AS value
+		ON CONFLICT (id) DO NOTHING
+	`, clean)
+	if err != nil {
+		return 0, fmt.Errorf("store basalam ids: %w", err)
+	}
+	return int(tag.RowsAffected()), nil
+}
+
+// DeleteBasalamIDs removes the given ids and returns how many were stored.
+// Ids that were never stored are ignored.
+func (r *Repository) DeleteBasalamIDs(ctx context.Context, ids []string) (int, error) {
+	clean := cleanBasalamIDs(ids)
+	if len(clean) == 0 {
+		return 0, nil
+	}
+
+	tag, err := r.pool.Exec(ctx, `
+		DELETE FROM basalam_order_ids
+		WHERE id = ANY($1::text[])
+	`, clean)
+	if err != nil {
+		return 0, fmt.Errorf("delete basalam ids: %w", err)
+	}
+	return int(tag.RowsAffected()), nil
+}
+
+// ListBasalamIDs pages through stored order ids, newest first, optionally
+// limited to a saved_at range.
+func (r *Repository) ListBasalamIDs(
+	ctx context.Context,
+	limit, offset int,
+	from, to *time.Time,
+) ([]domain.BasalamOrderID, bool, error) {
+	limit = normalizeLimit(limit)
+	offset = normalizeOffset(offset)
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, saved_at
+		FROM basalam_order_ids
+		WHERE ($1::timestamptz IS NULL OR saved_at >= $1)
+			AND ($2::timestamptz IS NULL OR saved_at <= $2)
+		ORDER BY saved_at DESC, id
+		LIMIT $3 OFFSET $4
+	`, from, to, limit+1, offset)
+	if err != nil {
+		return nil, false, fmt.Errorf("list basalam ids: %w", err)
+	}
+	defer rows.Close()
+
+	items := make([]domain.BasalamOrderID, 0, limit)
+	for rows.Next() {
+		var item domain.BasalamOrderID
+		if err := rows.Scan(&item.ID, &item.SavedAt); err != nil {
+			return nil, false, fmt.Errorf("scan basalam id: %w", err)
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, fmt.Errorf("iterate basalam ids: %w", err)
+	}
+	items, hasMore := trimPage(items, limit)
+	return items, hasMore, nil
+}
+
+func (r *Repository) CountBasalamIDs(ctx context.Context, from, to *time.Time) (int, error) {
+	var count int
+	if err := r.pool.QueryRow(ctx, `
+		SELECT COUNT(*)::int
+		FROM basalam_order_ids
+		WHERE ($1::timestamptz IS NULL OR saved_at >= $1)
+			AND ($2::timestamptz IS NULL OR saved_at <= $2)
+	`, from, to).Scan(&count); err != nil {
+		return 0, fmt.Errorf("count basalam ids: %w", err)
+	}
+	return count, nil
+}
+
+// cleanBasalamIDs trims ids and drops blanks and duplicates, keeping the
+// first occurrence order.
+func cleanBasalamIDs(ids []string) []string {
 	clean := make([]string, 0, len(ids))
-	seen := map[string]struct{}{}
+	seen := make(map[string]struct{}, len(ids))
 	for _, id := range ids {
 		value := strings.TrimSpace(id)
 		if value == "" {
@@ -66,23 +157,5 @@ func (r *Repository) StoreBasalamIDs(ctx context.Context, ids []string) (int, er
 		seen[value] = struct{}{}
 		clean = append(clean, value)
 	}
-	if len(clean) == 0 {
-		return 0, nil
-	}
-
-	inserted := 0
-	if err := r.pool.QueryRow(ctx, `
-		WITH inserted AS (
-			INSERT INTO basalam_order_ids (id)
-			SELECT DISTINCT value
-			FROM unnest($1::text[]) AS value
-			WHERE value <> ''
-			ON CONFLICT (id) DO NOTHING
-			RETURNING 1
-		)
-		SELECT COUNT(*)::int FROM inserted
-	`, clean).Scan(&inserted); err != nil {
-		return 0, fmt.Errorf("store basalam ids: %w", err)
-	}
-	return inserted, nil
+	return clean
 }
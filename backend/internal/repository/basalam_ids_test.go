@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestCleanBasalamIDs(t *testing.T) {
+	tests := []struct {
+		name string
+		ids  []string
+		want []string
+	}{
+		{name: "nil", ids: nil, want: []string{}},
+		{name: "blank only", ids: []string{"", "  "}, want: []string{}},
+		{name: "trims and keeps order", ids: []string{" b ", "a"}, want: []string{"b", "a"}},
+		{name: "first copy wins", ids: []string{"a", "b", " a", "b "}, want: []string{"a", "b"}},
+		{name: "case is significant", ids: []string{"A", "a"}, want: []string{"A", "a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cleanBasalamIDs(tt.ids); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("cleanBasalamIDs(%q) = %q, want %q", tt.ids, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStoreBasalamIDs(t *testing.T) {
+	repo := testRepository(t)
+	ctx := context.Background()
+
+	prefix := fmt.Sprintf("store test %d", time.Now().UnixNano())
+	id := func(n int) string { return fmt.Sprintf("%s-%d", prefix, n) }
+	t.Cleanup(func() {
+		_, _ = repo.DeleteBasalamIDs(context.Background(), []string{id(1), id(2), id(3), id(4)})
+	})
+
+	steps := []struct {
+		name string
+		ids  []string
+		want int
+	}{
+		{name: "empty", ids: []string{" "}, want: 0},
+		{name: "repeats in the input count once", ids: []string{id(1), " " + id(1), id(2), id(1)}, want: 2},
+		{name: "stored ids are not counted again", ids: []string{id(2), id(3), id(1)}, want: 1},
+		{name: "both at once", ids: []string{id(3), id(4), id(4) + " ", id(2)}, want: 1},
+		{name: "nothing new", ids: []string{id(4), id(1)}, want: 0},
+	}
+	for _, step := range steps {
+		got, err := repo.StoreBasalamIDs(ctx, step.ids)
+		if err != nil {
+			t.Fatalf("%s: StoreBasalamIDs: %v", step.name, err)
+		}
+		if got != step.want {
+			t.Fatalf("%s: stored %d, want %d", step.name, got, step.want)
+		}
+	}
+
+	existing, err := repo.FetchExistingBasalamIDs(ctx, []string{id(1), id(2), id(3), id(4), id(5)})
+	if err != nil {
+		t.Fatalf("FetchExistingBasalamIDs: %v", err)
+	}
+	slices.Sort(existing)
+	if want := []string{id(1), id(2), id(3), id(4)}; !reflect.DeepEqual(existing, want) {
+		t.Fatalf("stored ids = %q, want %q", existing, want)
+	}
+}
+
+func TestDeleteBasalamIDs(t *testing.T) {
+	repo := testRepository(t)
+	ctx := context.Background()
+
+	prefix := fmt.Sprintf("delete test %d", time.Now().UnixNano())
+	id := func(n int) string { return fmt.Sprintf("%s-%d", prefix, n) }
+	t.Cleanup(func() {
+		_, _ = repo.DeleteBasalamIDs(context.Background(), []string{id(1), id(2), id(3)})
+	})
+	if _, err := repo.StoreBasalamIDs(ctx, []string{id(1), id(2), id(3)}); err != nil {
+		t.Fatalf("StoreBasalamIDs: %v", err)
+	}
+
+	steps := []struct {
+		name string
+		ids  []string
+		want int
+	}{
+		{name: "empty", ids: []string{"", " "}, want: 0},
+		{name: "absent ids", ids: []string{id(4), id(5)}, want: 0},
+		{name: "repeats and padding count once", ids: []string{" " + id(1), id(1), id(1) + " "}, want: 1},
+		{name: "present and absent", ids: []string{id(2), id(4), id(1)}, want: 1},
+	}
+	for _, step := range steps {
+		got, err := repo.DeleteBasalamIDs(ctx, step.ids)
+		if err != nil {
+			t.Fatalf("%s: DeleteBasalamIDs: %v", step.name, err)
+		}
+		if got != step.want {
+			t.Fatalf("%s: deleted %d, want %d", step.name, got, step.want)
+		}
+	}
+
+	existing, err := repo.FetchExistingBasalamIDs(ctx, []string{id(1), id(2), id(3), id(4)})
+	if err != nil {
+		t.Fatalf("FetchExistingBasalamIDs: %v", err)
+	}
+	if want := []string{id(3)}; !reflect.DeepEqual(existing, want) {
+		t.Fatalf("remaining ids = %q, want %q", existing, want)
+	}
+}
+
+// BenchmarkStoreBasalamIDs stores a sync-sized batch of new ids, half of
+// them repeated, per iteration.
+func BenchmarkStoreBasalamIDs(b *testing.B) {
+	repo := testRepository(b)
+	ctx := context.Background()
+
+	const batch = 500
+	prefix := fmt.Sprintf("store bench %d", time.Now().UnixNano())
+	stored := make([]string, 0, b.N*batch)
+	b.Cleanup(func() {
+		_, _ = repo.DeleteBasalamIDs(context.Background(), stored)
+	})
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ids := make([]string, 0, batch+batch/2)
+		for n := 0; n < batch; n++ {
+			ids = append(ids, fmt.Sprintf("%s-%d-%d", prefix, i, n))
+		}
+		ids = append(ids, ids[:batch/2]...)
+		count, err := repo.StoreBasalamIDs(ctx, ids)
+		if err != nil {
+			b.Fatalf("StoreBasalamIDs: %v", err)
+		}
+		if count != batch {
+			b.Fatalf("stored %d, want %d", count, batch)
+		}
+		stored = append(stored, ids[:batch]...)
+	}
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"backend/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type queryRower interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+func (r *Repository) ListCategories(ctx context.Context) ([]domain.Category, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT
+			c.id,
+			c.name,
+			COUNT(p.id)::int
+		FROM categories c
+		LEFT JOIN products p ON p.category_id = c.id AND p.deleted_at IS NULL
+		GROUP BY c.id, c.name
+		ORDER BY c.name ASC, c.id ASC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("list categories: %w", err)
+	}
+	defer rows.Close()
+
+	items := make([]domain.Category, 0)
+	for rows.Next() {
+		var item domain.Category
+		if err := rows.Scan(&item.CategoryID, &item.Name, &item.ProductCount); err != nil {
+			return nil, fmt.Errorf("scan category: %w", err)
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate categories: %w", err)
+	}
+	return items, nil
+}
+
+func (r *Repository) CreateCategory(ctx context.Context, name string) (domain.Category, error) {
+	cleanName := strings.TrimSpace(name)
+	if cleanName == "" {
+		return domain.Category{}, fmt.Errorf("category name is required")
+	}
+	var exists bool
+	if err := r.pool.QueryRow(ctx, `
+		SELECT EXISTS(
+			SELECT 1
+			FROM categories
+			WHERE LOWER(name) = LOWER($1)
+		)
+	`, cleanName).Scan(&exists); err != nil {
+		return domain.Category{}, fmt.Errorf("check category name: %w", err)
+	}
+	if exists {
+		return domain.Category{}, fmt.Errorf("category name already exists: %s", cleanName)
+	}
+
+	category := domain.Category{Name: cleanName}
+	if err := r.pool.QueryRow(ctx, `
+		INSERT INTO categories (name)
+		VALUES ($1)
+		RETURNING id
+	`, cleanName).Scan(&category.CategoryID); err != nil {
+		return domain.Category{}, fmt.Errorf("create category: %w", err)
+	}
+	return category, nil
+}
+
+func ensureCategoryExists(ctx context.Context, q queryRower, categoryID int64) error {
+	var id int64
+	err := q.QueryRow(ctx, "SELECT id FROM categories WHERE id = $1", categoryID).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("category not found: %d", categoryID)
+	}
+	if err != nil {
+		return fmt.Errorf("load category %d: %w", categoryID, err)
+	}
+	return nil
+}
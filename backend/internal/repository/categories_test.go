@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCategories(t *testing.T) {
+	repo := testRepository(t)
+	ctx := context.Background()
+
+	suffix := time.Now().UnixNano()
+	tools, err := repo.CreateCategory(ctx, fmt.Sprintf(" tools %d ", suffix))
+	if err != nil {
+		t.Fatalf("create category: %v", err)
+	}
+	if want := fmt.Sprintf("tools %d", suffix); tools.Name != want {
+		t.Fatalf("category name = %q, want %q", tools.Name, want)
+	}
+	paint, err := repo.CreateCategory(ctx, fmt.Sprintf("paint %d", suffix))
+	if err != nil {
+		t.Fatalf("create category: %v", err)
+	}
+	if _, err := repo.CreateCategory(ctx, strings.ToUpper(tools.Name)); err == nil {
+		t.Fatalf("a name differing only in case was accepted")
+	}
+	if _, err := repo.CreateCategory(ctx, "  "); err == nil {
+		t.Fatalf("a blank name was accepted")
+	}
+
+	search := fmt.Sprintf("category test %d", suffix)
+	products := []struct {
+		name     string
+		category *int64
+	}{
+		{name: search + " hammer", category: &tools.CategoryID},
+		{name: search + " saw", category: &tools.CategoryID},
+		{name: search + " brush", category: &paint.CategoryID},
+		{name: search + " loose"},
+	}
+	for _, product := range products {
+		if _, err := repo.CreateProduct(ctx, ProductCreateInput{ProductName: product.name, CategoryID: product.category}); err != nil {
+			t.Fatalf("create product %q: %v", product.name, err)
+		}
+	}
+	missing := int64(-1)
+	if _, err := repo.CreateProduct(ctx, ProductCreateInput{ProductName: search + " orphan", CategoryID: &missing}); err == nil {
+		t.Fatalf("a product with an unknown category was created")
+	}
+
+	tests := []struct {
+		name     string
+		category *int64
+		want     []string
+		wantName string
+	}{
+		{name: "tools", category: &tools.CategoryID, want: []string{search + " hammer", search + " saw"}, wantName: tools.Name},
+		{name: "paint", category: &paint.CategoryID, want: []string{search + " brush"}, wantName: paint.Name},
+		{name: "no filter", want: []string{search + " brush", search + " hammer", search + " loose", search + " saw"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items, _, err := repo.ListProducts(ctx, ProductListFilter{Search: search, CategoryID: tt.category, Limit: 50})
+			if err != nil {
+				t.Fatalf("ListProducts: %v", err)
+			}
+			names := make([]string, 0, len(items))
+			for _, item := range items {
+				names = append(names, item.ProductName)
+				if tt.category == nil {
+					continue
+				}
+				if item.CategoryID == nil || *item.CategoryID != *tt.category || item.CategoryName == nil || *item.CategoryName != tt.wantName {
+					t.Errorf("%q category = %v/%v, want %d/%q", item.ProductName, item.CategoryID, item.CategoryName, *tt.category, tt.wantName)
+				}
+			}
+			slices.Sort(names)
+			if !slices.Equal(names, tt.want) {
+				t.Fatalf("products = %q, want %q", names, tt.want)
+			}
+		})
+	}
+
+	categories, err := repo.ListCategories(ctx)
+	if err != nil {
+		t.Fatalf("ListCategories: %v", err)
+	}
+	counts := make(map[int64]int)
+	for _, category := range categories {
+		counts[category.CategoryID] = category.ProductCount
+	}
+	if counts[tools.CategoryID] != 2 || counts[paint.CategoryID] != 1 {
+		t.Fatalf("product counts tools/paint = %d/%d, want 2/1", counts[tools.CategoryID], counts[paint.CategoryID])
+	}
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"backend/internal/domain"
+)
+
+// TestGetDailySummary spreads invoices over several days on an empty schema
+// and checks the per-day totals and the day window.
+func TestGetDailySummary(t *testing.T) {
+	repo := testEmptyRepository(t)
+	ctx := context.Background()
+
+	const name = "daily product"
+	// daysAgo moves an invoice to one in the morning, n days back, in the
+	// session time zone the query groups by.
+	daysAgo := func(id int64, n int) {
+		t.Helper()
+		if _, err := repo.pool.Exec(ctx, `
+			UPDATE invoices
+			SET created_at = DATE_TRUNC('day', NOW()) - ($1::int * INTERVAL '1 day') + INTERVAL '1 hour'
+			WHERE id = $2
+		`, n, id); err != nil {
+			t.Fatalf("backdate invoice %d: %v", id, err)
+		}
+	}
+	day := func(n int) string {
+		t.Helper()
+		var label string
+		if err := repo.pool.QueryRow(ctx,
+			"SELECT TO_CHAR(DATE_TRUNC('day', NOW()) - ($1::int * INTERVAL '1 day'), 'YYYY-MM-DD')", n,
+		).Scan(&label); err != nil {
+			t.Fatalf("day label: %v", err)
+		}
+		return label
+	}
+
+	purchaseID, err := repo.CreatePurchaseInvoice(ctx, nil, nil, nil,
+		[]domain.PurchaseLineInput{{ProductName: name, Price: 10, Quantity: 10}},
+		InvoiceAdjustments{}, InvoiceStatusFinalized)
+	if err != nil {
+		t.Fatalf("create purchase: %v", err)
+	}
+	daysAgo(purchaseID, 2)
+	sales := []struct {
+		daysAgo  int
+		quantity float64
+		status   string
+	}{
+		{daysAgo: 10, quantity: 1, status: InvoiceStatusFinalized},
+		{daysAgo: 1, quantity: 2, status: InvoiceStatusFinalized},
+		{daysAgo: 1, quantity: 5, status: InvoiceStatusDraft},
+		{daysAgo: 0, quantity: 1, status: InvoiceStatusFinalized},
+	}
+	for _, sale := range sales {
+		id, err := repo.CreateSalesInvoice(ctx, nil, nil, nil, "sales",
+			[]domain.SalesLineInput{{ProductName: name, Price: 25, Quantity: sale.quantity}},
+			InvoiceAdjustments{}, sale.status, false)
+		if err != nil {
+			t.Fatalf("create sale: %v", err)
+		}
+		daysAgo(id, sale.daysAgo)
+	}
+
+	// Every sale costs 10 a unit and sells for 25; drafts are left out.
+	today := domain.DailySummary{Day: day(0), SalesTotal: 25, Profit: 15, InvoiceCount: 1}
+	yesterday := domain.DailySummary{Day: day(1), SalesTotal: 50, Profit: 30, InvoiceCount: 1}
+	purchaseDay := domain.DailySummary{Day: day(2), PurchaseTotal: 100, InvoiceCount: 1}
+	older := domain.DailySummary{Day: day(10), SalesTotal: 25, Profit: 15, InvoiceCount: 1}
+	tests := []struct {
+		days int
+		want []domain.DailySummary
+	}{
+		{days: 1, want: []domain.DailySummary{today}},
+		{days: 3, want: []domain.DailySummary{today, yesterday, purchaseDay}},
+		{days: 0, want: []domain.DailySummary{today, yesterday, purchaseDay, older}},
+	}
+	for _, tt := range tests {
+		got, err := repo.GetDailySummary(ctx, tt.days)
+		if err != nil {
+			t.Fatalf("GetDailySummary(%d): %v", tt.days, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("GetDailySummary(%d) = %+v, want %+v", tt.days, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"backend/internal/domain"
+)
+
+// sellPriceMarginExpr mirrors the desktop inventory table: the margin is
+// measured against last_buy_price plus a fixed handling offset.
+const sellPriceMarginExpr = `
+	((sell_price - (last_buy_price + 10000)) / (last_buy_price + 10000) * 100)
+`
+
+const priceAlarmCondition = `deleted_at IS NULL AND last_buy_price > 0 AND ` + sellPriceMarginExpr + ` < $1`
+
+func (r *Repository) CountProducts(ctx context.Context) (int, error) {
+	var count int
+	if err := r.pool.QueryRow(ctx, "SELECT COUNT(*)::int FROM products WHERE deleted_at IS NULL").Scan(&count); err != nil {
+		return 0, fmt.Errorf("count products: %w", err)
+	}
+	return count, nil
+}
+
+func (r *Repository) CountLowStock(ctx context.Context, threshold int) (int, error) {
+	if threshold <= 0 {
+		threshold = defaultLowStockThreshold
+	}
+	var count int
+	if err := r.pool.QueryRow(ctx, `
+		SELECT COUNT(*)::int
+		FROM products
+		WHERE deleted_at IS NULL AND quantity < COALESCE(alarm, $1)
+	`, threshold).Scan(&count); err != nil {
+		return 0, fmt.Errorf("count low stock: %w", err)
+	}
+	return count, nil
+}
+
+func (r *Repository) CountPriceAlarms(ctx context.Context, percent float64) (int, error) {
+	var count int
+	if err := r.pool.QueryRow(ctx, `
+		SELECT COUNT(*)::int
+		FROM products
+		WHERE `+priceAlarmCondition,
+		percent,
+	).Scan(&count); err != nil {
+		return 0, fmt.Errorf("count price alarms: %w", err)
+	}
+	return count, nil
+}
+
+func (r *Repository) CountMissingSellPrice(ctx context.Context) (int, error) {
+	var count int
+	if err := r.pool.QueryRow(ctx, `
+		SELECT COUNT(*)::int
+		FROM products
+		WHERE deleted_at IS NULL AND sell_price <= 0
+	`).Scan(&count); err != nil {
+		return 0, fmt.Errorf("count missing sell price: %w", err)
+	}
+	return count, nil
+}
+
+func (r *Repository) CountUnattributedProducts(ctx context.Context) (int, error) {
+	var count int
+	if err := r.pool.QueryRow(ctx, `
+		SELECT COUNT(*)::int
+		FROM products
+		WHERE deleted_at IS NULL AND COALESCE(TRIM(source), '') = ''
+	`).Scan(&count); err != nil {
+		return 0, fmt.Errorf("count unattributed products: %w", err)
+	}
+	return count, nil
+}
+
+// StreamPriceAlarms calls fn for every product below the margin percent,
+// worst margin first, stopping at the first error fn returns.
+func (r *Repository) StreamPriceAlarms(
+	ctx context.Context,
+	percent float64,
+	fn func(domain.PriceAlarmRow) error,
+) error {
+	rows, err := r.pool.Query(ctx, `
+		SELECT
+			product_name,
+			avg_buy_price::double precision,
+			last_buy_price::double precision,
+			sell_price::double precision,
+			`+sellPriceMarginExpr+`::double precision AS margin_percent,
+			source
+		FROM products
+		WHERE `+priceAlarmCondition+`
+		ORDER BY margin_percent ASC, product_name ASC
+	`, percent)
+	if err != nil {
+		return fmt.Errorf("get price alarms: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			item   domain.PriceAlarmRow
+			source sql.NullString
+		)
+		if err := rows.Scan(
+			&item.ProductName,
+			&item.AvgBuyPrice,
+			&item.LastBuyPrice,
+			&item.SellPrice,
+			&item.MarginPercent,
+			&source,
+		); err != nil {
+			return fmt.Errorf("scan price alarm row: %w", err)
+		}
+		if source.Valid {
+			value := source.String
+			item.Source = &value
+		}
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("iterate price alarms: %w", err)
+	}
+	return nil
+}
+
+// GetMarginAlerts lists products priced below avg_buy_price plus percent,
+// worst margin first. Shortfall is the amount missing to reach that target.
+func (r *Repository) GetMarginAlerts(ctx context.Context, percent float64) ([]domain.MarginAlertRow, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT
+			id,
+			product_name,
+			avg_buy_price::double precision,
+			sell_price::double precision,
+			((sell_price - avg_buy_price) / avg_buy_price * 100)::double precision AS margin_percent,
+			(avg_buy_price * (1 + $1::numeric / 100) - sell_price)::double precision AS shortfall,
+			source
+		FROM products
+		WHERE deleted_at IS NULL
+		  AND avg_buy_price > 0
+		  AND sell_price < avg_buy_price * (1 + $1::numeric / 100)
+		ORDER BY margin_percent ASC, product_name ASC
+	`, percent)
+	if err != nil {
+		return nil, fmt.Errorf("get margin alerts: %w", err)
+	}
+	defer rows.Close()
+
+	items := make([]domain.MarginAlertRow, 0)
+	for rows.Next() {
+		var (
+			item   domain.MarginAlertRow
+			source sql.NullString
+		)
+		if err := rows.Scan(
+			&item.ProductID,
+			&item.ProductName,
+			&item.AvgBuyPrice,
+			&item.SellPrice,
+			&item.MarginPercent,
+			&item.Shortfall,
+			&source,
+		); err != nil {
+			return nil, fmt.Errorf("scan margin alert row: %w", err)
+		}
+		if source.Valid {
+			value := source.String
+			item.Source = &value
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate margin alerts: %w", err)
+	}
+	return items, nil
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/internal/domain"
+)
+
+// TestPreviewInventoryImport checks the dry run against the real import: an
+// existing name is an update, a new one a create, a repeated one a duplicate
+// and a name left behind by a merge is reported instead of counted.
+func TestPreviewInventoryImport(t *testing.T) {
+	repo := testRepository(t)
+	ctx := context.Background()
+
+	suffix := time.Now().UnixNano()
+	existing := fmt.Sprintf("preview existing %d", suffix)
+	merged := fmt.Sprintf("preview merged %d", suffix)
+	fresh := fmt.Sprintf("preview new %d", suffix)
+
+	ids := make([]int64, 0, 2)
+	for _, name := range []string{existing, merged} {
+		product, err := repo.CreateProduct(ctx, ProductCreateInput{ProductName: name, Quantity: 1, AvgBuyPrice: 10})
+		if err != nil {
+			t.Fatalf("create product %q: %v", name, err)
+		}
+		ids = append(ids, product.ID)
+	}
+	if _, _, err := repo.MergeProducts(ctx, ids[0], ids[1:]); err != nil {
+		t.Fatalf("merge products: %v", err)
+	}
+
+	rows := []domain.InventoryImportRow{
+		{ProductName: strings.ToUpper(existing), Quantity: 2},
+		{ProductName: fresh, Quantity: 3},
+		{ProductName: merged, Quantity: 4},
+		{ProductName: strings.ToUpper(fresh), Quantity: 5},
+		{ProductName: "  "},
+	}
+	preview, err := repo.PreviewInventoryImport(ctx, rows)
+	if err != nil {
+		t.Fatalf("PreviewInventoryImport: %v", err)
+	}
+	want := domain.InventoryImportPreview{
+		TotalRows:      4,
+		WouldCreate:    1,
+		WouldUpdate:    1,
+		DuplicateNames: []string{strings.ToUpper(fresh)},
+		MergedNames:    []string{merged},
+	}
+	if !reflect.DeepEqual(preview, want) {
+		t.Fatalf("preview = %+v, want %+v", preview, want)
+	}
+
+	if _, _, err := repo.UpsertInventoryRows(ctx, rows[2:3]); !errors.Is(err, ErrProductMerged) {
+		t.Fatalf("importing the merged name error = %v, want ErrProductMerged", err)
+	}
+}
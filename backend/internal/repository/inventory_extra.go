@@ -3,14 +3,12 @@ package repository
 import (
 	"context"
 	"database/sql"
-	"errors"
 	"fmt"
 	"sort"
 	"strings"
 
 	"backend/internal/domain"
-
-	"github.com/jackc/pgx/v5"
+	"backend/internal/matching"
 )
 
 func (r *Repository) ReplaceInventory(ctx context.Context, rows []domain.InventoryImportRow) error {
@@ -103,7 +101,7 @@ func (r *Repository) SyncInventory(
 	}
 
 	for _, line := range upsertByKey {
-		if _, execErr := tx.Exec(ctx, `
+		cmd, execErr := tx.Exec(ctx, `
 			INSERT INTO products (
 				product_name,
 				quantity,
@@ -123,6 +121,7 @@ func (r *Repository) SyncInventory(
 				alarm = EXCLUDED.alarm,
 				source = EXCLUDED.source,
 				updated_at = NOW()
+			WHERE products.deleted_at IS NULL
 		`,
 			line.ProductName,
 			line.Quantity,
@@ -131,7 +130,11 @@ func (r *Repository) SyncInventory(
 			line.SellPrice,
 			line.Alarm,
 			line.Source,
-		); execErr != nil {
+		)
+		if execErr == nil && cmd.RowsAffected() == 0 {
+			execErr = ErrProductMerged
+		}
+		if execErr != nil {
 			return result, fmt.Errorf(
 				"upsert product %q during sync: %w",
 				line.ProductName,
@@ -147,44 +150,98 @@ func (r *Repository) SyncInventory(
 	return result, nil
 }
 
+func (r *Repository) BulkSetProductSource(
+	ctx context.Context,
+	ids []int64,
+	search string,
+	source *string,
+) (int, error) {
+	search = strings.TrimSpace(search)
+	conditions := make([]string, 0, 2)
+	args := []any{source}
+	if len(ids) > 0 {
+		args = append(args, ids)
+		conditions = append(conditions, fmt.Sprintf("id = ANY($%d)", len(args)))
+	}
+	if search != "" {
+		args = append(args, search)
+		conditions = append(conditions, fmt.Sprintf("product_name ILIKE '%%' || $%d || '%%'", len(args)))
+	}
+	if len(conditions) == 0 {
+		return 0, fmt.Errorf("ids or search is required")
+	}
+
+	cmd, err := r.pool.Exec(ctx, `
+		UPDATE products
+		SET source = $1, updated_at = NOW()
+		WHERE deleted_at IS NULL AND `+strings.Join(conditions, " AND "),
+		args...,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("bulk set product source: %w", err)
+	}
+	return int(cmd.RowsAffected()), nil
+}
+
 func (r *Repository) ListAllProducts(ctx context.Context) ([]domain.Product, error) {
+	items := make([]domain.Product, 0)
+	err := r.StreamProducts(ctx, func(item domain.Product) error {
+		items = append(items, item)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// StreamProducts calls fn for every live product in id order, stopping at
+// the first error fn returns.
+func (r *Repository) StreamProducts(ctx context.Context, fn func(domain.Product) error) error {
 	rows, err := r.pool.Query(ctx, `
 		SELECT
-			id,
-			product_name,
-			quantity,
-			avg_buy_price::double precision,
-			last_buy_price::double precision,
-			sell_price::double precision,
-			alarm,
-			source,
-			created_at,
-			updated_at
-		FROM products
-		ORDER BY id ASC
+			p.id,
+			p.product_name,
+			p.quantity,
+			p.avg_buy_price::double precision,
+			p.last_buy_price::double precision,
+			p.sell_price::double precision,
+			p.alarm,
+			p.source,
+			p.created_at,
+			p.updated_at,
+			p.category_id,
+			c.name,
+			p.sku,
+			p.unit
+		FROM products p
+		LEFT JOIN categories c ON c.id = p.category_id
+		WHERE p.deleted_at IS NULL
+		ORDER BY p.id ASC
 	`)
 	if err != nil {
-		return nil, fmt.Errorf("list all products: %w", err)
+		return fmt.Errorf("list all products: %w", err)
 	}
 	defer rows.Close()
 
-	items := make([]domain.Product, 0)
 	for rows.Next() {
 		item, err := scanProduct(rows)
 		if err != nil {
-			return nil, err
+			return err
+		}
+		if err := fn(item); err != nil {
+			return err
 		}
-		items = append(items, item)
 	}
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("iterate all products: %w", err)
+		return fmt.Errorf("iterate all products: %w", err)
 	}
-	return items, nil
+	return nil
 }
 
 func (r *Repository) GetLowStock(ctx context.Context, threshold int) ([]domain.LowStockRow, error) {
 	if threshold <= 0 {
-		threshold = 5
+		threshold = defaultLowStockThreshold
 	}
 	rows, err := r.pool.Query(ctx, `
 		SELECT
@@ -196,7 +253,7 @@ func (r *Repository) GetLowStock(ctx context.Context, threshold int) ([]domain.L
 			sell_price::double precision,
 			source
 		FROM products
-		WHERE quantity < COALESCE(alarm, $1)
+		WHERE deleted_at IS NULL AND quantity < COALESCE(alarm, $1)
 		ORDER BY needed DESC, product_name ASC
 	`, threshold)
 	if err != nil {
@@ -233,9 +290,18 @@ func (r *Repository) GetLowStock(ctx context.Context, threshold int) ([]domain.L
 	return result, nil
 }
 
+type SellPriceImportOptions struct {
+	// FuzzyThreshold enables Levenshtein matching for names that miss the
+	// exact and normalized lookups.
+	FuzzyThreshold *float64
+	// TokenThreshold enables word-set matching, tried before fuzzy matching.
+	TokenThreshold *float64
+}
+
 func (r *Repository) ImportSellPrices(
 	ctx context.Context,
 	rows []domain.ProductPriceRow,
+	opts SellPriceImportOptions,
 ) (domain.SellPriceImportResult, error) {
 	result := domain.SellPriceImportResult{TotalRows: len(rows)}
 	if len(rows) == 0 {
@@ -251,6 +317,7 @@ func (r *Repository) ImportSellPrices(
 	productsRows, err := tx.Query(ctx, `
 		SELECT id, product_name
 		FROM products
+		WHERE deleted_at IS NULL
 	`)
 	if err != nil {
 		return result, fmt.Errorf("query products for sell price import: %w", err)
@@ -259,6 +326,8 @@ func (r *Repository) ImportSellPrices(
 
 	exactMap := make(map[string]int64)
 	normalizedMap := make(map[string]int64)
+	productIDs := make([]int64, 0)
+	productNames := make([]string, 0)
 	for productsRows.Next() {
 		var (
 			id   int64
@@ -279,10 +348,35 @@ func (r *Repository) ImportSellPrices(
 				normalizedMap[normalizedKey] = id
 			}
 		}
+		productIDs = append(productIDs, id)
+		productNames = append(productNames, name)
 	}
 	if err := productsRows.Err(); err != nil {
 		return result, fmt.Errorf("iterate products for sell price import: %w", err)
 	}
+	if len(productIDs) == 0 {
+		return result, ErrNoProducts
+	}
+	aliases, err := loadAliasLookup(ctx, tx)
+	if err != nil {
+		return result, err
+	}
+	// Canonical names win over aliases that happen to collide with them.
+	for key, id := range aliases {
+		if _, exists := exactMap[key]; !exists {
+			exactMap[key] = id
+		}
+		if _, exists := normalizedMap[key]; !exists {
+			normalizedMap[key] = id
+		}
+	}
+
+	var matcher *matching.Matcher
+	fuzzyEnabled := opts.FuzzyThreshold != nil && *opts.FuzzyThreshold < 100
+	tokenEnabled := opts.TokenThreshold != nil
+	if fuzzyEnabled || tokenEnabled {
+		matcher = matching.NewMatcher(productNames)
+	}
 
 	unmatchedSet := make(map[string]struct{})
 	priceByProductID := make(map[int64]float64)
@@ -300,6 +394,25 @@ func (r *Repository) ImportSellPrices(
 		if !ok {
 			productID, ok = normalizedMap[normalizeSellPriceLookupName(name)]
 		}
+		if !ok && tokenEnabled {
+			if match, found := matcher.BestTokenSet(name, *opts.TokenThreshold); found && !match.Ambiguous {
+				productID, ok = productIDs[match.Index], true
+				result.TokenMatchedRows++
+			}
+		}
+		if !ok && fuzzyEnabled {
+			if match, found := matcher.Best(name, *opts.FuzzyThreshold); found && !match.Ambiguous {
+				productID, ok = productIDs[match.Index], true
+				result.FuzzyMatchedRows++
+				if len(result.FuzzyMatches) < 50 {
+					result.FuzzyMatches = append(result.FuzzyMatches, domain.SellPriceFuzzyMatch{
+						ProductName: name,
+						MatchedName: match.Name,
+						Score:       match.Score,
+					})
+				}
+			}
+		}
 		if !ok {
 			unmatchedSet[name] = struct{}{}
 			continue
@@ -395,101 +508,3 @@ func normalizeSellPriceLookupName(value string) string {
 func normalizeInventoryNameKey(value string) string {
 	return strings.ToLower(strings.TrimSpace(value))
 }
-
-func (r *Repository) getNumericSetting(
-	ctx context.Context,
-	settingKey string,
-	defaultValue float64,
-	initLabel string,
-	getLabel string,
-) (float64, error) {
-	var value float64
-	err := r.pool.QueryRow(ctx, `
-		SELECT value_numeric::double precision
-		FROM app_settings
-		WHERE key = $1
-	`, settingKey).Scan(&value)
-	if errors.Is(err, pgx.ErrNoRows) {
-		if _, execErr := r.pool.Exec(ctx, `
-			INSERT INTO app_settings (key, value_numeric)
-			VALUES ($1, $2)
-			ON CONFLICT (key) DO NOTHING
-		`, settingKey, defaultValue); execErr != nil {
-			return 0, fmt.Errorf("init %s: %w", initLabel, execErr)
-		}
-		return defaultValue, nil
-	}
-	if err != nil {
-		return 0, fmt.Errorf("get %s: %w", getLabel, err)
-	}
-	if value < 0 {
-		value = 0
-	}
-	return value, nil
-}
-
-func (r *Repository) setPercentSetting(
-	ctx context.Context,
-	settingKey string,
-	percent float64,
-	setLabel string,
-) (float64, error) {
-	if percent < 0 || percent > 100 {
-		return 0, fmt.Errorf("percent must be between 0 and 100")
-	}
-	if _, err := r.pool.Exec(ctx, `
-		INSERT INTO app_settings (key, value_numeric, updated_at)
-		VALUES ($1, $2, NOW())
-		ON CONFLICT (key)
-		DO UPDATE SET
-			value_numeric = EXCLUDED.value_numeric,
-			updated_at = NOW()
-	`, settingKey, percent); err != nil {
-		return 0, fmt.Errorf("set %s: %w", setLabel, err)
-	}
-	return percent, nil
-}
-
-func (r *Repository) GetSellPriceAlarmPercent(ctx context.Context) (float64, error) {
-	return r.getNumericSetting(
-		ctx,
-		"sell_price_alarm_percent",
-		20.0,
-		"sell price alarm setting",
-		"sell price alarm setting",
-	)
-}
-
-func (r *Repository) SetSellPriceAlarmPercent(
-	ctx context.Context,
-	percent float64,
-) (float64, error) {
-	return r.setPercentSetting(
-		ctx,
-		"sell_price_alarm_percent",
-		percent,
-		"sell price alarm setting",
-	)
-}
-
-func (r *Repository) GetSalesImportFuzzyMatchPercent(ctx context.Context) (float64, error) {
-	return r.getNumericSetting(
-		ctx,
-		"sales_import_fuzzy_match_percent",
-		85.0,
-		"sales import fuzzy match setting",
-		"sales import fuzzy match setting",
-	)
-}
-
-func (r *Repository) SetSalesImportFuzzyMatchPercent(
-	ctx context.Context,
-	percent float64,
-) (float64, error) {
-	return r.setPercentSetting(
-		ctx,
-		"sales_import_fuzzy_match_percent",
-		percent,
-		"sales import fuzzy match setting",
-	)
-}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"backend/internal/domain"
+)
+
+const inventorySnapshotColumns = `
+	TO_CHAR(snapshot_date, 'YYYY-MM-DD'),
+	total_products,
+	total_quantity::double precision,
+	inventory_value::double precision,
+	recorded_at
+`
+
+// RecordInventorySnapshot stores today's inventory valuation. Recording again
+// on the same day overwrites that day's row.
+func (r *Repository) RecordInventorySnapshot(ctx context.Context) (domain.InventorySnapshot, error) {
+	var snapshot domain.InventorySnapshot
+	err := r.pool.QueryRow(ctx, `
+		INSERT INTO inventory_snapshots (
+			snapshot_date,
+			total_products,
+			total_quantity,
+			inventory_value
+		)
+		SELECT
+			CURRENT_DATE,
+			COUNT(*)::int,
+			COALESCE(SUM(quantity), 0),
+			COALESCE(SUM(quantity * avg_buy_price), 0)
+		FROM products
+		WHERE deleted_at IS NULL
+		ON CONFLICT (snapshot_date)
+		DO UPDATE SET
+			total_products = EXCLUDED.total_products,
+			total_quantity = EXCLUDED.total_quantity,
+			inventory_value = EXCLUDED.inventory_value,
+			recorded_at = NOW()
+		RETURNING `+inventorySnapshotColumns,
+	).Scan(
+		&snapshot.Date,
+		&snapshot.TotalProducts,
+		&snapshot.TotalQuantity,
+		&snapshot.InventoryValue,
+		&snapshot.RecordedAt,
+	)
+	if err != nil {
+		return domain.InventorySnapshot{}, fmt.Errorf("record inventory snapshot: %w", err)
+	}
+	return snapshot, nil
+}
+
+// ListInventorySnapshots returns the snapshots of the last days days, oldest
+// first so the result plots directly as a curve.
+func (r *Repository) ListInventorySnapshots(ctx context.Context, days int) ([]domain.InventorySnapshot, error) {
+	if days <= 0 {
+		days = 90
+	}
+	if days > 3660 {
+		days = 3660
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+inventorySnapshotColumns+`
+		FROM inventory_snapshots
+		WHERE snapshot_date > CURRENT_DATE - $1::int
+		ORDER BY snapshot_date ASC
+	`, days)
+	if err != nil {
+		return nil, fmt.Errorf("list inventory snapshots: %w", err)
+	}
+	defer rows.Close()
+
+	items := make([]domain.InventorySnapshot, 0)
+	for rows.Next() {
+		var snapshot domain.InventorySnapshot
+		if err := rows.Scan(
+			&snapshot.Date,
+			&snapshot.TotalProducts,
+			&snapshot.TotalQuantity,
+			&snapshot.InventoryValue,
+			&snapshot.RecordedAt,
+		); err != nil {
+			return nil, fmt.Errorf("scan inventory snapshot: %w", err)
+		}
+		items = append(items, snapshot)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate inventory snapshots: %w", err)
+	}
+	return items, nil
+}
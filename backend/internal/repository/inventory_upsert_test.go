@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/internal/domain"
+)
+
+func TestUpsertInventoryRows(t *testing.T) {
+	repo := testRepository(t)
+	ctx := context.Background()
+
+	suffix := time.Now().UnixNano()
+	name := func(label string) string { return fmt.Sprintf("upsert test %d %s", suffix, label) }
+	existing, err := repo.CreateProduct(ctx, ProductCreateInput{ProductName: name("existing"), Quantity: 1, AvgBuyPrice: 5, SellPrice: 8})
+	if err != nil {
+		t.Fatalf("create product: %v", err)
+	}
+
+	source := "stock file"
+	rows := []domain.InventoryImportRow{
+		{ProductName: strings.ToUpper(name("existing")), Quantity: 4, AvgBuyPrice: 6, LastBuyPrice: 7, SellPrice: 9, Source: &source},
+		{ProductName: name("new a"), Quantity: 2, AvgBuyPrice: 3},
+		{ProductName: "  "},
+		{ProductName: " " + name("new b") + " ", Quantity: 1, AvgBuyPrice: 2},
+	}
+	created, updated, err := repo.UpsertInventoryRows(ctx, rows)
+	if err != nil {
+		t.Fatalf("UpsertInventoryRows: %v", err)
+	}
+	if created != 2 || updated != 1 {
+		t.Fatalf("created/updated = %d/%d, want 2/1", created, updated)
+	}
+
+	got, err := repo.GetProductByID(ctx, existing.ID)
+	if err != nil {
+		t.Fatalf("get product: %v", err)
+	}
+	// The update takes the file's spelling and values.
+	if got.ProductName != strings.ToUpper(name("existing")) || got.Quantity != 4 || got.AvgBuyPrice != 6 ||
+		got.LastBuyPrice != 7 || got.SellPrice != 9 || got.Source == nil || *got.Source != source {
+		t.Fatalf("updated product = %+v", got)
+	}
+
+	// Running the same file again only updates.
+	created, updated, err = repo.UpsertInventoryRows(ctx, rows)
+	if err != nil {
+		t.Fatalf("UpsertInventoryRows again: %v", err)
+	}
+	if created != 0 || updated != 3 {
+		t.Fatalf("second run created/updated = %d/%d, want 0/3", created, updated)
+	}
+}
+
+// BenchmarkUpsertInventoryRows imports a 1000-row file per iteration: the
+// first half updates the same seeded products, the second half creates new
+// ones.
+func BenchmarkUpsertInventoryRows(b *testing.B) {
+	repo := testRepository(b)
+	ctx := context.Background()
+
+	const size = 1000
+	prefix := fmt.Sprintf("upsert bench %d", time.Now().UnixNano())
+	rows := make([]domain.InventoryImportRow, size)
+	for n := range rows {
+		rows[n] = domain.InventoryImportRow{ProductName: fmt.Sprintf("%s seed %d", prefix, n), Quantity: 1, AvgBuyPrice: 1}
+	}
+	if _, _, err := repo.UpsertInventoryRows(ctx, rows); err != nil {
+		b.Fatalf("seed: %v", err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for n := size / 2; n < size; n++ {
+			rows[n].ProductName = fmt.Sprintf("%s run %d %d", prefix, i, n)
+		}
+		if _, _, err := repo.UpsertInventoryRows(ctx, rows); err != nil {
+			b.Fatalf("UpsertInventoryRows: %v", err)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"backend/internal/domain"
+)
+
+// TestInvoiceAdjustments runs on an empty schema so the monthly summary holds
+// only the invoices created here. A discount lowers the total and the profit;
+// tax raises the total but is not profit.
+func TestInvoiceAdjustments(t *testing.T) {
+	repo := testEmptyRepository(t)
+	ctx := context.Background()
+
+	if _, err := repo.CreateProduct(ctx, ProductCreateInput{ProductName: "adjusted", Quantity: 100, AvgBuyPrice: 10}); err != nil {
+		t.Fatalf("create product: %v", err)
+	}
+	amount := func(value float64) *float64 { return &value }
+
+	purchaseID, err := repo.CreatePurchaseInvoice(ctx, nil, nil, nil,
+		[]domain.PurchaseLineInput{{ProductName: "adjusted", Price: 10, Quantity: 1}},
+		InvoiceAdjustments{TaxAmount: amount(10)}, InvoiceStatusFinalized)
+	if err != nil {
+		t.Fatalf("create purchase: %v", err)
+	}
+	sale := func(quantity float64, adjustments InvoiceAdjustments) (int64, error) {
+		return repo.CreateSalesInvoice(ctx, nil, nil, nil, "sales",
+			[]domain.SalesLineInput{{ProductName: "adjusted", Price: 50, Quantity: quantity}},
+			adjustments, InvoiceStatusFinalized, false)
+	}
+	discountedID, err := sale(2, InvoiceAdjustments{DiscountAmount: amount(20)})
+	if err != nil {
+		t.Fatalf("create discounted sale: %v", err)
+	}
+	taxedID, err := sale(1, InvoiceAdjustments{TaxAmount: amount(5)})
+	if err != nil {
+		t.Fatalf("create taxed sale: %v", err)
+	}
+
+	invoices := []struct {
+		id       int64
+		discount *float64
+		tax      *float64
+		total    float64
+	}{
+		{id: purchaseID, tax: amount(10), total: 20},
+		{id: discountedID, discount: amount(20), total: 80},
+		{id: taxedID, tax: amount(5), total: 55},
+	}
+	for _, want := range invoices {
+		invoice, err := repo.GetInvoice(ctx, want.id)
+		if err != nil {
+			t.Fatalf("get invoice %d: %v", want.id, err)
+		}
+		if invoice.TotalAmount != want.total ||
+			!reflect.DeepEqual(invoice.DiscountAmount, want.discount) ||
+			!reflect.DeepEqual(invoice.TaxAmount, want.tax) {
+			t.Errorf("invoice %d total/discount/tax = %v/%v/%v, want %v/%v/%v", want.id,
+				invoice.TotalAmount, ptrValue(invoice.DiscountAmount), ptrValue(invoice.TaxAmount),
+				want.total, ptrValue(want.discount), ptrValue(want.tax))
+		}
+	}
+
+	summary, err := repo.GetMonthlySummary(ctx, 0)
+	if err != nil {
+		t.Fatalf("GetMonthlySummary: %v", err)
+	}
+	if len(summary) != 1 {
+		t.Fatalf("monthly summary has %d months, want 1: %+v", len(summary), summary)
+	}
+	// Profit: (100 - 20 cost) - 20 discount + (50 - 10 cost).
+	want := domain.MonthlySummary{Month: summary[0].Month, PurchaseTotal: 20, SalesTotal: 135, Profit: 100, InvoiceCount: 3}
+	if summary[0] != want {
+		t.Fatalf("monthly summary = %+v, want %+v", summary[0], want)
+	}
+
+	rejected := []struct {
+		name        string
+		adjustments InvoiceAdjustments
+	}{
+		{name: "negative discount", adjustments: InvoiceAdjustments{DiscountAmount: amount(-1)}},
+		{name: "discount above subtotal", adjustments: InvoiceAdjustments{DiscountAmount: amount(51)}},
+		{name: "negative tax", adjustments: InvoiceAdjustments{TaxAmount: amount(-1)}},
+	}
+	for _, tt := range rejected {
+		if _, err := sale(1, tt.adjustments); err == nil {
+			t.Errorf("%s: sale was accepted", tt.name)
+		}
+	}
+}
+
+func ptrValue(value *float64) any {
+	if value == nil {
+		return nil
+	}
+	return *value
+}
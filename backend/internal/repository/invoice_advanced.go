@@ -27,6 +27,7 @@ func loadInvoiceLinesTx(ctx context.Context, tx pgx.Tx, invoiceID int64) ([]doma
 			product_name,
 			price::double precision,
 			quantity,
+			discount::double precision,
 			line_total::double precision,
 			cost_price::double precision
 		FROM invoice_lines
@@ -52,29 +53,34 @@ func loadInvoiceLinesTx(ctx context.Context, tx pgx.Tx, invoiceID int64) ([]doma
 	return items, nil
 }
 
-func loadProductForUpdate(ctx context.Context, tx pgx.Tx, name string) (int64, int, float64, float64, error) {
+func loadProductForUpdate(ctx context.Context, tx pgx.Tx, name string) (int64, float64, float64, float64, error) {
 	var (
 		id       int64
-		quantity int
+		quantity float64
 		avgBuy   float64
 		lastBuy  float64
+		deleted  bool
 	)
 	err := tx.QueryRow(ctx, `
 		SELECT
 			id,
 			quantity,
 			avg_buy_price::double precision,
-			last_buy_price::double precision
+			last_buy_price::double precision,
+			deleted_at IS NOT NULL
 		FROM products
-		WHERE LOWER(product_name) = LOWER($1)
+		WHERE product_name_normalized = LOWER($1)
 		FOR UPDATE
-	`, name).Scan(&id, &quantity, &avgBuy, &lastBuy)
+	`, name).Scan(&id, &quantity, &avgBuy, &lastBuy, &deleted)
 	if errors.Is(err, pgx.ErrNoRows) {
 		return 0, 0, 0, 0, ErrNotFound
 	}
 	if err != nil {
 		return 0, 0, 0, 0, fmt.Errorf("load product %q: %w", name, err)
 	}
+	if deleted {
+		return 0, 0, 0, 0, fmt.Errorf("%w: %q", ErrProductMerged, name)
+	}
 	return id, quantity, avgBuy, lastBuy, nil
 }
 
@@ -91,11 +97,16 @@ func validateNewInvoiceLines(lines []domain.InvoiceLine) ([]domain.InvoiceLine,
 		if line.Price <= 0 {
 			return nil, fmt.Errorf("invalid price for %q", name)
 		}
+		lineTotal, err := discountedLineTotal(name, line.Price, line.Quantity, line.Discount)
+		if err != nil {
+			return nil, err
+		}
 		cleaned = append(cleaned, domain.InvoiceLine{
 			ProductName: name,
 			Price:       line.Price,
 			Quantity:    line.Quantity,
-			LineTotal:   line.Price * float64(line.Quantity),
+			Discount:    line.Discount,
+			LineTotal:   lineTotal,
 			CostPrice:   line.CostPrice,
 		})
 	}
@@ -106,29 +117,19 @@ func upsertInvoiceLinesTx(ctx context.Context, tx pgx.Tx, invoiceID int64, invoi
 	if _, err := tx.Exec(ctx, "DELETE FROM invoice_lines WHERE invoice_id = $1", invoiceID); err != nil {
 		return fmt.Errorf("clear invoice lines: %w", err)
 	}
-	for _, line := range lines {
-		costPrice := line.CostPrice
-		if invoiceType == "purchase" {
-			costPrice = line.Price
-		}
-		if _, err := tx.Exec(ctx, `
-			INSERT INTO invoice_lines (
-				invoice_id,
-				product_name,
-				price,
-				quantity,
-				line_total,
-				cost_price
-			) VALUES ($1, $2, $3, $4, $5, $6)
-		`, invoiceID, line.ProductName, line.Price, line.Quantity, line.LineTotal, costPrice); err != nil {
-			return fmt.Errorf("insert invoice line for invoice %d: %w", invoiceID, err)
+	if invoiceType == "purchase" {
+		priced := make([]domain.InvoiceLine, len(lines))
+		for i, line := range lines {
+			line.CostPrice = line.Price
+			priced[i] = line
 		}
+		lines = priced
 	}
-	return nil
+	return copyInvoiceLinesTx(ctx, tx, invoiceID, lines)
 }
 
 func updateInvoiceTotalsTx(ctx context.Context, tx pgx.Tx, invoiceID int64, invoiceName *string, lines []domain.InvoiceLine) error {
-	totalQty := 0
+	totalQty := 0.0
 	totalAmount := 0.0
 	for _, line := range lines {
 		totalQty += line.Quantity
@@ -139,7 +140,7 @@ func updateInvoiceTotalsTx(ctx context.Context, tx pgx.Tx, invoiceID int64, invo
 		SET
 			total_lines = $2,
 			total_qty = $3,
-			total_amount = $4,
+			total_amount = $4 - COALESCE(discount_amount, 0) + COALESCE(tax_amount, 0),
 			invoice_name = $5
 		WHERE id = $1
 	`, invoiceID, len(lines), totalQty, totalAmount, invoiceName); err != nil {
@@ -148,81 +149,101 @@ func updateInvoiceTotalsTx(ctx context.Context, tx pgx.Tx, invoiceID int64, invo
 	return nil
 }
 
-func (r *Repository) UpdateInvoiceLinesReconciled(
+// reconcileInvoiceLinesTx moves stock from the invoice's recorded effects to
+// those of lines and returns the new effects for the caller to store.
+// Products whose effect is unchanged are left untouched. Line names are
+// resolved to their products in place, so the caller stores canonical names.
+func reconcileInvoiceLinesTx(
 	ctx context.Context,
+	tx pgx.Tx,
 	invoiceID int64,
-	invoiceName *string,
-	newLines []domain.InvoiceLine,
-) error {
-	cleanedLines, err := validateNewInvoiceLines(newLines)
-	if err != nil {
-		return err
-	}
-
-	tx, err := r.pool.Begin(ctx)
-	if err != nil {
-		return fmt.Errorf("begin update invoice tx: %w", err)
-	}
-	defer tx.Rollback(ctx)
-
-	var invoiceType string
-	err = tx.QueryRow(ctx, `
-		SELECT invoice_type
-		FROM invoices
-		WHERE id = $1
-		FOR UPDATE
-	`, invoiceID).Scan(&invoiceType)
-	if errors.Is(err, pgx.ErrNoRows) {
-		return ErrNotFound
-	}
+	invoiceType string,
+	status string,
+	lines []domain.InvoiceLine,
+	allowNegativeStock bool,
+) ([]inventoryEffect, error) {
+	oldEffects, err := loadInvoiceEffectsWithFallbackTx(ctx, tx, invoiceID, invoiceType)
 	if err != nil {
-		return fmt.Errorf("load invoice %d: %w", invoiceID, err)
+		return nil, err
 	}
-
-	oldLines, err := loadInvoiceLinesTx(ctx, tx, invoiceID)
+	resolved, err := lockInvoiceProductsTx(ctx, tx, invoiceLineNames(lines), oldEffects)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	oldEffects, err := loadInvoiceStockEffectsTx(ctx, tx, invoiceID)
-	if err != nil {
-		return err
+	for i := range lines {
+		lines[i].ProductName = resolvedProductName(resolved, lines[i].ProductName)
 	}
-	if len(oldEffects) == 0 {
-		if strings.HasPrefix(invoiceType, "sales") {
-			oldEffects = legacySalesEffectsFromInvoiceLines(oldLines)
-		} else {
-			oldEffects = legacyPurchaseEffectsFromInvoiceLines(oldLines)
-		}
+	if status == InvoiceStatusDraft {
+		// Drafts never touched stock, so only the recorded effects change.
+		oldEffects = nil
 	}
 
 	var newEffects []inventoryEffect
 
-	if strings.HasPrefix(invoiceType, "sales") {
+	if isSalesInvoiceType(invoiceType) {
 		newEffects, err = buildSalesEffectsFromInvoiceLinesTx(
 			ctx,
 			tx,
-			cleanedLines,
+			lines,
 		)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		if err := applySalesChangeTx(ctx, tx, oldEffects, newEffects); err != nil {
-			return err
+		newEffects = salesEffectsForType(invoiceType, newEffects)
+		if status == InvoiceStatusFinalized {
+			if err := applySalesChangeTx(ctx, tx, oldEffects, newEffects, allowNegativeStock); err != nil {
+				return nil, err
+			}
 		}
 	} else if invoiceType == "purchase" {
 		newEffects, err = buildPurchaseEffectsFromInvoiceLinesTx(
 			ctx,
 			tx,
-			cleanedLines,
+			lines,
 		)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		if err := applyPurchaseChangeTx(ctx, tx, oldEffects, newEffects); err != nil {
-			return err
+		if status == InvoiceStatusFinalized {
+			if err := applyPurchaseChangeTx(ctx, tx, oldEffects, newEffects); err != nil {
+				return nil, err
+			}
 		}
 	} else {
-		return fmt.Errorf("unsupported invoice type: %s", invoiceType)
+		return nil, fmt.Errorf("unsupported invoice type: %s", invoiceType)
+	}
+	return newEffects, nil
+}
+
+func (r *Repository) UpdateInvoiceLinesReconciled(
+	ctx context.Context,
+	invoiceID int64,
+	invoiceName *string,
+	newLines []domain.InvoiceLine,
+	allowNegativeStock bool,
+) error {
+	cleanedLines, err := validateNewInvoiceLines(newLines)
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin update invoice tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	invoiceType, status, err := loadInvoiceStatusForUpdateTx(ctx, tx, invoiceID)
+	if err != nil {
+		return err
+	}
+	if status == InvoiceStatusVoid {
+		return fmt.Errorf("%w: invoice %d is void", ErrInvoiceStatusConflict, invoiceID)
+	}
+
+	newEffects, err := reconcileInvoiceLinesTx(ctx, tx, invoiceID, invoiceType, status, cleanedLines, allowNegativeStock)
+	if err != nil {
+		return err
 	}
 
 	if err := upsertInvoiceLinesTx(ctx, tx, invoiceID, invoiceType, cleanedLines); err != nil {
@@ -234,6 +255,9 @@ func (r *Repository) UpdateInvoiceLinesReconciled(
 	if err := updateInvoiceTotalsTx(ctx, tx, invoiceID, invoiceName, cleanedLines); err != nil {
 		return err
 	}
+	if err := logInvoiceActionTx(ctx, tx, invoiceUpdateActionType, invoiceID, AdminUsernameFromContext(ctx)); err != nil {
+		return err
+	}
 
 	if err := tx.Commit(ctx); err != nil {
 		return fmt.Errorf("commit update invoice tx: %w", err)
@@ -241,53 +265,126 @@ func (r *Repository) UpdateInvoiceLinesReconciled(
 	return nil
 }
 
-func (r *Repository) DeleteInvoiceReconciled(ctx context.Context, invoiceID int64) error {
+// InvoiceLinePatch changes one invoice line; nil fields keep their value.
+type InvoiceLinePatch struct {
+	Price    *float64
+	Quantity *float64
+	Discount *float64
+}
+
+// UpdateInvoiceLineReconciled patches a single line in place, keeping its id,
+// and moves stock only for that line's product before refreshing the totals.
+func (r *Repository) UpdateInvoiceLineReconciled(
+	ctx context.Context,
+	invoiceID int64,
+	lineID int64,
+	patch InvoiceLinePatch,
+	allowNegativeStock bool,
+) (domain.InvoiceLine, error) {
 	tx, err := r.pool.Begin(ctx)
 	if err != nil {
-		return fmt.Errorf("begin delete invoice tx: %w", err)
+		return domain.InvoiceLine{}, fmt.Errorf("begin update invoice line tx: %w", err)
 	}
 	defer tx.Rollback(ctx)
 
-	var invoiceType string
-	err = tx.QueryRow(ctx, `
-		SELECT invoice_type
-		FROM invoices
-		WHERE id = $1
-		FOR UPDATE
-	`, invoiceID).Scan(&invoiceType)
-	if errors.Is(err, pgx.ErrNoRows) {
-		return ErrNotFound
+	invoiceType, status, err := loadInvoiceStatusForUpdateTx(ctx, tx, invoiceID)
+	if err != nil {
+		return domain.InvoiceLine{}, err
+	}
+	if status == InvoiceStatusVoid {
+		return domain.InvoiceLine{}, fmt.Errorf("%w: invoice %d is void", ErrInvoiceStatusConflict, invoiceID)
 	}
+
+	lines, err := loadInvoiceLinesTx(ctx, tx, invoiceID)
 	if err != nil {
-		return fmt.Errorf("load invoice %d: %w", invoiceID, err)
+		return domain.InvoiceLine{}, err
+	}
+	target := -1
+	for index, line := range lines {
+		if line.ID == lineID {
+			target = index
+			break
+		}
+	}
+	if target < 0 {
+		return domain.InvoiceLine{}, ErrInvoiceLineNotFound
+	}
+	if patch.Price != nil {
+		lines[target].Price = *patch.Price
+	}
+	if patch.Quantity != nil {
+		lines[target].Quantity = *patch.Quantity
+	}
+	if patch.Discount != nil {
+		lines[target].Discount = *patch.Discount
 	}
 
-	oldLines, err := loadInvoiceLinesTx(ctx, tx, invoiceID)
+	cleanedLines, err := validateNewInvoiceLines(lines)
 	if err != nil {
-		return err
+		return domain.InvoiceLine{}, err
 	}
-	oldEffects, err := loadInvoiceStockEffectsTx(ctx, tx, invoiceID)
+	newEffects, err := reconcileInvoiceLinesTx(ctx, tx, invoiceID, invoiceType, status, cleanedLines, allowNegativeStock)
 	if err != nil {
-		return err
+		return domain.InvoiceLine{}, err
 	}
-	if len(oldEffects) == 0 {
-		if strings.HasPrefix(invoiceType, "sales") {
-			oldEffects = legacySalesEffectsFromInvoiceLines(oldLines)
-		} else {
-			oldEffects = legacyPurchaseEffectsFromInvoiceLines(oldLines)
-		}
+
+	updated := cleanedLines[target]
+	updated.ID = lineID
+	updated.InvoiceID = invoiceID
+	if invoiceType == "purchase" {
+		updated.CostPrice = updated.Price
+	}
+	if _, err := tx.Exec(ctx, `
+		UPDATE invoice_lines
+		SET
+			price = $3,
+			quantity = $4,
+			discount = $5,
+			line_total = $6,
+			cost_price = $7
+		WHERE id = $1 AND invoice_id = $2
+	`, lineID, invoiceID, updated.Price, updated.Quantity, updated.Discount, updated.LineTotal, updated.CostPrice); err != nil {
+		return domain.InvoiceLine{}, fmt.Errorf("update invoice line %d: %w", lineID, err)
+	}
+	if err := replaceInvoiceStockEffectsTx(ctx, tx, invoiceID, newEffects); err != nil {
+		return domain.InvoiceLine{}, err
 	}
 
-	if strings.HasPrefix(invoiceType, "sales") {
-		if err := applySalesChangeTx(ctx, tx, oldEffects, nil); err != nil {
-			return err
-		}
-	} else if invoiceType == "purchase" {
-		if err := applyPurchaseChangeTx(ctx, tx, oldEffects, nil); err != nil {
+	var invoiceName *string
+	if err := tx.QueryRow(ctx, "SELECT invoice_name FROM invoices WHERE id = $1", invoiceID).Scan(&invoiceName); err != nil {
+		return domain.InvoiceLine{}, fmt.Errorf("load invoice %d name: %w", invoiceID, err)
+	}
+	if err := updateInvoiceTotalsTx(ctx, tx, invoiceID, invoiceName, cleanedLines); err != nil {
+		return domain.InvoiceLine{}, err
+	}
+	if err := logInvoiceActionTx(ctx, tx, invoiceUpdateActionType, invoiceID, AdminUsernameFromContext(ctx)); err != nil {
+		return domain.InvoiceLine{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return domain.InvoiceLine{}, fmt.Errorf("commit update invoice line tx: %w", err)
+	}
+	return updated, nil
+}
+
+func (r *Repository) DeleteInvoiceReconciled(ctx context.Context, invoiceID int64) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin delete invoice tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	invoiceType, status, err := loadInvoiceStatusForUpdateTx(ctx, tx, invoiceID)
+	if err != nil {
+		return err
+	}
+	if status == InvoiceStatusFinalized {
+		if err := reverseInvoiceStockTx(ctx, tx, invoiceID, invoiceType); err != nil {
 			return err
 		}
-	} else {
-		return fmt.Errorf("unsupported invoice type: %s", invoiceType)
+	}
+	if err := logInvoiceActionTx(ctx, tx, invoiceDeleteActionType, invoiceID, AdminUsernameFromContext(ctx)); err != nil {
+		return err
 	}
 
 	if _, err := tx.Exec(ctx, "DELETE FROM invoices WHERE id = $1", invoiceID); err != nil {
@@ -324,7 +421,7 @@ func legacyPurchaseEffectsFromInvoiceLines(lines []domain.InvoiceLine) []invento
 		result = append(result, inventoryEffect{
 			ProductName: name,
 			Quantity:    line.Quantity,
-			TotalCost:   line.Price * float64(line.Quantity),
+			TotalCost:   line.Price * line.Quantity,
 			LastPrice:   line.Price,
 		})
 	}
@@ -345,17 +442,38 @@ func (r *Repository) GetInvoiceStats(
 			COUNT(*)::int,
 			COALESCE(SUM(total_amount), 0)::double precision
 		FROM invoices
-		WHERE (
-			$1 = ''
-			OR ($1 = 'sales' AND invoice_type LIKE 'sales%')
-			OR invoice_type = $1
-		)
-	`, invoiceType).Scan(&count, &total); err != nil {
+		WHERE `+invoiceTypeCondition("invoice_type", "$1"),
+		invoiceType,
+	).Scan(&count, &total); err != nil {
 		return 0, 0, fmt.Errorf("get invoice stats: %w", err)
 	}
 	return count, total, nil
 }
 
+// GetInvoiceStatsFiltered counts and sums the invoices matched by filter,
+// ignoring its limit and offset.
+func (r *Repository) GetInvoiceStatsFiltered(
+	ctx context.Context,
+	filter InvoiceListFilter,
+) (int, float64, error) {
+	var (
+		count int
+		total float64
+	)
+	where, args := invoiceListWhere(filter)
+	if err := r.pool.QueryRow(ctx, `
+		SELECT
+			COUNT(*)::int,
+			COALESCE(SUM(total_amount), 0)::double precision
+		FROM invoices
+		WHERE `+where,
+		args...,
+	).Scan(&count, &total); err != nil {
+		return 0, 0, fmt.Errorf("get filtered invoice stats: %w", err)
+	}
+	return count, total, nil
+}
+
 func (r *Repository) ListInvoicesBetween(
 	ctx context.Context,
 	start time.Time,
@@ -364,6 +482,7 @@ func (r *Repository) ListInvoicesBetween(
 	fuzzy bool,
 	idFrom *int64,
 	idTo *int64,
+	includeLines bool,
 ) ([]domain.Invoice, error) {
 	conditions := []string{"i.created_at >= $1", "i.created_at <= $2"}
 	params := []any{start, end}
@@ -401,6 +520,11 @@ func (r *Repository) ListInvoicesBetween(
 					i.total_amount::double precision,
 					i.invoice_name,
 					i.admin_username,
+					i.discount_amount::double precision AS discount_amount,
+					i.tax_amount::double precision AS tax_amount,
+					i.status,
+					i.supplier_name,
+					i.customer_name,
 					il.product_name,
 					il.price::double precision,
 					il.quantity,
@@ -423,6 +547,11 @@ func (r *Repository) ListInvoicesBetween(
 				total_amount::double precision,
 				invoice_name,
 				admin_username,
+				discount_amount,
+				tax_amount,
+				status,
+				supplier_name,
+				customer_name,
 				COALESCE(
 					JSON_AGG(
 						JSON_BUILD_OBJECT(
@@ -447,10 +576,43 @@ func (r *Repository) ListInvoicesBetween(
 				total_qty,
 				total_amount,
 				invoice_name,
-				admin_username
+				admin_username,
+				discount_amount,
+				tax_amount,
+				status,
+				supplier_name,
+				customer_name
 			ORDER BY id DESC
 		`, whereClause, op, index)
 	} else {
+		linesColumn := "'[]'::json"
+		if includeLines {
+			linesColumn = `
+				COALESCE((
+					SELECT JSON_AGG(
+						JSON_BUILD_OBJECT(
+							'row_number', numbered.row_number,
+							'product_name', numbered.product_name,
+							'price', numbered.price,
+							'quantity', numbered.quantity,
+							'line_total', numbered.line_total,
+							'cost_price', numbered.cost_price
+						)
+						ORDER BY numbered.row_number
+					)
+					FROM (
+						SELECT
+							il.product_name,
+							il.price::double precision AS price,
+							il.quantity,
+							il.line_total::double precision AS line_total,
+							il.cost_price::double precision AS cost_price,
+							ROW_NUMBER() OVER (ORDER BY il.id)::int AS row_number
+						FROM invoice_lines il
+						WHERE il.invoice_id = i.id
+					) numbered
+				), '[]'::json)`
+		}
 		query = fmt.Sprintf(`
 			SELECT
 				i.id,
@@ -461,11 +623,16 @@ func (r *Repository) ListInvoicesBetween(
 				i.total_amount::double precision,
 				i.invoice_name,
 				i.admin_username,
-				'[]'::json
+				i.discount_amount::double precision,
+				i.tax_amount::double precision,
+				i.status,
+				i.supplier_name,
+				i.customer_name,
+				%s
 			FROM invoices i
 			WHERE %s
 			ORDER BY i.id DESC
-		`, whereClause)
+		`, linesColumn, whereClause)
 	}
 
 	rows, err := r.pool.Query(ctx, query, params...)
@@ -480,6 +647,10 @@ func (r *Repository) ListInvoicesBetween(
 			item     domain.Invoice
 			name     sql.NullString
 			admin    sql.NullString
+			discount sql.NullFloat64
+			tax      sql.NullFloat64
+			supplier sql.NullString
+			customer sql.NullString
 			rawMatch []byte
 		)
 		if err := rows.Scan(
@@ -491,6 +662,11 @@ func (r *Repository) ListInvoicesBetween(
 			&item.TotalAmount,
 			&name,
 			&admin,
+			&discount,
+			&tax,
+			&item.Status,
+			&supplier,
+			&customer,
 			&rawMatch,
 		); err != nil {
 			return nil, fmt.Errorf("scan invoices between row: %w", err)
@@ -503,6 +679,22 @@ func (r *Repository) ListInvoicesBetween(
 			value := admin.String
 			item.AdminUsername = &value
 		}
+		if discount.Valid {
+			value := discount.Float64
+			item.DiscountAmount = &value
+		}
+		if tax.Valid {
+			value := tax.Float64
+			item.TaxAmount = &value
+		}
+		if supplier.Valid {
+			value := supplier.String
+			item.SupplierName = &value
+		}
+		if customer.Valid {
+			value := customer.String
+			item.CustomerName = &value
+		}
 		if len(rawMatch) > 0 {
 			if err := json.Unmarshal(rawMatch, &item.ProductMatches); err != nil {
 				return nil, fmt.Errorf(
@@ -520,6 +712,94 @@ func (r *Repository) ListInvoicesBetween(
 	return items, nil
 }
 
+func (r *Repository) BackfillSalesCostPrices(ctx context.Context) (int, error) {
+	cmd, err := r.pool.Exec(ctx, `
+		UPDATE invoice_lines il
+		SET cost_price = p.avg_buy_price
+		FROM invoices i, products p
+		WHERE i.id = il.invoice_id
+		  AND i.invoice_type LIKE 'sales%'
+		  AND i.status = 'finalized'
+		  AND il.cost_price = 0
+		  AND p.product_name_normalized = LOWER(il.product_name)
+		  AND p.avg_buy_price > 0
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("backfill sales cost prices: %w", err)
+	}
+	return int(cmd.RowsAffected()), nil
+}
+
+// RecalcInvoiceTotals rewrites an invoice's total_lines, total_qty and
+// total_amount from its stored lines, for lines changed outside the API.
+func (r *Repository) RecalcInvoiceTotals(ctx context.Context, invoiceID int64) (*domain.Invoice, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin recalc invoice tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var invoiceName *string
+	err = tx.QueryRow(ctx, `
+		SELECT invoice_name
+		FROM invoices
+		WHERE id = $1
+		FOR UPDATE
+	`, invoiceID).Scan(&invoiceName)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load invoice %d: %w", invoiceID, err)
+	}
+	lines, err := loadInvoiceLinesTx(ctx, tx, invoiceID)
+	if err != nil {
+		return nil, err
+	}
+	if err := updateInvoiceTotalsTx(ctx, tx, invoiceID, invoiceName, lines); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("commit recalc invoice tx: %w", err)
+	}
+	return r.GetInvoice(ctx, invoiceID)
+}
+
+// RecalcAllInvoiceTotals applies the RecalcInvoiceTotals arithmetic to every
+// invoice in one statement and returns how many had stale totals.
+func (r *Repository) RecalcAllInvoiceTotals(ctx context.Context) (int, error) {
+	cmd, err := r.pool.Exec(ctx, `
+		WITH totals AS (
+			SELECT
+				i.id,
+				COUNT(il.id)::int AS total_lines,
+				COALESCE(SUM(il.quantity), 0) AS total_qty,
+				COALESCE(SUM(il.line_total), 0)
+					- COALESCE(i.discount_amount, 0)
+					+ COALESCE(i.tax_amount, 0) AS total_amount
+			FROM invoices i
+			LEFT JOIN invoice_lines il ON il.invoice_id = i.id
+			GROUP BY i.id
+		)
+		UPDATE invoices i
+		SET
+			total_lines = t.total_lines,
+			total_qty = t.total_qty,
+			total_amount = t.total_amount
+		FROM totals t
+		WHERE t.id = i.id
+		  AND (
+			i.total_lines IS DISTINCT FROM t.total_lines
+			OR i.total_qty IS DISTINCT FROM t.total_qty
+			OR i.total_amount IS DISTINCT FROM t.total_amount
+		  )
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("recalc invoice totals: %w", err)
+	}
+	return int(cmd.RowsAffected()), nil
+}
+
 func (r *Repository) RenameInvoiceProducts(
 	ctx context.Context,
 	changes [][2]string,
@@ -534,6 +814,23 @@ func (r *Repository) RenameInvoiceProducts(
 	}
 	defer tx.Rollback(ctx)
 
+	result, err := renameInvoiceProductsTx(ctx, tx, changes)
+	if err != nil {
+		return domain.ProductRenameResult{}, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return domain.ProductRenameResult{}, fmt.Errorf("commit rename tx: %w", err)
+	}
+	return result, nil
+}
+
+// renameInvoiceProductsTx rewrites invoice line product names for each
+// old => new pair and reports the touched lines and invoices.
+func renameInvoiceProductsTx(
+	ctx context.Context,
+	tx pgx.Tx,
+	changes [][2]string,
+) (domain.ProductRenameResult, error) {
 	result := domain.ProductRenameResult{}
 	invoiceSet := map[int64]struct{}{}
 	for _, pair := range changes {
@@ -579,18 +876,18 @@ func (r *Repository) RenameInvoiceProducts(
 	sort.Slice(result.UpdatedInvoiceIDs, func(i, j int) bool {
 		return result.UpdatedInvoiceIDs[i] < result.UpdatedInvoiceIDs[j]
 	})
-
-	if err := tx.Commit(ctx); err != nil {
-		return domain.ProductRenameResult{}, fmt.Errorf("commit rename tx: %w", err)
-	}
 	return result, nil
 }
 
-func (r *Repository) SetDefaultAdmin(ctx context.Context) error {
+func (r *Repository) SetDefaultAdmin(ctx context.Context, username, password string) error {
+	username = strings.TrimSpace(username)
+	if username == "" || password == "" {
+		return fmt.Errorf("default admin username and password are required")
+	}
 	var exists bool
 	if err := r.pool.QueryRow(ctx,
 		"SELECT EXISTS(SELECT 1 FROM admins WHERE username = $1)",
-		"reza",
+		username,
 	).Scan(&exists); err != nil {
 		return fmt.Errorf("check default admin: %w", err)
 	}
@@ -600,47 +897,69 @@ func (r *Repository) SetDefaultAdmin(ctx context.Context) error {
 	if _, err := r.pool.Exec(ctx, `
 		INSERT INTO admins (username, password, role, auto_lock_minutes)
 		VALUES ($1, $2, $3, $4)
-	`, "reza", "reza1375", "manager", 1); err != nil {
+	`, username, password, "manager", 1); err != nil {
 		return fmt.Errorf("create default admin: %w", err)
 	}
 	return nil
 }
 
+// AuthenticateAdmin checks the credentials and records the login. It returns
+// nil without an error when they do not match an active admin.
 func (r *Repository) AuthenticateAdmin(ctx context.Context, username, password string) (*domain.AdminUser, error) {
+	admin, err := r.VerifyAdminPassword(ctx, username, password)
+	if err != nil || admin == nil {
+		return nil, err
+	}
+	var lastLogin time.Time
+	if err := r.pool.QueryRow(ctx,
+		"UPDATE admins SET last_login_at = NOW() WHERE id = $1 RETURNING last_login_at",
+		admin.AdminID,
+	).Scan(&lastLogin); err != nil {
+		return nil, fmt.Errorf("update admin last_login_at: %w", err)
+	}
+	admin.LastLoginAt = &lastLogin
+	return admin, nil
+}
+
+// VerifyAdminPassword checks the credentials like AuthenticateAdmin but leaves
+// last_login_at alone, for confirming a password that is not a login.
+func (r *Repository) VerifyAdminPassword(ctx context.Context, username, password string) (*domain.AdminUser, error) {
 	var (
-		adminID        int64
-		role           string
-		autoLock       int
+		admin          domain.AdminUser
 		storedPassword string
 	)
 	err := r.pool.QueryRow(ctx, `
-		SELECT id, role, auto_lock_minutes, password
+		SELECT id, role, auto_lock_minutes, password, active, last_login_at
 		FROM admins
 		WHERE username = $1
-	`, strings.TrimSpace(username)).Scan(&adminID, &role, &autoLock, &storedPassword)
+	`, strings.TrimSpace(username)).Scan(
+		&admin.AdminID,
+		&admin.Role,
+		&admin.AutoLockMinutes,
+		&storedPassword,
+		&admin.Active,
+		&admin.LastLoginAt,
+	)
 	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, nil
 	}
 	if err != nil {
 		return nil, fmt.Errorf("authenticate admin query: %w", err)
 	}
-	if storedPassword != password {
+	if storedPassword != password || !admin.Active {
 		return nil, nil
 	}
-	return &domain.AdminUser{
-		AdminID:         adminID,
-		Username:        strings.TrimSpace(username),
-		Role:            role,
-		AutoLockMinutes: autoLock,
-	}, nil
+	admin.Username = strings.TrimSpace(username)
+	return &admin, nil
 }
 
-func (r *Repository) ListAdmins(ctx context.Context) ([]domain.AdminUser, error) {
+func (r *Repository) ListAdmins(ctx context.Context, includeInactive bool) ([]domain.AdminUser, error) {
 	rows, err := r.pool.Query(ctx, `
-		SELECT id, username, role, auto_lock_minutes
+		SELECT id, username, role, auto_lock_minutes, active, last_login_at
 		FROM admins
+		WHERE $1 OR active
 		ORDER BY username ASC
-	`)
+	`, includeInactive)
 	if err != nil {
 		return nil, fmt.Errorf("list admins: %w", err)
 	}
@@ -648,7 +967,14 @@ func (r *Repository) ListAdmins(ctx context.Context) ([]domain.AdminUser, error)
 	items := make([]domain.AdminUser, 0)
 	for rows.Next() {
 		var row domain.AdminUser
-		if err := rows.Scan(&row.AdminID, &row.Username, &row.Role, &row.AutoLockMinutes); err != nil {
+		if err := rows.Scan(
+			&row.AdminID,
+			&row.Username,
+			&row.Role,
+			&row.AutoLockMinutes,
+			&row.Active,
+			&row.LastLoginAt,
+		); err != nil {
 			return nil, fmt.Errorf("scan admin: %w", err)
 		}
 		items = append(items, row)
@@ -678,22 +1004,23 @@ func (r *Repository) CreateAdmin(
 	if autoLockMinutes <= 0 {
 		autoLockMinutes = 1
 	}
-	if autoLockMinutes > 60 {
-		autoLockMinutes = 60
-	}
 
 	var created domain.AdminUser
 	err := r.pool.QueryRow(ctx, `
 		INSERT INTO admins (username, password, role, auto_lock_minutes)
 		VALUES ($1, $2, $3, $4)
-		RETURNING id, username, role, auto_lock_minutes
+		RETURNING id, username, role, auto_lock_minutes, active
 	`, username, password, role, autoLockMinutes).Scan(
 		&created.AdminID,
 		&created.Username,
 		&created.Role,
 		&created.AutoLockMinutes,
+		&created.Active,
 	)
 	if err != nil {
+		if isUniqueViolation(err, "") {
+			return nil, ErrDuplicateUsername
+		}
 		return nil, fmt.Errorf("create admin: %w", err)
 	}
 	return &created, nil
@@ -718,8 +1045,8 @@ func (r *Repository) UpdateAdminPassword(ctx context.Context, adminID int64, pas
 }
 
 func (r *Repository) UpdateAdminAutoLock(ctx context.Context, adminID int64, minutes int) error {
-	if minutes < 1 || minutes > 60 {
-		return fmt.Errorf("auto_lock_minutes must be between 1 and 60")
+	if minutes < 1 {
+		return fmt.Errorf("auto_lock_minutes must be at least 1")
 	}
 	cmd, err := r.pool.Exec(ctx,
 		"UPDATE admins SET auto_lock_minutes = $2 WHERE id = $1",
@@ -735,6 +1062,21 @@ func (r *Repository) UpdateAdminAutoLock(ctx context.Context, adminID int64, min
 	return nil
 }
 
+func (r *Repository) SetAdminActive(ctx context.Context, adminID int64, active bool) error {
+	cmd, err := r.pool.Exec(ctx,
+		"UPDATE admins SET active = $2 WHERE id = $1",
+		adminID,
+		active,
+	)
+	if err != nil {
+		return fmt.Errorf("update admin active: %w", err)
+	}
+	if cmd.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
 func (r *Repository) DeleteAdmin(ctx context.Context, adminID int64) error {
 	cmd, err := r.pool.Exec(ctx,
 		"DELETE FROM admins WHERE id = $1",
@@ -751,12 +1093,19 @@ func (r *Repository) DeleteAdmin(ctx context.Context, adminID int64) error {
 
 func (r *Repository) GetAdminByID(ctx context.Context, adminID int64) (*domain.AdminUser, error) {
 	row := r.pool.QueryRow(ctx, `
-		SELECT id, username, role, auto_lock_minutes
+		SELECT id, username, role, auto_lock_minutes, active, last_login_at
 		FROM admins
 		WHERE id = $1
 	`, adminID)
 	var admin domain.AdminUser
-	if err := row.Scan(&admin.AdminID, &admin.Username, &admin.Role, &admin.AutoLockMinutes); err != nil {
+	if err := row.Scan(
+		&admin.AdminID,
+		&admin.Username,
+		&admin.Role,
+		&admin.AutoLockMinutes,
+		&admin.Active,
+		&admin.LastLoginAt,
+	); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, ErrNotFound
 		}
@@ -791,14 +1140,132 @@ func (r *Repository) LogAction(
 	return nil
 }
 
+type ActionInput struct {
+	ActionType    string
+	Title         string
+	Details       string
+	AdminUsername *string
+}
+
+// LogActions stores a batch of actions in one transaction. The whole batch
+// is rejected if any entry is missing action_type or title.
+func (r *Repository) LogActions(ctx context.Context, entries []ActionInput) (int, error) {
+	rows := make([][]any, 0, len(entries))
+	for index, entry := range entries {
+		actionType := strings.TrimSpace(entry.ActionType)
+		title := strings.TrimSpace(entry.Title)
+		if actionType == "" || title == "" {
+			return 0, fmt.Errorf("action %d: action_type and title are required", index+1)
+		}
+		details := entry.Details
+		if details == "" {
+			details = "-"
+		}
+		rows = append(rows, []any{entry.AdminUsername, actionType, title, details})
+	}
+	if len(rows) == 0 {
+		return 0, nil
+	}
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("begin log actions tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	inserted, err := tx.CopyFrom(
+		ctx,
+		pgx.Identifier{"actions"},
+		[]string{"admin_username", "action_type", "title", "details"},
+		pgx.CopyFromRows(rows),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("log actions: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("commit log actions tx: %w", err)
+	}
+	return int(inserted), nil
+}
+
+type ActionFilter struct {
+	// Search matches title, details or admin_username case-insensitively.
+	Search     string
+	ActionType string
+	From       *time.Time
+	To         *time.Time
+}
+
+// actionWhere builds the WHERE body shared by the action list, count and
+// export queries.
+func actionWhere(filter ActionFilter) (string, []any) {
+	conditions := []string{"TRUE"}
+	args := []any{}
+	if search := strings.TrimSpace(filter.Search); search != "" {
+		args = append(args, search)
+		idx := len(args)
+		conditions = append(conditions, fmt.Sprintf(
+			"(title ILIKE '%%' || $%[1]d || '%%' OR details ILIKE '%%' || $%[1]d || '%%' OR COALESCE(admin_username, '') ILIKE '%%' || $%[1]d || '%%')",
+			idx,
+		))
+	}
+	if actionType := strings.TrimSpace(filter.ActionType); actionType != "" {
+		args = append(args, actionType)
+		conditions = append(conditions, fmt.Sprintf("action_type = $%d", len(args)))
+	}
+	if filter.From != nil {
+		args = append(args, *filter.From)
+		conditions = append(conditions, fmt.Sprintf("created_at >= $%d", len(args)))
+	}
+	if filter.To != nil {
+		args = append(args, *filter.To)
+		conditions = append(conditions, fmt.Sprintf("created_at <= $%d", len(args)))
+	}
+	return strings.Join(conditions, " AND "), args
+}
+
 func (r *Repository) ListActions(
 	ctx context.Context,
 	limit, offset int,
-	search string,
+	filter ActionFilter,
+) ([]domain.ActionEntry, bool, error) {
+	limit = normalizeLimit(limit)
+	offset = normalizeOffset(offset)
+
+	where, args := actionWhere(filter)
+	args = append(args, limit+1, offset)
+	rows, err := r.pool.Query(ctx, fmt.Sprintf(`
+		SELECT
+			id,
+			created_at,
+			admin_username,
+			action_type,
+			title,
+			details
+		FROM actions
+		WHERE %s
+		ORDER BY id DESC
+		LIMIT $%d OFFSET $%d
+	`, where, len(args)-1, len(args)), args...)
+	if err != nil {
+		return nil, false, fmt.Errorf("list actions: %w", err)
+	}
+	defer rows.Close()
+	items, err := collectActions(rows, limit+1)
+	if err != nil {
+		return nil, false, err
+	}
+	items, hasMore := trimPage(items, limit)
+	return items, hasMore, nil
+}
+
+func (r *Repository) ListActionsByAdmin(
+	ctx context.Context,
+	username string,
+	limit, offset int,
 ) ([]domain.ActionEntry, error) {
 	limit = normalizeLimit(limit)
 	offset = normalizeOffset(offset)
-	search = strings.TrimSpace(search)
 
 	rows, err := r.pool.Query(ctx, `
 		SELECT
@@ -809,34 +1276,23 @@ func (r *Repository) ListActions(
 			title,
 			details
 		FROM actions
-		WHERE ($1 = '' OR title ILIKE '%' || $1 || '%' OR details ILIKE '%' || $1 || '%' OR COALESCE(admin_username, '') ILIKE '%' || $1 || '%')
-		ORDER BY id DESC
+		WHERE admin_username = $1
+		ORDER BY created_at DESC, id DESC
 		LIMIT $2 OFFSET $3
-	`, search, limit, offset)
+	`, username, limit, offset)
 	if err != nil {
-		return nil, fmt.Errorf("list actions: %w", err)
+		return nil, fmt.Errorf("list actions by admin: %w", err)
 	}
 	defer rows.Close()
+	return collectActions(rows, limit)
+}
 
-	items := make([]domain.ActionEntry, 0, limit)
+func collectActions(rows pgx.Rows, capacity int) ([]domain.ActionEntry, error) {
+	items := make([]domain.ActionEntry, 0, capacity)
 	for rows.Next() {
-		var (
-			row   domain.ActionEntry
-			admin sql.NullString
-		)
-		if err := rows.Scan(
-			&row.ActionID,
-			&row.CreatedAt,
-			&admin,
-			&row.ActionType,
-			&row.Title,
-			&row.Details,
-		); err != nil {
-			return nil, fmt.Errorf("scan action: %w", err)
-		}
-		if admin.Valid {
-			value := admin.String
-			row.AdminUsername = &value
+		row, err := scanAction(rows)
+		if err != nil {
+			return nil, err
 		}
 		items = append(items, row)
 	}
@@ -846,34 +1302,179 @@ func (r *Repository) ListActions(
 	return items, nil
 }
 
-func (r *Repository) CountActions(ctx context.Context, search string) (int, error) {
-	search = strings.TrimSpace(search)
+func scanAction(rows pgx.Rows) (domain.ActionEntry, error) {
+	var (
+		row   domain.ActionEntry
+		admin sql.NullString
+	)
+	if err := rows.Scan(
+		&row.ActionID,
+		&row.CreatedAt,
+		&admin,
+		&row.ActionType,
+		&row.Title,
+		&row.Details,
+	); err != nil {
+		return row, fmt.Errorf("scan action: %w", err)
+	}
+	if admin.Valid {
+		value := admin.String
+		row.AdminUsername = &value
+	}
+	return row, nil
+}
+
+func (r *Repository) CountActions(ctx context.Context, filter ActionFilter) (int, error) {
+	where, args := actionWhere(filter)
 	var count int
 	if err := r.pool.QueryRow(ctx, `
 		SELECT COUNT(*)::int
 		FROM actions
-		WHERE ($1 = '' OR title ILIKE '%' || $1 || '%' OR details ILIKE '%' || $1 || '%' OR COALESCE(admin_username, '') ILIKE '%' || $1 || '%')
-	`, search).Scan(&count); err != nil {
+		WHERE `+where, args...).Scan(&count); err != nil {
 		return 0, fmt.Errorf("count actions: %w", err)
 	}
 	return count, nil
 }
 
+const deleteActionsBatchSize = 5000
+
+// DeleteActionsBefore removes actions created strictly before cutoff and
+// returns how many rows were deleted. Rows go in batches of
+// deleteActionsBatchSize, each in its own statement, so a large purge never
+// holds locks on the whole table.
+func (r *Repository) DeleteActionsBefore(ctx context.Context, cutoff time.Time) (int, error) {
+	total := 0
+	for {
+		tag, err := r.pool.Exec(ctx, `
+			DELETE FROM actions
+			WHERE id IN (
+				SELECT id
+				FROM actions
+				WHERE created_at < $1
+				ORDER BY id
+				LIMIT $2
+			)
+		`, cutoff, deleteActionsBatchSize)
+		if err != nil {
+			return total, fmt.Errorf("delete actions before %s: %w", cutoff.Format(time.RFC3339), err)
+		}
+		deleted := int(tag.RowsAffected())
+		total += deleted
+		if deleted < deleteActionsBatchSize {
+			return total, nil
+		}
+	}
+}
+
+// StreamActions calls fn for every action matching filter, oldest first,
+// without a row cap. Rows are read from the cursor one at a time so memory
+// stays flat for large exports.
+func (r *Repository) StreamActions(
+	ctx context.Context,
+	filter ActionFilter,
+	fn func(domain.ActionEntry) error,
+) error {
+	where, args := actionWhere(filter)
+	rows, err := r.pool.Query(ctx, `
+		SELECT
+			id,
+			created_at,
+			admin_username,
+			action_type,
+			title,
+			details
+		FROM actions
+		WHERE `+where+`
+		ORDER BY id ASC
+	`, args...)
+	if err != nil {
+		return fmt.Errorf("export actions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		row, err := scanAction(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("iterate actions export: %w", err)
+	}
+	return nil
+}
+
+// draftSalesReservations sums, per product id, the stock that draft sales
+// invoices will take once finalized. Draft returns do not free stock up.
+func (r *Repository) draftSalesReservations(ctx context.Context) (map[int64]float64, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT e.product_id, SUM(e.quantity)::double precision
+		FROM invoice_stock_effects e
+		JOIN invoices i ON i.id = e.invoice_id
+		WHERE i.status = 'draft'
+			AND i.invoice_type LIKE 'sales%'
+			AND e.quantity > 0
+		GROUP BY e.product_id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("load draft sales reservations: %w", err)
+	}
+	defer rows.Close()
+
+	reserved := map[int64]float64{}
+	for rows.Next() {
+		var (
+			productID int64
+			quantity  float64
+		)
+		if err := rows.Scan(&productID, &quantity); err != nil {
+			return nil, fmt.Errorf("scan draft sales reservation: %w", err)
+		}
+		reserved[productID] = quantity
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate draft sales reservations: %w", err)
+	}
+	return reserved, nil
+}
+
+// PreviewSales simulates the rows against current stock minus what draft
+// sales invoices already reserve. Rows that would take stock below zero are
+// reported as errors unless allowNegativeStock is set.
 func (r *Repository) PreviewSales(
 	ctx context.Context,
 	rows []domain.SalesPreviewRow,
+	allowNegativeStock bool,
 ) ([]domain.SalesPreviewRow, int, int, error) {
 	products, err := r.ListAllProducts(ctx)
 	if err != nil {
 		return nil, 0, 0, err
 	}
-	available := map[string]int{}
+	reservations, err := r.draftSalesReservations(ctx)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	names := make([]string, 0, len(rows))
+	for _, row := range rows {
+		names = append(names, row.ProductName)
+	}
+	resolved, err := resolveProductNames(ctx, r.pool, names)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	available := map[string]float64{}
+	reservedMap := map[string]float64{}
 	costMap := map[string]float64{}
 	sellMap := map[string]float64{}
 	nameMap := map[string]string{}
 	for _, product := range products {
 		key := normalizeName(product.ProductName)
-		available[key] = product.Quantity
+		reserved := reservations[product.ID]
+		available[key] = product.Quantity - reserved
+		reservedMap[key] = reserved
 		costMap[key] = product.AvgBuyPrice
 		sellMap[key] = product.SellPrice
 		if _, exists := nameMap[key]; !exists {
@@ -910,7 +1511,7 @@ func (r *Repository) PreviewSales(
 			errorsCount++
 			continue
 		}
-		key := normalizeName(name)
+		key := normalizeName(resolvedProductName(resolved, name))
 		availableQty, ok := available[key]
 		if !ok {
 			result = append(result, domain.SalesPreviewRow{
@@ -933,12 +1534,31 @@ func (r *Repository) PreviewSales(
 				sellPrice = costPrice
 			}
 		}
+		if !allowNegativeStock && roundQuantity(availableQty-row.QuantitySold) < 0 {
+			result = append(result, domain.SalesPreviewRow{
+				ProductName:  name,
+				QuantitySold: row.QuantitySold,
+				SellPrice:    sellPrice,
+				CostPrice:    costPrice,
+				Reserved:     reservedMap[key],
+				Status:       "Error",
+				Message: fmt.Sprintf(
+					"Insufficient stock: available %s (%s reserved by drafts)",
+					formatQuantity(roundQuantity(availableQty)),
+					formatQuantity(reservedMap[key]),
+				),
+				ResolvedName: nameMap[key],
+			})
+			errorsCount++
+			continue
+		}
 		available[key] = availableQty - row.QuantitySold
 		result = append(result, domain.SalesPreviewRow{
 			ProductName:  name,
 			QuantitySold: row.QuantitySold,
 			SellPrice:    sellPrice,
 			CostPrice:    costPrice,
+			Reserved:     reservedMap[key],
 			Status:       "OK",
 			Message:      "Will update stock",
 			ResolvedName: nameMap[key],
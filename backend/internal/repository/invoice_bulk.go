@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"backend/internal/domain"
+)
+
+// BulkInvoiceInput is one invoice of a bulk create. InvoiceType "purchase",
+// in any case, creates a purchase from PurchaseLines; any other type (empty means
+// "sales") creates a sales invoice from SalesLines.
+type BulkInvoiceInput struct {
+	InvoiceType   string
+	InvoiceName   *string
+	AdminUsername *string
+	SupplierName  *string
+	CustomerName  *string
+	Adjustments   InvoiceAdjustments
+	Status        string
+	PurchaseLines []domain.PurchaseLineInput
+	SalesLines    []domain.SalesLineInput
+}
+
+// BulkInvoiceError names the invoice that made a bulk create fail. The
+// batch is all or nothing, so nothing was written.
+type BulkInvoiceError struct {
+	Index int
+	Err   error
+}
+
+func (e *BulkInvoiceError) Error() string {
+	return fmt.Sprintf("invoice %d: %v", e.Index, e.Err)
+}
+
+func (e *BulkInvoiceError) Unwrap() error {
+	return e.Err
+}
+
+// CreateInvoicesBulk creates the invoices in order inside one transaction,
+// so each one sees the stock left by the previous. The first failure rolls
+// back the whole batch and is returned as a *BulkInvoiceError.
+func (r *Repository) CreateInvoicesBulk(
+	ctx context.Context,
+	inputs []BulkInvoiceInput,
+	allowNegativeStock bool,
+) ([]int64, error) {
+	if len(inputs) == 0 {
+		return nil, fmt.Errorf("invoices cannot be empty")
+	}
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin bulk invoice tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Lock the products of the whole batch up front; each invoice then only
+	// re-takes locks it already holds.
+	names := make([]string, 0)
+	for _, input := range inputs {
+		names = append(names, purchaseLineNames(input.PurchaseLines)...)
+		names = append(names, salesLineNames(input.SalesLines)...)
+	}
+	if _, err := lockInvoiceProductsTx(ctx, tx, names, nil); err != nil {
+		return nil, err
+	}
+
+	ids := make([]int64, 0, len(inputs))
+	for index, input := range inputs {
+		var invoiceID int64
+		if strings.EqualFold(strings.TrimSpace(input.InvoiceType), "purchase") {
+			invoiceID, err = createPurchaseInvoiceTx(
+				ctx,
+				tx,
+				input.InvoiceName,
+				input.AdminUsername,
+				input.SupplierName,
+				input.PurchaseLines,
+				input.Adjustments,
+				input.Status,
+			)
+		} else {
+			invoiceID, err = createSalesInvoiceTx(
+				ctx,
+				tx,
+				input.InvoiceName,
+				input.AdminUsername,
+				input.CustomerName,
+				input.InvoiceType,
+				input.SalesLines,
+				input.Adjustments,
+				input.Status,
+				allowNegativeStock,
+			)
+		}
+		if err != nil {
+			return nil, &BulkInvoiceError{Index: index, Err: err}
+		}
+		ids = append(ids, invoiceID)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("commit bulk invoice tx: %w", err)
+	}
+	return ids, nil
+}
@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"backend/internal/domain"
+)
+
+// TestCreateInvoicesBulk runs on an empty schema so the invoice list holds
+// only what the batches write: invoices see the stock left by earlier ones
+// in the same batch, and one failure rolls back the whole batch.
+func TestCreateInvoicesBulk(t *testing.T) {
+	repo := testEmptyRepository(t)
+	ctx := context.Background()
+
+	quantity := func(step, name string, want float64) {
+		t.Helper()
+		units, err := repo.ProductUnits(ctx, []string{name})
+		if err != nil {
+			t.Fatalf("%s: ProductUnits: %v", step, err)
+		}
+		if _, ok := units[name]; !ok {
+			if want != 0 {
+				t.Fatalf("%s: product %q missing", step, name)
+			}
+			return
+		}
+		var got float64
+		if err := repo.pool.QueryRow(ctx, "SELECT quantity FROM products WHERE product_name = $1", name).Scan(&got); err != nil {
+			t.Fatalf("%s: read quantity of %q: %v", step, name, err)
+		}
+		if got != want {
+			t.Fatalf("%s: quantity of %q = %v, want %v", step, name, got, want)
+		}
+	}
+
+	ids, err := repo.CreateInvoicesBulk(ctx, []BulkInvoiceInput{
+		{
+			InvoiceType:   "Purchase",
+			Status:        InvoiceStatusFinalized,
+			PurchaseLines: []domain.PurchaseLineInput{{ProductName: "bulk x", Price: 10, Quantity: 5}},
+		},
+		{
+			InvoiceType: "sales",
+			Status:      InvoiceStatusFinalized,
+			SalesLines:  []domain.SalesLineInput{{ProductName: "bulk x", Price: 20, Quantity: 3}},
+		},
+	}, false)
+	if err != nil {
+		t.Fatalf("CreateInvoicesBulk: %v", err)
+	}
+	if len(ids) != 2 {
+		t.Fatalf("created %d invoices, want 2", len(ids))
+	}
+	quantity("after the batch", "bulk x", 2)
+	if got := listInvoiceIDs(t, repo, InvoiceListFilter{}); len(got) != 2 {
+		t.Fatalf("listed %d invoices, want 2", len(got))
+	}
+
+	// The sale at index 1 needs more than the 2 left, so the purchase before
+	// it must be rolled back too.
+	_, err = repo.CreateInvoicesBulk(ctx, []BulkInvoiceInput{
+		{
+			InvoiceType:   "purchase",
+			Status:        InvoiceStatusFinalized,
+			PurchaseLines: []domain.PurchaseLineInput{{ProductName: "bulk y", Price: 10, Quantity: 10}},
+		},
+		{
+			InvoiceType: "sales",
+			Status:      InvoiceStatusFinalized,
+			SalesLines:  []domain.SalesLineInput{{ProductName: "bulk x", Price: 20, Quantity: 50}},
+		},
+	}, false)
+	var bulkErr *BulkInvoiceError
+	if !errors.As(err, &bulkErr) {
+		t.Fatalf("error = %v, want *BulkInvoiceError", err)
+	}
+	if bulkErr.Index != 1 || !errors.Is(err, ErrInsufficientStock) {
+		t.Fatalf("error = %v (index %d), want insufficient stock at index 1", err, bulkErr.Index)
+	}
+	quantity("after the failed batch", "bulk x", 2)
+	quantity("after the failed batch", "bulk y", 0)
+	if got := listInvoiceIDs(t, repo, InvoiceListFilter{}); len(got) != 2 {
+		t.Fatalf("listed %d invoices after the failed batch, want 2", len(got))
+	}
+
+	if _, err := repo.CreateInvoicesBulk(ctx, nil, false); err == nil || !strings.Contains(err.Error(), "invoices cannot be empty") {
+		t.Fatalf("empty batch error = %v, want invoices cannot be empty", err)
+	}
+}
+
+func TestBulkInvoiceError(t *testing.T) {
+	err := error(&BulkInvoiceError{Index: 3, Err: ErrInsufficientStock})
+	if got, want := err.Error(), "invoice 3: insufficient stock"; got != want {
+		t.Fatalf("Error() = %q, want %q", got, want)
+	}
+	if !errors.Is(err, ErrInsufficientStock) {
+		t.Fatalf("errors.Is(%v, ErrInsufficientStock) = false", err)
+	}
+}
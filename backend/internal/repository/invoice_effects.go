@@ -3,15 +3,33 @@ package repository
 import (
 	"context"
 	"fmt"
+	"math"
 	"sort"
+	"strconv"
+
+	"backend/internal/domain"
 
 	"github.com/jackc/pgx/v5"
 )
 
+// avgCostEpsilon matches the NUMERIC(14,4) storage precision; recomputed
+// averages closer than this to the stored value are treated as unchanged.
+const avgCostEpsilon = 0.0001
+
+// roundQuantity trims float noise to the NUMERIC(14,3) quantity precision so
+// fractional stock math compares and stores cleanly.
+func roundQuantity(value float64) float64 {
+	return math.Round(value*1000) / 1000
+}
+
+func formatQuantity(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
 type inventoryEffect struct {
 	ProductID   int64
 	ProductName string
-	Quantity    int
+	Quantity    float64
 	TotalCost   float64
 	LastPrice   float64
 }
@@ -144,15 +162,120 @@ func replaceInvoiceStockEffectsTx(
 	return nil
 }
 
+// lockInvoiceProductsTx locks every product an invoice write may touch: the
+// named products, the products behind effects and all members of their
+// product groups. Names are resolved through resolveProductNames first, so an
+// alias locks the product it belongs to; the resolution is returned for the
+// caller to rewrite its lines with. Locks are taken in one statement in
+// product_name_normalized order, so concurrent multi-product invoices always
+// acquire them in the same order and cannot deadlock each other; the
+// per-product FOR UPDATE reads that follow only re-take locks the transaction
+// already holds. Every invoice write must call it before reading or changing
+// stock.
+func lockInvoiceProductsTx(
+	ctx context.Context,
+	tx pgx.Tx,
+	names []string,
+	effects []inventoryEffect,
+) (map[string]string, error) {
+	resolved, err := resolveProductNames(ctx, tx, names)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(names)+len(effects))
+	ids := make([]int64, 0, len(effects))
+	for _, name := range names {
+		keys = append(keys, normalizeName(resolvedProductName(resolved, name)))
+	}
+	for _, effect := range effects {
+		if effect.ProductID > 0 {
+			ids = append(ids, effect.ProductID)
+		} else {
+			keys = append(keys, normalizeName(effect.ProductName))
+		}
+	}
+	if len(keys) == 0 && len(ids) == 0 {
+		return resolved, nil
+	}
+	if _, err := tx.Exec(ctx, `
+		WITH base AS (
+			SELECT id
+			FROM products
+			WHERE product_name_normalized = ANY($1) OR id = ANY($2)
+		)
+		SELECT p.id
+		FROM products p
+		WHERE p.id IN (
+			SELECT id FROM base
+			UNION
+			SELECT group_member.product_id
+			FROM product_group_members base_member
+			JOIN product_group_members group_member
+				ON group_member.group_id = base_member.group_id
+			WHERE base_member.product_id IN (SELECT id FROM base)
+		)
+		ORDER BY p.product_name_normalized, p.id
+		FOR UPDATE OF p
+	`, keys, ids); err != nil {
+		return nil, fmt.Errorf("lock invoice products: %w", err)
+	}
+	return resolved, nil
+}
+
+func purchaseLineNames(lines []domain.PurchaseLineInput) []string {
+	names := make([]string, 0, len(lines))
+	for _, line := range lines {
+		names = append(names, line.ProductName)
+	}
+	return names
+}
+
+func salesLineNames(lines []domain.SalesLineInput) []string {
+	names := make([]string, 0, len(lines))
+	for _, line := range lines {
+		names = append(names, line.ProductName)
+	}
+	return names
+}
+
+// resolvePurchaseLineNames returns a copy of lines with product names
+// replaced by the products they resolved to.
+func resolvePurchaseLineNames(lines []domain.PurchaseLineInput, resolved map[string]string) []domain.PurchaseLineInput {
+	out := make([]domain.PurchaseLineInput, len(lines))
+	for i, line := range lines {
+		line.ProductName = resolvedProductName(resolved, line.ProductName)
+		out[i] = line
+	}
+	return out
+}
+
+// resolveSalesLineNames is resolvePurchaseLineNames for sales lines.
+func resolveSalesLineNames(lines []domain.SalesLineInput, resolved map[string]string) []domain.SalesLineInput {
+	out := make([]domain.SalesLineInput, len(lines))
+	for i, line := range lines {
+		line.ProductName = resolvedProductName(resolved, line.ProductName)
+		out[i] = line
+	}
+	return out
+}
+
+func invoiceLineNames(lines []domain.InvoiceLine) []string {
+	names := make([]string, 0, len(lines))
+	for _, line := range lines {
+		names = append(names, line.ProductName)
+	}
+	return names
+}
+
 func loadProductForEffectUpdate(
 	ctx context.Context,
 	tx pgx.Tx,
 	effect inventoryEffect,
-) (int64, string, int, float64, float64, error) {
+) (int64, string, float64, float64, float64, error) {
 	var (
 		id          int64
 		productName string
-		quantity    int
+		quantity    float64
 		avgBuy      float64
 		lastBuy     float64
 	)
@@ -187,14 +310,15 @@ func applySalesChangeTx(
 	tx pgx.Tx,
 	oldEffects []inventoryEffect,
 	newEffects []inventoryEffect,
+	allowNegativeStock bool,
 ) error {
 	oldMap := aggregateInventoryEffects(oldEffects)
 	newMap := aggregateInventoryEffects(newEffects)
 	keys := collectEffectKeys(oldMap, newMap)
 
 	for _, key := range keys {
-		oldQty := 0
-		newQty := 0
+		oldQty := 0.0
+		newQty := 0.0
 		effect := inventoryEffect{}
 		if oldEntry := oldMap[key]; oldEntry != nil {
 			oldQty = oldEntry.Quantity
@@ -204,7 +328,7 @@ func applySalesChangeTx(
 			newQty = newEntry.Quantity
 			effect = *newEntry
 		}
-		delta := oldQty - newQty
+		delta := roundQuantity(oldQty - newQty)
 		if delta == 0 {
 			continue
 		}
@@ -216,7 +340,16 @@ func applySalesChangeTx(
 		if err != nil {
 			return err
 		}
-		updatedQty := currentQty + delta
+		updatedQty := roundQuantity(currentQty + delta)
+		if delta < 0 && updatedQty < 0 && !allowNegativeStock {
+			return fmt.Errorf(
+				"%w for %q: available %s, requested %s",
+				ErrInsufficientStock,
+				productName,
+				formatQuantity(currentQty),
+				formatQuantity(-delta),
+			)
+		}
 		if _, err := tx.Exec(ctx, `
 			UPDATE products
 			SET quantity = $2, updated_at = NOW()
@@ -228,6 +361,40 @@ func applySalesChangeTx(
 	return nil
 }
 
+// purchaseStock returns a product's quantity and average cost after a
+// purchase effect of oldQty units costing oldCost is replaced by newQty units
+// costing newCost.
+//
+// Backing out the old effect can leave zero or negative stock: those units
+// were already sold, so they carry no cost base and any new quantity is
+// averaged on its own. With nothing left to average the current average is
+// kept, so later sales are still costed and the next purchase re-bases it.
+// Positive stock whose backed-out cost turns negative (the removed units were
+// dearer than the stock is now valued) keeps the current average instead of
+// going negative.
+func purchaseStock(currentQty, currentAvg, oldQty, oldCost, newQty, newCost float64) (float64, float64) {
+	remainingQty := roundQuantity(currentQty - oldQty)
+	remainingCost := (currentAvg * currentQty) - oldCost
+	avgBaseQty := 0.0
+	avgBaseCost := 0.0
+	if remainingQty > 0 {
+		avgBaseQty = remainingQty
+		avgBaseCost = remainingCost
+		if avgBaseCost < 0 {
+			avgBaseCost = currentAvg * remainingQty
+		}
+	}
+	avgDenominator := avgBaseQty + newQty
+	newAvg := currentAvg
+	if avgDenominator > 0 {
+		newAvg = (avgBaseCost + newCost) / avgDenominator
+	}
+	if math.Abs(newAvg-currentAvg) < avgCostEpsilon {
+		newAvg = currentAvg
+	}
+	return roundQuantity(remainingQty + newQty), newAvg
+}
+
 func applyPurchaseChangeTx(
 	ctx context.Context,
 	tx pgx.Tx,
@@ -239,9 +406,9 @@ func applyPurchaseChangeTx(
 	keys := collectEffectKeys(oldMap, newMap)
 
 	for _, key := range keys {
-		oldQty := 0
+		oldQty := 0.0
 		oldCost := 0.0
-		newQty := 0
+		newQty := 0.0
 		newCost := 0.0
 		newLastPrice := 0.0
 		effect := inventoryEffect{}
@@ -265,26 +432,14 @@ func applyPurchaseChangeTx(
 			return err
 		}
 
-		remainingQty := currentQty - oldQty
-		remainingCost := (currentAvg * float64(currentQty)) - oldCost
-		avgBaseQty := remainingQty
-		if avgBaseQty < 0 {
-			avgBaseQty = 0
-		}
-		avgBaseCost := remainingCost
-		if remainingQty <= 0 {
-			avgBaseCost = 0
-		}
-		avgDenominator := avgBaseQty + newQty
-		newAvg := 0.0
-		if avgDenominator > 0 {
-			newAvg = (avgBaseCost + newCost) / float64(avgDenominator)
-		}
-		updatedQty := remainingQty + newQty
+		updatedQty, newAvg := purchaseStock(currentQty, currentAvg, oldQty, oldCost, newQty, newCost)
 		updatedLast := currentLast
 		if newQty > 0 && newLastPrice > 0 {
 			updatedLast = newLastPrice
 		}
+		if updatedQty == currentQty && newAvg == currentAvg && updatedLast == currentLast {
+			continue
+		}
 
 		if _, err := tx.Exec(ctx, `
 			UPDATE products
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"backend/internal/domain"
+)
+
+func TestPurchaseStock(t *testing.T) {
+	tests := []struct {
+		name                             string
+		currentQty, currentAvg           float64
+		oldQty, oldCost, newQty, newCost float64
+		wantQty, wantAvg                 float64
+	}{
+		{name: "first purchase", newQty: 10, newCost: 100, wantQty: 10, wantAvg: 10},
+		{name: "purchase onto stock", currentQty: 10, currentAvg: 10, newQty: 10, newCost: 200, wantQty: 20, wantAvg: 15},
+		{name: "unchanged edit", currentQty: 20, currentAvg: 15, oldQty: 10, oldCost: 200, newQty: 10, newCost: 200, wantQty: 20, wantAvg: 15},
+		// Without the epsilon this comes back as 0.14560000000000003.
+		{name: "unchanged edit with float noise", currentQty: 3, currentAvg: 0.1456, oldQty: 1, oldCost: 0.15, newQty: 1, newCost: 0.15, wantQty: 3, wantAvg: 0.1456},
+		{name: "change below epsilon is dropped", currentQty: 1000, currentAvg: 10, newQty: 1, newCost: 10.05, wantQty: 1001, wantAvg: 10},
+		{name: "change above epsilon is kept", currentQty: 1000, currentAvg: 10, newQty: 1, newCost: 10.2, wantQty: 1001, wantAvg: 10010.2 / 1001},
+		{name: "edit after the stock was sold", currentQty: 2, currentAvg: 10, oldQty: 5, oldCost: 50, newQty: 5, newCost: 60, wantQty: 2, wantAvg: 12},
+		{name: "nothing left to average", currentQty: 5, currentAvg: 10, oldQty: 5, oldCost: 50, wantQty: 0, wantAvg: 10},
+		{name: "dearer units backed out", currentQty: 4, currentAvg: 10, oldQty: 2, oldCost: 60, wantQty: 2, wantAvg: 10},
+		{name: "fractional quantities", currentQty: 0.3, currentAvg: 100, newQty: 0.2, newCost: 30, wantQty: 0.5, wantAvg: 120},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			qty, avg := purchaseStock(tt.currentQty, tt.currentAvg, tt.oldQty, tt.oldCost, tt.newQty, tt.newCost)
+			if qty != tt.wantQty || math.Abs(avg-tt.wantAvg) > 1e-12 {
+				t.Fatalf("purchaseStock = %v, %v; want %v, %v", qty, avg, tt.wantQty, tt.wantAvg)
+			}
+			// A dropped change must leave the stored value bit for bit.
+			if tt.wantAvg == tt.currentAvg && avg != tt.currentAvg {
+				t.Fatalf("average drifted to %v", avg)
+			}
+		})
+	}
+}
+
+// TestPurchaseEditsKeepAverageCost edits a purchase's quantity back and forth
+// and then saves it unchanged; the average cost must return to and stay at
+// its original value.
+func TestPurchaseEditsKeepAverageCost(t *testing.T) {
+	repo := testRepository(t)
+	ctx := context.Background()
+
+	name := fmt.Sprintf("avg drift %d", time.Now().UnixNano())
+	product, err := repo.CreateProduct(ctx, ProductCreateInput{ProductName: name, Quantity: 2, AvgBuyPrice: 0.1434})
+	if err != nil {
+		t.Fatalf("create product: %v", err)
+	}
+	invoiceID, err := repo.CreatePurchaseInvoice(ctx, nil, nil, nil,
+		[]domain.PurchaseLineInput{{ProductName: name, Price: 0.15, Quantity: 1}},
+		InvoiceAdjustments{}, InvoiceStatusFinalized)
+	if err != nil {
+		t.Fatalf("create purchase: %v", err)
+	}
+	check := func(step string) {
+		t.Helper()
+		got, err := repo.GetProductByID(ctx, product.ID)
+		if err != nil {
+			t.Fatalf("%s: get product: %v", step, err)
+		}
+		if got.Quantity != 3 || got.AvgBuyPrice != 0.1456 {
+			t.Fatalf("%s: quantity/avg = %v/%v, want 3/0.1456", step, got.Quantity, got.AvgBuyPrice)
+		}
+	}
+	check("after purchase")
+
+	for round := 1; round <= 10; round++ {
+		for _, quantity := range []float64{2, 1} {
+			lines := []domain.InvoiceLine{{ProductName: name, Price: 0.15, Quantity: quantity}}
+			if err := repo.UpdateInvoiceLinesReconciled(ctx, invoiceID, nil, lines, false); err != nil {
+				t.Fatalf("round %d: set quantity %v: %v", round, quantity, err)
+			}
+		}
+		check(fmt.Sprintf("round %d", round))
+	}
+	for round := 1; round <= 10; round++ {
+		lines := []domain.InvoiceLine{{ProductName: name, Price: 0.15, Quantity: 1}}
+		if err := repo.UpdateInvoiceLinesReconciled(ctx, invoiceID, nil, lines, false); err != nil {
+			t.Fatalf("unchanged edit %d: %v", round, err)
+		}
+	}
+	check("after unchanged edits")
+}
@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"backend/internal/domain"
+)
+
+// TestUpdateInvoiceLineReconciled halves the quantity of one line of a
+// two-line invoice: only that product's stock moves, in the direction of the
+// invoice type, and the invoice total follows.
+func TestUpdateInvoiceLineReconciled(t *testing.T) {
+	repo := testRepository(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		invoiceType string
+		// wantCreated and wantPatched are the patched product's stock after
+		// the invoice is created and after the line is patched.
+		wantCreated float64
+		wantPatched float64
+		wantOther   float64
+	}{
+		{invoiceType: "sales", wantCreated: 6, wantPatched: 8, wantOther: 9},
+		{invoiceType: "purchase", wantCreated: 14, wantPatched: 12, wantOther: 11},
+	}
+	for _, tt := range tests {
+		t.Run(tt.invoiceType, func(t *testing.T) {
+			suffix := time.Now().UnixNano()
+			patched := fmt.Sprintf("line patch %s %d", tt.invoiceType, suffix)
+			other := fmt.Sprintf("line patch other %s %d", tt.invoiceType, suffix)
+			ids := make(map[string]int64, 2)
+			for _, name := range []string{patched, other} {
+				product, err := repo.CreateProduct(ctx, ProductCreateInput{ProductName: name, Quantity: 10, AvgBuyPrice: 10})
+				if err != nil {
+					t.Fatalf("create product %q: %v", name, err)
+				}
+				ids[name] = product.ID
+			}
+			quantity := func(step, name string, want float64) {
+				t.Helper()
+				got, err := repo.GetProductByID(ctx, ids[name])
+				if err != nil {
+					t.Fatalf("%s: get %q: %v", step, name, err)
+				}
+				if got.Quantity != want {
+					t.Fatalf("%s: quantity of %q = %v, want %v", step, name, got.Quantity, want)
+				}
+			}
+
+			var invoiceID int64
+			var err error
+			if tt.invoiceType == "purchase" {
+				invoiceID, err = repo.CreatePurchaseInvoice(ctx, nil, nil, nil, []domain.PurchaseLineInput{
+					{ProductName: patched, Price: 25, Quantity: 4},
+					{ProductName: other, Price: 5, Quantity: 1},
+				}, InvoiceAdjustments{}, InvoiceStatusFinalized)
+			} else {
+				invoiceID, err = repo.CreateSalesInvoice(ctx, nil, nil, nil, tt.invoiceType, []domain.SalesLineInput{
+					{ProductName: patched, Price: 25, Quantity: 4},
+					{ProductName: other, Price: 5, Quantity: 1},
+				}, InvoiceAdjustments{}, InvoiceStatusFinalized, false)
+			}
+			if err != nil {
+				t.Fatalf("create invoice: %v", err)
+			}
+			quantity("after create", patched, tt.wantCreated)
+			quantity("after create", other, tt.wantOther)
+
+			lines, err := repo.GetInvoiceLines(ctx, invoiceID)
+			if err != nil {
+				t.Fatalf("GetInvoiceLines: %v", err)
+			}
+			var lineID int64
+			for _, line := range lines {
+				if line.ProductName == patched {
+					lineID = line.ID
+				}
+			}
+			if lineID == 0 {
+				t.Fatalf("no line for %q in %+v", patched, lines)
+			}
+
+			half := 2.0
+			line, err := repo.UpdateInvoiceLineReconciled(ctx, invoiceID, lineID, InvoiceLinePatch{Quantity: &half}, false)
+			if err != nil {
+				t.Fatalf("UpdateInvoiceLineReconciled: %v", err)
+			}
+			if line.ID != lineID || line.Quantity != 2 || line.Price != 25 || line.LineTotal != 50 {
+				t.Fatalf("patched line = %+v, want id %d, 2 x 25 = 50", line, lineID)
+			}
+			quantity("after patch", patched, tt.wantPatched)
+			quantity("after patch", other, tt.wantOther)
+
+			invoice, err := repo.GetInvoice(ctx, invoiceID)
+			if err != nil {
+				t.Fatalf("GetInvoice: %v", err)
+			}
+			if invoice.TotalQty != 3 || invoice.TotalAmount != 55 {
+				t.Fatalf("invoice totals = %v qty, %v amount; want 3, 55", invoice.TotalQty, invoice.TotalAmount)
+			}
+		})
+	}
+}
+
+func TestUpdateInvoiceLineRejectsForeignLine(t *testing.T) {
+	repo := testRepository(t)
+	ctx := context.Background()
+
+	name := fmt.Sprintf("line patch foreign %d", time.Now().UnixNano())
+	lines := []domain.PurchaseLineInput{{ProductName: name, Price: 10, Quantity: 3}}
+	first, err := repo.CreatePurchaseInvoice(ctx, nil, nil, nil, lines, InvoiceAdjustments{}, InvoiceStatusFinalized)
+	if err != nil {
+		t.Fatalf("create first invoice: %v", err)
+	}
+	second, err := repo.CreatePurchaseInvoice(ctx, nil, nil, nil, lines, InvoiceAdjustments{}, InvoiceStatusFinalized)
+	if err != nil {
+		t.Fatalf("create second invoice: %v", err)
+	}
+	secondLines, err := repo.GetInvoiceLines(ctx, second)
+	if err != nil || len(secondLines) != 1 {
+		t.Fatalf("GetInvoiceLines(%d) = %+v, %v", second, secondLines, err)
+	}
+
+	one := 1.0
+	_, err = repo.UpdateInvoiceLineReconciled(ctx, first, secondLines[0].ID, InvoiceLinePatch{Quantity: &one}, false)
+	if !errors.Is(err, ErrInvoiceLineNotFound) {
+		t.Fatalf("patching a line of another invoice: error = %v, want ErrInvoiceLineNotFound", err)
+	}
+	stored, err := repo.GetInvoiceLines(ctx, second)
+	if err != nil || stored[0].Quantity != 3 {
+		t.Fatalf("foreign line after the rejected patch = %+v, %v; want quantity 3", stored, err)
+	}
+}
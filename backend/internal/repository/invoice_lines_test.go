@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"backend/internal/domain"
+)
+
+// TestLargeInvoiceLines pushes a 500-line purchase through the COPY paths of
+// insertInvoiceTx and upsertInvoiceLinesTx and checks every stored line and
+// the invoice totals, then that deleting the invoice cascades to its lines.
+// Prices, quantities and discounts are whole numbers so the sums are exact.
+func TestLargeInvoiceLines(t *testing.T) {
+	repo := testRepository(t)
+	ctx := context.Background()
+
+	const lineCount = 500
+	suffix := time.Now().UnixNano()
+	inputs := make([]domain.PurchaseLineInput, lineCount)
+	for i := range inputs {
+		discount := 0.0
+		if i%2 == 1 {
+			discount = 5
+		}
+		inputs[i] = domain.PurchaseLineInput{
+			ProductName: fmt.Sprintf("large invoice %d line %03d", suffix, i),
+			Price:       float64(i%7+1) * 10,
+			Quantity:    float64(i%3 + 1),
+			Discount:    discount,
+		}
+	}
+	invoiceDiscount, invoiceTax := 100.0, 40.0
+	adjustments := InvoiceAdjustments{DiscountAmount: &invoiceDiscount, TaxAmount: &invoiceTax}
+
+	check := func(t *testing.T, invoiceID int64, scale float64) {
+		t.Helper()
+		lines, err := repo.GetInvoiceLines(ctx, invoiceID)
+		if err != nil {
+			t.Fatalf("GetInvoiceLines: %v", err)
+		}
+		if len(lines) != lineCount {
+			t.Fatalf("stored %d lines, want %d", len(lines), lineCount)
+		}
+		totalQty, subtotal := 0.0, 0.0
+		for i, line := range lines {
+			input := inputs[i]
+			want := domain.InvoiceLine{
+				ID:          line.ID,
+				InvoiceID:   invoiceID,
+				ProductName: input.ProductName,
+				Price:       input.Price,
+				Quantity:    input.Quantity * scale,
+				Discount:    input.Discount,
+				LineTotal:   input.Price*input.Quantity*scale - input.Discount,
+				CostPrice:   input.Price,
+			}
+			if line != want {
+				t.Fatalf("line %d = %+v, want %+v", i, line, want)
+			}
+			totalQty += want.Quantity
+			subtotal += want.LineTotal
+		}
+
+		invoice, err := repo.GetInvoice(ctx, invoiceID)
+		if err != nil {
+			t.Fatalf("GetInvoice: %v", err)
+		}
+		if invoice.TotalLines != lineCount || invoice.TotalQty != totalQty {
+			t.Fatalf("invoice lines/qty = %d/%v, want %d/%v", invoice.TotalLines, invoice.TotalQty, lineCount, totalQty)
+		}
+		if want := subtotal - invoiceDiscount + invoiceTax; invoice.TotalAmount != want {
+			t.Fatalf("invoice total = %v, want %v", invoice.TotalAmount, want)
+		}
+	}
+
+	invoiceID, err := repo.CreatePurchaseInvoice(ctx, nil, nil, nil, inputs, adjustments, InvoiceStatusFinalized)
+	if err != nil {
+		t.Fatalf("create invoice: %v", err)
+	}
+	check(t, invoiceID, 1)
+
+	updated := make([]domain.InvoiceLine, lineCount)
+	for i, input := range inputs {
+		updated[i] = domain.InvoiceLine{
+			ProductName: input.ProductName,
+			Price:       input.Price,
+			Quantity:    input.Quantity * 2,
+			Discount:    input.Discount,
+		}
+	}
+	if err := repo.UpdateInvoiceLinesReconciled(ctx, invoiceID, nil, updated, false); err != nil {
+		t.Fatalf("update invoice lines: %v", err)
+	}
+	check(t, invoiceID, 2)
+
+	if err := repo.DeleteInvoiceReconciled(ctx, invoiceID); err != nil {
+		t.Fatalf("delete invoice: %v", err)
+	}
+	lines, err := repo.GetInvoiceLines(ctx, invoiceID)
+	if err != nil {
+		t.Fatalf("GetInvoiceLines after delete: %v", err)
+	}
+	if len(lines) != 0 {
+		t.Fatalf("%d lines survived deleting the invoice", len(lines))
+	}
+	if _, err := repo.GetInvoice(ctx, invoiceID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("GetInvoice after delete error = %v, want ErrNotFound", err)
+	}
+}
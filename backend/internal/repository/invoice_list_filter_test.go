@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"backend/internal/domain"
+)
+
+// seedPurchaseInvoices creates one finalized purchase invoice per entry, each
+// line at quantity 1, and returns their ids in order.
+func seedPurchaseInvoices(t *testing.T, repo *Repository, invoices [][]domain.PurchaseLineInput) []int64 {
+	t.Helper()
+	ids := make([]int64, len(invoices))
+	for i, lines := range invoices {
+		id, err := repo.CreatePurchaseInvoice(context.Background(), nil, nil, nil, lines, InvoiceAdjustments{}, InvoiceStatusFinalized)
+		if err != nil {
+			t.Fatalf("create invoice %d: %v", i, err)
+		}
+		ids[i] = id
+	}
+	return ids
+}
+
+// listInvoiceIDs lists filter and checks that the filtered stats count the
+// same invoices.
+func listInvoiceIDs(t *testing.T, repo *Repository, filter InvoiceListFilter) []int64 {
+	t.Helper()
+	ctx := context.Background()
+	items, _, err := repo.ListInvoices(ctx, filter)
+	if err != nil {
+		t.Fatalf("ListInvoices: %v", err)
+	}
+	count, _, err := repo.GetInvoiceStatsFiltered(ctx, filter)
+	if err != nil {
+		t.Fatalf("GetInvoiceStatsFiltered: %v", err)
+	}
+	if count != len(items) {
+		t.Fatalf("stats count %d, listed %d", count, len(items))
+	}
+	ids := make([]int64, len(items))
+	for i, item := range items {
+		ids[i] = item.ID
+	}
+	return ids
+}
+
+// TestListInvoicesByProduct runs on an empty schema. The first invoice has
+// two matching lines and must still be listed once.
+func TestListInvoicesByProduct(t *testing.T) {
+	repo := testEmptyRepository(t)
+	ctx := context.Background()
+
+	line := func(name string) domain.PurchaseLineInput {
+		return domain.PurchaseLineInput{ProductName: name, Price: 10, Quantity: 1}
+	}
+	ids := seedPurchaseInvoices(t, repo, [][]domain.PurchaseLineInput{
+		{line("red apple"), line("apple juice"), line("tea")},
+		{line("green apple")},
+		{line("tea")},
+	})
+
+	tests := []struct {
+		name    string
+		product string
+		want    []int64
+	}{
+		{name: "no product filter", want: []int64{ids[2], ids[1], ids[0]}},
+		{name: "substring across invoices", product: "apple", want: []int64{ids[1], ids[0]}},
+		{name: "case-insensitive", product: "APPLE JUICE", want: []int64{ids[0]}},
+		{name: "shared product", product: "tea", want: []int64{ids[2], ids[0]}},
+		{name: "no match", product: "coffee", want: []int64{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := listInvoiceIDs(t, repo, InvoiceListFilter{Product: tt.product})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("invoices = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// Paging counts invoices, not matching lines.
+	page, hasMore, err := repo.ListInvoices(ctx, InvoiceListFilter{Product: "apple", Limit: 1, Offset: 1})
+	if err != nil {
+		t.Fatalf("ListInvoices: %v", err)
+	}
+	if len(page) != 1 || page[0].ID != ids[0] || hasMore {
+		t.Fatalf("second page = %d invoices (has_more %v), want only invoice %d", len(page), hasMore, ids[0])
+	}
+}
+
+// TestListInvoicesByAmount runs on an empty schema with totals of 100, 200
+// and 300; both bounds are inclusive.
+func TestListInvoicesByAmount(t *testing.T) {
+	repo := testEmptyRepository(t)
+
+	ids := seedPurchaseInvoices(t, repo, [][]domain.PurchaseLineInput{
+		{{ProductName: "amount a", Price: 100, Quantity: 1}},
+		{{ProductName: "amount b", Price: 200, Quantity: 1}},
+		{{ProductName: "amount c", Price: 150, Quantity: 2}},
+	})
+
+	amount := func(value float64) *float64 { return &value }
+	tests := []struct {
+		name   string
+		filter InvoiceListFilter
+		want   []int64
+	}{
+		{name: "zero minimum keeps everything", filter: InvoiceListFilter{MinAmount: amount(0)}, want: []int64{ids[2], ids[1], ids[0]}},
+		{name: "minimum on a total", filter: InvoiceListFilter{MinAmount: amount(200)}, want: []int64{ids[2], ids[1]}},
+		{name: "minimum just above a total", filter: InvoiceListFilter{MinAmount: amount(200.01)}, want: []int64{ids[2]}},
+		{name: "maximum on a total", filter: InvoiceListFilter{MaxAmount: amount(200)}, want: []int64{ids[1], ids[0]}},
+		{name: "maximum just below the smallest", filter: InvoiceListFilter{MaxAmount: amount(99.99)}, want: []int64{}},
+		{name: "equal bounds", filter: InvoiceListFilter{MinAmount: amount(200), MaxAmount: amount(200)}, want: []int64{ids[1]}},
+		{name: "with a product", filter: InvoiceListFilter{MinAmount: amount(150), Product: "amount c"}, want: []int64{ids[2]}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := listInvoiceIDs(t, repo, tt.filter)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("invoices = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	_, total, err := repo.GetInvoiceStatsFiltered(context.Background(), InvoiceListFilter{MinAmount: amount(150), MaxAmount: amount(300)})
+	if err != nil {
+		t.Fatalf("GetInvoiceStatsFiltered: %v", err)
+	}
+	if total != 500 {
+		t.Fatalf("filtered total = %v, want 500", total)
+	}
+}
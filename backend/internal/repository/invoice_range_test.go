@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"backend/internal/domain"
+)
+
+// TestListInvoicesBetweenIncludeLines seeds two invoices and lists their id
+// range with and without include_lines and with a product filter. Other
+// tests may add invoices inside the range, so only the seeded ones are
+// checked.
+func TestListInvoicesBetweenIncludeLines(t *testing.T) {
+	repo := testRepository(t)
+	ctx := context.Background()
+
+	suffix := time.Now().UnixNano()
+	name := func(label string) string { return fmt.Sprintf("range test %d %s", suffix, label) }
+	seeded := [][]domain.PurchaseLineInput{
+		{{ProductName: name("a"), Price: 10, Quantity: 2}, {ProductName: name("b"), Price: 5, Quantity: 1}},
+		{{ProductName: name("c"), Price: 7, Quantity: 3}},
+	}
+	ids := make([]int64, len(seeded))
+	for i, lines := range seeded {
+		id, err := repo.CreatePurchaseInvoice(ctx, nil, nil, nil, lines, InvoiceAdjustments{}, InvoiceStatusFinalized)
+		if err != nil {
+			t.Fatalf("create invoice %d: %v", i, err)
+		}
+		ids[i] = id
+	}
+	matches := func(lines []domain.PurchaseLineInput) []domain.InvoiceProductMatch {
+		out := make([]domain.InvoiceProductMatch, len(lines))
+		for i, line := range lines {
+			out[i] = domain.InvoiceProductMatch{
+				RowNumber:   i + 1,
+				ProductName: line.ProductName,
+				Price:       line.Price,
+				Quantity:    line.Quantity,
+				LineTotal:   line.Price * line.Quantity,
+				CostPrice:   line.Price,
+			}
+		}
+		return out
+	}
+
+	start, end := time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+	tests := []struct {
+		name         string
+		filter       string
+		fuzzy        bool
+		includeLines bool
+		// want maps each seeded invoice that should be listed to its
+		// product_matches.
+		want map[int64][]domain.InvoiceProductMatch
+	}{
+		{
+			name: "lines are left out by default",
+			want: map[int64][]domain.InvoiceProductMatch{ids[0]: {}, ids[1]: {}},
+		},
+		{
+			name:         "include_lines returns every line",
+			includeLines: true,
+			want:         map[int64][]domain.InvoiceProductMatch{ids[0]: matches(seeded[0]), ids[1]: matches(seeded[1])},
+		},
+		{
+			name:   "a product filter returns only matching lines",
+			filter: name("b"),
+			want:   map[int64][]domain.InvoiceProductMatch{ids[0]: {matches(seeded[0])[1]}},
+		},
+		{
+			name:         "include_lines does not widen a product filter",
+			filter:       fmt.Sprintf("%d c", suffix),
+			fuzzy:        true,
+			includeLines: true,
+			want:         map[int64][]domain.InvoiceProductMatch{ids[1]: matches(seeded[1])},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items, err := repo.ListInvoicesBetween(ctx, start, end, tt.filter, tt.fuzzy, &ids[0], &ids[1], tt.includeLines)
+			if err != nil {
+				t.Fatalf("ListInvoicesBetween: %v", err)
+			}
+			got := make(map[int64][]domain.InvoiceProductMatch)
+			for _, item := range items {
+				if item.ID == ids[0] || item.ID == ids[1] {
+					matches := item.ProductMatches
+					if matches == nil {
+						matches = []domain.InvoiceProductMatch{}
+					}
+					got[item.ID] = matches
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("product_matches = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"backend/internal/domain"
+)
+
+// TestRecalcInvoiceTotals corrupts the stored totals of one invoice on an
+// empty schema and has both the single and the bulk recalculation repair
+// them, leaving the untouched invoice alone.
+func TestRecalcInvoiceTotals(t *testing.T) {
+	repo := testEmptyRepository(t)
+	ctx := context.Background()
+
+	amount := func(value float64) *float64 { return &value }
+	staleID, err := repo.CreatePurchaseInvoice(ctx, nil, nil, nil,
+		[]domain.PurchaseLineInput{
+			{ProductName: "recalc a", Price: 10, Quantity: 2},
+			{ProductName: "recalc b", Price: 5, Quantity: 1},
+		},
+		InvoiceAdjustments{DiscountAmount: amount(3), TaxAmount: amount(1)}, InvoiceStatusFinalized)
+	if err != nil {
+		t.Fatalf("create invoice: %v", err)
+	}
+	if _, err := repo.CreatePurchaseInvoice(ctx, nil, nil, nil,
+		[]domain.PurchaseLineInput{{ProductName: "recalc c", Price: 7, Quantity: 1}},
+		InvoiceAdjustments{}, InvoiceStatusFinalized); err != nil {
+		t.Fatalf("create invoice: %v", err)
+	}
+
+	corrupt := func() {
+		t.Helper()
+		if _, err := repo.pool.Exec(ctx,
+			"UPDATE invoices SET total_lines = 9, total_qty = 99, total_amount = 0 WHERE id = $1", staleID,
+		); err != nil {
+			t.Fatalf("corrupt totals: %v", err)
+		}
+	}
+	// 2 x 10 + 1 x 5, less the discount of 3, plus the tax of 1.
+	check := func(step string, invoice *domain.Invoice) {
+		t.Helper()
+		if invoice.TotalLines != 2 || invoice.TotalQty != 3 || invoice.TotalAmount != 23 {
+			t.Fatalf("%s: lines/qty/amount = %d/%v/%v, want 2/3/23", step, invoice.TotalLines, invoice.TotalQty, invoice.TotalAmount)
+		}
+	}
+
+	corrupt()
+	invoice, err := repo.RecalcInvoiceTotals(ctx, staleID)
+	if err != nil {
+		t.Fatalf("RecalcInvoiceTotals: %v", err)
+	}
+	check("single recalc", invoice)
+
+	corrupt()
+	updated, err := repo.RecalcAllInvoiceTotals(ctx)
+	if err != nil {
+		t.Fatalf("RecalcAllInvoiceTotals: %v", err)
+	}
+	if updated != 1 {
+		t.Fatalf("bulk recalc updated %d invoices, want only the corrupted one", updated)
+	}
+	invoice, err = repo.GetInvoice(ctx, staleID)
+	if err != nil {
+		t.Fatalf("GetInvoice: %v", err)
+	}
+	check("bulk recalc", invoice)
+	if updated, err := repo.RecalcAllInvoiceTotals(ctx); err != nil || updated != 0 {
+		t.Fatalf("second bulk recalc updated %d, %v; want 0", updated, err)
+	}
+
+	if _, err := repo.RecalcInvoiceTotals(ctx, staleID+1000); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("recalc of a missing invoice: error = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"backend/internal/domain"
+)
+
+// TestGetInvoiceStatsFiltered checks that the footer totals follow the same
+// type and date filter as the listed rows. It runs on an empty schema so the
+// counts are exact.
+func TestGetInvoiceStatsFiltered(t *testing.T) {
+	repo := testEmptyRepository(t)
+	ctx := context.Background()
+
+	day := func(month time.Month, d int) time.Time { return time.Date(2025, month, d, 12, 0, 0, 0, time.UTC) }
+	seeded := []struct {
+		createdAt time.Time
+		price     float64
+	}{
+		{day(time.January, 10), 20},
+		{day(time.February, 5), 10},
+		{day(time.February, 20), 30},
+	}
+	for i, invoice := range seeded {
+		lines := []domain.PurchaseLineInput{{ProductName: "stats test product", Price: invoice.price, Quantity: 1}}
+		id, err := repo.CreatePurchaseInvoice(ctx, nil, nil, nil, lines, InvoiceAdjustments{}, InvoiceStatusFinalized)
+		if err != nil {
+			t.Fatalf("create invoice %d: %v", i, err)
+		}
+		if _, err := repo.pool.Exec(ctx, "UPDATE invoices SET created_at = $1 WHERE id = $2", invoice.createdAt, id); err != nil {
+			t.Fatalf("backdate invoice %d: %v", i, err)
+		}
+	}
+
+	at := func(value time.Time) *time.Time { return &value }
+	tests := []struct {
+		name      string
+		filter    InvoiceListFilter
+		wantCount int
+		wantTotal float64
+	}{
+		{name: "no filter", wantCount: 3, wantTotal: 60},
+		{
+			name:      "one month",
+			filter:    InvoiceListFilter{From: at(day(time.February, 1)), To: at(day(time.February, 28))},
+			wantCount: 2,
+			wantTotal: 40,
+		},
+		{
+			name:      "narrow range inside a month",
+			filter:    InvoiceListFilter{From: at(day(time.February, 1)), To: at(day(time.February, 10))},
+			wantCount: 1,
+			wantTotal: 10,
+		},
+		{
+			name:      "range spanning both months",
+			filter:    InvoiceListFilter{From: at(day(time.January, 10)), To: at(day(time.February, 5))},
+			wantCount: 2,
+			wantTotal: 30,
+		},
+		{
+			name:   "type filter",
+			filter: InvoiceListFilter{InvoiceType: "sales", From: at(day(time.January, 1))},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			count, total, err := repo.GetInvoiceStatsFiltered(ctx, tc.filter)
+			if err != nil {
+				t.Fatalf("GetInvoiceStatsFiltered: %v", err)
+			}
+			if count != tc.wantCount || total != tc.wantTotal {
+				t.Fatalf("stats = %d, %v; want %d, %v", count, total, tc.wantCount, tc.wantTotal)
+			}
+			filter := tc.filter
+			filter.Limit = 10
+			items, _, err := repo.ListInvoices(ctx, filter)
+			if err != nil {
+				t.Fatalf("ListInvoices: %v", err)
+			}
+			if len(items) != count {
+				t.Fatalf("listed %d invoices, stats counted %d", len(items), count)
+			}
+		})
+	}
+}
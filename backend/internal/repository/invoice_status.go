@@ -0,0 +1,168 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	InvoiceStatusDraft     = "draft"
+	InvoiceStatusFinalized = "finalized"
+	InvoiceStatusVoid      = "void"
+)
+
+func normalizeInvoiceStatus(status string) (string, error) {
+	status = strings.ToLower(strings.TrimSpace(status))
+	switch status {
+	case "":
+		return InvoiceStatusFinalized, nil
+	case InvoiceStatusDraft, InvoiceStatusFinalized:
+		return status, nil
+	default:
+		return "", fmt.Errorf("status must be draft or finalized")
+	}
+}
+
+func (r *Repository) FinalizeInvoice(ctx context.Context, invoiceID int64, allowNegativeStock bool) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin finalize invoice tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	invoiceType, status, err := loadInvoiceStatusForUpdateTx(ctx, tx, invoiceID)
+	if err != nil {
+		return err
+	}
+	if status != InvoiceStatusDraft {
+		return fmt.Errorf("%w: invoice %d is %s", ErrInvoiceStatusConflict, invoiceID, status)
+	}
+
+	effects, err := loadInvoiceEffectsWithFallbackTx(ctx, tx, invoiceID, invoiceType)
+	if err != nil {
+		return err
+	}
+	if _, err := lockInvoiceProductsTx(ctx, tx, nil, effects); err != nil {
+		return err
+	}
+	if isSalesInvoiceType(invoiceType) {
+		if err := applySalesChangeTx(ctx, tx, nil, effects, allowNegativeStock); err != nil {
+			return err
+		}
+	} else if invoiceType == "purchase" {
+		if err := applyPurchaseChangeTx(ctx, tx, nil, effects); err != nil {
+			return err
+		}
+	} else {
+		return fmt.Errorf("unsupported invoice type: %s", invoiceType)
+	}
+
+	if err := setInvoiceStatusTx(ctx, tx, invoiceID, InvoiceStatusFinalized); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit finalize invoice tx: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) VoidInvoice(ctx context.Context, invoiceID int64) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin void invoice tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	invoiceType, status, err := loadInvoiceStatusForUpdateTx(ctx, tx, invoiceID)
+	if err != nil {
+		return err
+	}
+	if status == InvoiceStatusVoid {
+		return fmt.Errorf("%w: invoice %d is already void", ErrInvoiceStatusConflict, invoiceID)
+	}
+	if status == InvoiceStatusFinalized {
+		if err := reverseInvoiceStockTx(ctx, tx, invoiceID, invoiceType); err != nil {
+			return err
+		}
+	}
+
+	if err := setInvoiceStatusTx(ctx, tx, invoiceID, InvoiceStatusVoid); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit void invoice tx: %w", err)
+	}
+	return nil
+}
+
+func loadInvoiceStatusForUpdateTx(ctx context.Context, tx pgx.Tx, invoiceID int64) (string, string, error) {
+	var invoiceType, status string
+	err := tx.QueryRow(ctx, `
+		SELECT invoice_type, status
+		FROM invoices
+		WHERE id = $1
+		FOR UPDATE
+	`, invoiceID).Scan(&invoiceType, &status)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", "", ErrNotFound
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("load invoice %d: %w", invoiceID, err)
+	}
+	return invoiceType, status, nil
+}
+
+func loadInvoiceEffectsWithFallbackTx(
+	ctx context.Context,
+	tx pgx.Tx,
+	invoiceID int64,
+	invoiceType string,
+) ([]inventoryEffect, error) {
+	effects, err := loadInvoiceStockEffectsTx(ctx, tx, invoiceID)
+	if err != nil {
+		return nil, err
+	}
+	if len(effects) > 0 {
+		return effects, nil
+	}
+	lines, err := loadInvoiceLinesTx(ctx, tx, invoiceID)
+	if err != nil {
+		return nil, err
+	}
+	if isSalesInvoiceType(invoiceType) {
+		return salesEffectsForType(invoiceType, legacySalesEffectsFromInvoiceLines(lines)), nil
+	}
+	return legacyPurchaseEffectsFromInvoiceLines(lines), nil
+}
+
+func reverseInvoiceStockTx(ctx context.Context, tx pgx.Tx, invoiceID int64, invoiceType string) error {
+	effects, err := loadInvoiceEffectsWithFallbackTx(ctx, tx, invoiceID, invoiceType)
+	if err != nil {
+		return err
+	}
+	if _, err := lockInvoiceProductsTx(ctx, tx, nil, effects); err != nil {
+		return err
+	}
+	if isSalesInvoiceType(invoiceType) {
+		return applySalesChangeTx(ctx, tx, effects, nil, true)
+	}
+	if invoiceType == "purchase" {
+		return applyPurchaseChangeTx(ctx, tx, effects, nil)
+	}
+	return fmt.Errorf("unsupported invoice type: %s", invoiceType)
+}
+
+func setInvoiceStatusTx(ctx context.Context, tx pgx.Tx, invoiceID int64, status string) error {
+	if _, err := tx.Exec(ctx, `
+		UPDATE invoices
+		SET status = $2
+		WHERE id = $1
+	`, invoiceID, status); err != nil {
+		return fmt.Errorf("update invoice %d status: %w", invoiceID, err)
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+)
+
+const salesInvoiceTypePrefix = "sales"
+
+// salesReturnInvoiceType belongs to the sales family but puts stock back and
+// counts against sales totals.
+const salesReturnInvoiceType = "sales_return"
+
+// isSalesInvoiceType reports whether invoiceType belongs to the sales family
+// (sales, sales_basalam, sales_return, ...).
+func isSalesInvoiceType(invoiceType string) bool {
+	return strings.HasPrefix(invoiceType, salesInvoiceTypePrefix)
+}
+
+func isSalesReturnInvoiceType(invoiceType string) bool {
+	return invoiceType == salesReturnInvoiceType
+}
+
+// salesEffectsForType flips sales effects for returns so applySalesChangeTx
+// adds the quantities back instead of removing them.
+func salesEffectsForType(invoiceType string, effects []inventoryEffect) []inventoryEffect {
+	if !isSalesReturnInvoiceType(invoiceType) {
+		return effects
+	}
+	for i := range effects {
+		effects[i].Quantity = -effects[i].Quantity
+	}
+	return effects
+}
+
+// invoiceTypeCondition builds the SQL filter for a user supplied invoice type.
+// An empty value matches everything and "sales" matches the whole sales
+// family, anything else must match exactly.
+func invoiceTypeCondition(column string, placeholder string) string {
+	return fmt.Sprintf(
+		"(%[2]s = '' OR (%[2]s = '%[3]s' AND %[1]s LIKE '%[3]s%%') OR %[1]s = %[2]s)",
+		column,
+		placeholder,
+		salesInvoiceTypePrefix,
+	)
+}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"backend/internal/domain"
+)
+
+func TestInvoiceTypeCondition(t *testing.T) {
+	got := invoiceTypeCondition("i.invoice_type", "$3")
+	want := "($3 = '' OR ($3 = 'sales' AND i.invoice_type LIKE 'sales%') OR i.invoice_type = $3)"
+	if got != want {
+		t.Fatalf("invoiceTypeCondition = %q, want %q", got, want)
+	}
+}
+
+func TestIsSalesInvoiceType(t *testing.T) {
+	tests := []struct {
+		invoiceType string
+		sales       bool
+		salesReturn bool
+	}{
+		{invoiceType: "sales", sales: true},
+		{invoiceType: "sales_basalam", sales: true},
+		{invoiceType: "sales_return", sales: true, salesReturn: true},
+		{invoiceType: "purchase"},
+		{invoiceType: ""},
+	}
+	for _, tt := range tests {
+		if got := isSalesInvoiceType(tt.invoiceType); got != tt.sales {
+			t.Errorf("isSalesInvoiceType(%q) = %v, want %v", tt.invoiceType, got, tt.sales)
+		}
+		if got := isSalesReturnInvoiceType(tt.invoiceType); got != tt.salesReturn {
+			t.Errorf("isSalesReturnInvoiceType(%q) = %v, want %v", tt.invoiceType, got, tt.salesReturn)
+		}
+	}
+}
+
+func TestSalesEffectsForType(t *testing.T) {
+	for _, tt := range []struct {
+		invoiceType string
+		want        float64
+	}{
+		{invoiceType: "sales", want: 2},
+		{invoiceType: "sales_basalam", want: 2},
+		{invoiceType: "sales_return", want: -2},
+	} {
+		effects := salesEffectsForType(tt.invoiceType, []inventoryEffect{{ProductID: 1, Quantity: 2}})
+		if effects[0].Quantity != tt.want {
+			t.Errorf("salesEffectsForType(%q) quantity = %v, want %v", tt.invoiceType, effects[0].Quantity, tt.want)
+		}
+	}
+}
+
+// TestInvoiceTypeFilter runs on an empty schema so the counts cover only the
+// invoices created here: "sales" must take in every sales subtype in the list
+// and in both stats queries, while a subtype matches only itself.
+func TestInvoiceTypeFilter(t *testing.T) {
+	repo := testEmptyRepository(t)
+	ctx := context.Background()
+
+	if _, err := repo.CreatePurchaseInvoice(ctx, nil, nil, nil,
+		[]domain.PurchaseLineInput{{ProductName: "typed", Price: 10, Quantity: 10}},
+		InvoiceAdjustments{}, InvoiceStatusFinalized); err != nil {
+		t.Fatalf("create purchase: %v", err)
+	}
+	for _, invoiceType := range []string{"sales", "sales_basalam", "sales_return"} {
+		lines := []domain.SalesLineInput{{ProductName: "typed", Price: 20, Quantity: 1}}
+		if _, err := repo.CreateSalesInvoice(ctx, nil, nil, nil, invoiceType, lines, InvoiceAdjustments{}, InvoiceStatusFinalized, false); err != nil {
+			t.Fatalf("create %s invoice: %v", invoiceType, err)
+		}
+	}
+
+	tests := []struct {
+		invoiceType string
+		want        int
+	}{
+		{invoiceType: "", want: 4},
+		{invoiceType: "sales", want: 3},
+		{invoiceType: "sales_basalam", want: 1},
+		{invoiceType: "sales_return", want: 1},
+		{invoiceType: "purchase", want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.invoiceType, func(t *testing.T) {
+			filter := InvoiceListFilter{InvoiceType: tt.invoiceType}
+			items, _, err := repo.ListInvoices(ctx, filter)
+			if err != nil {
+				t.Fatalf("ListInvoices: %v", err)
+			}
+			count, _, err := repo.GetInvoiceStats(ctx, tt.invoiceType)
+			if err != nil {
+				t.Fatalf("GetInvoiceStats: %v", err)
+			}
+			filtered, _, err := repo.GetInvoiceStatsFiltered(ctx, filter)
+			if err != nil {
+				t.Fatalf("GetInvoiceStatsFiltered: %v", err)
+			}
+			if len(items) != tt.want || count != tt.want || filtered != tt.want {
+				t.Fatalf("list/stats/filtered stats = %d/%d/%d, want %d", len(items), count, filtered, tt.want)
+			}
+		})
+	}
+}
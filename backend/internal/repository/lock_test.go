@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"backend/internal/db"
+	"backend/internal/domain"
+)
+
+// testRepository connects to TEST_DATABASE_URL and migrates it. The database
+// should be a scratch one; tests create rows with unique names and leave them.
+func testRepository(t testing.TB) *Repository {
+	t.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	pool, err := db.NewPool(ctx, url, db.PoolOptions{MaxConns: 8, MinConns: 1})
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(pool.Close)
+	if err := db.RunMigrations(ctx, pool); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return New(pool)
+}
+
+// TestConcurrentInvoiceUpdatesDoNotDeadlock updates two finalized sales
+// invoices at the same time, one listing the shared products as A, B and the
+// other as B, A. Without lockInvoiceProductsTx taking its locks in one fixed
+// order, the per-line FOR UPDATE reads deadlock.
+func TestConcurrentInvoiceUpdatesDoNotDeadlock(t *testing.T) {
+	repo := testRepository(t)
+	ctx := context.Background()
+
+	suffix := time.Now().UnixNano()
+	names := []string{
+		fmt.Sprintf("lock test a %d", suffix),
+		fmt.Sprintf("lock test b %d", suffix),
+	}
+	const initialQty = 1000.0
+	productIDs := make([]int64, len(names))
+	for i, name := range names {
+		product, err := repo.CreateProduct(ctx, ProductCreateInput{
+			ProductName: name,
+			Quantity:    initialQty,
+			AvgBuyPrice: 10,
+			SellPrice:   20,
+		})
+		if err != nil {
+			t.Fatalf("create product %q: %v", name, err)
+		}
+		productIDs[i] = product.ID
+	}
+
+	orders := [][]string{{names[0], names[1]}, {names[1], names[0]}}
+	invoiceIDs := make([]int64, len(orders))
+	for i, order := range orders {
+		lines := make([]domain.SalesLineInput, 0, len(order))
+		for _, name := range order {
+			lines = append(lines, domain.SalesLineInput{ProductName: name, Price: 20, Quantity: 1})
+		}
+		id, err := repo.CreateSalesInvoice(ctx, nil, nil, nil, "sales", lines, InvoiceAdjustments{}, InvoiceStatusFinalized, false)
+		if err != nil {
+			t.Fatalf("create invoice %d: %v", i, err)
+		}
+		invoiceIDs[i] = id
+	}
+
+	const rounds = 25
+	var wg sync.WaitGroup
+	errs := make(chan error, len(orders)*rounds)
+	for i, order := range orders {
+		wg.Add(1)
+		go func(invoiceID int64, order []string) {
+			defer wg.Done()
+			for round := 1; round <= rounds; round++ {
+				lines := make([]domain.InvoiceLine, 0, len(order))
+				for _, name := range order {
+					lines = append(lines, domain.InvoiceLine{ProductName: name, Price: 20, Quantity: float64(round)})
+				}
+				if err := repo.UpdateInvoiceLinesReconciled(ctx, invoiceID, nil, lines, false); err != nil {
+					errs <- fmt.Errorf("invoice %d round %d: %w", invoiceID, round, err)
+				}
+			}
+		}(invoiceIDs[i], order)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	// Each invoice ends with quantity rounds on both products.
+	want := initialQty - float64(len(orders)*rounds)
+	for i, id := range productIDs {
+		product, err := repo.GetProductByID(ctx, id)
+		if err != nil {
+			t.Fatalf("get product %q: %v", names[i], err)
+		}
+		if product.Quantity != want {
+			t.Errorf("%q quantity = %v, want %v", names[i], product.Quantity, want)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"backend/internal/domain"
+)
+
+func TestTrimPage(t *testing.T) {
+	tests := []struct {
+		items    []int
+		limit    int
+		want     []int
+		wantMore bool
+	}{
+		{items: []int{}, limit: 2, want: []int{}},
+		{items: []int{1}, limit: 2, want: []int{1}},
+		{items: []int{1, 2}, limit: 2, want: []int{1, 2}},
+		{items: []int{1, 2, 3}, limit: 2, want: []int{1, 2}, wantMore: true},
+	}
+	for _, tt := range tests {
+		got, more := trimPage(tt.items, tt.limit)
+		if !reflect.DeepEqual(got, tt.want) || more != tt.wantMore {
+			t.Errorf("trimPage(%v, %d) = %v, %v; want %v, %v", tt.items, tt.limit, got, more, tt.want, tt.wantMore)
+		}
+	}
+}
+
+func TestNormalizePage(t *testing.T) {
+	tests := []struct {
+		limit, offset         int
+		wantLimit, wantOffset int
+	}{
+		{limit: 0, offset: 0, wantLimit: 200, wantOffset: 0},
+		{limit: -1, offset: -5, wantLimit: 200, wantOffset: 0},
+		{limit: 50, offset: 100, wantLimit: 50, wantOffset: 100},
+		{limit: 5000, offset: 10, wantLimit: 1000, wantOffset: 10},
+	}
+	for _, tt := range tests {
+		limit, offset := NormalizePage(tt.limit, tt.offset)
+		if limit != tt.wantLimit || offset != tt.wantOffset {
+			t.Errorf("NormalizePage(%d, %d) = %d, %d; want %d, %d", tt.limit, tt.offset, limit, offset, tt.wantLimit, tt.wantOffset)
+		}
+	}
+}
+
+// TestListPageBoundaries seeds four products, invoices and actions on an
+// empty schema and pages through each list around the exact boundaries.
+func TestListPageBoundaries(t *testing.T) {
+	repo := testEmptyRepository(t)
+	ctx := context.Background()
+
+	const total = 4
+	actions := make([]ActionInput, total)
+	for i := range total {
+		name := fmt.Sprintf("page product %d", i)
+		if _, err := repo.CreatePurchaseInvoice(ctx, nil, nil, nil,
+			[]domain.PurchaseLineInput{{ProductName: name, Price: 10, Quantity: 1}},
+			InvoiceAdjustments{}, InvoiceStatusFinalized); err != nil {
+			t.Fatalf("create invoice %d: %v", i, err)
+		}
+		actions[i] = ActionInput{ActionType: "page_test", Title: name}
+	}
+	if _, err := repo.LogActions(ctx, actions); err != nil {
+		t.Fatalf("log actions: %v", err)
+	}
+
+	lists := map[string]func(limit, offset int) (int, bool, error){
+		"products": func(limit, offset int) (int, bool, error) {
+			items, more, err := repo.ListProducts(ctx, ProductListFilter{Limit: limit, Offset: offset})
+			return len(items), more, err
+		},
+		"invoices": func(limit, offset int) (int, bool, error) {
+			items, more, err := repo.ListInvoices(ctx, InvoiceListFilter{Limit: limit, Offset: offset})
+			return len(items), more, err
+		},
+		"actions": func(limit, offset int) (int, bool, error) {
+			items, more, err := repo.ListActions(ctx, limit, offset, ActionFilter{ActionType: "page_test"})
+			return len(items), more, err
+		},
+	}
+	tests := []struct {
+		limit, offset int
+		wantCount     int
+		wantMore      bool
+	}{
+		{limit: 3, offset: 0, wantCount: 3, wantMore: true},
+		{limit: 2, offset: 0, wantCount: 2, wantMore: true},
+		{limit: 2, offset: 2, wantCount: 2, wantMore: false},
+		{limit: 4, offset: 0, wantCount: 4, wantMore: false},
+		{limit: 3, offset: 3, wantCount: 1, wantMore: false},
+		{limit: 2, offset: 4, wantCount: 0, wantMore: false},
+	}
+	for name, list := range lists {
+		for _, tt := range tests {
+			t.Run(fmt.Sprintf("%s limit %d offset %d", name, tt.limit, tt.offset), func(t *testing.T) {
+				count, more, err := list(tt.limit, tt.offset)
+				if err != nil {
+					t.Fatalf("list: %v", err)
+				}
+				if count != tt.wantCount || more != tt.wantMore {
+					t.Fatalf("count, has_more = %d, %v; want %d, %v", count, more, tt.wantCount, tt.wantMore)
+				}
+			})
+		}
+	}
+}
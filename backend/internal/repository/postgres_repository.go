@@ -12,41 +12,98 @@ import (
 	"backend/internal/domain"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 var ErrNotFound = errors.New("not found")
 
+var ErrInvoiceStatusConflict = errors.New("invoice status conflict")
+
+// ErrDuplicateSKU is returned when a product write would reuse the SKU of
+// another product.
+var ErrDuplicateSKU = errors.New("sku already in use")
+
+// ErrDuplicateProductName is returned when a rename would collide with the
+// normalized name of another product.
+var ErrDuplicateProductName = errors.New("product name already exists")
+
+// ErrDuplicateUsername is returned when an admin is created with a username
+// that is already taken.
+var ErrDuplicateUsername = errors.New("username already exists")
+
+// ErrProductStale is returned when a patch was based on an older version of
+// the product than the one stored.
+var ErrProductStale = errors.New("product was modified by someone else")
+
+// ErrInsufficientStock is returned when a sale or adjustment would drive a
+// product below zero while negative stock is not allowed.
+var ErrInsufficientStock = errors.New("insufficient stock")
+
+// ErrInvoiceLineNotFound is returned when a line id does not belong to the
+// invoice it was addressed through.
+var ErrInvoiceLineNotFound = errors.New("invoice line not found")
+
+// ErrProductMerged is returned when a write names a product that was merged
+// into another one. The merged name lives on as an alias of the target.
+var ErrProductMerged = errors.New("product was merged into another product")
+
+// ErrNoProducts is returned by imports that match against the inventory when
+// there is no product to match.
+var ErrNoProducts = errors.New("no products in inventory to match against; import inventory first")
+
 type ProductListFilter struct {
-	Search    string
-	Limit     int
-	Offset    int
-	Threshold *int
+	Search     string
+	Limit      int
+	Offset     int
+	Threshold  *int
+	CategoryID *int64
+	MinSell    *float64
+	MaxSell    *float64
+	Source     string
+	Sort       ProductSort
+	// MissingSellPrice keeps only products whose sell_price is not set (<= 0).
+	MissingSellPrice bool
+	// Fuzzy ranks Search by similarity in the service instead of matching
+	// substrings; FuzzyThreshold is the minimum score in percent.
+	Fuzzy          bool
+	FuzzyThreshold float64
 }
 
 type ProductCreateInput struct {
 	ProductName  string
-	Quantity     int
+	Quantity     float64
 	AvgBuyPrice  float64
 	LastBuyPrice float64
 	SellPrice    float64
 	Alarm        *int
 	Source       *string
+	CategoryID   *int64
+	SKU          *string
+	// Unit is ProductUnitPiece or ProductUnitWeight; empty means piece.
+	Unit string
 }
 
 type ProductPatchInput struct {
 	ProductName  *string
-	Quantity     *int
+	Quantity     *float64
 	AvgBuyPrice  *float64
 	LastBuyPrice *float64
 	SellPrice    *float64
 	Alarm        *int
 	Source       *string
+	CategoryID   *int64
+	// SKU replaces the product code; an empty string clears it.
+	SKU  *string
+	Unit *string
+	// ExpectedUpdatedAt, when set, must equal the stored updated_at or the
+	// patch fails with ErrProductStale.
+	ExpectedUpdatedAt *time.Time
 }
 
 type InventorySummary struct {
 	TotalProducts  int     `json:"total_products"`
-	TotalQuantity  int     `json:"total_quantity"`
+	TotalQuantity  float64 `json:"total_quantity"`
 	InventoryValue float64 `json:"inventory_value"`
 }
 
@@ -54,15 +111,33 @@ type InvoiceListFilter struct {
 	InvoiceType string
 	From        *time.Time
 	To          *time.Time
-	Limit       int
-	Offset      int
+	Supplier    string
+	Customer    string
+	// Product keeps invoices with at least one line whose product name
+	// contains it.
+	Product string
+	// MinAmount and MaxAmount bound total_amount, both inclusive.
+	MinAmount *float64
+	MaxAmount *float64
+	Limit     int
+	Offset    int
+}
+
+type InvoiceAdjustments struct {
+	DiscountAmount *float64
+	TaxAmount      *float64
 }
 
 type CreateInvoiceInput struct {
-	InvoiceType   string
-	InvoiceName   *string
-	AdminUsername *string
-	Lines         []domain.InvoiceLine
+	InvoiceType    string
+	InvoiceName    *string
+	AdminUsername  *string
+	SupplierName   *string
+	CustomerName   *string
+	DiscountAmount *float64
+	TaxAmount      *float64
+	Status         string
+	Lines          []domain.InvoiceLine
 }
 
 type Repository struct {
@@ -73,71 +148,112 @@ func New(pool *pgxpool.Pool) *Repository {
 	return &Repository{pool: pool}
 }
 
-func (r *Repository) ListProducts(ctx context.Context, filter ProductListFilter) ([]domain.Product, error) {
+// PoolStat returns a snapshot of the connection pool counters.
+func (r *Repository) PoolStat() *pgxpool.Stat {
+	return r.pool.Stat()
+}
+
+// ListProducts returns one page of products and whether another page follows.
+func (r *Repository) ListProducts(ctx context.Context, filter ProductListFilter) ([]domain.Product, bool, error) {
 	limit := normalizeLimit(filter.Limit)
 	offset := normalizeOffset(filter.Offset)
 	search := strings.TrimSpace(filter.Search)
 
 	base := `
 		SELECT
-			id,
-			product_name,
-			quantity,
-			avg_buy_price::double precision,
-			last_buy_price::double precision,
-			sell_price::double precision,
-			alarm,
-			source,
-			created_at,
-			updated_at
-		FROM products
-		WHERE ($1 = '' OR product_name ILIKE '%' || $1 || '%')
+			p.id,
+			p.product_name,
+			p.quantity,
+			p.avg_buy_price::double precision,
+			p.last_buy_price::double precision,
+			p.sell_price::double precision,
+			p.alarm,
+			p.source,
+			p.created_at,
+			p.updated_at,
+			p.category_id,
+			c.name,
+			p.sku,
+			p.unit
+		FROM products p
+		LEFT JOIN categories c ON c.id = p.category_id
+		WHERE p.deleted_at IS NULL
+		  AND ($1 = '' OR p.product_name ILIKE '%' || $1 || '%')
 	`
 	args := []any{search}
 	argIndex := 2
 	if filter.Threshold != nil {
-		base += fmt.Sprintf(" AND quantity <= COALESCE(alarm, $%d)", argIndex)
+		base += fmt.Sprintf(" AND p.quantity <= COALESCE(p.alarm, $%d)", argIndex)
 		args = append(args, *filter.Threshold)
 		argIndex++
 	}
-	base += fmt.Sprintf(" ORDER BY id ASC LIMIT $%d OFFSET $%d", argIndex, argIndex+1)
-	args = append(args, limit, offset)
+	if filter.CategoryID != nil {
+		base += fmt.Sprintf(" AND p.category_id = $%d", argIndex)
+		args = append(args, *filter.CategoryID)
+		argIndex++
+	}
+	if filter.MinSell != nil {
+		base += fmt.Sprintf(" AND p.sell_price >= $%d", argIndex)
+		args = append(args, *filter.MinSell)
+		argIndex++
+	}
+	if filter.MaxSell != nil {
+		base += fmt.Sprintf(" AND p.sell_price <= $%d", argIndex)
+		args = append(args, *filter.MaxSell)
+		argIndex++
+	}
+	if source := strings.TrimSpace(filter.Source); source != "" {
+		base += fmt.Sprintf(" AND LOWER(p.source) = LOWER($%d)", argIndex)
+		args = append(args, source)
+		argIndex++
+	}
+	if filter.MissingSellPrice {
+		base += " AND p.sell_price <= 0"
+	}
+	base += fmt.Sprintf(" ORDER BY %s LIMIT $%d OFFSET $%d", filter.Sort.orderBy(), argIndex, argIndex+1)
+	args = append(args, limit+1, offset)
 
 	rows, err := r.pool.Query(ctx, base, args...)
 	if err != nil {
-		return nil, fmt.Errorf("list products: %w", err)
+		return nil, false, fmt.Errorf("list products: %w", err)
 	}
 	defer rows.Close()
 
-	products := make([]domain.Product, 0, limit)
+	products := make([]domain.Product, 0, limit+1)
 	for rows.Next() {
 		p, err := scanProduct(rows)
 		if err != nil {
-			return nil, err
+			return nil, false, err
 		}
 		products = append(products, p)
 	}
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("iterate products: %w", err)
+		return nil, false, fmt.Errorf("iterate products: %w", err)
 	}
-	return products, nil
+	products, hasMore := trimPage(products, limit)
+	return products, hasMore, nil
 }
 
 func (r *Repository) GetProductByID(ctx context.Context, id int64) (*domain.Product, error) {
 	row := r.pool.QueryRow(ctx, `
 		SELECT
-			id,
-			product_name,
-			quantity,
-			avg_buy_price::double precision,
-			last_buy_price::double precision,
-			sell_price::double precision,
-			alarm,
-			source,
-			created_at,
-			updated_at
-		FROM products
-		WHERE id = $1
+			p.id,
+			p.product_name,
+			p.quantity,
+			p.avg_buy_price::double precision,
+			p.last_buy_price::double precision,
+			p.sell_price::double precision,
+			p.alarm,
+			p.source,
+			p.created_at,
+			p.updated_at,
+			p.category_id,
+			c.name,
+			p.sku,
+			p.unit
+		FROM products p
+		LEFT JOIN categories c ON c.id = p.category_id
+		WHERE p.id = $1 AND p.deleted_at IS NULL
 	`, id)
 	product, err := scanProductRow(row)
 	if err != nil {
@@ -149,6 +265,37 @@ func (r *Repository) GetProductByID(ctx context.Context, id int64) (*domain.Prod
 	return &product, nil
 }
 
+func (r *Repository) GetProductBySKU(ctx context.Context, sku string) (*domain.Product, error) {
+	row := r.pool.QueryRow(ctx, `
+		SELECT
+			p.id,
+			p.product_name,
+			p.quantity,
+			p.avg_buy_price::double precision,
+			p.last_buy_price::double precision,
+			p.sell_price::double precision,
+			p.alarm,
+			p.source,
+			p.created_at,
+			p.updated_at,
+			p.category_id,
+			c.name,
+			p.sku,
+			p.unit
+		FROM products p
+		LEFT JOIN categories c ON c.id = p.category_id
+		WHERE p.sku = $1 AND p.deleted_at IS NULL
+	`, strings.TrimSpace(sku))
+	product, err := scanProductRow(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("get product by sku: %w", err)
+	}
+	return &product, nil
+}
+
 func (r *Repository) CreateProduct(ctx context.Context, input ProductCreateInput) (domain.Product, error) {
 	name := strings.TrimSpace(input.ProductName)
 	if name == "" {
@@ -160,6 +307,15 @@ func (r *Repository) CreateProduct(ctx context.Context, input ProductCreateInput
 	if input.AvgBuyPrice < 0 || input.LastBuyPrice < 0 || input.SellPrice < 0 {
 		return domain.Product{}, fmt.Errorf("prices cannot be negative")
 	}
+	unit, err := NormalizeProductUnit(input.Unit)
+	if err != nil {
+		return domain.Product{}, err
+	}
+	if input.CategoryID != nil {
+		if err := ensureCategoryExists(ctx, r.pool, *input.CategoryID); err != nil {
+			return domain.Product{}, err
+		}
+	}
 
 	row := r.pool.QueryRow(ctx, `
 		INSERT INTO products (
@@ -169,9 +325,12 @@ func (r *Repository) CreateProduct(ctx context.Context, input ProductCreateInput
 			last_buy_price,
 			sell_price,
 			alarm,
-			source
+			source,
+			category_id,
+			sku,
+			unit
 		)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 		ON CONFLICT ON CONSTRAINT uq_products_name_normalized
 		DO UPDATE SET
 			quantity = EXCLUDED.quantity,
@@ -180,7 +339,11 @@ func (r *Repository) CreateProduct(ctx context.Context, input ProductCreateInput
 			sell_price = EXCLUDED.sell_price,
 			alarm = EXCLUDED.alarm,
 			source = EXCLUDED.source,
+			category_id = EXCLUDED.category_id,
+			sku = COALESCE(EXCLUDED.sku, products.sku),
+			unit = EXCLUDED.unit,
 			updated_at = NOW()
+		WHERE products.deleted_at IS NULL
 		RETURNING
 			id,
 			product_name,
@@ -191,12 +354,19 @@ func (r *Repository) CreateProduct(ctx context.Context, input ProductCreateInput
 			alarm,
 			source,
 			created_at,
-			updated_at
-	`, name, input.Quantity, input.AvgBuyPrice, input.LastBuyPrice, input.SellPrice, input.Alarm, input.Source)
+			updated_at,
+			category_id,
+			(SELECT name FROM categories WHERE id = products.category_id),
+			sku,
+			unit
+	`, name, input.Quantity, input.AvgBuyPrice, input.LastBuyPrice, input.SellPrice, input.Alarm, input.Source, input.CategoryID, normalizeSKU(input.SKU), unit)
 
 	product, err := scanProductRow(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.Product{}, fmt.Errorf("create product %q: %w", name, ErrProductMerged)
+	}
 	if err != nil {
-		return domain.Product{}, fmt.Errorf("create product: %w", err)
+		return domain.Product{}, productWriteError("create product", err)
 	}
 	return product, nil
 }
@@ -219,9 +389,13 @@ func (r *Repository) PatchProduct(ctx context.Context, id int64, input ProductPa
 			alarm,
 			source,
 			created_at,
-			updated_at
+			updated_at,
+			category_id,
+			(SELECT name FROM categories WHERE id = products.category_id),
+			sku,
+			unit
 		FROM products
-		WHERE id = $1
+		WHERE id = $1 AND deleted_at IS NULL
 		FOR UPDATE
 	`, id)
 	product, err := scanProductRow(row)
@@ -231,6 +405,14 @@ func (r *Repository) PatchProduct(ctx context.Context, id int64, input ProductPa
 		}
 		return nil, fmt.Errorf("load product for patch: %w", err)
 	}
+	if input.ExpectedUpdatedAt != nil && !product.UpdatedAt.Equal(*input.ExpectedUpdatedAt) {
+		return nil, fmt.Errorf(
+			"%w: product %d was updated at %s",
+			ErrProductStale,
+			id,
+			product.UpdatedAt.Format(time.RFC3339Nano),
+		)
+	}
 
 	if input.ProductName != nil {
 		name := strings.TrimSpace(*input.ProductName)
@@ -269,6 +451,26 @@ func (r *Repository) PatchProduct(ctx context.Context, id int64, input ProductPa
 	if input.Source != nil {
 		product.Source = input.Source
 	}
+	if input.CategoryID != nil {
+		if *input.CategoryID <= 0 {
+			product.CategoryID = nil
+		} else {
+			if err := ensureCategoryExists(ctx, tx, *input.CategoryID); err != nil {
+				return nil, err
+			}
+			product.CategoryID = input.CategoryID
+		}
+	}
+	if input.SKU != nil {
+		product.SKU = normalizeSKU(input.SKU)
+	}
+	if input.Unit != nil {
+		unit, err := NormalizeProductUnit(*input.Unit)
+		if err != nil {
+			return nil, err
+		}
+		product.Unit = unit
+	}
 
 	row = tx.QueryRow(ctx, `
 		UPDATE products
@@ -280,6 +482,9 @@ func (r *Repository) PatchProduct(ctx context.Context, id int64, input ProductPa
 			sell_price = $6,
 			alarm = $7,
 			source = $8,
+			category_id = $9,
+			sku = $10,
+			unit = $11,
 			updated_at = NOW()
 		WHERE id = $1
 		RETURNING
@@ -292,7 +497,11 @@ func (r *Repository) PatchProduct(ctx context.Context, id int64, input ProductPa
 			alarm,
 			source,
 			created_at,
-			updated_at
+			updated_at,
+			category_id,
+			(SELECT name FROM categories WHERE id = products.category_id),
+			sku,
+			unit
 	`,
 		id,
 		product.ProductName,
@@ -302,10 +511,13 @@ func (r *Repository) PatchProduct(ctx context.Context, id int64, input ProductPa
 		product.SellPrice,
 		product.Alarm,
 		product.Source,
+		product.CategoryID,
+		product.SKU,
+		product.Unit,
 	)
 	updated, err := scanProductRow(row)
 	if err != nil {
-		return nil, fmt.Errorf("update product: %w", err)
+		return nil, productWriteError("update product", err)
 	}
 
 	if err := tx.Commit(ctx); err != nil {
@@ -325,70 +537,111 @@ func (r *Repository) DeleteProduct(ctx context.Context, id int64) error {
 	return nil
 }
 
-func (r *Repository) UpsertInventoryRows(ctx context.Context, rows []domain.InventoryImportRow) (int, int, error) {
-	if len(rows) == 0 {
-		return 0, 0, nil
+// PreviewInventoryImport reports what UpsertInventoryRows would do with rows
+// without writing. Names are keyed by LOWER() in SQL so the preview uses the
+// same rule as the uq_products_name_normalized conflict target. Names still
+// held by a merged product are reported in MergedNames rather than counted,
+// since the real import fails on them with ErrProductMerged.
+func (r *Repository) PreviewInventoryImport(
+	ctx context.Context,
+	rows []domain.InventoryImportRow,
+) (domain.InventoryImportPreview, error) {
+	preview := domain.InventoryImportPreview{DuplicateNames: []string{}, MergedNames: []string{}}
+	names := make([]string, 0, len(rows))
+	for _, line := range rows {
+		if name := strings.TrimSpace(line.ProductName); name != "" {
+			names = append(names, name)
+		}
 	}
-	tx, err := r.pool.Begin(ctx)
+	preview.TotalRows = len(names)
+	if len(names) == 0 {
+		return preview, nil
+	}
+
+	result, err := r.pool.Query(ctx, `
+		SELECT
+			input.name,
+			LOWER(input.name),
+			p.id IS NOT NULL,
+			p.deleted_at IS NOT NULL
+		FROM unnest($1::text[]) WITH ORDINALITY AS input(name, position)
+		LEFT JOIN products p ON p.product_name_normalized = LOWER(input.name)
+		ORDER BY input.position
+	`, names)
 	if err != nil {
-		return 0, 0, fmt.Errorf("begin import tx: %w", err)
+		return preview, fmt.Errorf("preview inventory import: %w", err)
 	}
-	defer tx.Rollback(ctx)
+	defer result.Close()
 
-	created := 0
-	updated := 0
-	for _, line := range rows {
-		name := strings.TrimSpace(line.ProductName)
-		if name == "" {
+	seen := make(map[string]struct{}, len(names))
+	duplicates := make(map[string]struct{})
+	for result.Next() {
+		var (
+			name   string
+			key    string
+			exists bool
+			merged bool
+		)
+		if err := result.Scan(&name, &key, &exists, &merged); err != nil {
+			return preview, fmt.Errorf("scan inventory import preview: %w", err)
+		}
+		if _, ok := seen[key]; ok {
+			if _, reported := duplicates[key]; !reported {
+				duplicates[key] = struct{}{}
+				preview.DuplicateNames = append(preview.DuplicateNames, name)
+			}
 			continue
 		}
-
-		var existingID int64
-		err := tx.QueryRow(ctx, "SELECT id FROM products WHERE LOWER(product_name) = LOWER($1)", name).Scan(&existingID)
-		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
-			return 0, 0, fmt.Errorf("query existing product %q: %w", name, err)
+		seen[key] = struct{}{}
+		if merged {
+			preview.MergedNames = append(preview.MergedNames, name)
+		} else if exists {
+			preview.WouldUpdate++
+		} else {
+			preview.WouldCreate++
 		}
+	}
+	if err := result.Err(); err != nil {
+		return preview, fmt.Errorf("iterate inventory import preview: %w", err)
+	}
+	return preview, nil
+}
 
-		if errors.Is(err, pgx.ErrNoRows) {
-			if _, err := tx.Exec(ctx, `
-				INSERT INTO products (
-					product_name,
-					quantity,
-					avg_buy_price,
-					last_buy_price,
-					sell_price,
-					alarm,
-					source
-				) VALUES ($1, $2, $3, $4, $5, $6, $7)
-			`,
-				name,
-				line.Quantity,
-				line.AvgBuyPrice,
-				line.LastBuyPrice,
-				line.SellPrice,
-				line.Alarm,
-				line.Source,
-			); err != nil {
-				return 0, 0, fmt.Errorf("insert imported product %q: %w", name, err)
-			}
-			created++
+func (r *Repository) UpsertInventoryRows(ctx context.Context, rows []domain.InventoryImportRow) (int, int, error) {
+	if len(rows) == 0 {
+		return 0, 0, nil
+	}
+
+	batch := &pgx.Batch{}
+	names := make([]string, 0, len(rows))
+	for _, line := range rows {
+		name := strings.TrimSpace(line.ProductName)
+		if name == "" {
 			continue
 		}
-
-		if _, err := tx.Exec(ctx, `
-			UPDATE products
-			SET
-				product_name = $2,
-				quantity = $3,
-				avg_buy_price = $4,
-				last_buy_price = $5,
-				sell_price = $6,
-				alarm = $7,
-				source = $8,
+		batch.Queue(`
+			INSERT INTO products (
+				product_name,
+				quantity,
+				avg_buy_price,
+				last_buy_price,
+				sell_price,
+				alarm,
+				source
+			) VALUES ($1, $2, $3, $4, $5, $6, $7)
+			ON CONFLICT ON CONSTRAINT uq_products_name_normalized
+			DO UPDATE SET
+				product_name = EXCLUDED.product_name,
+				quantity = EXCLUDED.quantity,
+				avg_buy_price = EXCLUDED.avg_buy_price,
+				last_buy_price = EXCLUDED.last_buy_price,
+				sell_price = EXCLUDED.sell_price,
+				alarm = EXCLUDED.alarm,
+				source = EXCLUDED.source,
 				updated_at = NOW()
-			WHERE id = $1
+			WHERE products.deleted_at IS NULL
+			RETURNING (xmax = 0)
 		`,
-			existingID,
 			name,
 			line.Quantity,
 			line.AvgBuyPrice,
@@ -396,10 +649,40 @@ func (r *Repository) UpsertInventoryRows(ctx context.Context, rows []domain.Inve
 			line.SellPrice,
 			line.Alarm,
 			line.Source,
-		); err != nil {
-			return 0, 0, fmt.Errorf("update imported product %q: %w", name, err)
+		)
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return 0, 0, nil
+	}
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return 0, 0, fmt.Errorf("begin import tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	results := tx.SendBatch(ctx, batch)
+	created := 0
+	updated := 0
+	for _, name := range names {
+		var inserted bool
+		err := results.QueryRow().Scan(&inserted)
+		if errors.Is(err, pgx.ErrNoRows) {
+			err = ErrProductMerged
+		}
+		if err != nil {
+			_ = results.Close()
+			return 0, 0, fmt.Errorf("upsert imported product %q: %w", name, err)
+		}
+		if inserted {
+			created++
+		} else {
+			updated++
 		}
-		updated++
+	}
+	if err := results.Close(); err != nil {
+		return 0, 0, fmt.Errorf("close import batch: %w", err)
 	}
 
 	if err := tx.Commit(ctx); err != nil {
@@ -412,9 +695,10 @@ func (r *Repository) GetInventorySummary(ctx context.Context) (InventorySummary,
 	row := r.pool.QueryRow(ctx, `
 		SELECT
 			COUNT(*)::int,
-			COALESCE(SUM(quantity), 0)::int,
+			COALESCE(SUM(quantity), 0)::double precision,
 			COALESCE(SUM(quantity * avg_buy_price), 0)::double precision
 		FROM products
+		WHERE deleted_at IS NULL
 	`)
 	var summary InventorySummary
 	if err := row.Scan(&summary.TotalProducts, &summary.TotalQuantity, &summary.InventoryValue); err != nil {
@@ -427,18 +711,50 @@ func (r *Repository) CreatePurchaseInvoice(
 	ctx context.Context,
 	invoiceName *string,
 	adminUsername *string,
+	supplierName *string,
 	lines []domain.PurchaseLineInput,
+	adjustments InvoiceAdjustments,
+	status string,
 ) (int64, error) {
-	if len(lines) == 0 {
-		return 0, fmt.Errorf("lines cannot be empty")
-	}
-
 	tx, err := r.pool.Begin(ctx)
 	if err != nil {
 		return 0, fmt.Errorf("begin purchase tx: %w", err)
 	}
 	defer tx.Rollback(ctx)
 
+	invoiceID, err := createPurchaseInvoiceTx(ctx, tx, invoiceName, adminUsername, supplierName, lines, adjustments, status)
+	if err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("commit purchase tx: %w", err)
+	}
+	return invoiceID, nil
+}
+
+func createPurchaseInvoiceTx(
+	ctx context.Context,
+	tx pgx.Tx,
+	invoiceName *string,
+	adminUsername *string,
+	supplierName *string,
+	lines []domain.PurchaseLineInput,
+	adjustments InvoiceAdjustments,
+	status string,
+) (int64, error) {
+	if len(lines) == 0 {
+		return 0, fmt.Errorf("lines cannot be empty")
+	}
+	status, err := normalizeInvoiceStatus(status)
+	if err != nil {
+		return 0, err
+	}
+	resolved, err := lockInvoiceProductsTx(ctx, tx, purchaseLineNames(lines), nil)
+	if err != nil {
+		return 0, err
+	}
+	lines = resolvePurchaseLineNames(lines, resolved)
+
 	invoiceLines, effects, err := buildPurchaseInvoiceLinesAndEffectsTx(
 		ctx,
 		tx,
@@ -447,15 +763,21 @@ func (r *Repository) CreatePurchaseInvoice(
 	if err != nil {
 		return 0, err
 	}
-	if err := applyPurchaseChangeTx(ctx, tx, nil, effects); err != nil {
-		return 0, err
+	if status == InvoiceStatusFinalized {
+		if err := applyPurchaseChangeTx(ctx, tx, nil, effects); err != nil {
+			return 0, err
+		}
 	}
 
 	invoiceID, err := insertInvoiceTx(ctx, tx, CreateInvoiceInput{
-		InvoiceType:   "purchase",
-		InvoiceName:   invoiceName,
-		AdminUsername: adminUsername,
-		Lines:         invoiceLines,
+		InvoiceType:    "purchase",
+		InvoiceName:    invoiceName,
+		AdminUsername:  adminUsername,
+		SupplierName:   supplierName,
+		DiscountAmount: adjustments.DiscountAmount,
+		TaxAmount:      adjustments.TaxAmount,
+		Status:         status,
+		Lines:          invoiceLines,
 	})
 	if err != nil {
 		return 0, err
@@ -463,33 +785,78 @@ func (r *Repository) CreatePurchaseInvoice(
 	if err := replaceInvoiceStockEffectsTx(ctx, tx, invoiceID, effects); err != nil {
 		return 0, err
 	}
+	if err := logInvoiceActionTx(ctx, tx, invoiceCreateActionType, invoiceID, actingAdmin(ctx, adminUsername)); err != nil {
+		return 0, err
+	}
+	return invoiceID, nil
+}
+
+func (r *Repository) CreateSalesInvoice(
+	ctx context.Context,
+	invoiceName *string,
+	adminUsername *string,
+	customerName *string,
+	invoiceType string,
+	lines []domain.SalesLineInput,
+	adjustments InvoiceAdjustments,
+	status string,
+	allowNegativeStock bool,
+) (int64, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("begin sales tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
 
+	invoiceID, err := createSalesInvoiceTx(
+		ctx,
+		tx,
+		invoiceName,
+		adminUsername,
+		customerName,
+		invoiceType,
+		lines,
+		adjustments,
+		status,
+		allowNegativeStock,
+	)
+	if err != nil {
+		return 0, err
+	}
 	if err := tx.Commit(ctx); err != nil {
-		return 0, fmt.Errorf("commit purchase tx: %w", err)
+		return 0, fmt.Errorf("commit sales tx: %w", err)
 	}
 	return invoiceID, nil
 }
 
-func (r *Repository) CreateSalesInvoice(
+func createSalesInvoiceTx(
 	ctx context.Context,
+	tx pgx.Tx,
 	invoiceName *string,
 	adminUsername *string,
+	customerName *string,
 	invoiceType string,
 	lines []domain.SalesLineInput,
+	adjustments InvoiceAdjustments,
+	status string,
+	allowNegativeStock bool,
 ) (int64, error) {
 	if len(lines) == 0 {
 		return 0, fmt.Errorf("lines cannot be empty")
 	}
+	status, err := normalizeInvoiceStatus(status)
+	if err != nil {
+		return 0, err
+	}
 	invoiceType = strings.TrimSpace(invoiceType)
 	if invoiceType == "" {
 		invoiceType = "sales"
 	}
-
-	tx, err := r.pool.Begin(ctx)
+	resolved, err := lockInvoiceProductsTx(ctx, tx, salesLineNames(lines), nil)
 	if err != nil {
-		return 0, fmt.Errorf("begin sales tx: %w", err)
+		return 0, err
 	}
-	defer tx.Rollback(ctx)
+	lines = resolveSalesLineNames(lines, resolved)
 
 	invoiceLines, effects, err := buildSalesInvoiceLinesAndEffectsTx(
 		ctx,
@@ -499,15 +866,22 @@ func (r *Repository) CreateSalesInvoice(
 	if err != nil {
 		return 0, err
 	}
-	if err := applySalesChangeTx(ctx, tx, nil, effects); err != nil {
-		return 0, err
+	effects = salesEffectsForType(invoiceType, effects)
+	if status == InvoiceStatusFinalized {
+		if err := applySalesChangeTx(ctx, tx, nil, effects, allowNegativeStock); err != nil {
+			return 0, err
+		}
 	}
 
 	invoiceID, err := insertInvoiceTx(ctx, tx, CreateInvoiceInput{
-		InvoiceType:   invoiceType,
-		InvoiceName:   invoiceName,
-		AdminUsername: adminUsername,
-		Lines:         invoiceLines,
+		InvoiceType:    invoiceType,
+		InvoiceName:    invoiceName,
+		AdminUsername:  adminUsername,
+		CustomerName:   customerName,
+		DiscountAmount: adjustments.DiscountAmount,
+		TaxAmount:      adjustments.TaxAmount,
+		Status:         status,
+		Lines:          invoiceLines,
 	})
 	if err != nil {
 		return 0, err
@@ -515,9 +889,8 @@ func (r *Repository) CreateSalesInvoice(
 	if err := replaceInvoiceStockEffectsTx(ctx, tx, invoiceID, effects); err != nil {
 		return 0, err
 	}
-
-	if err := tx.Commit(ctx); err != nil {
-		return 0, fmt.Errorf("commit sales tx: %w", err)
+	if err := logInvoiceActionTx(ctx, tx, invoiceCreateActionType, invoiceID, actingAdmin(ctx, adminUsername)); err != nil {
+		return 0, err
 	}
 	return invoiceID, nil
 }
@@ -540,11 +913,15 @@ func buildPurchaseInvoiceLinesAndEffectsTx(
 		if line.Price <= 0 {
 			return nil, nil, fmt.Errorf("invalid price for %q", name)
 		}
-		lineTotal := line.Price * float64(line.Quantity)
+		lineTotal, err := discountedLineTotal(name, line.Price, line.Quantity, line.Discount)
+		if err != nil {
+			return nil, nil, err
+		}
 		invoiceLines = append(invoiceLines, domain.InvoiceLine{
 			ProductName: name,
 			Price:       line.Price,
 			Quantity:    line.Quantity,
+			Discount:    line.Discount,
 			LineTotal:   lineTotal,
 			CostPrice:   line.Price,
 		})
@@ -562,6 +939,19 @@ func buildPurchaseInvoiceLinesAndEffectsTx(
 	return invoiceLines, inventoryEffectValues(effectMap), nil
 }
 
+// discountedLineTotal returns price*quantity minus the line discount, which
+// must not be negative or exceed the gross line amount.
+func discountedLineTotal(name string, price float64, quantity float64, discount float64) (float64, error) {
+	gross := price * quantity
+	if discount < 0 {
+		return 0, fmt.Errorf("discount for %q cannot be negative", name)
+	}
+	if discount > gross {
+		return 0, fmt.Errorf("discount for %q cannot exceed the line amount", name)
+	}
+	return gross - discount, nil
+}
+
 func buildSalesInvoiceLinesAndEffectsTx(
 	ctx context.Context,
 	tx pgx.Tx,
@@ -593,11 +983,16 @@ func buildSalesInvoiceLinesAndEffectsTx(
 				sellPrice = avgCost
 			}
 		}
+		lineTotal, err := discountedLineTotal(name, sellPrice, line.Quantity, line.Discount)
+		if err != nil {
+			return nil, nil, err
+		}
 		invoiceLines = append(invoiceLines, domain.InvoiceLine{
 			ProductName: name,
 			Price:       sellPrice,
 			Quantity:    line.Quantity,
-			LineTotal:   sellPrice * float64(line.Quantity),
+			Discount:    line.Discount,
+			LineTotal:   lineTotal,
 			CostPrice:   avgCost,
 		})
 		if err := appendSalesEffectsByIDTx(
@@ -667,7 +1062,7 @@ func appendPurchaseEffectsTx(
 	tx pgx.Tx,
 	effectMap map[string]*inventoryEffect,
 	productName string,
-	quantity int,
+	quantity float64,
 	unitPrice float64,
 ) error {
 	productID, err := ensurePurchaseBaseProductTx(ctx, tx, productName)
@@ -689,7 +1084,7 @@ func appendPurchaseEffectsTx(
 			effectMap[key] = entry
 		}
 		entry.Quantity += quantity
-		entry.TotalCost += unitPrice * float64(quantity)
+		entry.TotalCost += unitPrice * quantity
 		entry.LastPrice = unitPrice
 	}
 	return nil
@@ -700,7 +1095,7 @@ func appendSalesEffectsByIDTx(
 	tx pgx.Tx,
 	effectMap map[string]*inventoryEffect,
 	productID int64,
-	quantity int,
+	quantity float64,
 ) error {
 	groupedProducts, err := resolveGroupedProductsTx(ctx, tx, productID)
 	if err != nil {
@@ -752,29 +1147,34 @@ func loadSalesProductSnapshotTx(
 	ctx context.Context,
 	tx pgx.Tx,
 	productName string,
-) (int64, int, float64, float64, error) {
+) (int64, float64, float64, float64, error) {
 	var (
 		productID    int64
-		currentQty   int
+		currentQty   float64
 		avgCost      float64
 		productPrice float64
+		deleted      bool
 	)
 	err := tx.QueryRow(ctx, `
 		SELECT
 			id,
 			quantity,
 			avg_buy_price::double precision,
-			sell_price::double precision
+			sell_price::double precision,
+			deleted_at IS NOT NULL
 		FROM products
-		WHERE LOWER(product_name) = LOWER($1)
+		WHERE product_name_normalized = LOWER($1)
 		FOR UPDATE
-	`, productName).Scan(&productID, &currentQty, &avgCost, &productPrice)
+	`, productName).Scan(&productID, &currentQty, &avgCost, &productPrice, &deleted)
 	if errors.Is(err, pgx.ErrNoRows) {
 		return 0, 0, 0, 0, fmt.Errorf("product not found: %s", productName)
 	}
 	if err != nil {
 		return 0, 0, 0, 0, fmt.Errorf("load product %q for sales: %w", productName, err)
 	}
+	if deleted {
+		return 0, 0, 0, 0, fmt.Errorf("%w: %q", ErrProductMerged, productName)
+	}
 	return productID, currentQty, avgCost, productPrice, nil
 }
 
@@ -797,12 +1197,27 @@ func inventoryEffectValues(
 }
 
 func insertInvoiceTx(ctx context.Context, tx pgx.Tx, input CreateInvoiceInput) (int64, error) {
-	totalQty := 0
+	totalQty := 0.0
 	totalAmount := 0.0
 	for _, line := range input.Lines {
 		totalQty += line.Quantity
 		totalAmount += line.LineTotal
 	}
+	if input.DiscountAmount != nil {
+		if *input.DiscountAmount < 0 {
+			return 0, fmt.Errorf("discount_amount cannot be negative")
+		}
+		if *input.DiscountAmount > totalAmount {
+			return 0, fmt.Errorf("discount_amount cannot exceed invoice subtotal")
+		}
+		totalAmount -= *input.DiscountAmount
+	}
+	if input.TaxAmount != nil {
+		if *input.TaxAmount < 0 {
+			return 0, fmt.Errorf("tax_amount cannot be negative")
+		}
+		totalAmount += *input.TaxAmount
+	}
 
 	var invoiceID int64
 	if err := tx.QueryRow(ctx, `
@@ -812,76 +1227,191 @@ func insertInvoiceTx(ctx context.Context, tx pgx.Tx, input CreateInvoiceInput) (
 			total_qty,
 			total_amount,
 			invoice_name,
-			admin_username
+			admin_username,
+			discount_amount,
+			tax_amount,
+			status,
+			supplier_name,
+			customer_name
 		)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, COALESCE(NULLIF($9, ''), 'finalized'), $10, $11)
 		RETURNING id
-	`, input.InvoiceType, len(input.Lines), totalQty, totalAmount, input.InvoiceName, input.AdminUsername).Scan(&invoiceID); err != nil {
+	`,
+		input.InvoiceType,
+		len(input.Lines),
+		totalQty,
+		totalAmount,
+		input.InvoiceName,
+		input.AdminUsername,
+		input.DiscountAmount,
+		input.TaxAmount,
+		input.Status,
+		input.SupplierName,
+		input.CustomerName,
+	).Scan(&invoiceID); err != nil {
 		return 0, fmt.Errorf("insert invoice: %w", err)
 	}
 
-	for _, line := range input.Lines {
-		if _, err := tx.Exec(ctx, `
-			INSERT INTO invoice_lines (
-				invoice_id,
-				product_name,
-				price,
-				quantity,
-				line_total,
-				cost_price
-			) VALUES ($1, $2, $3, $4, $5, $6)
-		`, invoiceID, line.ProductName, line.Price, line.Quantity, line.LineTotal, line.CostPrice); err != nil {
-			return 0, fmt.Errorf("insert invoice line: %w", err)
-		}
+	if err := copyInvoiceLinesTx(ctx, tx, invoiceID, input.Lines); err != nil {
+		return 0, err
 	}
 
 	return invoiceID, nil
 }
 
-func (r *Repository) ListInvoices(ctx context.Context, filter InvoiceListFilter) ([]domain.Invoice, error) {
-	limit := normalizeLimit(filter.Limit)
-	offset := normalizeOffset(filter.Offset)
+func copyInvoiceLinesTx(ctx context.Context, tx pgx.Tx, invoiceID int64, lines []domain.InvoiceLine) error {
+	if len(lines) == 0 {
+		return nil
+	}
+	rows := make([][]any, 0, len(lines))
+	for _, line := range lines {
+		rows = append(rows, []any{
+			invoiceID,
+			line.ProductName,
+			line.Price,
+			line.Quantity,
+			line.Discount,
+			line.LineTotal,
+			line.CostPrice,
+		})
+	}
+	if _, err := tx.CopyFrom(
+		ctx,
+		pgx.Identifier{"invoice_lines"},
+		[]string{"invoice_id", "product_name", "price", "quantity", "discount", "line_total", "cost_price"},
+		pgx.CopyFromRows(rows),
+	); err != nil {
+		return fmt.Errorf("insert invoice lines for invoice %d: %w", invoiceID, err)
+	}
+	return nil
+}
 
-	query := `
-		SELECT
-			id,
-			invoice_type,
-			created_at,
-			total_lines,
-			total_qty,
-			total_amount::double precision,
-			invoice_name,
-			admin_username
-		FROM invoices
-		WHERE (
-			$1 = ''
-			OR ($1 = 'sales' AND invoice_type LIKE 'sales%')
-			OR invoice_type = $1
-		)
-	`
+// invoiceListWhere builds the WHERE body shared by ListInvoices and
+// GetInvoiceStatsFiltered so list rows and footer totals always agree.
+func invoiceListWhere(filter InvoiceListFilter) (string, []any) {
+	where := invoiceTypeCondition("invoice_type", "$1")
 	args := []any{strings.TrimSpace(filter.InvoiceType)}
 	idx := 2
 
 	if filter.From != nil {
-		query += fmt.Sprintf(" AND created_at >= $%d", idx)
+		where += fmt.Sprintf(" AND created_at >= $%d", idx)
 		args = append(args, *filter.From)
 		idx++
 	}
 	if filter.To != nil {
-		query += fmt.Sprintf(" AND created_at <= $%d", idx)
+		where += fmt.Sprintf(" AND created_at <= $%d", idx)
 		args = append(args, *filter.To)
 		idx++
 	}
+	if filter.Supplier != "" {
+		where += fmt.Sprintf(" AND supplier_name ILIKE $%d", idx)
+		args = append(args, "%"+filter.Supplier+"%")
+		idx++
+	}
+	if filter.Customer != "" {
+		where += fmt.Sprintf(" AND customer_name ILIKE $%d", idx)
+		args = append(args, "%"+filter.Customer+"%")
+		idx++
+	}
+	if filter.Product != "" {
+		// EXISTS rather than a join keeps one row per invoice, so paging
+		// and the footer totals are unaffected by multi-line matches.
+		where += fmt.Sprintf(` AND EXISTS (
+			SELECT 1 FROM invoice_lines il
+			WHERE il.invoice_id = invoices.id AND il.product_name ILIKE $%d
+		)`, idx)
+		args = append(args, "%"+filter.Product+"%")
+		idx++
+	}
+	if filter.MinAmount != nil {
+		where += fmt.Sprintf(" AND total_amount >= $%d", idx)
+		args = append(args, *filter.MinAmount)
+		idx++
+	}
+	if filter.MaxAmount != nil {
+		where += fmt.Sprintf(" AND total_amount <= $%d", idx)
+		args = append(args, *filter.MaxAmount)
+	}
+	return where, args
+}
+
+// ListInvoices returns one page of invoices and whether another page follows.
+func (r *Repository) ListInvoices(ctx context.Context, filter InvoiceListFilter) ([]domain.Invoice, bool, error) {
+	limit := normalizeLimit(filter.Limit)
+	offset := normalizeOffset(filter.Offset)
+
+	query := `
+		SELECT
+			id,
+			invoice_type,
+			created_at,
+			total_lines,
+			total_qty,
+			total_amount::double precision,
+			invoice_name,
+			admin_username,
+			discount_amount::double precision,
+			tax_amount::double precision,
+			status,
+			supplier_name,
+			customer_name
+		FROM invoices
+		WHERE `
+	where, args := invoiceListWhere(filter)
+	idx := len(args) + 1
+	query += where
 	query += fmt.Sprintf(" ORDER BY id DESC LIMIT $%d OFFSET $%d", idx, idx+1)
-	args = append(args, limit, offset)
+	args = append(args, limit+1, offset)
 
 	rows, err := r.pool.Query(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("list invoices: %w", err)
+		return nil, false, fmt.Errorf("list invoices: %w", err)
 	}
 	defer rows.Close()
 
-	result := make([]domain.Invoice, 0, limit)
+	result := make([]domain.Invoice, 0, limit+1)
+	for rows.Next() {
+		inv, err := scanInvoice(rows)
+		if err != nil {
+			return nil, false, err
+		}
+		result = append(result, inv)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, fmt.Errorf("iterate invoices: %w", err)
+	}
+	result, hasMore := trimPage(result, limit)
+	return result, hasMore, nil
+}
+
+func (r *Repository) ListRecentInvoices(ctx context.Context, minutes, limit int) ([]domain.Invoice, error) {
+	limit = normalizeLimit(limit)
+	rows, err := r.pool.Query(ctx, `
+		SELECT
+			id,
+			invoice_type,
+			created_at,
+			total_lines,
+			total_qty,
+			total_amount::double precision,
+			invoice_name,
+			admin_username,
+			discount_amount::double precision,
+			tax_amount::double precision,
+			status,
+			supplier_name,
+			customer_name
+		FROM invoices
+		WHERE created_at >= NOW() - ($1 * INTERVAL '1 minute')
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2
+	`, minutes, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list recent invoices: %w", err)
+	}
+	defer rows.Close()
+
+	result := make([]domain.Invoice, 0)
 	for rows.Next() {
 		inv, err := scanInvoice(rows)
 		if err != nil {
@@ -890,7 +1420,7 @@ func (r *Repository) ListInvoices(ctx context.Context, filter InvoiceListFilter)
 		result = append(result, inv)
 	}
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("iterate invoices: %w", err)
+		return nil, fmt.Errorf("iterate recent invoices: %w", err)
 	}
 	return result, nil
 }
@@ -905,7 +1435,12 @@ func (r *Repository) GetInvoice(ctx context.Context, id int64) (*domain.Invoice,
 			total_qty,
 			total_amount::double precision,
 			invoice_name,
-			admin_username
+			admin_username,
+			discount_amount::double precision,
+			tax_amount::double precision,
+			status,
+			supplier_name,
+			customer_name
 		FROM invoices
 		WHERE id = $1
 	`, id)
@@ -927,6 +1462,7 @@ func (r *Repository) GetInvoiceLines(ctx context.Context, invoiceID int64) ([]do
 			product_name,
 			price::double precision,
 			quantity,
+			discount::double precision,
 			line_total::double precision,
 			cost_price::double precision
 		FROM invoice_lines
@@ -952,12 +1488,49 @@ func (r *Repository) GetInvoiceLines(ctx context.Context, invoiceID int64) ([]do
 	return lines, nil
 }
 
-func (r *Repository) UpdateInvoiceName(ctx context.Context, id int64, invoiceName *string) error {
+func (r *Repository) FindInvoiceIDsByName(ctx context.Context, invoiceName string) ([]int64, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT id
+		FROM invoices
+		WHERE LOWER(TRIM(invoice_name)) = LOWER(TRIM($1))
+		ORDER BY id ASC
+	`, invoiceName)
+	if err != nil {
+		return nil, fmt.Errorf("find invoices by name: %w", err)
+	}
+	defer rows.Close()
+
+	ids := make([]int64, 0)
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scan invoice id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate invoice ids: %w", err)
+	}
+	return ids, nil
+}
+
+// UpdateInvoiceName sets invoice_name. A nil supplierName or customerName
+// keeps the stored value and an empty one clears it.
+func (r *Repository) UpdateInvoiceName(
+	ctx context.Context,
+	id int64,
+	invoiceName *string,
+	supplierName *string,
+	customerName *string,
+) error {
 	cmd, err := r.pool.Exec(ctx, `
 		UPDATE invoices
-		SET invoice_name = $2
+		SET
+			invoice_name = $2,
+			supplier_name = CASE WHEN $3::text IS NULL THEN supplier_name ELSE NULLIF($3, '') END,
+			customer_name = CASE WHEN $4::text IS NULL THEN customer_name ELSE NULLIF($4, '') END
 		WHERE id = $1
-	`, id, invoiceName)
+	`, id, invoiceName, supplierName, customerName)
 	if err != nil {
 		return fmt.Errorf("update invoice name: %w", err)
 	}
@@ -991,28 +1564,50 @@ func (r *Repository) GetMonthlySummary(ctx context.Context, limit int) ([]domain
 			SELECT
 				TO_CHAR(DATE_TRUNC('month', created_at), 'YYYY-MM') AS month,
 				SUM(CASE WHEN invoice_type = 'purchase' THEN total_amount ELSE 0 END)::double precision AS purchase_total,
-				SUM(CASE WHEN invoice_type LIKE 'sales%' THEN total_amount ELSE 0 END)::double precision AS sales_total,
+				SUM(CASE
+					WHEN invoice_type = 'sales_return' THEN -total_amount
+					WHEN invoice_type LIKE 'sales%' THEN total_amount
+					ELSE 0
+				END)::double precision AS sales_total,
 				COUNT(*)::int AS invoice_count
 			FROM invoices
+			WHERE status = 'finalized'
 			GROUP BY 1
 		),
 		sales_profit AS (
 			SELECT
 				TO_CHAR(DATE_TRUNC('month', i.created_at), 'YYYY-MM') AS month,
-				SUM(il.line_total - il.cost_price * il.quantity)::double precision AS profit
+				SUM(
+					(il.line_total - il.cost_price * il.quantity)
+					* CASE WHEN i.invoice_type = 'sales_return' THEN -1 ELSE 1 END
+				)::double precision AS profit
 			FROM invoices i
 			JOIN invoice_lines il ON il.invoice_id = i.id
 			WHERE i.invoice_type LIKE 'sales%'
+			  AND i.status = 'finalized'
+			GROUP BY 1
+		),
+		sales_discounts AS (
+			SELECT
+				TO_CHAR(DATE_TRUNC('month', created_at), 'YYYY-MM') AS month,
+				SUM(
+					COALESCE(discount_amount, 0)
+					* CASE WHEN invoice_type = 'sales_return' THEN -1 ELSE 1 END
+				)::double precision AS discount
+			FROM invoices
+			WHERE invoice_type LIKE 'sales%'
+			  AND status = 'finalized'
 			GROUP BY 1
 		)
 		SELECT
 			im.month,
 			COALESCE(im.purchase_total, 0)::double precision,
 			COALESCE(im.sales_total, 0)::double precision,
-			COALESCE(sp.profit, 0)::double precision,
+			(COALESCE(sp.profit, 0) - COALESCE(sd.discount, 0))::double precision,
 			im.invoice_count
 		FROM invoice_months im
 		LEFT JOIN sales_profit sp ON sp.month = im.month
+		LEFT JOIN sales_discounts sd ON sd.month = im.month
 		ORDER BY im.month DESC
 		LIMIT $1
 	`, limit)
@@ -1035,6 +1630,89 @@ func (r *Repository) GetMonthlySummary(ctx context.Context, limit int) ([]domain
 	return list, nil
 }
 
+// GetDailySummary mirrors GetMonthlySummary per calendar day for the last
+// days days, today included, newest first.
+func (r *Repository) GetDailySummary(ctx context.Context, days int) ([]domain.DailySummary, error) {
+	if days <= 0 {
+		days = 30
+	}
+	if days > 366 {
+		days = 366
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		WITH windowed AS (
+			SELECT *
+			FROM invoices
+			WHERE created_at >= DATE_TRUNC('day', NOW()) - (($1::int - 1) * INTERVAL '1 day')
+			  AND status = 'finalized'
+		),
+		invoice_days AS (
+			SELECT
+				TO_CHAR(DATE_TRUNC('day', created_at), 'YYYY-MM-DD') AS day,
+				SUM(CASE WHEN invoice_type = 'purchase' THEN total_amount ELSE 0 END)::double precision AS purchase_total,
+				SUM(CASE
+					WHEN invoice_type = 'sales_return' THEN -total_amount
+					WHEN invoice_type LIKE 'sales%' THEN total_amount
+					ELSE 0
+				END)::double precision AS sales_total,
+				COUNT(*)::int AS invoice_count
+			FROM windowed
+			GROUP BY 1
+		),
+		sales_profit AS (
+			SELECT
+				TO_CHAR(DATE_TRUNC('day', i.created_at), 'YYYY-MM-DD') AS day,
+				SUM(
+					(il.line_total - il.cost_price * il.quantity)
+					* CASE WHEN i.invoice_type = 'sales_return' THEN -1 ELSE 1 END
+				)::double precision AS profit
+			FROM windowed i
+			JOIN invoice_lines il ON il.invoice_id = i.id
+			WHERE i.invoice_type LIKE 'sales%'
+			GROUP BY 1
+		),
+		sales_discounts AS (
+			SELECT
+				TO_CHAR(DATE_TRUNC('day', created_at), 'YYYY-MM-DD') AS day,
+				SUM(
+					COALESCE(discount_amount, 0)
+					* CASE WHEN invoice_type = 'sales_return' THEN -1 ELSE 1 END
+				)::double precision AS discount
+			FROM windowed
+			WHERE invoice_type LIKE 'sales%'
+			GROUP BY 1
+		)
+		SELECT
+			d.day,
+			COALESCE(d.purchase_total, 0)::double precision,
+			COALESCE(d.sales_total, 0)::double precision,
+			(COALESCE(sp.profit, 0) - COALESCE(sd.discount, 0))::double precision,
+			d.invoice_count
+		FROM invoice_days d
+		LEFT JOIN sales_profit sp ON sp.day = d.day
+		LEFT JOIN sales_discounts sd ON sd.day = d.day
+		ORDER BY d.day DESC
+	`, days)
+	if err != nil {
+		return nil, fmt.Errorf("daily summary query: %w", err)
+	}
+	defer rows.Close()
+
+	list := make([]domain.DailySummary, 0, days)
+	for rows.Next() {
+		var row domain.DailySummary
+		if err := rows.Scan(&row.Day, &row.PurchaseTotal, &row.SalesTotal, &row.Profit, &row.InvoiceCount); err != nil {
+			return nil, fmt.Errorf("scan daily summary: %w", err)
+		}
+		list = append(list, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate daily summary: %w", err)
+	}
+	return list, nil
+}
+
 func (r *Repository) GetMonthlyQuantitySummary(ctx context.Context, limit int) ([]domain.MonthlyQuantitySummary, error) {
 	if limit <= 0 {
 		limit = 12
@@ -1046,11 +1724,16 @@ func (r *Repository) GetMonthlyQuantitySummary(ctx context.Context, limit int) (
 	rows, err := r.pool.Query(ctx, `
 		SELECT
 			TO_CHAR(DATE_TRUNC('month', created_at), 'YYYY-MM') AS month,
-			COALESCE(SUM(CASE WHEN invoice_type LIKE 'sales%' THEN total_qty ELSE 0 END), 0)::int AS sales_qty,
-			COALESCE(SUM(CASE WHEN invoice_type = 'purchase' THEN total_qty ELSE 0 END), 0)::int AS purchase_qty,
-			COUNT(CASE WHEN invoice_type LIKE 'sales%' THEN 1 END)::int AS sales_invoices,
+			COALESCE(SUM(CASE
+				WHEN invoice_type = 'sales_return' THEN -total_qty
+				WHEN invoice_type LIKE 'sales%' THEN total_qty
+				ELSE 0
+			END), 0)::double precision AS sales_qty,
+			COALESCE(SUM(CASE WHEN invoice_type = 'purchase' THEN total_qty ELSE 0 END), 0)::double precision AS purchase_qty,
+			COUNT(CASE WHEN invoice_type LIKE 'sales%' AND invoice_type <> 'sales_return' THEN 1 END)::int AS sales_invoices,
 			COUNT(CASE WHEN invoice_type = 'purchase' THEN 1 END)::int AS purchase_invoices
 		FROM invoices
+		WHERE status = 'finalized'
 		GROUP BY 1
 		ORDER BY month DESC
 		LIMIT $1
@@ -1081,29 +1764,43 @@ func (r *Repository) GetMonthlyQuantitySummary(ctx context.Context, limit int) (
 	return list, nil
 }
 
-func (r *Repository) GetTopSoldProducts(ctx context.Context, days, limit int) ([]domain.TopSoldProduct, error) {
+// GetTopSoldProducts ranks products by quantity sold. When from or to is set
+// they bound created_at and days is ignored; otherwise the window is the last
+// days days (0 = all time).
+func (r *Repository) GetTopSoldProducts(
+	ctx context.Context,
+	days, limit int,
+	from, to *time.Time,
+) ([]domain.TopSoldProduct, error) {
 	if limit <= 0 {
 		limit = 10
 	}
 	if limit > 200 {
 		limit = 200
 	}
+	if from != nil || to != nil {
+		days = 0
+	}
 
 	rows, err := r.pool.Query(ctx, `
 		SELECT
 			il.product_name,
-			COALESCE(SUM(il.quantity), 0)::int AS sold_qty,
+			COALESCE(SUM(il.quantity), 0)::double precision AS sold_qty,
 			COUNT(DISTINCT i.id)::int AS invoice_count,
 			MAX(i.created_at) AS last_sold_at
 		FROM invoices i
 		JOIN invoice_lines il ON il.invoice_id = i.id
 		WHERE
 			i.invoice_type LIKE 'sales%'
+			AND i.invoice_type <> 'sales_return'
+			AND i.status = 'finalized'
 			AND ($1::int <= 0 OR i.created_at >= NOW() - ($1 * INTERVAL '1 day'))
+			AND ($3::timestamptz IS NULL OR i.created_at >= $3)
+			AND ($4::timestamptz IS NULL OR i.created_at <= $4)
 		GROUP BY il.product_name
 		ORDER BY sold_qty DESC, il.product_name ASC
 		LIMIT $2
-	`, days, limit)
+	`, days, limit, from, to)
 	if err != nil {
 		return nil, fmt.Errorf("top sold products query: %w", err)
 	}
@@ -1135,6 +1832,200 @@ func (r *Repository) GetTopSoldProducts(ctx context.Context, days, limit int) ([
 	return list, nil
 }
 
+// GetProductProfit ranks products by gross profit (line total minus cost)
+// over the last days days, with sales returns subtracted from every column.
+func (r *Repository) GetProductProfit(ctx context.Context, days, limit int) ([]domain.ProductProfit, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > 200 {
+		limit = 200
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		WITH product_profit AS (
+			SELECT
+				il.product_name,
+				SUM(il.quantity * CASE WHEN i.invoice_type = 'sales_return' THEN -1 ELSE 1 END) AS units_sold,
+				SUM(il.line_total * CASE WHEN i.invoice_type = 'sales_return' THEN -1 ELSE 1 END) AS revenue,
+				SUM(
+					(il.line_total - il.cost_price * il.quantity)
+					* CASE WHEN i.invoice_type = 'sales_return' THEN -1 ELSE 1 END
+				) AS profit
+			FROM invoices i
+			JOIN invoice_lines il ON il.invoice_id = i.id
+			WHERE
+				i.invoice_type LIKE 'sales%'
+				AND i.status = 'finalized'
+				AND ($1::int <= 0 OR i.created_at >= NOW() - ($1 * INTERVAL '1 day'))
+			GROUP BY il.product_name
+		)
+		SELECT
+			product_name,
+			units_sold::double precision,
+			revenue::double precision,
+			profit::double precision,
+			(CASE WHEN revenue > 0 THEN profit / revenue * 100 ELSE 0 END)::double precision
+		FROM product_profit
+		ORDER BY profit DESC, product_name ASC
+		LIMIT $2
+	`, days, limit)
+	if err != nil {
+		return nil, fmt.Errorf("product profit query: %w", err)
+	}
+	defer rows.Close()
+
+	list := make([]domain.ProductProfit, 0, limit)
+	for rows.Next() {
+		var row domain.ProductProfit
+		if err := rows.Scan(
+			&row.ProductName,
+			&row.UnitsSold,
+			&row.Revenue,
+			&row.Profit,
+			&row.MarginPercent,
+		); err != nil {
+			return nil, fmt.Errorf("scan product profit: %w", err)
+		}
+		list = append(list, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate product profit: %w", err)
+	}
+	return list, nil
+}
+
+// unassignedAdminBucket groups sales invoices recorded without an admin.
+const unassignedAdminBucket = "unassigned"
+
+// GetSalesByAdmin attributes finalized sales-family invoices in [from, to] to
+// the admin who recorded them, with sales returns subtracted from the totals.
+func (r *Repository) GetSalesByAdmin(ctx context.Context, from, to *time.Time) ([]domain.AdminSalesStat, error) {
+	rows, err := r.pool.Query(ctx, `
+		WITH scoped AS (
+			SELECT
+				i.id,
+				COALESCE(NULLIF(TRIM(i.admin_username), ''), $3) AS admin_username,
+				i.total_amount,
+				COALESCE(i.discount_amount, 0) AS discount_amount,
+				CASE WHEN i.invoice_type = 'sales_return' THEN -1 ELSE 1 END AS sign
+			FROM invoices i
+			WHERE
+				i.invoice_type LIKE 'sales%'
+				AND i.status = 'finalized'
+				AND ($1::timestamptz IS NULL OR i.created_at >= $1)
+				AND ($2::timestamptz IS NULL OR i.created_at <= $2)
+		),
+		line_totals AS (
+			SELECT
+				il.invoice_id,
+				SUM(il.quantity) AS units,
+				SUM(il.line_total - il.cost_price * il.quantity) AS profit
+			FROM invoice_lines il
+			JOIN scoped s ON s.id = il.invoice_id
+			GROUP BY il.invoice_id
+		)
+		SELECT
+			s.admin_username,
+			COUNT(*)::int,
+			SUM(s.sign * s.total_amount)::double precision AS total_sales,
+			SUM(s.sign * (COALESCE(lt.profit, 0) - s.discount_amount))::double precision,
+			SUM(s.sign * COALESCE(lt.units, 0))::double precision
+		FROM scoped s
+		LEFT JOIN line_totals lt ON lt.invoice_id = s.id
+		GROUP BY s.admin_username
+		ORDER BY total_sales DESC, s.admin_username ASC
+	`, from, to, unassignedAdminBucket)
+	if err != nil {
+		return nil, fmt.Errorf("sales by admin query: %w", err)
+	}
+	defer rows.Close()
+
+	list := make([]domain.AdminSalesStat, 0)
+	for rows.Next() {
+		var row domain.AdminSalesStat
+		if err := rows.Scan(
+			&row.AdminUsername,
+			&row.InvoiceCount,
+			&row.TotalSales,
+			&row.TotalProfit,
+			&row.UnitsSold,
+		); err != nil {
+			return nil, fmt.Errorf("scan sales by admin: %w", err)
+		}
+		list = append(list, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate sales by admin: %w", err)
+	}
+	return list, nil
+}
+
+func (r *Repository) GetRevenuePareto(ctx context.Context, days int) ([]domain.RevenueParetoRow, error) {
+	rows, err := r.pool.Query(ctx, `
+		WITH product_revenue AS (
+			SELECT
+				il.product_name,
+				SUM(
+					il.line_total * CASE WHEN i.invoice_type = 'sales_return' THEN -1 ELSE 1 END
+				)::double precision AS revenue
+			FROM invoices i
+			JOIN invoice_lines il ON il.invoice_id = i.id
+			WHERE
+				i.invoice_type LIKE 'sales%'
+				AND i.status = 'finalized'
+				AND ($1::int <= 0 OR i.created_at >= NOW() - ($1 * INTERVAL '1 day'))
+			GROUP BY il.product_name
+			HAVING SUM(
+				il.line_total * CASE WHEN i.invoice_type = 'sales_return' THEN -1 ELSE 1 END
+			) > 0
+		),
+		ranked AS (
+			SELECT
+				product_name,
+				revenue,
+				SUM(revenue) OVER () AS total_revenue,
+				SUM(revenue) OVER (
+					ORDER BY revenue DESC, product_name ASC
+					ROWS BETWEEN UNBOUNDED PRECEDING AND CURRENT ROW
+				) AS running_revenue
+			FROM product_revenue
+		)
+		SELECT
+			product_name,
+			revenue,
+			(revenue * 100.0 / total_revenue)::double precision AS share_percent,
+			(running_revenue * 100.0 / total_revenue)::double precision AS cumulative_percent
+		FROM ranked
+		ORDER BY revenue DESC, product_name ASC
+	`, days)
+	if err != nil {
+		return nil, fmt.Errorf("revenue pareto query: %w", err)
+	}
+	defer rows.Close()
+
+	list := make([]domain.RevenueParetoRow, 0)
+	for rows.Next() {
+		var row domain.RevenueParetoRow
+		if err := rows.Scan(
+			&row.ProductName,
+			&row.Revenue,
+			&row.SharePercent,
+			&row.CumulativePercent,
+		); err != nil {
+			return nil, fmt.Errorf("scan revenue pareto row: %w", err)
+		}
+		list = append(list, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate revenue pareto rows: %w", err)
+	}
+	if len(list) > 0 {
+		list[len(list)-1].CumulativePercent = 100
+	}
+	return list, nil
+}
+
 func (r *Repository) GetUnsoldProducts(ctx context.Context, days, limit int) ([]domain.UnsoldProduct, error) {
 	if limit <= 0 {
 		limit = 200
@@ -1150,6 +2041,8 @@ func (r *Repository) GetUnsoldProducts(ctx context.Context, days, limit int) ([]
 			JOIN invoice_lines il ON il.invoice_id = i.id
 			WHERE
 				i.invoice_type LIKE 'sales%'
+				AND i.invoice_type <> 'sales_return'
+				AND i.status = 'finalized'
 				AND ($1::int <= 0 OR i.created_at >= NOW() - ($1 * INTERVAL '1 day'))
 		)
 		SELECT
@@ -1163,6 +2056,7 @@ func (r *Repository) GetUnsoldProducts(ctx context.Context, days, limit int) ([]
 		LEFT JOIN sold_recent s
 			ON s.product_name_normalized = LOWER(TRIM(p.product_name))
 		WHERE s.product_name_normalized IS NULL
+		  AND p.deleted_at IS NULL
 		ORDER BY p.quantity DESC, p.product_name ASC
 		LIMIT $2
 	`, days, limit)
@@ -1199,15 +2093,251 @@ func (r *Repository) GetUnsoldProducts(ctx context.Context, days, limit int) ([]
 	return list, nil
 }
 
+// GetDeadStock lists in-stock products with no sales in the last days days,
+// ranked by the capital they tie up (quantity * avg_buy_price). minValue
+// drops rows below that amount.
+func (r *Repository) GetDeadStock(ctx context.Context, days, limit int, minValue float64) ([]domain.DeadStockRow, error) {
+	if limit <= 0 {
+		limit = 200
+	}
+	if limit > 5000 {
+		limit = 5000
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		WITH sold_recent AS (
+			SELECT DISTINCT LOWER(TRIM(il.product_name)) AS product_name_normalized
+			FROM invoices i
+			JOIN invoice_lines il ON il.invoice_id = i.id
+			WHERE
+				i.invoice_type LIKE 'sales%'
+				AND i.invoice_type <> 'sales_return'
+				AND i.status = 'finalized'
+				AND ($1::int <= 0 OR i.created_at >= NOW() - ($1 * INTERVAL '1 day'))
+		)
+		SELECT
+			p.id,
+			p.product_name,
+			p.quantity::double precision,
+			p.avg_buy_price::double precision,
+			(p.quantity * p.avg_buy_price)::double precision AS tied_up_value,
+			p.source
+		FROM products p
+		LEFT JOIN sold_recent s
+			ON s.product_name_normalized = LOWER(TRIM(p.product_name))
+		WHERE s.product_name_normalized IS NULL
+		  AND p.deleted_at IS NULL
+		  AND p.quantity > 0
+		  AND p.quantity * p.avg_buy_price >= $3
+		ORDER BY tied_up_value DESC, p.product_name ASC
+		LIMIT $2
+	`, days, limit, minValue)
+	if err != nil {
+		return nil, fmt.Errorf("dead stock query: %w", err)
+	}
+	defer rows.Close()
+
+	list := make([]domain.DeadStockRow, 0)
+	for rows.Next() {
+		var (
+			row    domain.DeadStockRow
+			source sql.NullString
+		)
+		if err := rows.Scan(
+			&row.ProductID,
+			&row.ProductName,
+			&row.Quantity,
+			&row.AvgBuyPrice,
+			&row.TiedUpValue,
+			&source,
+		); err != nil {
+			return nil, fmt.Errorf("scan dead stock row: %w", err)
+		}
+		if source.Valid {
+			value := source.String
+			row.Source = &value
+		}
+		list = append(list, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate dead stock: %w", err)
+	}
+	return list, nil
+}
+
+type ReorderOptions struct {
+	// Days is the sales history window used for the daily velocity.
+	Days int
+	// LeadDays flags products that run out within this many days.
+	LeadDays int
+	// CoverDays is how long the stock should last once the order arrives.
+	CoverDays int
+}
+
+// GetReorderSuggestions flags products whose stock runs out within the lead
+// time at their recent net sales rate and suggests enough to cover lead time
+// plus CoverDays, soonest stockout first.
+func (r *Repository) GetReorderSuggestions(ctx context.Context, opts ReorderOptions) ([]domain.ReorderSuggestion, error) {
+	if opts.Days <= 0 {
+		opts.Days = 60
+	}
+	if opts.Days > 365 {
+		opts.Days = 365
+	}
+	if opts.LeadDays < 0 {
+		opts.LeadDays = 0
+	}
+	if opts.CoverDays < 0 {
+		opts.CoverDays = 0
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		WITH sales AS (
+			SELECT
+				LOWER(TRIM(il.product_name)) AS product_name_normalized,
+				SUM(il.quantity * CASE WHEN i.invoice_type = 'sales_return' THEN -1 ELSE 1 END) AS sold_qty
+			FROM invoices i
+			JOIN invoice_lines il ON il.invoice_id = i.id
+			WHERE
+				i.invoice_type LIKE 'sales%'
+				AND i.status = 'finalized'
+				AND i.created_at >= NOW() - ($1::int * INTERVAL '1 day')
+			GROUP BY 1
+		),
+		velocity AS (
+			SELECT
+				p.id,
+				p.product_name,
+				p.quantity,
+				p.source,
+				s.sold_qty / $1::int AS daily_velocity
+			FROM products p
+			JOIN sales s ON s.product_name_normalized = LOWER(TRIM(p.product_name))
+			WHERE p.deleted_at IS NULL AND s.sold_qty > 0
+		)
+		SELECT
+			id,
+			product_name,
+			quantity::double precision,
+			daily_velocity::double precision,
+			GREATEST(quantity / daily_velocity, 0)::double precision AS days_to_stockout,
+			GREATEST(CEIL(daily_velocity * ($2::int + $3::int) - quantity), 0)::double precision,
+			source
+		FROM velocity
+		WHERE quantity / daily_velocity <= $2::int
+		ORDER BY days_to_stockout ASC, product_name ASC
+	`, opts.Days, opts.LeadDays, opts.CoverDays)
+	if err != nil {
+		return nil, fmt.Errorf("reorder suggestions query: %w", err)
+	}
+	defer rows.Close()
+
+	list := make([]domain.ReorderSuggestion, 0)
+	for rows.Next() {
+		var (
+			row    domain.ReorderSuggestion
+			source sql.NullString
+		)
+		if err := rows.Scan(
+			&row.ProductID,
+			&row.ProductName,
+			&row.Quantity,
+			&row.DailyVelocity,
+			&row.DaysToStockout,
+			&row.SuggestedQty,
+			&source,
+		); err != nil {
+			return nil, fmt.Errorf("scan reorder suggestion: %w", err)
+		}
+		if source.Valid {
+			value := source.String
+			row.Source = &value
+		}
+		list = append(list, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate reorder suggestions: %w", err)
+	}
+	return list, nil
+}
+
+// GetStockReconciliation compares each product's stock with purchased minus
+// sold quantity across all invoices, largest discrepancy first.
+func (r *Repository) GetStockReconciliation(ctx context.Context, limit int) ([]domain.StockReconciliationRow, error) {
+	if limit <= 0 {
+		limit = 200
+	}
+	if limit > 5000 {
+		limit = 5000
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		WITH movements AS (
+			SELECT
+				LOWER(TRIM(il.product_name)) AS product_name_normalized,
+				SUM(CASE WHEN i.invoice_type = 'purchase' THEN il.quantity ELSE 0 END) AS purchased,
+				SUM(CASE
+					WHEN i.invoice_type = 'sales_return' THEN -il.quantity
+					WHEN i.invoice_type LIKE 'sales%' THEN il.quantity
+					ELSE 0
+				END) AS sold
+			FROM invoices i
+			JOIN invoice_lines il ON il.invoice_id = i.id
+			WHERE i.status = 'finalized'
+			GROUP BY LOWER(TRIM(il.product_name))
+		)
+		SELECT
+			p.product_name,
+			COALESCE(m.purchased, 0)::double precision AS total_purchased,
+			COALESCE(m.sold, 0)::double precision AS total_sold,
+			p.quantity::double precision
+		FROM products p
+		LEFT JOIN movements m
+			ON m.product_name_normalized = LOWER(TRIM(p.product_name))
+		WHERE p.deleted_at IS NULL
+		ORDER BY
+			ABS(p.quantity - (COALESCE(m.purchased, 0) - COALESCE(m.sold, 0))) DESC,
+			p.product_name ASC
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("stock reconciliation query: %w", err)
+	}
+	defer rows.Close()
+
+	list := make([]domain.StockReconciliationRow, 0, limit)
+	for rows.Next() {
+		var row domain.StockReconciliationRow
+		if err := rows.Scan(
+			&row.ProductName,
+			&row.TotalPurchased,
+			&row.TotalSold,
+			&row.ActualQty,
+		); err != nil {
+			return nil, fmt.Errorf("scan stock reconciliation row: %w", err)
+		}
+		row.ExpectedQty = row.TotalPurchased - row.TotalSold
+		row.Discrepancy = row.ActualQty - row.ExpectedQty
+		list = append(list, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate stock reconciliation rows: %w", err)
+	}
+	return list, nil
+}
+
 func scanProduct(rows pgx.CollectableRow) (domain.Product, error) {
 	return scanProductRow(rows)
 }
 
 func scanProductRow(row pgx.Row) (domain.Product, error) {
 	var (
-		product domain.Product
-		alarm   sql.NullInt32
-		source  sql.NullString
+		product      domain.Product
+		alarm        sql.NullInt32
+		source       sql.NullString
+		categoryID   sql.NullInt64
+		categoryName sql.NullString
+		sku          sql.NullString
 	)
 	if err := row.Scan(
 		&product.ID,
@@ -1220,6 +2350,10 @@ func scanProductRow(row pgx.Row) (domain.Product, error) {
 		&source,
 		&product.CreatedAt,
 		&product.UpdatedAt,
+		&categoryID,
+		&categoryName,
+		&sku,
+		&product.Unit,
 	); err != nil {
 		return domain.Product{}, err
 	}
@@ -1231,18 +2365,146 @@ func scanProductRow(row pgx.Row) (domain.Product, error) {
 		value := source.String
 		product.Source = &value
 	}
+	if categoryID.Valid {
+		value := categoryID.Int64
+		product.CategoryID = &value
+	}
+	if categoryName.Valid {
+		value := categoryName.String
+		product.CategoryName = &value
+	}
+	if sku.Valid {
+		value := sku.String
+		product.SKU = &value
+	}
 	return product, nil
 }
 
+const (
+	ProductUnitPiece  = "piece"
+	ProductUnitWeight = "weight"
+)
+
+// NormalizeProductUnit maps an empty unit to ProductUnitPiece and rejects
+// anything other than piece or weight.
+func NormalizeProductUnit(unit string) (string, error) {
+	switch unit = strings.ToLower(strings.TrimSpace(unit)); unit {
+	case "":
+		return ProductUnitPiece, nil
+	case ProductUnitPiece, ProductUnitWeight:
+		return unit, nil
+	default:
+		return "", fmt.Errorf("unit must be %q or %q", ProductUnitPiece, ProductUnitWeight)
+	}
+}
+
+// WeightProductNames returns the lower-cased names of live products sold by
+// weight.
+func (r *Repository) WeightProductNames(ctx context.Context) (map[string]bool, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT product_name_normalized
+		FROM products
+		WHERE deleted_at IS NULL AND unit = $1
+	`, ProductUnitWeight)
+	if err != nil {
+		return nil, fmt.Errorf("list weight products: %w", err)
+	}
+	defer rows.Close()
+	names := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("scan weight product: %w", err)
+		}
+		names[name] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate weight products: %w", err)
+	}
+	return names, nil
+}
+
+// ProductUnits returns the unit of each live product named in names, keyed
+// by the lower-cased name. Unknown names are left out.
+func (r *Repository) ProductUnits(ctx context.Context, names []string) (map[string]string, error) {
+	keys := make([]string, 0, len(names))
+	for _, name := range names {
+		if key := normalizeName(name); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	units := make(map[string]string, len(keys))
+	if len(keys) == 0 {
+		return units, nil
+	}
+	rows, err := r.pool.Query(ctx, `
+		SELECT product_name_normalized, unit
+		FROM products
+		WHERE deleted_at IS NULL AND product_name_normalized = ANY($1)
+	`, keys)
+	if err != nil {
+		return nil, fmt.Errorf("load product units: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var key, unit string
+		if err := rows.Scan(&key, &unit); err != nil {
+			return nil, fmt.Errorf("scan product unit: %w", err)
+		}
+		units[key] = unit
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate product units: %w", err)
+	}
+	return units, nil
+}
+
+// normalizeSKU trims a product code and maps blank codes to NULL so they
+// never collide in the unique index.
+func normalizeSKU(value *string) *string {
+	if value == nil {
+		return nil
+	}
+	trimmed := strings.TrimSpace(*value)
+	if trimmed == "" {
+		return nil
+	}
+	return &trimmed
+}
+
+func productWriteError(op string, err error) error {
+	if isUniqueViolation(err, "uq_products_sku") {
+		return fmt.Errorf("%s: %w", op, ErrDuplicateSKU)
+	}
+	if isUniqueViolation(err, "uq_products_name_normalized") {
+		return fmt.Errorf("%s: %w", op, ErrDuplicateProductName)
+	}
+	return fmt.Errorf("%s: %w", op, err)
+}
+
+// isUniqueViolation reports whether err is a Postgres unique violation. An
+// empty constraint matches any unique constraint.
+func isUniqueViolation(err error, constraint string) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != "23505" {
+		return false
+	}
+	return constraint == "" || pgErr.ConstraintName == constraint
+}
+
 func scanInvoice(rows pgx.CollectableRow) (domain.Invoice, error) {
 	return scanInvoiceRow(rows)
 }
 
 func scanInvoiceRow(row pgx.Row) (domain.Invoice, error) {
 	var (
-		inv   domain.Invoice
-		name  sql.NullString
-		admin sql.NullString
+		inv      domain.Invoice
+		name     sql.NullString
+		admin    sql.NullString
+		discount sql.NullFloat64
+		tax      sql.NullFloat64
+		supplier sql.NullString
+		customer sql.NullString
 	)
 	if err := row.Scan(
 		&inv.ID,
@@ -1253,9 +2515,22 @@ func scanInvoiceRow(row pgx.Row) (domain.Invoice, error) {
 		&inv.TotalAmount,
 		&name,
 		&admin,
+		&discount,
+		&tax,
+		&inv.Status,
+		&supplier,
+		&customer,
 	); err != nil {
 		return domain.Invoice{}, err
 	}
+	if supplier.Valid {
+		value := supplier.String
+		inv.SupplierName = &value
+	}
+	if customer.Valid {
+		value := customer.String
+		inv.CustomerName = &value
+	}
 	if name.Valid {
 		value := name.String
 		inv.InvoiceName = &value
@@ -1264,6 +2539,14 @@ func scanInvoiceRow(row pgx.Row) (domain.Invoice, error) {
 		value := admin.String
 		inv.AdminUsername = &value
 	}
+	if discount.Valid {
+		value := discount.Float64
+		inv.DiscountAmount = &value
+	}
+	if tax.Valid {
+		value := tax.Float64
+		inv.TaxAmount = &value
+	}
 	return inv, nil
 }
 
@@ -1275,6 +2558,7 @@ func scanInvoiceLine(row pgx.Row) (domain.InvoiceLine, error) {
 		&line.ProductName,
 		&line.Price,
 		&line.Quantity,
+		&line.Discount,
 		&line.LineTotal,
 		&line.CostPrice,
 	); err != nil {
@@ -1299,3 +2583,18 @@ func normalizeOffset(offset int) int {
 	}
 	return offset
 }
+
+// NormalizePage applies the same limit/offset defaults and caps as the list
+// queries so handlers can echo the effective page.
+func NormalizePage(limit, offset int) (int, int) {
+	return normalizeLimit(limit), normalizeOffset(offset)
+}
+
+// trimPage drops the probe row fetched past limit and reports whether it
+// existed.
+func trimPage[T any](items []T, limit int) ([]T, bool) {
+	if len(items) > limit {
+		return items[:limit], true
+	}
+	return items, false
+}
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"backend/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const stockAdjustmentActionType = "stock_adjustment"
+
+// AdjustProductQuantity applies a signed delta to a product's stock and
+// records the change in actions within the same transaction.
+func (r *Repository) AdjustProductQuantity(
+	ctx context.Context,
+	id int64,
+	delta float64,
+	reason string,
+	adminUsername *string,
+	force bool,
+) (*domain.Product, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin adjust product tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var (
+		productName string
+		oldQty      float64
+	)
+	err = tx.QueryRow(ctx, `
+		SELECT product_name, quantity::double precision
+		FROM products
+		WHERE id = $1 AND deleted_at IS NULL
+		FOR UPDATE
+	`, id).Scan(&productName, &oldQty)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load product for adjust: %w", err)
+	}
+
+	newQty := roundQuantity(oldQty + delta)
+	if newQty < 0 && !force {
+		return nil, fmt.Errorf(
+			"%w: adjustment would make quantity of %q negative: available %s, delta %s",
+			ErrInsufficientStock,
+			productName,
+			formatQuantity(oldQty),
+			formatQuantity(delta),
+		)
+	}
+
+	row := tx.QueryRow(ctx, `
+		UPDATE products
+		SET quantity = $2, updated_at = NOW()
+		WHERE id = $1
+		RETURNING
+			id,
+			product_name,
+			quantity,
+			avg_buy_price::double precision,
+			last_buy_price::double precision,
+			sell_price::double precision,
+			alarm,
+			source,
+			created_at,
+			updated_at,
+			category_id,
+			(SELECT name FROM categories WHERE id = products.category_id),
+			sku,
+			unit
+	`, id, newQty)
+	updated, err := scanProductRow(row)
+	if err != nil {
+		return nil, fmt.Errorf("adjust product quantity: %w", err)
+	}
+
+	details := fmt.Sprintf(
+		"product_id: %d\nold_quantity: %s\nnew_quantity: %s\ndelta: %s\nreason: %s",
+		id,
+		formatQuantity(oldQty),
+		formatQuantity(newQty),
+		formatQuantity(delta),
+		reason,
+	)
+	if err := insertActionTx(
+		ctx,
+		tx,
+		actingAdmin(ctx, adminUsername),
+		stockAdjustmentActionType,
+		"Stock adjustment: "+productName,
+		details,
+	); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("commit adjust product tx: %w", err)
+	}
+	return &updated, nil
+}
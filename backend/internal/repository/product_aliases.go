@@ -0,0 +1,227 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"backend/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrAliasInUse is returned when an alias already points at another product.
+var ErrAliasInUse = errors.New("alias already belongs to another product")
+
+type rowsQuerier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+// AddProductAlias stores an alternative name for a product and returns the
+// product's aliases. Aliases are matched after the same Persian/Arabic
+// normalization used by the sell price import.
+func (r *Repository) AddProductAlias(ctx context.Context, productID int64, alias string) ([]domain.ProductAlias, error) {
+	alias = strings.TrimSpace(alias)
+	normalized := normalizeSellPriceLookupName(alias)
+	if normalized == "" {
+		return nil, fmt.Errorf("alias is required")
+	}
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin add alias tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var exists bool
+	if err := tx.QueryRow(ctx, `
+		SELECT EXISTS (SELECT 1 FROM products WHERE id = $1 AND deleted_at IS NULL)
+	`, productID).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("check product %d: %w", productID, err)
+	}
+	if !exists {
+		return nil, ErrNotFound
+	}
+
+	var ownerID int64
+	if err := tx.QueryRow(ctx, `
+		INSERT INTO product_aliases (alias_normalized, product_id, alias)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (alias_normalized) DO UPDATE
+		SET alias_normalized = product_aliases.alias_normalized
+		RETURNING product_id
+	`, normalized, productID, alias).Scan(&ownerID); err != nil {
+		return nil, fmt.Errorf("add product alias: %w", err)
+	}
+	if ownerID != productID {
+		return nil, fmt.Errorf("%w: %q is an alias of product %d", ErrAliasInUse, alias, ownerID)
+	}
+
+	aliases, err := listProductAliases(ctx, tx, productID)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("commit add alias tx: %w", err)
+	}
+	return aliases, nil
+}
+
+func (r *Repository) DeleteProductAlias(ctx context.Context, productID int64, alias string) ([]domain.ProductAlias, error) {
+	cmd, err := r.pool.Exec(ctx, `
+		DELETE FROM product_aliases
+		WHERE product_id = $1 AND alias_normalized = $2
+	`, productID, normalizeSellPriceLookupName(strings.TrimSpace(alias)))
+	if err != nil {
+		return nil, fmt.Errorf("delete product alias: %w", err)
+	}
+	if cmd.RowsAffected() == 0 {
+		return nil, ErrNotFound
+	}
+	return listProductAliases(ctx, r.pool, productID)
+}
+
+func (r *Repository) ListProductAliases(ctx context.Context, productID int64) ([]domain.ProductAlias, error) {
+	return listProductAliases(ctx, r.pool, productID)
+}
+
+func listProductAliases(ctx context.Context, q rowsQuerier, productID int64) ([]domain.ProductAlias, error) {
+	rows, err := q.Query(ctx, `
+		SELECT alias, created_at
+		FROM product_aliases
+		WHERE product_id = $1
+		ORDER BY alias_normalized
+	`, productID)
+	if err != nil {
+		return nil, fmt.Errorf("list product aliases: %w", err)
+	}
+	defer rows.Close()
+
+	aliases := make([]domain.ProductAlias, 0)
+	for rows.Next() {
+		var alias domain.ProductAlias
+		if err := rows.Scan(&alias.Alias, &alias.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan product alias: %w", err)
+		}
+		aliases = append(aliases, alias)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate product aliases: %w", err)
+	}
+	return aliases, nil
+}
+
+// loadAliasLookup maps every alias of a live product to its product id.
+// The keys are both the lower-cased alias and its lookup normalization, so
+// callers can probe with either form.
+func loadAliasLookup(ctx context.Context, q rowsQuerier) (map[string]int64, error) {
+	rows, err := q.Query(ctx, `
+		SELECT a.alias, a.alias_normalized, a.product_id
+		FROM product_aliases a
+		JOIN products p ON p.id = a.product_id
+		WHERE p.deleted_at IS NULL
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("load product aliases: %w", err)
+	}
+	defer rows.Close()
+
+	lookup := map[string]int64{}
+	for rows.Next() {
+		var (
+			alias      string
+			normalized string
+			productID  int64
+		)
+		if err := rows.Scan(&alias, &normalized, &productID); err != nil {
+			return nil, fmt.Errorf("scan product alias: %w", err)
+		}
+		lookup[normalized] = productID
+		if key := normalizeName(alias); key != "" {
+			lookup[key] = productID
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate product aliases: %w", err)
+	}
+	return lookup, nil
+}
+
+// resolveProductNames maps each name, keyed by normalizeName, to the live
+// product it refers to: the product with that name, otherwise the product
+// that owns it as an alias. Names matching neither are left out. Sales
+// preview, invoice creation, line updates and product locking all resolve
+// through here, so an alias always lands on the same product.
+func resolveProductNames(ctx context.Context, q rowsQuerier, names []string) (map[string]string, error) {
+	inputs := make([]string, 0, len(names))
+	lookups := make([]string, 0, len(names))
+	seen := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		key := normalizeName(name)
+		if key == "" {
+			continue
+		}
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		inputs = append(inputs, name)
+		lookups = append(lookups, normalizeSellPriceLookupName(name))
+	}
+	if len(inputs) == 0 {
+		return nil, nil
+	}
+
+	rows, err := q.Query(ctx, `
+		SELECT input.name, resolved.product_name
+		FROM unnest($1::text[], $2::text[]) AS input(name, lookup)
+		CROSS JOIN LATERAL (
+			SELECT candidate.product_name
+			FROM (
+				SELECT p.product_name, 0 AS rank
+				FROM products p
+				WHERE p.product_name_normalized = LOWER(input.name)
+					AND p.deleted_at IS NULL
+				UNION ALL
+				SELECT
+					p.product_name,
+					CASE WHEN LOWER(a.alias) = LOWER(input.name) THEN 1 ELSE 2 END
+				FROM product_aliases a
+				JOIN products p ON p.id = a.product_id
+				WHERE (LOWER(a.alias) = LOWER(input.name) OR a.alias_normalized = input.lookup)
+					AND p.deleted_at IS NULL
+			) candidate
+			ORDER BY candidate.rank
+			LIMIT 1
+		) resolved
+	`, inputs, lookups)
+	if err != nil {
+		return nil, fmt.Errorf("resolve product names: %w", err)
+	}
+	defer rows.Close()
+
+	resolved := make(map[string]string, len(inputs))
+	for rows.Next() {
+		var name, productName string
+		if err := rows.Scan(&name, &productName); err != nil {
+			return nil, fmt.Errorf("scan resolved product name: %w", err)
+		}
+		resolved[normalizeName(name)] = productName
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate resolved product names: %w", err)
+	}
+	return resolved, nil
+}
+
+// resolvedProductName returns the product that name resolves to, or the
+// trimmed name itself when it did not resolve.
+func resolvedProductName(resolved map[string]string, name string) string {
+	name = strings.TrimSpace(name)
+	if productName, ok := resolved[normalizeName(name)]; ok {
+		return productName
+	}
+	return name
+}
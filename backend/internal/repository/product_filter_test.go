@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+// TestListProductsPriceAndSource runs on an empty schema and checks that the
+// sell-price band, source and low-stock threshold compose.
+func TestListProductsPriceAndSource(t *testing.T) {
+	repo := testEmptyRepository(t)
+	ctx := context.Background()
+
+	text := func(value string) *string { return &value }
+	alarm := 10
+	seeded := []ProductCreateInput{
+		{ProductName: "a", Quantity: 2, SellPrice: 10, Source: text("Basalam")},
+		{ProductName: "b", Quantity: 20, SellPrice: 50, Source: text("basalam")},
+		{ProductName: "c", Quantity: 1, SellPrice: 30, Source: text("Market")},
+		{ProductName: "d", Quantity: 8, SellPrice: 30, Alarm: &alarm},
+	}
+	for _, input := range seeded {
+		if _, err := repo.CreateProduct(ctx, input); err != nil {
+			t.Fatalf("create %s: %v", input.ProductName, err)
+		}
+	}
+
+	price := func(value float64) *float64 { return &value }
+	threshold := 5
+	tests := []struct {
+		name   string
+		filter ProductListFilter
+		want   []string
+	}{
+		{name: "min sell", filter: ProductListFilter{MinSell: price(20)}, want: []string{"b", "c", "d"}},
+		{name: "max sell", filter: ProductListFilter{MaxSell: price(30)}, want: []string{"a", "c", "d"}},
+		{name: "inclusive band", filter: ProductListFilter{MinSell: price(30), MaxSell: price(30)}, want: []string{"c", "d"}},
+		{name: "source ignores case", filter: ProductListFilter{Source: " BASALAM "}, want: []string{"a", "b"}},
+		{name: "source is not a substring match", filter: ProductListFilter{Source: "bas"}, want: []string{}},
+		{name: "source and min sell", filter: ProductListFilter{Source: "basalam", MinSell: price(20)}, want: []string{"b"}},
+		// d is under its own alarm of 10 even though the default is 5.
+		{name: "low stock", filter: ProductListFilter{Threshold: &threshold}, want: []string{"a", "c", "d"}},
+		{name: "low stock and source", filter: ProductListFilter{Threshold: &threshold, Source: "market"}, want: []string{"c"}},
+		{name: "low stock and band", filter: ProductListFilter{Threshold: &threshold, MinSell: price(20), MaxSell: price(40)}, want: []string{"c", "d"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items, _, err := repo.ListProducts(ctx, tt.filter)
+			if err != nil {
+				t.Fatalf("ListProducts: %v", err)
+			}
+			got := make([]string, len(items))
+			for i, item := range items {
+				got[i] = item.ProductName
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("products = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
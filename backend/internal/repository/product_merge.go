@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"backend/internal/domain"
+)
+
+// MergeProducts folds sourceIDs into targetID in one transaction: invoice
+// lines and stock effects are repointed to the target, quantities are summed,
+// avg_buy_price is re-weighted by stock on hand and the sources are
+// soft-deleted. Source names become aliases of the target so later invoices
+// and imports that still use them land on the merged product. It returns the
+// merged product and the repointed line count.
+func (r *Repository) MergeProducts(
+	ctx context.Context,
+	targetID int64,
+	sourceIDs []int64,
+) (*domain.Product, int, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, 0, fmt.Errorf("begin merge products tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	ids := append([]int64{targetID}, sourceIDs...)
+	rows, err := tx.Query(ctx, `
+		SELECT
+			id,
+			product_name,
+			quantity::double precision,
+			avg_buy_price::double precision
+		FROM products
+		WHERE id = ANY($1) AND deleted_at IS NULL
+		ORDER BY id ASC
+		FOR UPDATE
+	`, ids)
+	if err != nil {
+		return nil, 0, fmt.Errorf("lock products for merge: %w", err)
+	}
+	type mergeRow struct {
+		name     string
+		quantity float64
+		avgBuy   float64
+	}
+	locked := make(map[int64]mergeRow, len(ids))
+	for rows.Next() {
+		var (
+			id  int64
+			row mergeRow
+		)
+		if err := rows.Scan(&id, &row.name, &row.quantity, &row.avgBuy); err != nil {
+			rows.Close()
+			return nil, 0, fmt.Errorf("scan product for merge: %w", err)
+		}
+		locked[id] = row
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return nil, 0, fmt.Errorf("iterate products for merge: %w", err)
+	}
+	rows.Close()
+	for _, id := range ids {
+		if _, ok := locked[id]; !ok {
+			return nil, 0, fmt.Errorf("%w: product %d", ErrNotFound, id)
+		}
+	}
+
+	target := locked[targetID]
+	totalQty := 0.0
+	stockQty := 0.0
+	stockCost := 0.0
+	changes := make([][2]string, 0, len(sourceIDs))
+	for _, id := range ids {
+		row := locked[id]
+		totalQty += row.quantity
+		if row.quantity > 0 {
+			stockQty += row.quantity
+			stockCost += row.quantity * row.avgBuy
+		}
+		if id != targetID {
+			changes = append(changes, [2]string{row.name, target.name})
+		}
+	}
+	newAvg := target.avgBuy
+	if stockQty > 0 {
+		newAvg = stockCost / stockQty
+	}
+
+	renamed, err := renameInvoiceProductsTx(ctx, tx, changes)
+	if err != nil {
+		return nil, 0, err
+	}
+	if _, err := tx.Exec(ctx, `
+		UPDATE invoice_stock_effects
+		SET product_id = $1, product_name = $2
+		WHERE product_id = ANY($3)
+	`, targetID, target.name, sourceIDs); err != nil {
+		return nil, 0, fmt.Errorf("repoint invoice stock effects: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `
+		DELETE FROM product_group_members
+		WHERE product_id = ANY($1)
+	`, sourceIDs); err != nil {
+		return nil, 0, fmt.Errorf("remove merged products from groups: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `
+		UPDATE product_aliases
+		SET product_id = $1
+		WHERE product_id = ANY($2)
+	`, targetID, sourceIDs); err != nil {
+		return nil, 0, fmt.Errorf("move aliases of merged products: %w", err)
+	}
+	for _, change := range changes {
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO product_aliases (alias_normalized, product_id, alias)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (alias_normalized) DO NOTHING
+		`, normalizeSellPriceLookupName(change[0]), targetID, change[0]); err != nil {
+			return nil, 0, fmt.Errorf("record merged name %q as alias: %w", change[0], err)
+		}
+	}
+	if _, err := tx.Exec(ctx, `
+		UPDATE products
+		SET quantity = 0, sku = NULL, deleted_at = NOW(), updated_at = NOW()
+		WHERE id = ANY($1)
+	`, sourceIDs); err != nil {
+		return nil, 0, fmt.Errorf("soft-delete merged products: %w", err)
+	}
+
+	row := tx.QueryRow(ctx, `
+		UPDATE products
+		SET quantity = $2, avg_buy_price = $3, updated_at = NOW()
+		WHERE id = $1
+		RETURNING
+			id,
+			product_name,
+			quantity,
+			avg_buy_price::double precision,
+			last_buy_price::double precision,
+			sell_price::double precision,
+			alarm,
+			source,
+			created_at,
+			updated_at,
+			category_id,
+			(SELECT name FROM categories WHERE id = products.category_id),
+			sku,
+			unit
+	`, targetID, roundQuantity(totalQty), newAvg)
+	merged, err := scanProductRow(row)
+	if err != nil {
+		return nil, 0, fmt.Errorf("update merged product: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, 0, fmt.Errorf("commit merge products tx: %w", err)
+	}
+	return &merged, renamed.UpdatedLines, nil
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestPatchProductExpectedUpdatedAt(t *testing.T) {
+	repo := testRepository(t)
+	ctx := context.Background()
+
+	name := fmt.Sprintf("patch version %d", time.Now().UnixNano())
+	created, err := repo.CreateProduct(ctx, ProductCreateInput{ProductName: name, Quantity: 1, SellPrice: 10})
+	if err != nil {
+		t.Fatalf("create product: %v", err)
+	}
+	price := func(value float64) *float64 { return &value }
+	seen := created.UpdatedAt
+
+	// A patch based on the current version goes through and moves it on.
+	first, err := repo.PatchProduct(ctx, created.ID, ProductPatchInput{SellPrice: price(20), ExpectedUpdatedAt: &seen})
+	if err != nil {
+		t.Fatalf("patch with the current version: %v", err)
+	}
+	if first.SellPrice != 20 || !first.UpdatedAt.After(seen) {
+		t.Fatalf("patched sell_price/updated_at = %v/%v, want 20 after %v", first.SellPrice, first.UpdatedAt, seen)
+	}
+
+	// The second editor still holds the original version.
+	_, err = repo.PatchProduct(ctx, created.ID, ProductPatchInput{SellPrice: price(30), ExpectedUpdatedAt: &seen})
+	if !errors.Is(err, ErrProductStale) {
+		t.Fatalf("patch with a stale version: error = %v, want ErrProductStale", err)
+	}
+	stored, err := repo.GetProductByID(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("get product: %v", err)
+	}
+	if stored.SellPrice != 20 || !stored.UpdatedAt.Equal(first.UpdatedAt) {
+		t.Fatalf("stale patch changed the row: sell_price/updated_at = %v/%v", stored.SellPrice, stored.UpdatedAt)
+	}
+
+	// Refreshing to the new version, or sending none, writes again.
+	if _, err := repo.PatchProduct(ctx, created.ID, ProductPatchInput{SellPrice: price(30), ExpectedUpdatedAt: &first.UpdatedAt}); err != nil {
+		t.Fatalf("patch with the refreshed version: %v", err)
+	}
+	last, err := repo.PatchProduct(ctx, created.ID, ProductPatchInput{SellPrice: price(40)})
+	if err != nil {
+		t.Fatalf("patch without a version: %v", err)
+	}
+	if last.SellPrice != 40 {
+		t.Fatalf("sell_price = %v, want 40", last.SellPrice)
+	}
+
+	missing := time.Now()
+	if _, err := repo.PatchProduct(ctx, -1, ProductPatchInput{ExpectedUpdatedAt: &missing}); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("patch of a missing product: error = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// productSortColumns is the allowlist of ?sort= keys for ListProducts.
+var productSortColumns = map[string]string{
+	"name":          "p.product_name",
+	"quantity":      "p.quantity",
+	"sell_price":    "p.sell_price",
+	"avg_buy_price": "p.avg_buy_price",
+	"updated_at":    "p.updated_at",
+}
+
+type ProductSort struct {
+	Key  string
+	Desc bool
+}
+
+// ParseProductSort reads "key" or "key:asc|desc". An empty value keeps the
+// default id order.
+func ParseProductSort(raw string) (ProductSort, error) {
+	raw = strings.ToLower(strings.TrimSpace(raw))
+	if raw == "" {
+		return ProductSort{}, nil
+	}
+	key, direction, _ := strings.Cut(raw, ":")
+	if _, ok := productSortColumns[key]; !ok {
+		keys := make([]string, 0, len(productSortColumns))
+		for name := range productSortColumns {
+			keys = append(keys, name)
+		}
+		sort.Strings(keys)
+		return ProductSort{}, fmt.Errorf("sort must be one of: %s", strings.Join(keys, ", "))
+	}
+	switch direction {
+	case "", "asc":
+		return ProductSort{Key: key}, nil
+	case "desc":
+		return ProductSort{Key: key, Desc: true}, nil
+	default:
+		return ProductSort{}, fmt.Errorf("sort direction must be asc or desc")
+	}
+}
+
+func (s ProductSort) orderBy() string {
+	column, ok := productSortColumns[s.Key]
+	if !ok {
+		return "p.id ASC"
+	}
+	if s.Desc {
+		return column + " DESC, p.id ASC"
+	}
+	return column + " ASC, p.id ASC"
+}
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestParseProductSort(t *testing.T) {
+	tests := []struct {
+		raw         string
+		want        ProductSort
+		wantOrderBy string
+		wantErr     string
+	}{
+		{raw: "", wantOrderBy: "p.id ASC"},
+		{raw: "name", want: ProductSort{Key: "name"}, wantOrderBy: "p.product_name ASC, p.id ASC"},
+		{raw: " Quantity:DESC ", want: ProductSort{Key: "quantity", Desc: true}, wantOrderBy: "p.quantity DESC, p.id ASC"},
+		{raw: "sell_price:asc", want: ProductSort{Key: "sell_price"}, wantOrderBy: "p.sell_price ASC, p.id ASC"},
+		{raw: "updated_at:desc", want: ProductSort{Key: "updated_at", Desc: true}, wantOrderBy: "p.updated_at DESC, p.id ASC"},
+		{raw: "id; DROP TABLE products", wantErr: "sort must be one of: avg_buy_price, name, quantity, sell_price, updated_at"},
+		{raw: "name:sideways", wantErr: "sort direction must be asc or desc"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			got, err := ParseProductSort(tt.raw)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseProductSort: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("sort = %+v, want %+v", got, tt.want)
+			}
+			if orderBy := got.orderBy(); orderBy != tt.wantOrderBy {
+				t.Fatalf("orderBy = %q, want %q", orderBy, tt.wantOrderBy)
+			}
+		})
+	}
+}
+
+// TestListProductsSort runs on an empty schema so only the seeded products
+// are listed.
+func TestListProductsSort(t *testing.T) {
+	repo := testEmptyRepository(t)
+	ctx := context.Background()
+
+	seeded := []ProductCreateInput{
+		{ProductName: "b product", Quantity: 5, AvgBuyPrice: 30, SellPrice: 10},
+		{ProductName: "c product", Quantity: 1, AvgBuyPrice: 10, SellPrice: 30},
+		{ProductName: "a product", Quantity: 5, AvgBuyPrice: 20, SellPrice: 20},
+	}
+	for _, input := range seeded {
+		if _, err := repo.CreateProduct(ctx, input); err != nil {
+			t.Fatalf("create %s: %v", input.ProductName, err)
+		}
+	}
+
+	tests := []struct {
+		sort string
+		want []string
+	}{
+		{sort: "", want: []string{"b product", "c product", "a product"}},
+		{sort: "name", want: []string{"a product", "b product", "c product"}},
+		{sort: "name:desc", want: []string{"c product", "b product", "a product"}},
+		// Equal quantities keep id order.
+		{sort: "quantity:desc", want: []string{"b product", "a product", "c product"}},
+		{sort: "sell_price", want: []string{"b product", "a product", "c product"}},
+		{sort: "avg_buy_price:desc", want: []string{"b product", "a product", "c product"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sort, func(t *testing.T) {
+			order, err := ParseProductSort(tt.sort)
+			if err != nil {
+				t.Fatalf("ParseProductSort: %v", err)
+			}
+			items, _, err := repo.ListProducts(ctx, ProductListFilter{Sort: order})
+			if err != nil {
+				t.Fatalf("ListProducts: %v", err)
+			}
+			got := make([]string, len(items))
+			for i, item := range items {
+				got[i] = item.ProductName
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("order = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
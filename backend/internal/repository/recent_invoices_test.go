@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"backend/internal/domain"
+)
+
+// TestListRecentInvoices seeds a fresh and a two-hour-old invoice on an empty
+// schema and lists them with windows on either side of the old one.
+func TestListRecentInvoices(t *testing.T) {
+	repo := testEmptyRepository(t)
+	ctx := context.Background()
+
+	lines := []domain.PurchaseLineInput{{ProductName: "recent test product", Price: 10, Quantity: 1}}
+	oldID, err := repo.CreatePurchaseInvoice(ctx, nil, nil, nil, lines, InvoiceAdjustments{}, InvoiceStatusFinalized)
+	if err != nil {
+		t.Fatalf("create old invoice: %v", err)
+	}
+	if _, err := repo.pool.Exec(ctx, "UPDATE invoices SET created_at = NOW() - INTERVAL '2 hours' WHERE id = $1", oldID); err != nil {
+		t.Fatalf("backdate invoice: %v", err)
+	}
+	recentID, err := repo.CreatePurchaseInvoice(ctx, nil, nil, nil, lines, InvoiceAdjustments{}, InvoiceStatusFinalized)
+	if err != nil {
+		t.Fatalf("create recent invoice: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		minutes int
+		limit   int
+		want    []int64
+	}{
+		{name: "short window keeps the recent invoice", minutes: 60, limit: 10, want: []int64{recentID}},
+		{name: "wide window lists newest first", minutes: 180, limit: 10, want: []int64{recentID, oldID}},
+		{name: "limit caps the feed", minutes: 180, limit: 1, want: []int64{recentID}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			items, err := repo.ListRecentInvoices(ctx, tc.minutes, tc.limit)
+			if err != nil {
+				t.Fatalf("ListRecentInvoices: %v", err)
+			}
+			got := make([]int64, len(items))
+			for i, item := range items {
+				got[i] = item.ID
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("ids = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
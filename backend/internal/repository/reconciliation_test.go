@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"backend/internal/domain"
+)
+
+// TestGetStockReconciliation runs on an empty schema so the report holds only
+// the products created here. "counted" moves only through invoices and
+// balances; "found" starts with stock no purchase explains.
+func TestGetStockReconciliation(t *testing.T) {
+	repo := testEmptyRepository(t)
+	ctx := context.Background()
+
+	for _, input := range []ProductCreateInput{
+		{ProductName: "counted", AvgBuyPrice: 10},
+		{ProductName: "found", Quantity: 5, AvgBuyPrice: 10},
+	} {
+		if _, err := repo.CreateProduct(ctx, input); err != nil {
+			t.Fatalf("create product %q: %v", input.ProductName, err)
+		}
+	}
+
+	purchase := []domain.PurchaseLineInput{{ProductName: "counted", Price: 10, Quantity: 10}}
+	if _, err := repo.CreatePurchaseInvoice(ctx, nil, nil, nil, purchase, InvoiceAdjustments{}, InvoiceStatusFinalized); err != nil {
+		t.Fatalf("create purchase: %v", err)
+	}
+	sales := []struct {
+		invoiceType string
+		quantity    float64
+		status      string
+	}{
+		{"sales", 3, InvoiceStatusFinalized},
+		{"sales_return", 1, InvoiceStatusFinalized},
+		// Drafts move no stock and must not count as sold either.
+		{"sales", 2, InvoiceStatusDraft},
+	}
+	for _, sale := range sales {
+		lines := []domain.SalesLineInput{{ProductName: "counted", Price: 20, Quantity: sale.quantity}}
+		if _, err := repo.CreateSalesInvoice(ctx, nil, nil, nil, sale.invoiceType, lines, InvoiceAdjustments{}, sale.status, false); err != nil {
+			t.Fatalf("create %s %s invoice: %v", sale.status, sale.invoiceType, err)
+		}
+	}
+
+	want := []domain.StockReconciliationRow{
+		{ProductName: "found", ExpectedQty: 0, ActualQty: 5, Discrepancy: 5},
+		{ProductName: "counted", TotalPurchased: 10, TotalSold: 2, ExpectedQty: 8, ActualQty: 8},
+	}
+	got, err := repo.GetStockReconciliation(ctx, 0)
+	if err != nil {
+		t.Fatalf("GetStockReconciliation: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("reconciliation = %+v, want %+v", got, want)
+	}
+
+	got, err = repo.GetStockReconciliation(ctx, 1)
+	if err != nil {
+		t.Fatalf("GetStockReconciliation limit 1: %v", err)
+	}
+	if !reflect.DeepEqual(got, want[:1]) {
+		t.Fatalf("limit 1 = %+v, want the largest discrepancy %+v", got, want[:1])
+	}
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"backend/internal/domain"
+)
+
+// TestGetSalesByAdmin runs on an empty schema with sales from two admins and
+// one without an admin. Every unit costs 10 and sells for 25.
+func TestGetSalesByAdmin(t *testing.T) {
+	repo := testEmptyRepository(t)
+	ctx := context.Background()
+
+	const name = "attributed product"
+	if _, err := repo.CreateProduct(ctx, ProductCreateInput{ProductName: name, Quantity: 100, AvgBuyPrice: 10}); err != nil {
+		t.Fatalf("create product: %v", err)
+	}
+	text := func(value string) *string { return &value }
+	old := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	seeded := []struct {
+		admin       *string
+		invoiceType string
+		quantity    float64
+		status      string
+		createdAt   *time.Time
+	}{
+		{admin: text("alice"), invoiceType: "sales", quantity: 3, status: InvoiceStatusFinalized},
+		{admin: text("alice"), invoiceType: "sales_basalam", quantity: 1, status: InvoiceStatusFinalized},
+		{admin: text("alice"), invoiceType: "sales", quantity: 4, status: InvoiceStatusFinalized, createdAt: &old},
+		{admin: text("bob"), invoiceType: "sales", quantity: 2, status: InvoiceStatusFinalized},
+		{admin: text("bob"), invoiceType: "sales_return", quantity: 1, status: InvoiceStatusFinalized},
+		{admin: text("bob"), invoiceType: "sales", quantity: 9, status: InvoiceStatusDraft},
+		{invoiceType: "sales", quantity: 1, status: InvoiceStatusFinalized},
+	}
+	for i, sale := range seeded {
+		id, err := repo.CreateSalesInvoice(ctx, nil, sale.admin, nil, sale.invoiceType,
+			[]domain.SalesLineInput{{ProductName: name, Price: 25, Quantity: sale.quantity}},
+			InvoiceAdjustments{}, sale.status, false)
+		if err != nil {
+			t.Fatalf("create sale %d: %v", i, err)
+		}
+		if sale.createdAt != nil {
+			if _, err := repo.pool.Exec(ctx, "UPDATE invoices SET created_at = $1 WHERE id = $2", *sale.createdAt, id); err != nil {
+				t.Fatalf("backdate sale %d: %v", i, err)
+			}
+		}
+	}
+
+	since := time.Now().Add(-24 * time.Hour)
+	tests := []struct {
+		name string
+		from *time.Time
+		want []domain.AdminSalesStat
+	}{
+		{
+			name: "all time",
+			want: []domain.AdminSalesStat{
+				{AdminUsername: "alice", InvoiceCount: 3, TotalSales: 200, TotalProfit: 120, UnitsSold: 8},
+				// A return counts as an invoice but takes its sales back.
+				{AdminUsername: "bob", InvoiceCount: 2, TotalSales: 25, TotalProfit: 15, UnitsSold: 1},
+				{AdminUsername: "unassigned", InvoiceCount: 1, TotalSales: 25, TotalProfit: 15, UnitsSold: 1},
+			},
+		},
+		{
+			name: "range leaves out the old sale",
+			from: &since,
+			want: []domain.AdminSalesStat{
+				{AdminUsername: "alice", InvoiceCount: 2, TotalSales: 100, TotalProfit: 60, UnitsSold: 4},
+				{AdminUsername: "bob", InvoiceCount: 2, TotalSales: 25, TotalProfit: 15, UnitsSold: 1},
+				{AdminUsername: "unassigned", InvoiceCount: 1, TotalSales: 25, TotalProfit: 15, UnitsSold: 1},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := repo.GetSalesByAdmin(ctx, tt.from, nil)
+			if err != nil {
+				t.Fatalf("GetSalesByAdmin: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("stats = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
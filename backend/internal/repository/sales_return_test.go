@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"backend/internal/domain"
+)
+
+// TestSalesReturn runs on an empty schema so the monthly summary holds only
+// the invoices created here: a return puts stock back, is taken off the
+// month's sales and profit, and deleting it takes the stock out again.
+func TestSalesReturn(t *testing.T) {
+	repo := testEmptyRepository(t)
+	ctx := context.Background()
+
+	const name = "returned product"
+	product, err := repo.CreateProduct(ctx, ProductCreateInput{ProductName: name, Quantity: 10, AvgBuyPrice: 10})
+	if err != nil {
+		t.Fatalf("create product: %v", err)
+	}
+	quantity := func(step string, want float64) {
+		t.Helper()
+		got, err := repo.GetProductByID(ctx, product.ID)
+		if err != nil {
+			t.Fatalf("%s: get product: %v", step, err)
+		}
+		if got.Quantity != want {
+			t.Fatalf("%s: quantity = %v, want %v", step, got.Quantity, want)
+		}
+	}
+	sell := func(invoiceType string, count float64) int64 {
+		t.Helper()
+		lines := []domain.SalesLineInput{{ProductName: name, Price: 25, Quantity: count}}
+		id, err := repo.CreateSalesInvoice(ctx, nil, nil, nil, invoiceType, lines, InvoiceAdjustments{}, InvoiceStatusFinalized, false)
+		if err != nil {
+			t.Fatalf("create %s invoice: %v", invoiceType, err)
+		}
+		return id
+	}
+
+	sell("sales", 4)
+	quantity("after the sale", 6)
+	returnID := sell("sales_return", 1)
+	quantity("after the return", 7)
+
+	summary, err := repo.GetMonthlySummary(ctx, 12)
+	if err != nil {
+		t.Fatalf("GetMonthlySummary: %v", err)
+	}
+	if len(summary) != 1 {
+		t.Fatalf("summary has %d months, want 1", len(summary))
+	}
+	// Sale: 4 x 25 at cost 10. Return: 1 x 25 at cost 10.
+	got := summary[0]
+	if got.SalesTotal != 75 || got.Profit != 45 || got.PurchaseTotal != 0 || got.InvoiceCount != 2 {
+		t.Fatalf("summary = %+v, want sales 75, profit 45, no purchases, 2 invoices", got)
+	}
+
+	if err := repo.DeleteInvoiceReconciled(ctx, returnID); err != nil {
+		t.Fatalf("delete return: %v", err)
+	}
+	quantity("after deleting the return", 6)
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"backend/internal/db"
+	"backend/internal/domain"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// testEmptyRepository is testRepository on a fresh schema, for tests that need
+// tables no other test writes to. The schema is dropped afterwards.
+func testEmptyRepository(t *testing.T) *Repository {
+	t.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	admin, err := db.NewPool(ctx, url, db.PoolOptions{MaxConns: 1, MinConns: 1})
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	schema := fmt.Sprintf("repository_test_%d", time.Now().UnixNano())
+	if _, err := admin.Exec(ctx, "CREATE SCHEMA "+schema); err != nil {
+		admin.Close()
+		t.Fatalf("create schema: %v", err)
+	}
+	t.Cleanup(func() {
+		_, _ = admin.Exec(context.Background(), "DROP SCHEMA "+schema+" CASCADE")
+		admin.Close()
+	})
+
+	cfg, err := pgxpool.ParseConfig(url)
+	if err != nil {
+		t.Fatalf("parse url: %v", err)
+	}
+	cfg.ConnConfig.RuntimeParams["search_path"] = schema
+	pool, err := pgxpool.NewWithConfig(ctx, cfg)
+	if err != nil {
+		t.Fatalf("connect to schema: %v", err)
+	}
+	t.Cleanup(pool.Close)
+	if err := db.RunMigrations(ctx, pool); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return New(pool)
+}
+
+func TestImportSellPricesWithoutProducts(t *testing.T) {
+	repo := testEmptyRepository(t)
+	ctx := context.Background()
+
+	rows := []domain.ProductPriceRow{{ProductName: "anything", Price: 100}}
+	if _, err := repo.ImportSellPrices(ctx, rows, SellPriceImportOptions{}); !errors.Is(err, ErrNoProducts) {
+		t.Fatalf("import into empty inventory error = %v, want ErrNoProducts", err)
+	}
+
+	// The guard only applies to an empty inventory.
+	if _, err := repo.CreateProduct(ctx, ProductCreateInput{ProductName: "anything", Quantity: 1}); err != nil {
+		t.Fatalf("create product: %v", err)
+	}
+	result, err := repo.ImportSellPrices(ctx, rows, SellPriceImportOptions{})
+	if err != nil {
+		t.Fatalf("import after creating a product: %v", err)
+	}
+	if result.MatchedRows != 1 {
+		t.Fatalf("matched rows = %d, want 1", result.MatchedRows)
+	}
+}
@@ -0,0 +1,184 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"backend/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	settingSellPriceAlarmPercent        = "sell_price_alarm_percent"
+	settingSalesImportFuzzyMatchPercent = "sales_import_fuzzy_match_percent"
+	settingLowStockDefault              = "low_stock_default"
+)
+
+// defaultLowStockThreshold applies to products without their own alarm until
+// low_stock_default is changed.
+const defaultLowStockThreshold = 5
+
+type settingSpec struct {
+	Default float64
+	Min     float64
+	Max     float64
+	Integer bool
+}
+
+// settingSpecs lists every key that may live in app_settings. Keys missing
+// here are rejected by the generic settings API.
+var settingSpecs = map[string]settingSpec{
+	settingSellPriceAlarmPercent:        {Default: 20, Min: 0, Max: 100},
+	settingSalesImportFuzzyMatchPercent: {Default: 85, Min: 0, Max: 100},
+	settingLowStockDefault:              {Default: defaultLowStockThreshold, Min: 1, Max: 1000000, Integer: true},
+}
+
+// ListSettings returns every registered setting, using the default for keys
+// that were never stored.
+func (r *Repository) ListSettings(ctx context.Context) ([]domain.Setting, error) {
+	keys := make([]string, 0, len(settingSpecs))
+	for key := range settingSpecs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT key, value_numeric::double precision, updated_at
+		FROM app_settings
+		WHERE key = ANY($1)
+	`, keys)
+	if err != nil {
+		return nil, fmt.Errorf("list settings: %w", err)
+	}
+	defer rows.Close()
+
+	stored := make(map[string]domain.Setting, len(keys))
+	for rows.Next() {
+		var (
+			key       string
+			value     float64
+			updatedAt time.Time
+		)
+		if err := rows.Scan(&key, &value, &updatedAt); err != nil {
+			return nil, fmt.Errorf("scan setting: %w", err)
+		}
+		stored[key] = newSetting(key, value, &updatedAt)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate settings: %w", err)
+	}
+
+	settings := make([]domain.Setting, 0, len(keys))
+	for _, key := range keys {
+		setting, ok := stored[key]
+		if !ok {
+			setting = newSetting(key, settingSpecs[key].Default, nil)
+		}
+		settings = append(settings, setting)
+	}
+	return settings, nil
+}
+
+// GetSetting returns ErrNotFound for keys outside settingSpecs.
+func (r *Repository) GetSetting(ctx context.Context, key string) (domain.Setting, error) {
+	spec, ok := settingSpecs[key]
+	if !ok {
+		return domain.Setting{}, ErrNotFound
+	}
+	var (
+		value     float64
+		updatedAt time.Time
+	)
+	err := r.pool.QueryRow(ctx, `
+		SELECT value_numeric::double precision, updated_at
+		FROM app_settings
+		WHERE key = $1
+	`, key).Scan(&value, &updatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return newSetting(key, spec.Default, nil), nil
+	}
+	if err != nil {
+		return domain.Setting{}, fmt.Errorf("get setting %s: %w", key, err)
+	}
+	return newSetting(key, value, &updatedAt), nil
+}
+
+// SetSetting validates value against the key's registered range and stores
+// it. Unknown keys return ErrNotFound.
+func (r *Repository) SetSetting(ctx context.Context, key string, value float64) (domain.Setting, error) {
+	spec, ok := settingSpecs[key]
+	if !ok {
+		return domain.Setting{}, ErrNotFound
+	}
+	if math.IsNaN(value) || value < spec.Min || value > spec.Max {
+		return domain.Setting{}, fmt.Errorf("%s must be between %g and %g", key, spec.Min, spec.Max)
+	}
+	if spec.Integer && value != math.Trunc(value) {
+		return domain.Setting{}, fmt.Errorf("%s must be a whole number", key)
+	}
+
+	var updatedAt time.Time
+	if err := r.pool.QueryRow(ctx, `
+		INSERT INTO app_settings (key, value_numeric, updated_at)
+		VALUES ($1, $2, NOW())
+		ON CONFLICT (key)
+		DO UPDATE SET
+			value_numeric = EXCLUDED.value_numeric,
+			updated_at = NOW()
+		RETURNING updated_at
+	`, key, value).Scan(&updatedAt); err != nil {
+		return domain.Setting{}, fmt.Errorf("set setting %s: %w", key, err)
+	}
+	return newSetting(key, value, &updatedAt), nil
+}
+
+func newSetting(key string, value float64, updatedAt *time.Time) domain.Setting {
+	spec := settingSpecs[key]
+	if value < spec.Min {
+		value = spec.Min
+	}
+	return domain.Setting{
+		Key:       key,
+		Value:     value,
+		Default:   spec.Default,
+		Min:       spec.Min,
+		Max:       spec.Max,
+		Integer:   spec.Integer,
+		UpdatedAt: updatedAt,
+	}
+}
+
+func (r *Repository) GetSellPriceAlarmPercent(ctx context.Context) (float64, error) {
+	setting, err := r.GetSetting(ctx, settingSellPriceAlarmPercent)
+	return setting.Value, err
+}
+
+func (r *Repository) SetSellPriceAlarmPercent(ctx context.Context, percent float64) (float64, error) {
+	setting, err := r.SetSetting(ctx, settingSellPriceAlarmPercent, percent)
+	return setting.Value, err
+}
+
+func (r *Repository) GetSalesImportFuzzyMatchPercent(ctx context.Context) (float64, error) {
+	setting, err := r.GetSetting(ctx, settingSalesImportFuzzyMatchPercent)
+	return setting.Value, err
+}
+
+func (r *Repository) SetSalesImportFuzzyMatchPercent(ctx context.Context, percent float64) (float64, error) {
+	setting, err := r.SetSetting(ctx, settingSalesImportFuzzyMatchPercent, percent)
+	return setting.Value, err
+}
+
+func (r *Repository) GetLowStockDefault(ctx context.Context) (int, error) {
+	setting, err := r.GetSetting(ctx, settingLowStockDefault)
+	return int(setting.Value), err
+}
+
+func (r *Repository) SetLowStockDefault(ctx context.Context, threshold int) (int, error) {
+	setting, err := r.SetSetting(ctx, settingLowStockDefault, float64(threshold))
+	return int(setting.Value), err
+}
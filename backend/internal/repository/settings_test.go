@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+)
+
+// TestSetSettingValidation covers the checks made before the database is
+// touched, so it runs without one.
+func TestSetSettingValidation(t *testing.T) {
+	repo := New(nil)
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		key     string
+		value   float64
+		wantErr string
+	}{
+		{name: "below range", key: settingSellPriceAlarmPercent, value: -1, wantErr: "sell_price_alarm_percent must be between 0 and 100"},
+		{name: "above range", key: settingSalesImportFuzzyMatchPercent, value: 100.5, wantErr: "sales_import_fuzzy_match_percent must be between 0 and 100"},
+		{name: "not a number", key: settingSellPriceAlarmPercent, value: math.NaN(), wantErr: "sell_price_alarm_percent must be between 0 and 100"},
+		{name: "fraction for an integer key", key: settingLowStockDefault, value: 2.5, wantErr: "low_stock_default must be a whole number"},
+		{name: "integer key below its minimum", key: settingLowStockDefault, value: 0, wantErr: "low_stock_default must be between 1 and 1e+06"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := repo.SetSetting(ctx, tt.key, tt.value)
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("SetSetting(%s, %v) error = %v, want %q", tt.key, tt.value, err, tt.wantErr)
+			}
+		})
+	}
+
+	for _, key := range []string{"", "unknown", "SELL_PRICE_ALARM_PERCENT"} {
+		if _, err := repo.GetSetting(ctx, key); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetSetting(%q) error = %v, want ErrNotFound", key, err)
+		}
+		if _, err := repo.SetSetting(ctx, key, 1); !errors.Is(err, ErrNotFound) {
+			t.Errorf("SetSetting(%q) error = %v, want ErrNotFound", key, err)
+		}
+	}
+}
+
+// TestSettings runs on an empty schema so every key starts at its default.
+func TestSettings(t *testing.T) {
+	repo := testEmptyRepository(t)
+	ctx := context.Background()
+
+	settings, err := repo.ListSettings(ctx)
+	if err != nil {
+		t.Fatalf("ListSettings: %v", err)
+	}
+	if len(settings) != len(settingSpecs) {
+		t.Fatalf("listed %d settings, want %d", len(settings), len(settingSpecs))
+	}
+	for i, setting := range settings {
+		if i > 0 && settings[i-1].Key >= setting.Key {
+			t.Errorf("settings are not sorted by key: %q before %q", settings[i-1].Key, setting.Key)
+		}
+		if setting.Value != setting.Default || setting.UpdatedAt != nil {
+			t.Errorf("%s = %v (updated %v), want the default %v and no timestamp", setting.Key, setting.Value, setting.UpdatedAt, setting.Default)
+		}
+	}
+
+	stored, err := repo.SetSetting(ctx, settingSellPriceAlarmPercent, 35)
+	if err != nil {
+		t.Fatalf("SetSetting: %v", err)
+	}
+	if stored.Value != 35 || stored.UpdatedAt == nil {
+		t.Fatalf("stored setting = %+v", stored)
+	}
+	got, err := repo.GetSetting(ctx, settingSellPriceAlarmPercent)
+	if err != nil {
+		t.Fatalf("GetSetting: %v", err)
+	}
+	if got.Value != 35 || got.Default != 20 {
+		t.Fatalf("GetSetting = %+v, want value 35 and default 20", got)
+	}
+	// The dedicated getter reads the same row as the generic API.
+	if percent, err := repo.GetSellPriceAlarmPercent(ctx); err != nil || percent != 35 {
+		t.Fatalf("GetSellPriceAlarmPercent = %v, %v; want 35", percent, err)
+	}
+	if percent, err := repo.SetSalesImportFuzzyMatchPercent(ctx, 70); err != nil || percent != 70 {
+		t.Fatalf("SetSalesImportFuzzyMatchPercent = %v, %v; want 70", percent, err)
+	}
+	if got, err := repo.GetSetting(ctx, settingSalesImportFuzzyMatchPercent); err != nil || got.Value != 70 {
+		t.Fatalf("GetSetting after the dedicated setter = %+v, %v; want 70", got, err)
+	}
+}
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"backend/internal/domain"
+)
+
+// TestGetTopSoldProductsRange sells one product last December and another
+// now, on an empty schema, and lists them by days window and by date range.
+func TestGetTopSoldProductsRange(t *testing.T) {
+	repo := testEmptyRepository(t)
+	ctx := context.Background()
+
+	december := time.Date(2024, time.December, 15, 12, 0, 0, 0, time.UTC)
+	seeded := []struct {
+		name      string
+		quantity  float64
+		createdAt *time.Time
+	}{
+		{name: "december product", quantity: 5, createdAt: &december},
+		{name: "recent product", quantity: 2},
+	}
+	for _, sale := range seeded {
+		if _, err := repo.CreateProduct(ctx, ProductCreateInput{ProductName: sale.name, Quantity: 100, AvgBuyPrice: 1}); err != nil {
+			t.Fatalf("create %s: %v", sale.name, err)
+		}
+		id, err := repo.CreateSalesInvoice(ctx, nil, nil, nil, "sales",
+			[]domain.SalesLineInput{{ProductName: sale.name, Price: 10, Quantity: sale.quantity}},
+			InvoiceAdjustments{}, InvoiceStatusFinalized, false)
+		if err != nil {
+			t.Fatalf("sell %s: %v", sale.name, err)
+		}
+		if sale.createdAt != nil {
+			if _, err := repo.pool.Exec(ctx, "UPDATE invoices SET created_at = $1 WHERE id = $2", *sale.createdAt, id); err != nil {
+				t.Fatalf("backdate %s: %v", sale.name, err)
+			}
+		}
+	}
+
+	at := func(value time.Time) *time.Time { return &value }
+	decemberStart := at(time.Date(2024, time.December, 1, 0, 0, 0, 0, time.UTC))
+	decemberEnd := at(time.Date(2024, time.December, 31, 23, 59, 59, 0, time.UTC))
+	tests := []struct {
+		name     string
+		days     int
+		from, to *time.Time
+		want     []string
+	}{
+		{name: "days window", days: 30, want: []string{"recent product"}},
+		{name: "no window", want: []string{"december product", "recent product"}},
+		{name: "range replaces days", days: 30, from: decemberStart, to: decemberEnd, want: []string{"december product"}},
+		{name: "open-ended from", days: 30, from: decemberStart, want: []string{"december product", "recent product"}},
+		{name: "open-ended to", days: 30, to: decemberEnd, want: []string{"december product"}},
+		{name: "empty range", from: at(time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC)), to: at(time.Date(2023, time.January, 31, 0, 0, 0, 0, time.UTC)), want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := repo.GetTopSoldProducts(ctx, tt.days, 10, tt.from, tt.to)
+			if err != nil {
+				t.Fatalf("GetTopSoldProducts: %v", err)
+			}
+			got := make([]string, len(rows))
+			for i, row := range rows {
+				got[i] = row.ProductName
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("products = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
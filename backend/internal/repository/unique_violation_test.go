@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestIsUniqueViolation(t *testing.T) {
+	skuConflict := fmt.Errorf("insert product: %w", &pgconn.PgError{Code: "23505", ConstraintName: "uq_products_sku"})
+	tests := []struct {
+		name       string
+		err        error
+		constraint string
+		want       bool
+	}{
+		{name: "wrapped, matching constraint", err: skuConflict, constraint: "uq_products_sku", want: true},
+		{name: "other constraint", err: skuConflict, constraint: "uq_products_name_normalized"},
+		{name: "any constraint", err: skuConflict, want: true},
+		{name: "other error code", err: &pgconn.PgError{Code: "23503", ConstraintName: "uq_products_sku"}, constraint: "uq_products_sku"},
+		{name: "not a Postgres error", err: errors.New("duplicate key value"), constraint: ""},
+		{name: "nil", err: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isUniqueViolation(tt.err, tt.constraint); got != tt.want {
+				t.Fatalf("isUniqueViolation(%v, %q) = %v, want %v", tt.err, tt.constraint, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProductWriteError(t *testing.T) {
+	tests := []struct {
+		constraint string
+		want       error
+	}{
+		{constraint: "uq_products_sku", want: ErrDuplicateSKU},
+		{constraint: "uq_products_name_normalized", want: ErrDuplicateProductName},
+	}
+	for _, tt := range tests {
+		t.Run(tt.constraint, func(t *testing.T) {
+			err := productWriteError("update product 5", &pgconn.PgError{Code: "23505", ConstraintName: tt.constraint})
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("productWriteError() = %v, want %v", err, tt.want)
+			}
+			if got, want := err.Error(), "update product 5: "+tt.want.Error(); got != want {
+				t.Fatalf("message = %q, want %q", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestValidateAutoLockMinutes(t *testing.T) {
+	tests := []struct {
+		name    string
+		max     int
+		minutes int
+		wantErr string
+	}{
+		{name: "default max allows 60", minutes: 60},
+		{name: "default max rejects 61", minutes: 61, wantErr: "auto_lock_minutes must be between 1 and 60"},
+		{name: "zero", minutes: 0, wantErr: "auto_lock_minutes must be between 1 and 60"},
+		{name: "raised max allows 90", max: 120, minutes: 90},
+		{name: "raised max allows its bound", max: 120, minutes: 120},
+		{name: "raised max rejects above it", max: 120, minutes: 121, wantErr: "auto_lock_minutes must be between 1 and 120"},
+		{name: "lowered max", max: 15, minutes: 30, wantErr: "auto_lock_minutes must be between 1 and 15"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := New(nil, Options{MaxAutoLockMinutes: tt.max}).validateAutoLockMinutes(tt.minutes)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("validateAutoLockMinutes(%d): %v", tt.minutes, err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("validateAutoLockMinutes(%d) error = %v, want %q", tt.minutes, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestAdminAutoLockAboveDefault creates and updates an admin with lock times
+// above the default 60 minutes once MaxAutoLockMinutes is raised.
+func TestAdminAutoLockAboveDefault(t *testing.T) {
+	svc, repo := testService(t, Options{MaxAutoLockMinutes: 120})
+	ctx := context.Background()
+
+	admin, err := svc.CreateAdmin(ctx, fmt.Sprintf("autolock%d", time.Now().UnixNano()), "Long-enough-1", "employee", 90)
+	if err != nil {
+		t.Fatalf("CreateAdmin: %v", err)
+	}
+	if admin.AutoLockMinutes != 90 {
+		t.Fatalf("auto lock = %d, want 90", admin.AutoLockMinutes)
+	}
+	if err := svc.UpdateAdminAutoLock(ctx, admin.AdminID, 120); err != nil {
+		t.Fatalf("UpdateAdminAutoLock(120): %v", err)
+	}
+	if err := svc.UpdateAdminAutoLock(ctx, admin.AdminID, 121); err == nil {
+		t.Fatalf("UpdateAdminAutoLock(121) was accepted")
+	}
+	stored, err := repo.GetAdminByID(ctx, admin.AdminID)
+	if err != nil {
+		t.Fatalf("GetAdminByID: %v", err)
+	}
+	if stored.AutoLockMinutes != 120 {
+		t.Fatalf("stored auto lock = %d, want 120", stored.AutoLockMinutes)
+	}
+
+	// The same values are out of range under the default max.
+	strict := New(repo, Options{})
+	if err := strict.UpdateAdminAutoLock(ctx, admin.AdminID, 90); err == nil {
+		t.Fatalf("90 minutes was accepted under the default max")
+	}
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"backend/internal/repository"
+
+	"golang.org/x/sync/errgroup"
+)
+
+const dashboardTimeout = 5 * time.Second
+
+type Dashboard struct {
+	Products     *int                         `json:"products"`
+	LowStock     *int                         `json:"low_stock"`
+	Invoices     *int                         `json:"invoices"`
+	Actions      *int                         `json:"actions"`
+	PriceAlarms  *int                         `json:"price_alarms"`
+	Unattributed *int                         `json:"unattributed"`
+	Inventory    *repository.InventorySummary `json:"inventory"`
+	Errors       map[string]string            `json:"errors,omitempty"`
+}
+
+// Dashboard gathers every badge count concurrently. A failing section is
+// reported in Errors and left null instead of failing the whole response.
+func (s *Service) Dashboard(ctx context.Context, lowStockThreshold int) Dashboard {
+	ctx, cancel := context.WithTimeout(ctx, dashboardTimeout)
+	defer cancel()
+
+	var (
+		result Dashboard
+		mu     sync.Mutex
+		group  errgroup.Group
+	)
+	fail := func(section string, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if result.Errors == nil {
+			result.Errors = make(map[string]string)
+		}
+		result.Errors[section] = err.Error()
+	}
+	count := func(section string, target **int, load func(context.Context) (int, error)) {
+		group.Go(func() error {
+			value, err := load(ctx)
+			if err != nil {
+				fail(section, err)
+				return nil
+			}
+			*target = &value
+			return nil
+		})
+	}
+
+	count("products", &result.Products, s.repo.CountProducts)
+	count("low_stock", &result.LowStock, func(ctx context.Context) (int, error) {
+		threshold, err := s.lowStockThreshold(ctx, lowStockThreshold)
+		if err != nil {
+			return 0, err
+		}
+		return s.repo.CountLowStock(ctx, threshold)
+	})
+	count("invoices", &result.Invoices, func(ctx context.Context) (int, error) {
+		total, _, err := s.repo.GetInvoiceStats(ctx, "")
+		return total, err
+	})
+	count("actions", &result.Actions, func(ctx context.Context) (int, error) {
+		return s.repo.CountActions(ctx, repository.ActionFilter{})
+	})
+	count("price_alarms", &result.PriceAlarms, func(ctx context.Context) (int, error) {
+		percent, err := s.repo.GetSellPriceAlarmPercent(ctx)
+		if err != nil {
+			return 0, err
+		}
+		return s.repo.CountPriceAlarms(ctx, percent)
+	})
+	count("unattributed", &result.Unattributed, s.repo.CountUnattributedProducts)
+	group.Go(func() error {
+		summary, err := s.repo.GetInventorySummary(ctx)
+		if err != nil {
+			fail("inventory", err)
+			return nil
+		}
+		result.Inventory = &summary
+		return nil
+	})
+
+	_ = group.Wait()
+	return result
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"backend/internal/repository"
+)
+
+func TestDashboard(t *testing.T) {
+	svc, repo := testService(t, Options{})
+	ctx := context.Background()
+
+	if _, err := repo.CreateProduct(ctx, repository.ProductCreateInput{
+		ProductName: fmt.Sprintf("dashboard test %d", time.Now().UnixNano()),
+		Quantity:    0,
+		AvgBuyPrice: 10,
+	}); err != nil {
+		t.Fatalf("create product: %v", err)
+	}
+
+	dashboard := svc.Dashboard(ctx, 5)
+	if dashboard.Errors != nil {
+		t.Fatalf("dashboard errors: %v", dashboard.Errors)
+	}
+	counts := map[string]*int{
+		"products":     dashboard.Products,
+		"low_stock":    dashboard.LowStock,
+		"invoices":     dashboard.Invoices,
+		"actions":      dashboard.Actions,
+		"price_alarms": dashboard.PriceAlarms,
+		"unattributed": dashboard.Unattributed,
+	}
+	for section, value := range counts {
+		if value == nil {
+			t.Errorf("%s is missing", section)
+		}
+	}
+	if dashboard.Inventory == nil {
+		t.Fatalf("inventory is missing")
+	}
+	// The product above is out of stock, so both counts include it.
+	if *dashboard.Products < 1 || *dashboard.LowStock < 1 {
+		t.Fatalf("products/low_stock = %d/%d, want at least 1", *dashboard.Products, *dashboard.LowStock)
+	}
+}
+
+// TestDashboardReportsFailedSections cancels the context up front so every
+// query fails; each section must be reported instead of failing the call.
+func TestDashboardReportsFailedSections(t *testing.T) {
+	svc, _ := testService(t, Options{})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	dashboard := svc.Dashboard(ctx, 5)
+	sections := make([]string, 0, len(dashboard.Errors))
+	for section := range dashboard.Errors {
+		sections = append(sections, section)
+	}
+	sort.Strings(sections)
+	want := []string{"actions", "inventory", "invoices", "low_stock", "price_alarms", "products", "unattributed"}
+	if fmt.Sprint(sections) != fmt.Sprint(want) {
+		t.Fatalf("failed sections = %v, want %v", sections, want)
+	}
+	if dashboard.Products != nil || dashboard.Inventory != nil {
+		t.Fatalf("failed sections should stay null: %+v", dashboard)
+	}
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"backend/internal/repository"
+)
+
+// TestLowStockDefault runs on an empty schema because low_stock_default is a
+// global setting. A product with its own alarm ignores the default.
+func TestLowStockDefault(t *testing.T) {
+	svc, pool := testEmptyService(t, Options{})
+	repo := repository.New(pool)
+	ctx := context.Background()
+
+	alarm := 10
+	for _, input := range []repository.ProductCreateInput{
+		{ProductName: "a", Quantity: 3},
+		{ProductName: "b", Quantity: 7},
+		{ProductName: "c", Quantity: 8, Alarm: &alarm},
+		{ProductName: "d", Quantity: 50},
+	} {
+		if _, err := repo.CreateProduct(ctx, input); err != nil {
+			t.Fatalf("create %s: %v", input.ProductName, err)
+		}
+	}
+
+	check := func(step string, threshold int, wantReport, wantList []string) {
+		t.Helper()
+		rows, err := svc.LowStock(ctx, threshold)
+		if err != nil {
+			t.Fatalf("%s: LowStock: %v", step, err)
+		}
+		report := make([]string, 0, len(rows))
+		for _, row := range rows {
+			report = append(report, row.ProductName)
+		}
+		items, _, err := svc.ListProducts(ctx, repository.ProductListFilter{Threshold: &threshold, Sort: repository.ProductSort{Key: "name"}})
+		if err != nil {
+			t.Fatalf("%s: ListProducts: %v", step, err)
+		}
+		list := make([]string, 0, len(items))
+		for _, item := range items {
+			list = append(list, item.ProductName)
+		}
+		if !reflect.DeepEqual(report, wantReport) || !reflect.DeepEqual(list, wantList) {
+			t.Fatalf("%s: report %v, list %v; want %v, %v", step, report, list, wantReport, wantList)
+		}
+	}
+
+	if value, err := svc.GetLowStockDefault(ctx); err != nil || value != 5 {
+		t.Fatalf("GetLowStockDefault = %d, %v; want the built-in 5", value, err)
+	}
+	// The report sorts by how much is needed; the list is sorted by name.
+	check("built-in default", 0, []string{"a", "c"}, []string{"a", "c"})
+
+	if value, err := svc.SetLowStockDefault(ctx, 8); err != nil || value != 8 {
+		t.Fatalf("SetLowStockDefault = %d, %v; want 8", value, err)
+	}
+	check("stored default", 0, []string{"a", "c", "b"}, []string{"a", "b", "c"})
+	check("explicit threshold overrides the stored default", 4, []string{"c", "a"}, []string{"a", "c"})
+
+	if _, err := svc.SetLowStockDefault(ctx, 0); err == nil {
+		t.Fatal("SetLowStockDefault(0) succeeded, want a range error")
+	}
+	if value, err := svc.GetLowStockDefault(ctx); err != nil || value != 8 {
+		t.Fatalf("GetLowStockDefault after a rejected update = %d, %v; want 8", value, err)
+	}
+}
@@ -0,0 +1,35 @@
+package service
+
+import (
+	"context"
+
+	"backend/internal/db"
+)
+
+// PendingMigrations lists embedded migrations missing from schema_migrations.
+func (s *Service) PendingMigrations(ctx context.Context) ([]string, error) {
+	versions, err := db.MigrationVersions()
+	if err != nil {
+		return nil, err
+	}
+	applied, err := s.repo.ListAppliedMigrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return pendingVersions(versions, applied), nil
+}
+
+// pendingVersions returns the versions missing from applied, in their order.
+func pendingVersions(versions, applied []string) []string {
+	appliedSet := make(map[string]struct{}, len(applied))
+	for _, version := range applied {
+		appliedSet[version] = struct{}{}
+	}
+	pending := make([]string, 0)
+	for _, version := range versions {
+		if _, ok := appliedSet[version]; !ok {
+			pending = append(pending, version)
+		}
+	}
+	return pending
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"backend/internal/db"
+)
+
+func TestPendingVersions(t *testing.T) {
+	versions := []string{"001_a.sql", "002_b.sql", "003_c.sql"}
+	tests := []struct {
+		name    string
+		applied []string
+		want    []string
+	}{
+		{name: "all applied", applied: versions, want: []string{}},
+		{name: "latest missing", applied: versions[:2], want: []string{"003_c.sql"}},
+		{name: "gap", applied: []string{"001_a.sql", "003_c.sql"}, want: []string{"002_b.sql"}},
+		{name: "fresh database", applied: nil, want: versions},
+		{name: "unknown applied versions are ignored", applied: append([]string{"000_old.sql"}, versions...), want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pendingVersions(versions, tt.applied); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("pendingVersions = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestPendingMigrations migrates a fresh schema, then forgets the latest
+// migration so it shows up as pending. The schema keeps the edit away from
+// other tests sharing the database.
+func TestPendingMigrations(t *testing.T) {
+	svc, pool := testEmptyService(t, Options{})
+	ctx := context.Background()
+
+	pending, err := svc.PendingMigrations(ctx)
+	if err != nil {
+		t.Fatalf("PendingMigrations: %v", err)
+	}
+	if len(pending) != 0 {
+		t.Fatalf("pending after migrating = %v, want none", pending)
+	}
+
+	versions, err := db.MigrationVersions()
+	if err != nil {
+		t.Fatalf("MigrationVersions: %v", err)
+	}
+	latest := versions[len(versions)-1]
+	if _, err := pool.Exec(ctx, "DELETE FROM schema_migrations WHERE version = $1", latest); err != nil {
+		t.Fatalf("forget %s: %v", latest, err)
+	}
+	pending, err = svc.PendingMigrations(ctx)
+	if err != nil {
+		t.Fatalf("PendingMigrations: %v", err)
+	}
+	if want := []string{latest}; !reflect.DeepEqual(pending, want) {
+		t.Fatalf("pending = %v, want %v", pending, want)
+	}
+}
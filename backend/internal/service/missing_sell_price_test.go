@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"backend/internal/repository"
+)
+
+// TestListMissingSellPrice runs on an empty schema so the total counts only
+// the products seeded here. A negative price counts as missing; a deleted
+// product does not.
+func TestListMissingSellPrice(t *testing.T) {
+	svc, pool := testEmptyService(t, Options{})
+	repo := repository.New(pool)
+	ctx := context.Background()
+
+	for _, input := range []repository.ProductCreateInput{
+		{ProductName: "priced", Quantity: 1, SellPrice: 10},
+		{ProductName: "unpriced a", Quantity: 1},
+		{ProductName: "unpriced b", Quantity: 1},
+		{ProductName: "negative", Quantity: 1, SellPrice: 5},
+		{ProductName: "deleted", Quantity: 1},
+	} {
+		if _, err := repo.CreateProduct(ctx, input); err != nil {
+			t.Fatalf("create %s: %v", input.ProductName, err)
+		}
+	}
+	if _, err := pool.Exec(ctx, "UPDATE products SET sell_price = -1 WHERE product_name = 'negative'"); err != nil {
+		t.Fatalf("set negative price: %v", err)
+	}
+	if _, err := pool.Exec(ctx, "UPDATE products SET deleted_at = NOW() WHERE product_name = 'deleted'"); err != nil {
+		t.Fatalf("delete product: %v", err)
+	}
+
+	tests := []struct {
+		limit, offset int
+		want          []string
+		wantMore      bool
+	}{
+		{limit: 2, offset: 0, want: []string{"negative", "unpriced a"}, wantMore: true},
+		{limit: 2, offset: 2, want: []string{"unpriced b"}},
+		{limit: 10, offset: 0, want: []string{"negative", "unpriced a", "unpriced b"}},
+	}
+	for _, tt := range tests {
+		items, hasMore, total, err := svc.ListMissingSellPrice(ctx, tt.limit, tt.offset)
+		if err != nil {
+			t.Fatalf("limit %d offset %d: %v", tt.limit, tt.offset, err)
+		}
+		names := make([]string, 0, len(items))
+		for _, item := range items {
+			names = append(names, item.ProductName)
+		}
+		if !reflect.DeepEqual(names, tt.want) || hasMore != tt.wantMore || total != 3 {
+			t.Fatalf("limit %d offset %d = %v, more %v, total %d; want %v, more %v, total 3",
+				tt.limit, tt.offset, names, hasMore, total, tt.want, tt.wantMore)
+		}
+	}
+}
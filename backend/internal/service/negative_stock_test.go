@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/internal/domain"
+	"backend/internal/repository"
+)
+
+// TestSalesInvoiceNegativeStock sells from a stock of 3 in strict mode, with
+// the per-request force flag and with AllowNegativeStock set.
+func TestSalesInvoiceNegativeStock(t *testing.T) {
+	tests := []struct {
+		name          string
+		allowNegative bool
+		force         bool
+		sold          float64
+		wantErr       bool
+		wantLeft      float64
+	}{
+		{name: "whole stock", sold: 3, wantLeft: 0},
+		{name: "strict rejects overselling", sold: 5, wantErr: true, wantLeft: 3},
+		{name: "force allows overselling", force: true, sold: 5, wantLeft: -2},
+		{name: "option allows overselling", allowNegative: true, sold: 5, wantLeft: -2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, repo := testService(t, Options{AllowNegativeStock: tt.allowNegative})
+			ctx := context.Background()
+			product, err := repo.CreateProduct(ctx, repository.ProductCreateInput{
+				ProductName: fmt.Sprintf("negative stock %d", time.Now().UnixNano()),
+				Quantity:    3,
+				AvgBuyPrice: 10,
+			})
+			if err != nil {
+				t.Fatalf("create product: %v", err)
+			}
+
+			_, err = svc.CreateSalesInvoice(ctx, nil, nil, nil, "sales",
+				[]domain.SalesLineInput{{ProductName: product.ProductName, Price: 20, Quantity: tt.sold}},
+				repository.InvoiceAdjustments{}, "", tt.force)
+			if tt.wantErr {
+				if !errors.Is(err, repository.ErrInsufficientStock) {
+					t.Fatalf("CreateSalesInvoice error = %v, want ErrInsufficientStock", err)
+				}
+				// The message names the product and what is available.
+				if !strings.Contains(err.Error(), product.ProductName) || !strings.Contains(err.Error(), "available 3") {
+					t.Fatalf("error %q should name the product and the available quantity", err)
+				}
+			} else if err != nil {
+				t.Fatalf("CreateSalesInvoice: %v", err)
+			}
+
+			got, err := repo.GetProductByID(ctx, product.ID)
+			if err != nil {
+				t.Fatalf("get product: %v", err)
+			}
+			if got.Quantity != tt.wantLeft {
+				t.Fatalf("quantity = %v, want %v", got.Quantity, tt.wantLeft)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+const defaultPasswordMinLength = 8
+
+func (s *Service) validatePassword(username, password string) error {
+	failed := make([]string, 0, 4)
+	if len([]rune(password)) < s.opts.PasswordMinLength {
+		failed = append(failed, fmt.Sprintf("must be at least %d characters", s.opts.PasswordMinLength))
+	}
+	hasLetter := false
+	hasDigit := false
+	for _, ch := range password {
+		switch {
+		case unicode.IsLetter(ch):
+			hasLetter = true
+		case unicode.IsDigit(ch):
+			hasDigit = true
+		}
+	}
+	if !hasLetter {
+		failed = append(failed, "must contain at least one letter")
+	}
+	if !hasDigit {
+		failed = append(failed, "must contain at least one digit")
+	}
+	name := strings.ToLower(strings.TrimSpace(username))
+	if name != "" && strings.Contains(strings.ToLower(password), name) {
+		failed = append(failed, "must not contain the username")
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("password %s", strings.Join(failed, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestValidatePassword(t *testing.T) {
+	svc := New(nil, Options{PasswordMinLength: 8})
+	tests := []struct {
+		name     string
+		username string
+		password string
+		// wantErr lists the rule messages the error must contain; empty means
+		// the password is accepted.
+		wantErr []string
+	}{
+		{name: "valid", username: "reza", password: "secret123"},
+		{name: "persian letters count as letters", username: "reza", password: "رمزعبور12"},
+		{name: "too short", username: "reza", password: "abc123", wantErr: []string{"at least 8 characters"}},
+		{name: "length counts runes", username: "reza", password: "رمز۱۲۳", wantErr: []string{"at least 8 characters"}},
+		{name: "no digit", username: "reza", password: "onlyletters", wantErr: []string{"at least one digit"}},
+		{name: "no letter", username: "reza", password: "12345678", wantErr: []string{"at least one letter"}},
+		{name: "contains username", username: "reza", password: "myREZA2024", wantErr: []string{"must not contain the username"}},
+		{name: "blank username is ignored", username: "  ", password: "secret123"},
+		{
+			name:     "every failure is reported",
+			username: "abc",
+			password: "abc",
+			wantErr:  []string{"at least 8 characters", "at least one digit", "must not contain the username"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := svc.validatePassword(tt.username, tt.password)
+			if len(tt.wantErr) == 0 {
+				if err != nil {
+					t.Fatalf("validatePassword(%q, %q) = %v, want nil", tt.username, tt.password, err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("validatePassword(%q, %q) = nil, want error", tt.username, tt.password)
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q does not mention %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestValidatePasswordDefaultMinLength(t *testing.T) {
+	svc := New(nil, Options{})
+	if err := svc.validatePassword("", "abc1234"); err == nil {
+		t.Fatal("7 characters accepted with the default minimum of 8")
+	}
+	if err := svc.validatePassword("", "abc12345"); err != nil {
+		t.Fatalf("8 characters rejected: %v", err)
+	}
+}
+
+func TestChangeOwnPasswordRequiresFields(t *testing.T) {
+	svc := New(nil, Options{})
+	tests := []struct {
+		name                    string
+		username, current, next string
+		wantErr                 string
+	}{
+		{name: "no username", username: " ", current: "old12345", next: "new12345", wantErr: "admin username is required"},
+		{name: "no current password", username: "reza", next: "new12345", wantErr: "current_password and new_password are required"},
+		{name: "blank new password", username: "reza", current: "old12345", next: "  ", wantErr: "current_password and new_password are required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := svc.ChangeOwnPassword(context.Background(), tt.username, tt.current, tt.next)
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
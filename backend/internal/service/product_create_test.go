@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"backend/internal/repository"
+)
+
+func TestCreateProductRequiresSellPrice(t *testing.T) {
+	svc := New(nil, Options{RequireSellPrice: true})
+	for _, price := range []float64{0, -5} {
+		_, err := svc.CreateProduct(context.Background(), repository.ProductCreateInput{ProductName: "unpriced", SellPrice: price})
+		if err == nil || err.Error() != "sell_price is required" {
+			t.Errorf("sell price %v: error = %v, want sell_price is required", price, err)
+		}
+	}
+}
+
+func TestCreateProductSellPriceModes(t *testing.T) {
+	tests := []struct {
+		name      string
+		require   bool
+		sellPrice float64
+		wantErr   bool
+	}{
+		{name: "relaxed without price", sellPrice: 0},
+		{name: "relaxed with price", sellPrice: 10},
+		{name: "enforced without price", require: true, sellPrice: 0, wantErr: true},
+		{name: "enforced with price", require: true, sellPrice: 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, repo := testService(t, Options{RequireSellPrice: tt.require})
+			ctx := context.Background()
+			name := fmt.Sprintf("sell price mode %d", time.Now().UnixNano())
+			product, err := svc.CreateProduct(ctx, repository.ProductCreateInput{ProductName: name, SellPrice: tt.sellPrice})
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("CreateProduct accepted sell price %v", tt.sellPrice)
+				}
+				items, _, err := repo.ListProducts(ctx, repository.ProductListFilter{Search: name})
+				if err != nil {
+					t.Fatalf("ListProducts: %v", err)
+				}
+				if len(items) != 0 {
+					t.Fatalf("rejected product %q was stored", name)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CreateProduct: %v", err)
+			}
+			if product.SellPrice != tt.sellPrice {
+				t.Fatalf("sell price = %v, want %v", product.SellPrice, tt.sellPrice)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"backend/internal/domain"
+	"backend/internal/matching"
+	"backend/internal/repository"
+)
+
+const (
+	defaultProductSearchThreshold = 80.0
+	// maxFuzzySearchCandidates bounds the products scored per query so a
+	// very large catalog cannot turn one search into a full scan.
+	maxFuzzySearchCandidates = 20000
+)
+
+// searchProductsFuzzy ranks products by name similarity to filter.Search and
+// pages through the hits, best first. The other list filters still apply;
+// Sort is ignored because results are ordered by score.
+func (s *Service) searchProductsFuzzy(
+	ctx context.Context,
+	filter repository.ProductListFilter,
+) ([]domain.Product, bool, error) {
+	threshold := filter.FuzzyThreshold
+	if threshold == 0 {
+		threshold = defaultProductSearchThreshold
+	}
+	if threshold < 0 || threshold > 100 {
+		return nil, false, fmt.Errorf("threshold must be between 0 and 100")
+	}
+
+	products, err := s.repo.ListAllProducts(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	candidates := make([]domain.Product, 0, len(products))
+	for _, product := range products {
+		if len(candidates) == maxFuzzySearchCandidates {
+			break
+		}
+		if productMatchesFilter(product, filter) {
+			candidates = append(candidates, product)
+		}
+	}
+	names := make([]string, len(candidates))
+	for i, product := range candidates {
+		names[i] = product.ProductName
+	}
+
+	matches := matching.NewMatcher(names).Rank(filter.Search, threshold)
+	limit, offset := repository.NormalizePage(filter.Limit, filter.Offset)
+	if offset >= len(matches) {
+		return []domain.Product{}, false, nil
+	}
+	end := min(offset+limit, len(matches))
+	items := make([]domain.Product, 0, end-offset)
+	for _, match := range matches[offset:end] {
+		product := candidates[match.Index]
+		score := match.Score
+		product.MatchScore = &score
+		items = append(items, product)
+	}
+	return items, end < len(matches), nil
+}
+
+// productMatchesFilter applies the non-search ProductListFilter conditions
+// in memory, mirroring the SQL in ListProducts.
+func productMatchesFilter(product domain.Product, filter repository.ProductListFilter) bool {
+	if filter.Threshold != nil {
+		alarm := *filter.Threshold
+		if product.Alarm != nil {
+			alarm = *product.Alarm
+		}
+		if product.Quantity > float64(alarm) {
+			return false
+		}
+	}
+	if filter.CategoryID != nil && (product.CategoryID == nil || *product.CategoryID != *filter.CategoryID) {
+		return false
+	}
+	if filter.MinSell != nil && product.SellPrice < *filter.MinSell {
+		return false
+	}
+	if filter.MaxSell != nil && product.SellPrice > *filter.MaxSell {
+		return false
+	}
+	if source := strings.TrimSpace(filter.Source); source != "" {
+		if product.Source == nil || !strings.EqualFold(*product.Source, source) {
+			return false
+		}
+	}
+	return true
+}
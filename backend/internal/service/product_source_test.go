@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"backend/internal/repository"
+)
+
+func TestBulkSetProductSourceValidation(t *testing.T) {
+	svc := New(nil, Options{})
+	source := "supplier"
+	tests := []struct {
+		name    string
+		ids     []int64
+		search  string
+		wantErr string
+	}{
+		{name: "no selector", wantErr: "ids or search is required"},
+		{name: "blank search", search: "   ", wantErr: "ids or search is required"},
+		{name: "empty ids", ids: []int64{}, wantErr: "ids or search is required"},
+		{name: "zero id", ids: []int64{3, 0}, wantErr: "invalid product id: 0"},
+		{name: "negative id with search", ids: []int64{-2}, search: "a", wantErr: "invalid product id: -2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.BulkSetProductSource(context.Background(), tt.ids, tt.search, &source)
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("BulkSetProductSource error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBulkSetProductSource(t *testing.T) {
+	svc, repo := testService(t, Options{})
+	ctx := context.Background()
+
+	suffix := time.Now().UnixNano()
+	names := []string{
+		fmt.Sprintf("source test %d alpha", suffix),
+		fmt.Sprintf("source test %d beta", suffix),
+		fmt.Sprintf("source test %d gamma", suffix),
+		fmt.Sprintf("source test %d gamma merged", suffix),
+	}
+	ids := make([]int64, len(names))
+	for i, name := range names {
+		product, err := repo.CreateProduct(ctx, repository.ProductCreateInput{ProductName: name, Quantity: 1})
+		if err != nil {
+			t.Fatalf("create product %q: %v", name, err)
+		}
+		ids[i] = product.ID
+	}
+	// Merged products are soft-deleted and must be left alone.
+	if _, _, err := repo.MergeProducts(ctx, ids[2], ids[3:]); err != nil {
+		t.Fatalf("merge products: %v", err)
+	}
+
+	sourceOf := func(id int64) string {
+		t.Helper()
+		product, err := repo.GetProductByID(ctx, id)
+		if err != nil {
+			t.Fatalf("get product %d: %v", id, err)
+		}
+		if product.Source == nil {
+			return ""
+		}
+		return *product.Source
+	}
+
+	steps := []struct {
+		name   string
+		ids    []int64
+		search string
+		source string
+		want   int
+		// wantSources lists the source of names[0..2] after the step.
+		wantSources []string
+	}{
+		{name: "by ids", ids: ids[:2], source: "by id", want: 2, wantSources: []string{"by id", "by id", ""}},
+		{name: "by search", search: fmt.Sprintf("%d GAMMA", suffix), source: "by search", want: 1, wantSources: []string{"by id", "by id", "by search"}},
+		{name: "ids and search both apply", ids: ids[1:3], search: "beta", source: "both", want: 1, wantSources: []string{"by id", "both", "by search"}},
+		{name: "merged id is skipped", ids: ids[3:], source: "merged", want: 0, wantSources: []string{"by id", "both", "by search"}},
+		{name: "blank source clears", ids: ids[:1], source: "  ", want: 1, wantSources: []string{"", "both", "by search"}},
+	}
+	for _, step := range steps {
+		source := step.source
+		updated, err := svc.BulkSetProductSource(ctx, step.ids, step.search, &source)
+		if err != nil {
+			t.Fatalf("%s: BulkSetProductSource: %v", step.name, err)
+		}
+		if updated != step.want {
+			t.Fatalf("%s: updated %d, want %d", step.name, updated, step.want)
+		}
+		for i, want := range step.wantSources {
+			if got := sourceOf(ids[i]); got != want {
+				t.Fatalf("%s: %q source = %q, want %q", step.name, names[i], got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+
+	"backend/internal/domain"
+	"backend/internal/repository"
+)
+
+// FractionalQuantity reports whether decimal quantities are accepted.
+func (s *Service) FractionalQuantity() bool {
+	return s.opts.FractionalQuantity
+}
+
+// DecimalQuantityProducts returns the lower-cased names of products that
+// accept decimal quantities while FractionalQuantity is off: those sold by
+// weight.
+func (s *Service) DecimalQuantityProducts(ctx context.Context) (map[string]bool, error) {
+	return s.repo.WeightProductNames(ctx)
+}
+
+func (s *Service) validateQuantity(productName string, quantity float64) error {
+	if s.opts.FractionalQuantity || quantity == math.Trunc(quantity) {
+		return nil
+	}
+	name := strings.TrimSpace(productName)
+	if name == "" {
+		return fmt.Errorf("quantity must be a whole number unless the product unit is weight")
+	}
+	return fmt.Errorf("quantity for %q must be a whole number unless its unit is weight", name)
+}
+
+// validateUnitQuantity is validateQuantity for a product whose unit is
+// known; weight products always accept decimals.
+func (s *Service) validateUnitQuantity(unit, productName string, quantity float64) error {
+	if unit == repository.ProductUnitWeight {
+		return nil
+	}
+	return s.validateQuantity(productName, quantity)
+}
+
+type namedQuantity struct {
+	name     string
+	quantity float64
+}
+
+// validateNamedQuantities rejects decimal quantities unless fractional
+// quantities are enabled or the named product is sold by weight. Units are
+// only looked up when some quantity is actually fractional.
+func (s *Service) validateNamedQuantities(ctx context.Context, items []namedQuantity) error {
+	if s.opts.FractionalQuantity {
+		return nil
+	}
+	fractional := make([]string, 0)
+	for _, item := range items {
+		if item.quantity != math.Trunc(item.quantity) {
+			fractional = append(fractional, item.name)
+		}
+	}
+	if len(fractional) == 0 {
+		return nil
+	}
+	units, err := s.repo.ProductUnits(ctx, fractional)
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		unit := units[strings.ToLower(strings.TrimSpace(item.name))]
+		if err := s.validateUnitQuantity(unit, item.name, item.quantity); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Service) validateInventoryRowQuantities(ctx context.Context, rows []domain.InventoryImportRow) error {
+	items := make([]namedQuantity, 0, len(rows))
+	for _, row := range rows {
+		items = append(items, namedQuantity{name: row.ProductName, quantity: row.Quantity})
+	}
+	return s.validateNamedQuantities(ctx, items)
+}
+
+// productUnit returns the stored unit of a product, for validating a
+// quantity change that does not carry the unit itself.
+func (s *Service) productUnit(ctx context.Context, id int64) (string, error) {
+	product, err := s.repo.GetProductByID(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	return product.Unit, nil
+}
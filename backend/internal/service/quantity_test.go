@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"backend/internal/domain"
+	"backend/internal/repository"
+)
+
+func TestValidateUnitQuantity(t *testing.T) {
+	tests := []struct {
+		name       string
+		fractional bool
+		unit       string
+		product    string
+		quantity   float64
+		wantErr    string
+	}{
+		{name: "whole piece", unit: repository.ProductUnitPiece, product: "Pen", quantity: 2},
+		{name: "decimal piece", unit: repository.ProductUnitPiece, product: "Pen", quantity: 2.5, wantErr: `quantity for "Pen" must be a whole number unless its unit is weight`},
+		{name: "decimal without a name", unit: repository.ProductUnitPiece, product: " ", quantity: 0.5, wantErr: "quantity must be a whole number unless the product unit is weight"},
+		{name: "unknown unit is held to whole units", product: "Pen", quantity: 1.5, wantErr: `quantity for "Pen" must be a whole number unless its unit is weight`},
+		{name: "decimal by weight", unit: repository.ProductUnitWeight, product: "Rice", quantity: 2.5},
+		{name: "decimal piece in fractional mode", fractional: true, unit: repository.ProductUnitPiece, product: "Cable", quantity: 2.5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(nil, Options{FractionalQuantity: tt.fractional})
+			err := s.validateUnitQuantity(tt.unit, tt.product, tt.quantity)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("validateUnitQuantity: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("validateUnitQuantity error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestSaleQuantities sells whole and decimal quantities from a stock of 10
+// with fractional quantities off and on.
+func TestSaleQuantities(t *testing.T) {
+	tests := []struct {
+		name       string
+		fractional bool
+		unit       string
+		sold       float64
+		wantErr    bool
+		wantLeft   float64
+	}{
+		{name: "whole units", unit: repository.ProductUnitPiece, sold: 2, wantLeft: 8},
+		{name: "decimal rejected for pieces", unit: repository.ProductUnitPiece, sold: 2.5, wantErr: true, wantLeft: 10},
+		{name: "decimal by weight", unit: repository.ProductUnitWeight, sold: 2.5, wantLeft: 7.5},
+		{name: "decimal in fractional mode", fractional: true, unit: repository.ProductUnitPiece, sold: 2.5, wantLeft: 7.5},
+		{name: "three decimal places", fractional: true, unit: repository.ProductUnitPiece, sold: 0.125, wantLeft: 9.875},
+		{name: "whole units in fractional mode", fractional: true, unit: repository.ProductUnitPiece, sold: 3, wantLeft: 7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, repo := testService(t, Options{FractionalQuantity: tt.fractional})
+			ctx := context.Background()
+			product, err := svc.CreateProduct(ctx, repository.ProductCreateInput{
+				ProductName: fmt.Sprintf("sale quantity %d", time.Now().UnixNano()),
+				Quantity:    10,
+				AvgBuyPrice: 100,
+				SellPrice:   150,
+				Unit:        tt.unit,
+			})
+			if err != nil {
+				t.Fatalf("create product: %v", err)
+			}
+
+			_, err = svc.CreateSalesInvoice(ctx, nil, nil, nil, "sales",
+				[]domain.SalesLineInput{{ProductName: product.ProductName, Price: 150, Quantity: tt.sold}},
+				repository.InvoiceAdjustments{}, "", false)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CreateSalesInvoice error = %v, want error %v", err, tt.wantErr)
+			}
+
+			got, err := repo.GetProductByID(ctx, product.ID)
+			if err != nil {
+				t.Fatalf("get product: %v", err)
+			}
+			if got.Quantity != tt.wantLeft {
+				t.Fatalf("quantity = %v, want %v", got.Quantity, tt.wantLeft)
+			}
+		})
+	}
+}
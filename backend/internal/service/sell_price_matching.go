@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"backend/internal/domain"
+	"backend/internal/matching"
+)
+
+const defaultSellPriceMatchThreshold = 96.0
+
+// PreviewSellPriceMatches resolves each price row to its closest product
+// without writing anything, so the caller can review matches first.
+func (s *Service) PreviewSellPriceMatches(
+	ctx context.Context,
+	rows []domain.ProductPriceRow,
+	threshold *float64,
+) ([]domain.SellPriceMatchPreview, error) {
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("rows cannot be empty")
+	}
+	limit := defaultSellPriceMatchThreshold
+	if threshold != nil {
+		if *threshold <= 0 || *threshold > 100 {
+			return nil, fmt.Errorf("threshold must be between 0 and 100")
+		}
+		limit = *threshold
+	}
+
+	products, err := s.repo.ListAllProducts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(products))
+	for i, product := range products {
+		names[i] = product.ProductName
+	}
+	matcher := matching.NewMatcher(names)
+
+	result := make([]domain.SellPriceMatchPreview, 0, len(rows))
+	for index, row := range rows {
+		preview := domain.SellPriceMatchPreview{
+			Row:         index + 1,
+			ProductName: strings.TrimSpace(row.ProductName),
+			Price:       row.Price,
+			MatchType:   "none",
+		}
+		if match, ok := matcher.Best(row.ProductName, limit); ok {
+			product := products[match.Index]
+			preview.MatchedProductID = &product.ID
+			preview.MatchedProductName = &product.ProductName
+			preview.Score = match.Score
+			preview.MatchType = "fuzzy"
+			if match.Exact {
+				preview.MatchType = "exact"
+			}
+		}
+		result = append(result, preview)
+	}
+	return result, nil
+}
@@ -1,63 +1,257 @@
 package service
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
 	"backend/internal/domain"
+	"backend/internal/report"
 	"backend/internal/repository"
+
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+type Options struct {
+	PasswordMinLength  int
+	AllowNegativeStock bool
+	RequireSellPrice   bool
+	MaxAutoLockMinutes int
+	ReportFontPath     string
+	FractionalQuantity bool
+}
+
+const defaultMaxAutoLockMinutes = 60
+
 type Service struct {
 	repo *repository.Repository
+	opts Options
+}
+
+func New(repo *repository.Repository, opts Options) *Service {
+	if opts.PasswordMinLength <= 0 {
+		opts.PasswordMinLength = defaultPasswordMinLength
+	}
+	if opts.MaxAutoLockMinutes <= 0 {
+		opts.MaxAutoLockMinutes = defaultMaxAutoLockMinutes
+	}
+	return &Service{repo: repo, opts: opts}
 }
 
-func New(repo *repository.Repository) *Service {
-	return &Service{repo: repo}
+// DBPoolStat exposes the database pool counters for metrics.
+func (s *Service) DBPoolStat() *pgxpool.Stat {
+	return s.repo.PoolStat()
 }
 
-func (s *Service) ListProducts(ctx context.Context, search string, limit, offset int, threshold *int) ([]domain.Product, error) {
-	return s.repo.ListProducts(ctx, repository.ProductListFilter{
-		Search:    search,
-		Limit:     limit,
-		Offset:    offset,
-		Threshold: threshold,
+func (s *Service) ListProducts(ctx context.Context, filter repository.ProductListFilter) ([]domain.Product, bool, error) {
+	if filter.Threshold != nil {
+		threshold, err := s.lowStockThreshold(ctx, *filter.Threshold)
+		if err != nil {
+			return nil, false, err
+		}
+		filter.Threshold = &threshold
+	}
+	if filter.Fuzzy && strings.TrimSpace(filter.Search) != "" {
+		return s.searchProductsFuzzy(ctx, filter)
+	}
+	return s.repo.ListProducts(ctx, filter)
+}
+
+// ListMissingSellPrice pages through live products without a sell price, by
+// name, and returns how many there are in total.
+func (s *Service) ListMissingSellPrice(ctx context.Context, limit, offset int) ([]domain.Product, bool, int, error) {
+	items, hasMore, err := s.repo.ListProducts(ctx, repository.ProductListFilter{
+		Limit:            limit,
+		Offset:           offset,
+		Sort:             repository.ProductSort{Key: "name"},
+		MissingSellPrice: true,
 	})
+	if err != nil {
+		return nil, false, 0, err
+	}
+	total, err := s.repo.CountMissingSellPrice(ctx)
+	if err != nil {
+		return nil, false, 0, err
+	}
+	return items, hasMore, total, nil
 }
 
 func (s *Service) GetProduct(ctx context.Context, id int64) (*domain.Product, error) {
 	return s.repo.GetProductByID(ctx, id)
 }
 
+func (s *Service) ListProductAliases(ctx context.Context, productID int64) ([]domain.ProductAlias, error) {
+	return s.repo.ListProductAliases(ctx, productID)
+}
+
+func (s *Service) AddProductAlias(ctx context.Context, productID int64, alias string) ([]domain.ProductAlias, error) {
+	return s.repo.AddProductAlias(ctx, productID, alias)
+}
+
+func (s *Service) DeleteProductAlias(ctx context.Context, productID int64, alias string) ([]domain.ProductAlias, error) {
+	return s.repo.DeleteProductAlias(ctx, productID, alias)
+}
+
+func (s *Service) GetProductBySKU(ctx context.Context, sku string) (*domain.Product, error) {
+	return s.repo.GetProductBySKU(ctx, sku)
+}
+
 func (s *Service) CreateProduct(ctx context.Context, input repository.ProductCreateInput) (domain.Product, error) {
 	input.ProductName = strings.TrimSpace(input.ProductName)
 	if input.ProductName == "" {
 		return domain.Product{}, fmt.Errorf("product_name is required")
 	}
+	if s.opts.RequireSellPrice && input.SellPrice <= 0 {
+		return domain.Product{}, fmt.Errorf("sell_price is required")
+	}
+	unit, err := repository.NormalizeProductUnit(input.Unit)
+	if err != nil {
+		return domain.Product{}, err
+	}
+	if err := s.validateUnitQuantity(unit, input.ProductName, input.Quantity); err != nil {
+		return domain.Product{}, err
+	}
+	input.Unit = unit
 	return s.repo.CreateProduct(ctx, input)
 }
 
 func (s *Service) PatchProduct(ctx context.Context, id int64, input repository.ProductPatchInput) (*domain.Product, error) {
+	if input.Quantity != nil && s.validateQuantity("", *input.Quantity) != nil {
+		var unit string
+		if input.Unit != nil {
+			normalized, err := repository.NormalizeProductUnit(*input.Unit)
+			if err != nil {
+				return nil, err
+			}
+			unit = normalized
+		} else {
+			stored, err := s.productUnit(ctx, id)
+			if err != nil {
+				return nil, err
+			}
+			unit = stored
+		}
+		if err := s.validateUnitQuantity(unit, "", *input.Quantity); err != nil {
+			return nil, err
+		}
+	}
 	return s.repo.PatchProduct(ctx, id, input)
 }
 
+func (s *Service) AdjustProductQuantity(
+	ctx context.Context,
+	id int64,
+	delta float64,
+	reason string,
+	adminUsername *string,
+	force bool,
+) (*domain.Product, error) {
+	if delta == 0 {
+		return nil, fmt.Errorf("delta must not be zero")
+	}
+	if s.validateQuantity("", delta) != nil {
+		unit, err := s.productUnit(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.validateUnitQuantity(unit, "", delta); err != nil {
+			return nil, err
+		}
+	}
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, fmt.Errorf("reason is required")
+	}
+	return s.repo.AdjustProductQuantity(ctx, id, delta, reason, normalizeNullable(adminUsername), force)
+}
+
+func (s *Service) MergeProducts(
+	ctx context.Context,
+	targetID int64,
+	sourceIDs []int64,
+) (*domain.Product, int, error) {
+	if targetID <= 0 {
+		return nil, 0, fmt.Errorf("target_id must be a positive integer")
+	}
+	if len(sourceIDs) == 0 {
+		return nil, 0, fmt.Errorf("source_ids is required")
+	}
+	seen := make(map[int64]struct{}, len(sourceIDs))
+	unique := make([]int64, 0, len(sourceIDs))
+	for _, id := range sourceIDs {
+		if id <= 0 {
+			return nil, 0, fmt.Errorf("source_ids must be positive integers")
+		}
+		if id == targetID {
+			return nil, 0, fmt.Errorf("source_ids must not include target_id")
+		}
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		unique = append(unique, id)
+	}
+	return s.repo.MergeProducts(ctx, targetID, unique)
+}
+
 func (s *Service) DeleteProduct(ctx context.Context, id int64) error {
 	return s.repo.DeleteProduct(ctx, id)
 }
 
+func (s *Service) BulkSetProductSource(
+	ctx context.Context,
+	ids []int64,
+	search string,
+	source *string,
+) (int, error) {
+	cleanIDs := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		if id <= 0 {
+			return 0, fmt.Errorf("invalid product id: %d", id)
+		}
+		cleanIDs = append(cleanIDs, id)
+	}
+	if len(cleanIDs) == 0 && strings.TrimSpace(search) == "" {
+		return 0, fmt.Errorf("ids or search is required")
+	}
+	return s.repo.BulkSetProductSource(ctx, cleanIDs, search, normalizeNullable(source))
+}
+
 func (s *Service) ImportInventory(ctx context.Context, rows []domain.InventoryImportRow) (int, int, error) {
 	if len(rows) == 0 {
 		return 0, 0, fmt.Errorf("import file has no data rows")
 	}
+	if err := s.validateInventoryRowQuantities(ctx, rows); err != nil {
+		return 0, 0, err
+	}
 	return s.repo.UpsertInventoryRows(ctx, rows)
 }
 
+func (s *Service) ExportProducts(ctx context.Context, fn func(domain.Product) error) error {
+	return s.repo.StreamProducts(ctx, fn)
+}
+
+func (s *Service) PreviewInventoryImport(
+	ctx context.Context,
+	rows []domain.InventoryImportRow,
+) (domain.InventoryImportPreview, error) {
+	if len(rows) == 0 {
+		return domain.InventoryImportPreview{}, fmt.Errorf("import file has no data rows")
+	}
+	return s.repo.PreviewInventoryImport(ctx, rows)
+}
+
 func (s *Service) ReplaceInventory(ctx context.Context, rows []domain.InventoryImportRow) error {
 	if len(rows) == 0 {
 		return fmt.Errorf("inventory rows are required")
 	}
+	if err := s.validateInventoryRowQuantities(ctx, rows); err != nil {
+		return err
+	}
 	return s.repo.ReplaceInventory(ctx, rows)
 }
 
@@ -71,27 +265,78 @@ func (s *Service) SyncInventory(
 			"upserts or deletes are required",
 		)
 	}
+	if err := s.validateInventoryRowQuantities(ctx, upserts); err != nil {
+		return domain.InventorySyncResult{}, err
+	}
 	return s.repo.SyncInventory(ctx, upserts, deletes)
 }
 
 func (s *Service) ImportSellPrices(
 	ctx context.Context,
 	rows []domain.ProductPriceRow,
+	opts repository.SellPriceImportOptions,
 ) (domain.SellPriceImportResult, error) {
 	if len(rows) == 0 {
 		return domain.SellPriceImportResult{}, fmt.Errorf("price rows are required")
 	}
-	return s.repo.ImportSellPrices(ctx, rows)
+	if opts.FuzzyThreshold != nil && (*opts.FuzzyThreshold <= 0 || *opts.FuzzyThreshold > 100) {
+		return domain.SellPriceImportResult{}, fmt.Errorf("threshold must be between 0 and 100")
+	}
+	if opts.TokenThreshold != nil && (*opts.TokenThreshold <= 0 || *opts.TokenThreshold > 100) {
+		return domain.SellPriceImportResult{}, fmt.Errorf("token_threshold must be between 0 and 100")
+	}
+	return s.repo.ImportSellPrices(ctx, rows, opts)
 }
 
 func (s *Service) InventorySummary(ctx context.Context) (repository.InventorySummary, error) {
 	return s.repo.GetInventorySummary(ctx)
 }
 
+func (s *Service) RecordInventorySnapshot(ctx context.Context) (domain.InventorySnapshot, error) {
+	return s.repo.RecordInventorySnapshot(ctx)
+}
+
+func (s *Service) InventoryHistory(ctx context.Context, days int) ([]domain.InventorySnapshot, error) {
+	return s.repo.ListInventorySnapshots(ctx, days)
+}
+
 func (s *Service) LowStock(ctx context.Context, threshold int) ([]domain.LowStockRow, error) {
+	threshold, err := s.lowStockThreshold(ctx, threshold)
+	if err != nil {
+		return nil, err
+	}
 	return s.repo.GetLowStock(ctx, threshold)
 }
 
+// lowStockThreshold returns threshold, or the stored low_stock_default when
+// the caller did not pass one.
+func (s *Service) lowStockThreshold(ctx context.Context, threshold int) (int, error) {
+	if threshold > 0 {
+		return threshold, nil
+	}
+	return s.repo.GetLowStockDefault(ctx)
+}
+
+func (s *Service) GetLowStockDefault(ctx context.Context) (int, error) {
+	return s.repo.GetLowStockDefault(ctx)
+}
+
+func (s *Service) SetLowStockDefault(ctx context.Context, threshold int) (int, error) {
+	return s.repo.SetLowStockDefault(ctx, threshold)
+}
+
+func (s *Service) ListSettings(ctx context.Context) ([]domain.Setting, error) {
+	return s.repo.ListSettings(ctx)
+}
+
+func (s *Service) GetSetting(ctx context.Context, key string) (domain.Setting, error) {
+	return s.repo.GetSetting(ctx, key)
+}
+
+func (s *Service) SetSetting(ctx context.Context, key string, value float64) (domain.Setting, error) {
+	return s.repo.SetSetting(ctx, key, value)
+}
+
 func (s *Service) GetSellPriceAlarmPercent(ctx context.Context) (float64, error) {
 	return s.repo.GetSellPriceAlarmPercent(ctx)
 }
@@ -114,6 +359,38 @@ func (s *Service) SetSalesImportFuzzyMatchPercent(
 	return s.repo.SetSalesImportFuzzyMatchPercent(ctx, percent)
 }
 
+// ExportPriceAlarms streams the products below the configured sell price
+// margin to fn.
+func (s *Service) ExportPriceAlarms(ctx context.Context, fn func(domain.PriceAlarmRow) error) error {
+	percent, err := s.repo.GetSellPriceAlarmPercent(ctx)
+	if err != nil {
+		return err
+	}
+	return s.repo.StreamPriceAlarms(ctx, percent, fn)
+}
+
+func (s *Service) MarginAlerts(ctx context.Context, percent *float64) ([]domain.MarginAlertRow, error) {
+	if percent == nil {
+		value, err := s.repo.GetSellPriceAlarmPercent(ctx)
+		if err != nil {
+			return nil, err
+		}
+		percent = &value
+	}
+	if *percent < 0 || *percent > 100 {
+		return nil, fmt.Errorf("percent must be between 0 and 100")
+	}
+	return s.repo.GetMarginAlerts(ctx, *percent)
+}
+
+func (s *Service) ListCategories(ctx context.Context) ([]domain.Category, error) {
+	return s.repo.ListCategories(ctx)
+}
+
+func (s *Service) CreateCategory(ctx context.Context, name string) (domain.Category, error) {
+	return s.repo.CreateCategory(ctx, name)
+}
+
 func (s *Service) ListProductGroups(ctx context.Context) ([]domain.ProductGroup, error) {
 	return s.repo.ListProductGroups(ctx)
 }
@@ -142,50 +419,216 @@ func (s *Service) CreatePurchaseInvoice(
 	ctx context.Context,
 	invoiceName *string,
 	adminUsername *string,
+	supplierName *string,
 	lines []domain.PurchaseLineInput,
+	adjustments repository.InvoiceAdjustments,
+	status string,
 ) (int64, error) {
-	return s.repo.CreatePurchaseInvoice(ctx, normalizeNullable(invoiceName), normalizeNullable(adminUsername), lines)
+	items := make([]namedQuantity, 0, len(lines))
+	for _, line := range lines {
+		items = append(items, namedQuantity{name: line.ProductName, quantity: line.Quantity})
+	}
+	if err := s.validateNamedQuantities(ctx, items); err != nil {
+		return 0, err
+	}
+	return s.repo.CreatePurchaseInvoice(
+		ctx,
+		normalizeNullable(invoiceName),
+		normalizeNullable(adminUsername),
+		normalizeNullable(supplierName),
+		lines,
+		adjustments,
+		status,
+	)
 }
 
 func (s *Service) CreateSalesInvoice(
 	ctx context.Context,
 	invoiceName *string,
 	adminUsername *string,
+	customerName *string,
 	invoiceType string,
 	lines []domain.SalesLineInput,
+	adjustments repository.InvoiceAdjustments,
+	status string,
+	force bool,
 ) (int64, error) {
-	invoiceType = strings.TrimSpace(invoiceType)
+	items := make([]namedQuantity, 0, len(lines))
+	for _, line := range lines {
+		items = append(items, namedQuantity{name: line.ProductName, quantity: line.Quantity})
+	}
+	if err := s.validateNamedQuantities(ctx, items); err != nil {
+		return 0, err
+	}
+	invoiceType, err := normalizeSalesInvoiceType(invoiceType)
+	if err != nil {
+		return 0, err
+	}
+	return s.repo.CreateSalesInvoice(
+		ctx,
+		normalizeNullable(invoiceName),
+		normalizeNullable(adminUsername),
+		normalizeNullable(customerName),
+		invoiceType,
+		lines,
+		adjustments,
+		status,
+		s.opts.AllowNegativeStock || force,
+	)
+}
+
+// SalesInvoiceTypes are the invoice_type values accepted for sales invoices,
+// one per sales channel. Analytics select sales with LIKE 'sales%', so new
+// entries must keep the "sales" prefix.
+var SalesInvoiceTypes = []string{
+	"sales",
+	"sales_manual",
+	"sales_site",
+	"sales_basalam",
+	"sales_return",
+}
+
+// normalizeSalesInvoiceType defaults an empty type to "sales" and rejects
+// anything outside SalesInvoiceTypes, so typos cannot fall out of reports.
+func normalizeSalesInvoiceType(invoiceType string) (string, error) {
+	invoiceType = strings.ToLower(strings.TrimSpace(invoiceType))
 	if invoiceType == "" {
-		invoiceType = "sales"
+		return "sales", nil
+	}
+	if slices.Contains(SalesInvoiceTypes, invoiceType) {
+		return invoiceType, nil
 	}
-	return s.repo.CreateSalesInvoice(ctx, normalizeNullable(invoiceName), normalizeNullable(adminUsername), invoiceType, lines)
+	return "", fmt.Errorf(
+		"unknown invoice_type %q; expected one of: %s",
+		invoiceType,
+		strings.Join(SalesInvoiceTypes, ", "),
+	)
+}
+
+// normalizeBulkInvoiceType accepts "purchase" in any case and spacing and
+// otherwise defers to normalizeSalesInvoiceType.
+func normalizeBulkInvoiceType(invoiceType string) (string, error) {
+	if strings.EqualFold(strings.TrimSpace(invoiceType), "purchase") {
+		return "purchase", nil
+	}
+	return normalizeSalesInvoiceType(invoiceType)
 }
 
-func (s *Service) ListInvoices(
+// maxBulkInvoices caps one bulk create, which runs in a single transaction.
+const maxBulkInvoices = 500
+
+func (s *Service) CreateInvoicesBulk(
 	ctx context.Context,
-	invoiceType string,
-	from, to *time.Time,
-	limit, offset int,
-) ([]domain.Invoice, error) {
-	return s.repo.ListInvoices(ctx, repository.InvoiceListFilter{
-		InvoiceType: strings.TrimSpace(invoiceType),
-		From:        from,
-		To:          to,
-		Limit:       limit,
-		Offset:      offset,
-	})
+	inputs []repository.BulkInvoiceInput,
+	force bool,
+) ([]int64, error) {
+	if len(inputs) > maxBulkInvoices {
+		return nil, fmt.Errorf("at most %d invoices can be created at once", maxBulkInvoices)
+	}
+	for index := range inputs {
+		input := &inputs[index]
+		invoiceType, err := normalizeBulkInvoiceType(input.InvoiceType)
+		if err != nil {
+			return nil, &repository.BulkInvoiceError{Index: index, Err: err}
+		}
+		input.InvoiceType = invoiceType
+		input.InvoiceName = normalizeNullable(input.InvoiceName)
+		input.AdminUsername = normalizeNullable(input.AdminUsername)
+		input.SupplierName = normalizeNullable(input.SupplierName)
+		input.CustomerName = normalizeNullable(input.CustomerName)
+
+		items := make([]namedQuantity, 0, len(input.PurchaseLines)+len(input.SalesLines))
+		for _, line := range input.PurchaseLines {
+			items = append(items, namedQuantity{name: line.ProductName, quantity: line.Quantity})
+		}
+		for _, line := range input.SalesLines {
+			items = append(items, namedQuantity{name: line.ProductName, quantity: line.Quantity})
+		}
+		if err := s.validateNamedQuantities(ctx, items); err != nil {
+			return nil, &repository.BulkInvoiceError{Index: index, Err: err}
+		}
+	}
+	return s.repo.CreateInvoicesBulk(ctx, inputs, s.opts.AllowNegativeStock || force)
+}
+
+func (s *Service) DuplicateInvoiceNameIDs(ctx context.Context, invoiceName *string) ([]int64, error) {
+	name := normalizeNullable(invoiceName)
+	if name == nil {
+		return nil, nil
+	}
+	return s.repo.FindInvoiceIDsByName(ctx, *name)
+}
+
+func (s *Service) ListInvoices(ctx context.Context, filter repository.InvoiceListFilter) ([]domain.Invoice, bool, error) {
+	return s.repo.ListInvoices(ctx, normalizeInvoiceListFilter(filter))
+}
+
+func (s *Service) ListRecentInvoices(ctx context.Context, minutes, limit int) ([]domain.Invoice, error) {
+	if minutes <= 0 {
+		return nil, fmt.Errorf("minutes must be greater than zero")
+	}
+	return s.repo.ListRecentInvoices(ctx, minutes, limit)
 }
 
 func (s *Service) GetInvoice(ctx context.Context, id int64) (*domain.Invoice, error) {
 	return s.repo.GetInvoice(ctx, id)
 }
 
+func (s *Service) InvoicePDF(ctx context.Context, id int64) ([]byte, error) {
+	invoice, err := s.repo.GetInvoice(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	lines, err := s.repo.GetInvoiceLines(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := report.RenderInvoicePDF(&buf, *invoice, lines, report.Options{FontPath: s.opts.ReportFontPath}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
 func (s *Service) GetInvoiceLines(ctx context.Context, invoiceID int64) ([]domain.InvoiceLine, error) {
 	return s.repo.GetInvoiceLines(ctx, invoiceID)
 }
 
-func (s *Service) UpdateInvoiceName(ctx context.Context, id int64, invoiceName *string) error {
-	return s.repo.UpdateInvoiceName(ctx, id, normalizeNullable(invoiceName))
+// InvoiceProfit sums line_total - cost_price*quantity over the lines of a
+// sales invoice and subtracts the invoice discount. A sales return gives the
+// profit back, so its result is negated. Purchases have no profit, so it
+// returns false for them.
+func InvoiceProfit(invoice domain.Invoice, lines []domain.InvoiceLine) (float64, bool) {
+	if !strings.HasPrefix(invoice.InvoiceType, "sales") {
+		return 0, false
+	}
+	profit := 0.0
+	for _, line := range lines {
+		profit += line.LineTotal - line.CostPrice*line.Quantity
+	}
+	if invoice.DiscountAmount != nil {
+		profit -= *invoice.DiscountAmount
+	}
+	if invoice.InvoiceType == "sales_return" {
+		profit = -profit
+	}
+	return profit, true
+}
+
+func (s *Service) UpdateInvoiceName(
+	ctx context.Context,
+	id int64,
+	invoiceName *string,
+	supplierName *string,
+	customerName *string,
+) error {
+	return s.repo.UpdateInvoiceName(
+		ctx,
+		id,
+		normalizeNullable(invoiceName),
+		trimOptional(supplierName),
+		trimOptional(customerName),
+	)
 }
 
 func (s *Service) UpdateInvoiceLines(
@@ -194,34 +637,133 @@ func (s *Service) UpdateInvoiceLines(
 	invoiceName *string,
 	lines []domain.InvoiceLine,
 ) error {
-	return s.repo.UpdateInvoiceLinesReconciled(ctx, id, normalizeNullable(invoiceName), lines)
+	items := make([]namedQuantity, 0, len(lines))
+	for _, line := range lines {
+		items = append(items, namedQuantity{name: line.ProductName, quantity: line.Quantity})
+	}
+	if err := s.validateNamedQuantities(ctx, items); err != nil {
+		return err
+	}
+	return s.repo.UpdateInvoiceLinesReconciled(
+		ctx,
+		id,
+		normalizeNullable(invoiceName),
+		lines,
+		s.opts.AllowNegativeStock,
+	)
+}
+
+func (s *Service) UpdateInvoiceLine(
+	ctx context.Context,
+	invoiceID int64,
+	lineID int64,
+	patch repository.InvoiceLinePatch,
+) (domain.InvoiceLine, error) {
+	if patch.Price == nil && patch.Quantity == nil && patch.Discount == nil {
+		return domain.InvoiceLine{}, fmt.Errorf("price, quantity or discount is required")
+	}
+	if patch.Quantity != nil && s.validateQuantity("", *patch.Quantity) != nil {
+		lines, err := s.repo.GetInvoiceLines(ctx, invoiceID)
+		if err != nil {
+			return domain.InvoiceLine{}, err
+		}
+		for _, line := range lines {
+			if line.ID != lineID {
+				continue
+			}
+			err := s.validateNamedQuantities(ctx, []namedQuantity{{name: line.ProductName, quantity: *patch.Quantity}})
+			if err != nil {
+				return domain.InvoiceLine{}, err
+			}
+		}
+	}
+	return s.repo.UpdateInvoiceLineReconciled(ctx, invoiceID, lineID, patch, s.opts.AllowNegativeStock)
 }
 
 func (s *Service) DeleteInvoice(ctx context.Context, id int64) error {
 	return s.repo.DeleteInvoiceReconciled(ctx, id)
 }
 
+func (s *Service) FinalizeInvoice(ctx context.Context, id int64, force bool) error {
+	return s.repo.FinalizeInvoice(ctx, id, s.opts.AllowNegativeStock || force)
+}
+
+func (s *Service) VoidInvoice(ctx context.Context, id int64) error {
+	return s.repo.VoidInvoice(ctx, id)
+}
+
 func (s *Service) MonthlySummary(ctx context.Context, limit int) ([]domain.MonthlySummary, error) {
 	return s.repo.GetMonthlySummary(ctx, limit)
 }
 
+func (s *Service) DailySummary(ctx context.Context, days int) ([]domain.DailySummary, error) {
+	return s.repo.GetDailySummary(ctx, days)
+}
+
 func (s *Service) MonthlyQuantitySummary(ctx context.Context, limit int) ([]domain.MonthlyQuantitySummary, error) {
 	return s.repo.GetMonthlyQuantitySummary(ctx, limit)
 }
 
-func (s *Service) TopSoldProducts(ctx context.Context, days, limit int) ([]domain.TopSoldProduct, error) {
-	return s.repo.GetTopSoldProducts(ctx, days, limit)
+func (s *Service) ProductProfit(ctx context.Context, days, limit int) ([]domain.ProductProfit, error) {
+	return s.repo.GetProductProfit(ctx, days, limit)
+}
+
+func (s *Service) DeadStock(ctx context.Context, days, limit int, minValue float64) ([]domain.DeadStockRow, error) {
+	return s.repo.GetDeadStock(ctx, days, limit, minValue)
+}
+
+func (s *Service) ReorderSuggestions(
+	ctx context.Context,
+	opts repository.ReorderOptions,
+) ([]domain.ReorderSuggestion, error) {
+	return s.repo.GetReorderSuggestions(ctx, opts)
+}
+
+func (s *Service) SalesByAdmin(ctx context.Context, from, to *time.Time) ([]domain.AdminSalesStat, error) {
+	return s.repo.GetSalesByAdmin(ctx, from, to)
+}
+
+func (s *Service) TopSoldProducts(
+	ctx context.Context,
+	days, limit int,
+	from, to *time.Time,
+) ([]domain.TopSoldProduct, error) {
+	return s.repo.GetTopSoldProducts(ctx, days, limit, from, to)
+}
+
+func (s *Service) RevenuePareto(ctx context.Context, days int) ([]domain.RevenueParetoRow, error) {
+	return s.repo.GetRevenuePareto(ctx, days)
 }
 
 func (s *Service) UnsoldProducts(ctx context.Context, days, limit int) ([]domain.UnsoldProduct, error) {
 	return s.repo.GetUnsoldProducts(ctx, days, limit)
 }
 
+func (s *Service) StockReconciliation(ctx context.Context, limit int) ([]domain.StockReconciliationRow, error) {
+	return s.repo.GetStockReconciliation(ctx, limit)
+}
+
 func (s *Service) InvoiceStats(
 	ctx context.Context,
 	invoiceType string,
 ) (int, float64, error) {
-	return s.repo.GetInvoiceStats(ctx, strings.TrimSpace(invoiceType))
+	return s.repo.GetInvoiceStats(ctx, normalizeInvoiceTypeFilter(invoiceType))
+}
+
+func (s *Service) InvoiceStatsFiltered(ctx context.Context, filter repository.InvoiceListFilter) (int, float64, error) {
+	return s.repo.GetInvoiceStatsFiltered(ctx, normalizeInvoiceListFilter(filter))
+}
+
+func normalizeInvoiceTypeFilter(invoiceType string) string {
+	return strings.ToLower(strings.TrimSpace(invoiceType))
+}
+
+func normalizeInvoiceListFilter(filter repository.InvoiceListFilter) repository.InvoiceListFilter {
+	filter.InvoiceType = normalizeInvoiceTypeFilter(filter.InvoiceType)
+	filter.Supplier = strings.TrimSpace(filter.Supplier)
+	filter.Customer = strings.TrimSpace(filter.Customer)
+	filter.Product = strings.TrimSpace(filter.Product)
+	return filter
 }
 
 func (s *Service) ListInvoicesBetween(
@@ -232,8 +774,21 @@ func (s *Service) ListInvoicesBetween(
 	fuzzy bool,
 	idFrom *int64,
 	idTo *int64,
+	includeLines bool,
 ) ([]domain.Invoice, error) {
-	return s.repo.ListInvoicesBetween(ctx, start, end, strings.TrimSpace(productFilter), fuzzy, idFrom, idTo)
+	return s.repo.ListInvoicesBetween(ctx, start, end, strings.TrimSpace(productFilter), fuzzy, idFrom, idTo, includeLines)
+}
+
+func (s *Service) BackfillSalesCostPrices(ctx context.Context) (int, error) {
+	return s.repo.BackfillSalesCostPrices(ctx)
+}
+
+func (s *Service) RecalcInvoiceTotals(ctx context.Context, id int64) (*domain.Invoice, error) {
+	return s.repo.RecalcInvoiceTotals(ctx, id)
+}
+
+func (s *Service) RecalcAllInvoiceTotals(ctx context.Context) (int, error) {
+	return s.repo.RecalcAllInvoiceTotals(ctx)
 }
 
 func (s *Service) RenameInvoiceProducts(
@@ -243,16 +798,16 @@ func (s *Service) RenameInvoiceProducts(
 	return s.repo.RenameInvoiceProducts(ctx, changes)
 }
 
-func (s *Service) EnsureDefaultAdmin(ctx context.Context) error {
-	return s.repo.SetDefaultAdmin(ctx)
+func (s *Service) EnsureDefaultAdmin(ctx context.Context, username, password string) error {
+	return s.repo.SetDefaultAdmin(ctx, username, password)
 }
 
 func (s *Service) AuthenticateAdmin(ctx context.Context, username, password string) (*domain.AdminUser, error) {
 	return s.repo.AuthenticateAdmin(ctx, username, password)
 }
 
-func (s *Service) ListAdmins(ctx context.Context) ([]domain.AdminUser, error) {
-	return s.repo.ListAdmins(ctx)
+func (s *Service) ListAdmins(ctx context.Context, includeInactive bool) ([]domain.AdminUser, error) {
+	return s.repo.ListAdmins(ctx, includeInactive)
 }
 
 func (s *Service) CreateAdmin(
@@ -260,17 +815,82 @@ func (s *Service) CreateAdmin(
 	username, password, role string,
 	autoLockMinutes int,
 ) (*domain.AdminUser, error) {
+	if strings.TrimSpace(username) != "" && password != "" {
+		if err := s.validatePassword(strings.TrimSpace(username), password); err != nil {
+			return nil, err
+		}
+	}
+	if autoLockMinutes <= 0 {
+		autoLockMinutes = 1
+	}
+	if err := s.validateAutoLockMinutes(autoLockMinutes); err != nil {
+		return nil, err
+	}
 	return s.repo.CreateAdmin(ctx, username, password, role, autoLockMinutes)
 }
 
 func (s *Service) UpdateAdminPassword(ctx context.Context, adminID int64, password string) error {
+	if strings.TrimSpace(password) == "" {
+		return fmt.Errorf("password is required")
+	}
+	admin, err := s.repo.GetAdminByID(ctx, adminID)
+	if err != nil {
+		return err
+	}
+	if err := s.validatePassword(admin.Username, password); err != nil {
+		return err
+	}
 	return s.repo.UpdateAdminPassword(ctx, adminID, password)
 }
 
+// ErrWrongPassword is returned by ChangeOwnPassword when the current
+// password does not match.
+var ErrWrongPassword = errors.New("current password is incorrect")
+
+// ChangeOwnPassword lets an admin replace their own password. The current
+// password must authenticate the same account, so knowing the admin id is
+// not enough as it is with UpdateAdminPassword.
+func (s *Service) ChangeOwnPassword(ctx context.Context, username, currentPassword, newPassword string) error {
+	username = strings.TrimSpace(username)
+	if username == "" {
+		return fmt.Errorf("admin username is required")
+	}
+	if currentPassword == "" || strings.TrimSpace(newPassword) == "" {
+		return fmt.Errorf("current_password and new_password are required")
+	}
+	admin, err := s.repo.VerifyAdminPassword(ctx, username, currentPassword)
+	if err != nil {
+		return err
+	}
+	if admin == nil {
+		return ErrWrongPassword
+	}
+	if err := s.validatePassword(admin.Username, newPassword); err != nil {
+		return err
+	}
+	return s.repo.UpdateAdminPassword(ctx, admin.AdminID, newPassword)
+}
+
 func (s *Service) UpdateAdminAutoLock(ctx context.Context, adminID int64, minutes int) error {
+	if err := s.validateAutoLockMinutes(minutes); err != nil {
+		return err
+	}
 	return s.repo.UpdateAdminAutoLock(ctx, adminID, minutes)
 }
 
+func (s *Service) validateAutoLockMinutes(minutes int) error {
+	if minutes < 1 || minutes > s.opts.MaxAutoLockMinutes {
+		return fmt.Errorf("auto_lock_minutes must be between 1 and %d", s.opts.MaxAutoLockMinutes)
+	}
+	return nil
+}
+
+// SetAdminActive disables or re-enables an account. Disabled admins cannot
+// authenticate, but their username stays on the invoices and actions they made.
+func (s *Service) SetAdminActive(ctx context.Context, adminID int64, active bool) error {
+	return s.repo.SetAdminActive(ctx, adminID, active)
+}
+
 func (s *Service) DeleteAdmin(ctx context.Context, adminID int64) error {
 	return s.repo.DeleteAdmin(ctx, adminID)
 }
@@ -287,23 +907,53 @@ func (s *Service) LogAction(
 	return s.repo.LogAction(ctx, actionType, title, details, normalizeNullable(adminUsername))
 }
 
+func (s *Service) LogActions(ctx context.Context, entries []repository.ActionInput) (int, error) {
+	if len(entries) == 0 {
+		return 0, fmt.Errorf("actions are required")
+	}
+	for i := range entries {
+		entries[i].AdminUsername = normalizeNullable(entries[i].AdminUsername)
+	}
+	return s.repo.LogActions(ctx, entries)
+}
+
 func (s *Service) ListActions(
 	ctx context.Context,
 	limit, offset int,
-	search string,
+	filter repository.ActionFilter,
+) ([]domain.ActionEntry, bool, error) {
+	return s.repo.ListActions(ctx, limit, offset, filter)
+}
+
+func (s *Service) ListAdminActions(
+	ctx context.Context,
+	adminID int64,
+	limit, offset int,
 ) ([]domain.ActionEntry, error) {
-	return s.repo.ListActions(ctx, limit, offset, search)
+	admin, err := s.repo.GetAdminByID(ctx, adminID)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.ListActionsByAdmin(ctx, admin.Username, limit, offset)
+}
+
+func (s *Service) ExportActions(
+	ctx context.Context,
+	filter repository.ActionFilter,
+	fn func(domain.ActionEntry) error,
+) error {
+	return s.repo.StreamActions(ctx, filter, fn)
 }
 
-func (s *Service) CountActions(ctx context.Context, search string) (int, error) {
-	return s.repo.CountActions(ctx, search)
+func (s *Service) CountActions(ctx context.Context, filter repository.ActionFilter) (int, error) {
+	return s.repo.CountActions(ctx, filter)
 }
 
 func (s *Service) PreviewSales(
 	ctx context.Context,
 	rows []domain.SalesPreviewRow,
 ) ([]domain.SalesPreviewRow, int, int, error) {
-	return s.repo.PreviewSales(ctx, rows)
+	return s.repo.PreviewSales(ctx, rows, s.opts.AllowNegativeStock)
 }
 
 func (s *Service) FetchExistingBasalamIDs(
@@ -320,6 +970,35 @@ func (s *Service) StoreBasalamIDs(
 	return s.repo.StoreBasalamIDs(ctx, ids)
 }
 
+func (s *Service) ListBasalamIDs(
+	ctx context.Context,
+	limit, offset int,
+	from, to *time.Time,
+) ([]domain.BasalamOrderID, bool, error) {
+	return s.repo.ListBasalamIDs(ctx, limit, offset, from, to)
+}
+
+func (s *Service) CountBasalamIDs(ctx context.Context, from, to *time.Time) (int, error) {
+	return s.repo.CountBasalamIDs(ctx, from, to)
+}
+
+func (s *Service) DeleteBasalamIDs(
+	ctx context.Context,
+	ids []string,
+) (int, error) {
+	return s.repo.DeleteBasalamIDs(ctx, ids)
+}
+
+// trimOptional trims value but keeps an empty string, which callers use to
+// clear a field as opposed to nil for leaving it unchanged.
+func trimOptional(value *string) *string {
+	if value == nil {
+		return nil
+	}
+	v := strings.TrimSpace(*value)
+	return &v
+}
+
 func normalizeNullable(value *string) *string {
 	if value == nil {
 		return nil
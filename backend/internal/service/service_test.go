@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"backend/internal/db"
+	"backend/internal/domain"
+	"backend/internal/repository"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// testService connects to TEST_DATABASE_URL, migrates it, and returns a
+// service with opts plus its repository for seeding and checks. Tests create
+// rows with unique names and leave them.
+func testService(t *testing.T, opts Options) (*Service, *repository.Repository) {
+	t.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	pool, err := db.NewPool(ctx, url, db.PoolOptions{MaxConns: 4, MinConns: 1})
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(pool.Close)
+	if err := db.RunMigrations(ctx, pool); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	repo := repository.New(pool)
+	return New(repo, opts), repo
+}
+
+// testEmptyService is testService on a fresh schema, for tests that touch
+// global state such as app_settings or schema_migrations. It returns the pool
+// for direct edits; the schema is dropped afterwards.
+func testEmptyService(t *testing.T, opts Options) (*Service, *pgxpool.Pool) {
+	t.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	admin, err := db.NewPool(ctx, url, db.PoolOptions{MaxConns: 1, MinConns: 1})
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	schema := fmt.Sprintf("service_test_%d", time.Now().UnixNano())
+	if _, err := admin.Exec(ctx, "CREATE SCHEMA "+schema); err != nil {
+		admin.Close()
+		t.Fatalf("create schema: %v", err)
+	}
+	t.Cleanup(func() {
+		_, _ = admin.Exec(context.Background(), "DROP SCHEMA "+schema+" CASCADE")
+		admin.Close()
+	})
+
+	cfg, err := pgxpool.ParseConfig(url)
+	if err != nil {
+		t.Fatalf("parse url: %v", err)
+	}
+	cfg.ConnConfig.RuntimeParams["search_path"] = schema
+	pool, err := pgxpool.NewWithConfig(ctx, cfg)
+	if err != nil {
+		t.Fatalf("connect to schema: %v", err)
+	}
+	t.Cleanup(pool.Close)
+	if err := db.RunMigrations(ctx, pool); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return New(repository.New(pool), opts), pool
+}
+
+func TestInvoiceProfit(t *testing.T) {
+	lines := []domain.InvoiceLine{
+		{Quantity: 2, LineTotal: 300, CostPrice: 100},
+		{Quantity: 1, LineTotal: 50, CostPrice: 20},
+	}
+	discount := 30.0
+	noDiscount := 0.0
+	tests := []struct {
+		name     string
+		invoice  domain.Invoice
+		lines    []domain.InvoiceLine
+		want     float64
+		wantSale bool
+	}{
+		{name: "sales", invoice: domain.Invoice{InvoiceType: "sales"}, lines: lines, want: 130, wantSale: true},
+		{name: "sales with discount", invoice: domain.Invoice{InvoiceType: "sales", DiscountAmount: &discount}, lines: lines, want: 100, wantSale: true},
+		{name: "zero discount", invoice: domain.Invoice{InvoiceType: "sales_site", DiscountAmount: &noDiscount}, lines: lines, want: 130, wantSale: true},
+		{name: "discount can use up the margin", invoice: domain.Invoice{InvoiceType: "sales_basalam", DiscountAmount: &discount}, lines: lines[1:], want: 0, wantSale: true},
+		{name: "return gives profit back", invoice: domain.Invoice{InvoiceType: "sales_return"}, lines: lines, want: -130, wantSale: true},
+		{name: "return with discount", invoice: domain.Invoice{InvoiceType: "sales_return", DiscountAmount: &discount}, lines: lines, want: -100, wantSale: true},
+		{name: "no lines", invoice: domain.Invoice{InvoiceType: "sales"}, want: 0, wantSale: true},
+		{name: "purchase has no profit", invoice: domain.Invoice{InvoiceType: "purchase"}, lines: lines, wantSale: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := InvoiceProfit(tt.invoice, tt.lines)
+			if ok != tt.wantSale {
+				t.Fatalf("InvoiceProfit ok = %v, want %v", ok, tt.wantSale)
+			}
+			if got != tt.want {
+				t.Fatalf("InvoiceProfit = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeInvoiceTypes(t *testing.T) {
+	tests := []struct {
+		input     string
+		wantSales string
+		wantBulk  string
+		// wantSalesErr and wantBulkErr mark inputs each function rejects.
+		wantSalesErr bool
+		wantBulkErr  bool
+	}{
+		{input: "", wantSales: "sales", wantBulk: "sales"},
+		{input: "sales", wantSales: "sales", wantBulk: "sales"},
+		{input: "  Sales_Return ", wantSales: "sales_return", wantBulk: "sales_return"},
+		{input: "SALES_BASALAM", wantSales: "sales_basalam", wantBulk: "sales_basalam"},
+		{input: "purchase", wantSalesErr: true, wantBulk: "purchase"},
+		{input: " Purchase ", wantSalesErr: true, wantBulk: "purchase"},
+		{input: "sale", wantSalesErr: true, wantBulkErr: true},
+		{input: "sales-return", wantSalesErr: true, wantBulkErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := normalizeSalesInvoiceType(tt.input)
+			if (err != nil) != tt.wantSalesErr || got != tt.wantSales {
+				t.Errorf("normalizeSalesInvoiceType(%q) = %q, %v; want %q, error %v", tt.input, got, err, tt.wantSales, tt.wantSalesErr)
+			}
+			got, err = normalizeBulkInvoiceType(tt.input)
+			if (err != nil) != tt.wantBulkErr || got != tt.wantBulk {
+				t.Errorf("normalizeBulkInvoiceType(%q) = %q, %v; want %q, error %v", tt.input, got, err, tt.wantBulk, tt.wantBulkErr)
+			}
+		})
+	}
+}
+
+// TestCreateInvoicesBulkValidation covers the checks made before the
+// repository is reached, so it needs no database.
+func TestCreateInvoicesBulkValidation(t *testing.T) {
+	svc := New(nil, Options{})
+	sale := repository.BulkInvoiceInput{
+		SalesLines: []domain.SalesLineInput{{ProductName: "x", Price: 1, Quantity: 1}},
+	}
+
+	tooMany := make([]repository.BulkInvoiceInput, maxBulkInvoices+1)
+	_, err := svc.CreateInvoicesBulk(context.Background(), tooMany, false)
+	if want := fmt.Sprintf("at most %d invoices can be created at once", maxBulkInvoices); err == nil || err.Error() != want {
+		t.Fatalf("oversized batch error = %v, want %q", err, want)
+	}
+
+	badType := sale
+	badType.InvoiceType = "refund"
+	_, err = svc.CreateInvoicesBulk(context.Background(), []repository.BulkInvoiceInput{sale, badType}, false)
+	var bulkErr *repository.BulkInvoiceError
+	if !errors.As(err, &bulkErr) || bulkErr.Index != 1 {
+		t.Fatalf("unknown type error = %v, want *BulkInvoiceError at index 1", err)
+	}
+}
+
+func TestUpdateInvoiceLineRequiresAField(t *testing.T) {
+	svc := New(nil, Options{})
+	_, err := svc.UpdateInvoiceLine(context.Background(), 1, 1, repository.InvoiceLinePatch{})
+	if err == nil || err.Error() != "price, quantity or discount is required" {
+		t.Fatalf("empty patch error = %v", err)
+	}
+}
+
+func TestListRecentInvoicesRequiresMinutes(t *testing.T) {
+	svc := New(nil, Options{})
+	for _, minutes := range []int{0, -5} {
+		_, err := svc.ListRecentInvoices(context.Background(), minutes, 10)
+		if err == nil || err.Error() != "minutes must be greater than zero" {
+			t.Errorf("minutes %d: error = %v", minutes, err)
+		}
+	}
+}
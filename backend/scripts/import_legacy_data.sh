@@ -27,12 +27,12 @@ go_is_supported() {
   (( minor >= 25 ))
 }
 
-if ! command -v go >/dev/null 2>&1 || ! command -v sqlite3 >/dev/null 2>&1 || ! go_is_supported; then
+if ! command -v go >/dev/null 2>&1 || ! go_is_supported; then
   if command -v docker >/dev/null 2>&1; then
-    echo "info: go>=1.25 and sqlite3 not available locally; using Docker importer" >&2
+    echo "info: go>=1.25 not available locally; using Docker importer" >&2
     exec "${SCRIPT_DIR}/import_legacy_data_docker.sh" "$@"
   fi
-  echo "error: legacy import needs go >= 1.25 and sqlite3, or docker for fallback" >&2
+  echo "error: legacy import needs go >= 1.25, or docker for fallback" >&2
   exit 1
 fi
 